@@ -0,0 +1,490 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/ipc"
+	"github.com/apache/arrow/go/v11/arrow/memory"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/pkg/errors"
+)
+
+// WriteArrowIPC runs the query on conn and writes its results to w as an Arrow IPC
+// stream, e.g. to a socket, a file or an HTTP response, so that the results can be
+// handed to another process or language without converting them row by row. It
+// returns the number of rows written.
+//
+// The result pages are written as record batches as they are fetched, so the
+// results are not held in memory at once. See Rows.WriteArrowIPC for the types of
+// the Arrow columns.
+func WriteArrowIPC(ctx context.Context, conn *sql.Conn, w io.Writer, query string, args ...any) (int64, error) {
+	var n int64
+	err := queryRows(ctx, conn, query, args, func(r Rows) (err error) {
+		n, err = r.WriteArrowIPC(w)
+		return err
+	})
+	return n, err
+}
+
+// queryRows runs the query on the driver connection of conn and calls fn with its rows
+func queryRows(ctx context.Context, conn *sql.Conn, query string, args []any, fn func(Rows) error) error {
+	return conn.Raw(func(driverConn any) error {
+		queryer, ok := driverConn.(driver.QueryerContext)
+		if !ok {
+			return errors.New(ErrNotImplemented)
+		}
+		named := make([]driver.NamedValue, len(args))
+		for i, arg := range args {
+			named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+		}
+		r, err := queryer.QueryContext(ctx, query, named)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return fn(r.(Rows))
+	})
+}
+
+// WriteArrowIPC writes the rows that were not read yet to w as an Arrow IPC stream:
+// the schema, a record batch per result page and the end of stream marker.
+//
+// BOOLEAN, TINYINT, SMALLINT, INT, BIGINT, FLOAT, DOUBLE and BINARY columns are
+// written as the matching Arrow types, DATE columns as date32, TIMESTAMP columns
+// as timestamps in microseconds in the session time zone and DECIMAL columns as
+// decimal128. The other columns, e.g. STRING, INTERVAL and complex types, are
+// written as utf8 text.
+func (r *rows) WriteArrowIPC(w io.Writer) (int64, error) {
+	columns, err := r.arrowColumns()
+	if err != nil {
+		return 0, err
+	}
+	schema := arrowSchema(columns, r.location)
+	writer := ipc.NewWriter(w, ipc.WithSchema(schema))
+	n, err := r.readArrowBatches(columns, func(batch arrowBatch) error {
+		record := arrowRecord(schema, batch)
+		defer record.Release()
+		return writer.Write(record)
+	})
+	if err != nil {
+		return n, err
+	}
+	// writes the schema if there was no batch, and the end of stream marker
+	return n, writer.Close()
+}
+
+// arrowColumns returns the columns of the results as written to Arrow
+func (r *rows) arrowColumns() ([]arrowColumn, error) {
+	if err := isValidRows(r); err != nil {
+		return nil, err
+	}
+	if r.noResultSet {
+		return nil, nil
+	}
+	metadata, err := r.getResultMetadata()
+	if err != nil {
+		return nil, r.wrapErr(err)
+	}
+	var columns []arrowColumn
+	for _, desc := range metadata.GetSchema().GetColumns() {
+		columns = append(columns, newArrowColumn(desc))
+	}
+	return columns, nil
+}
+
+// arrowBatch holds rows of a result page decoded into Arrow buffers
+type arrowBatch struct {
+	rows int64
+	// the number of NULL values of each column
+	nulls []int64
+	// the buffers of each column: the validity bitmap, then the values, or the
+	// offsets and the data of variable length values
+	buffers [][][]byte
+}
+
+// readArrowBatches calls fn with the rows of each result page, from the next row up
+// to the end of the results, and returns the number of rows. The buffers of a batch
+// are reused by the next one, so fn must not keep them once it returns.
+func (r *rows) readArrowBatches(columns []arrowColumn, fn func(arrowBatch) error) (int64, error) {
+	var n int64
+	var batch arrowBatch
+	// the buffers of the batch are held until the end, and accounted for meanwhile
+	var account *memoryAccount
+	if r.conn != nil {
+		account = r.conn.memory
+	}
+	var reserved int64
+	defer func() { account.release(reserved) }()
+	for !r.noResultSet {
+		if !r.isNextRowInPage() {
+			if err := r.fetchResultPage(); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return n, r.wrapErr(err)
+			}
+		}
+		page := r.fetchResults.Results.Columns
+		if len(page) != len(columns) {
+			if r.invalidated == nil {
+				r.invalidate(fmt.Sprintf("page has %d columns, the results have %d", len(page), len(columns)), nil)
+			}
+			return n, r.wrapErr(r.invalidated)
+		}
+		from, to := r.nextRowIndex, getNRows(r.fetchResults.Results)
+		batch.rows = to - from
+		if batch.buffers == nil {
+			batch.buffers = make([][][]byte, len(columns))
+			batch.nulls = make([]int64, len(columns))
+		}
+		for i, column := range columns {
+			buffers, nulls, err := r.arrowBuffers(column, page[i], from, to, batch.buffers[i])
+			if err != nil {
+				return n, r.wrapErr(err)
+			}
+			batch.buffers[i] = buffers
+			batch.nulls[i] = nulls
+		}
+		account.release(reserved)
+		reserved = 0
+		size := arrowBatchSize(batch)
+		if err := account.reserve(size); err != nil {
+			return n, r.wrapErr(err)
+		}
+		reserved = size
+		if err := fn(batch); err != nil {
+			return n, err
+		}
+		n += batch.rows
+		r.nextRowNumber += batch.rows
+		r.nextRowIndex = to
+	}
+	return n, nil
+}
+
+// arrowKind tells how the values of a column are written
+type arrowKind int
+
+const (
+	arrowUtf8 arrowKind = iota
+	arrowBinary
+	arrowBool
+	arrowInt8
+	arrowInt16
+	arrowInt32
+	arrowInt64
+	arrowFloat32
+	arrowFloat64
+	arrowDate
+	arrowTimestamp
+	arrowDecimal
+)
+
+// arrowColumn is a column of the results as written to the Arrow stream
+type arrowColumn struct {
+	name             string
+	kind             arrowKind
+	precision, scale int64
+}
+
+func newArrowColumn(desc *cli_service.TColumnDesc) arrowColumn {
+	column := arrowColumn{name: desc.ColumnName}
+	switch getDBTypeID(desc) {
+	case cli_service.TTypeId_BOOLEAN_TYPE:
+		column.kind = arrowBool
+	case cli_service.TTypeId_TINYINT_TYPE:
+		column.kind = arrowInt8
+	case cli_service.TTypeId_SMALLINT_TYPE:
+		column.kind = arrowInt16
+	case cli_service.TTypeId_INT_TYPE:
+		column.kind = arrowInt32
+	case cli_service.TTypeId_BIGINT_TYPE:
+		column.kind = arrowInt64
+	case cli_service.TTypeId_FLOAT_TYPE:
+		column.kind = arrowFloat32
+	case cli_service.TTypeId_DOUBLE_TYPE:
+		column.kind = arrowFloat64
+	case cli_service.TTypeId_BINARY_TYPE:
+		column.kind = arrowBinary
+	case cli_service.TTypeId_DATE_TYPE:
+		column.kind = arrowDate
+	case cli_service.TTypeId_TIMESTAMP_TYPE:
+		column.kind = arrowTimestamp
+	case cli_service.TTypeId_DECIMAL_TYPE:
+		column.kind = arrowDecimal
+		d := newColumnDescriptor(desc)
+		column.precision, column.scale = d.Precision, d.Scale
+	}
+	return column
+}
+
+// dataType returns the Arrow type of the column
+func (c arrowColumn) dataType(location *time.Location) arrow.DataType {
+	switch c.kind {
+	case arrowBool:
+		return arrow.FixedWidthTypes.Boolean
+	case arrowInt8:
+		return arrow.PrimitiveTypes.Int8
+	case arrowInt16:
+		return arrow.PrimitiveTypes.Int16
+	case arrowInt32:
+		return arrow.PrimitiveTypes.Int32
+	case arrowInt64:
+		return arrow.PrimitiveTypes.Int64
+	case arrowFloat32:
+		return arrow.PrimitiveTypes.Float32
+	case arrowFloat64:
+		return arrow.PrimitiveTypes.Float64
+	case arrowBinary:
+		return arrow.BinaryTypes.Binary
+	case arrowDate:
+		return arrow.FixedWidthTypes.Date32
+	case arrowTimestamp:
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: location.String()}
+	case arrowDecimal:
+		return &arrow.Decimal128Type{Precision: int32(c.precision), Scale: int32(c.scale)}
+	}
+	return arrow.BinaryTypes.String
+}
+
+// arrowSchema returns the Arrow schema of the columns, which are all nullable
+func arrowSchema(columns []arrowColumn, location *time.Location) *arrow.Schema {
+	if location == nil {
+		location = time.UTC
+	}
+	fields := make([]arrow.Field, len(columns))
+	for i, column := range columns {
+		fields[i] = arrow.Field{Name: column.name, Type: column.dataType(location), Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// arrowRecord returns a record holding the rows of the batch. The record refers to
+// the buffers of the batch rather than copying them, so it must be released before
+// the next batch is read.
+func arrowRecord(schema *arrow.Schema, batch arrowBatch) arrow.Record {
+	columns := make([]arrow.Array, len(batch.buffers))
+	for i, values := range batch.buffers {
+		buffers := make([]*memory.Buffer, len(values))
+		for j, buf := range values {
+			buffers[j] = memory.NewBufferBytes(buf)
+		}
+		data := array.NewData(schema.Field(i).Type, int(batch.rows), buffers, nil, int(batch.nulls[i]), 0)
+		columns[i] = array.MakeFromData(data)
+		data.Release()
+	}
+	record := array.NewRecord(schema, columns, batch.rows)
+	for _, column := range columns {
+		column.Release()
+	}
+	return record
+}
+
+// arrowBuffers returns the buffers of the values of a column from the row from up to
+// to, starting with the validity bitmap, and the number of NULL values. The buffers
+// are written over the ones of reuse, returned by a previous call for the column, to
+// avoid allocating them for every page.
+func (r *rows) arrowBuffers(column arrowColumn, tColumn *cli_service.TColumn, from, to int64, reuse [][]byte) ([][]byte, int64, error) {
+	var reused [3][]byte
+	copy(reused[:], reuse)
+	n := to - from
+	validity := zeroedBuffer(reused[0], int((n+7)/8))
+	var nulls int64
+	values, data := reused[1][:0], reused[2][:0]
+	if column.kind == arrowBool {
+		values = zeroedBuffer(reused[1], int((n+7)/8))
+	}
+	var offsets []byte
+	if column.kind == arrowUtf8 || column.kind == arrowBinary {
+		offsets = appendInt32(reused[1][:0], 0)
+	}
+	for i := int64(0); i < n; i++ {
+		v := rawValue(tColumn, from+i)
+		if v == nil {
+			nulls++
+		} else {
+			validity[i/8] |= 1 << (i % 8)
+		}
+		var err error
+		switch column.kind {
+		case arrowUtf8, arrowBinary:
+			switch v := v.(type) {
+			case nil:
+			case string:
+				data = append(data, v...)
+			case []byte:
+				data = append(data, v...)
+			default:
+				data = append(data, fmt.Sprint(v)...)
+			}
+			offsets = appendInt32(offsets, int32(len(data)))
+		case arrowBool:
+			if b, ok := v.(bool); ok && b {
+				values[i/8] |= 1 << (i % 8)
+			} else if v != nil && !ok {
+				err = arrowValueError(column, v)
+			}
+		case arrowInt8, arrowInt16, arrowInt32, arrowInt64:
+			values, err = appendArrowInt(values, column, v)
+		case arrowFloat32, arrowFloat64:
+			f, ok := v.(float64)
+			if v != nil && !ok {
+				err = arrowValueError(column, v)
+			} else if column.kind == arrowFloat32 {
+				values = binary.LittleEndian.AppendUint32(values, math.Float32bits(float32(f)))
+			} else {
+				values = binary.LittleEndian.AppendUint64(values, math.Float64bits(f))
+			}
+		case arrowDate, arrowTimestamp:
+			values, err = r.appendArrowTime(values, column, v)
+		case arrowDecimal:
+			values, err = appendArrowDecimal(values, column, v)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if offsets != nil {
+		return append(reuse[:0], validity, offsets, data), nulls, nil
+	}
+	return append(reuse[:0], validity, values), nulls, nil
+}
+
+// zeroedBuffer returns n zero bytes, in the array of b when it is large enough
+func zeroedBuffer(b []byte, n int) []byte {
+	if cap(b) < n {
+		return make([]byte, n)
+	}
+	b = b[:n]
+	for i := range b {
+		b[i] = 0
+	}
+	return b
+}
+
+func arrowValueError(column arrowColumn, v any) error {
+	return errors.Errorf("databricks: cannot write %T value of column %s to arrow", v, column.name)
+}
+
+// appendArrowInt appends the integer v, or 0 when it is NULL, with the width of the column
+func appendArrowInt(values []byte, column arrowColumn, v any) ([]byte, error) {
+	var i int64
+	switch v := v.(type) {
+	case nil:
+	case int8:
+		i = int64(v)
+	case int16:
+		i = int64(v)
+	case int32:
+		i = int64(v)
+	case int64:
+		i = v
+	default:
+		return nil, arrowValueError(column, v)
+	}
+	switch column.kind {
+	case arrowInt8:
+		return append(values, byte(i)), nil
+	case arrowInt16:
+		return binary.LittleEndian.AppendUint16(values, uint16(i)), nil
+	case arrowInt32:
+		return appendInt32(values, int32(i)), nil
+	}
+	return appendInt64(values, i), nil
+}
+
+// appendArrowTime appends the DATE value v as days since the epoch, or the TIMESTAMP
+// value v as microseconds since the epoch, or 0 when it is NULL.
+func (r *rows) appendArrowTime(values []byte, column arrowColumn, v any) ([]byte, error) {
+	var t time.Time
+	if v != nil {
+		s, ok := v.(string)
+		if !ok {
+			return nil, arrowValueError(column, v)
+		}
+		var layouts []string
+		layout, location := DateFormat, time.UTC
+		if column.kind == arrowTimestamp {
+			layout = TimestampFormat
+			if r.location != nil {
+				location = r.location
+			}
+		}
+		if r.config != nil {
+			layouts = r.config.DateLayouts
+			if column.kind == arrowTimestamp {
+				layouts = r.config.TimestampLayouts
+			}
+		}
+		if t, ok = parseTime(s, layouts, layout, location); !ok {
+			return nil, errors.Errorf("databricks: cannot write value %q of column %s to arrow", s, column.name)
+		}
+	}
+	if column.kind == arrowTimestamp {
+		if v == nil {
+			return appendInt64(values, 0), nil
+		}
+		return appendInt64(values, t.UnixMicro()), nil
+	}
+	days := int64(0)
+	if v != nil {
+		days = int64(math.Floor(float64(t.Unix()) / 86400))
+	}
+	return appendInt32(values, int32(days)), nil
+}
+
+// appendArrowDecimal appends the DECIMAL value v as a 128 bits little-endian integer
+// scaled by the scale of the column, or 0 when it is NULL.
+func appendArrowDecimal(values []byte, column arrowColumn, v any) ([]byte, error) {
+	var b [16]byte
+	if v != nil {
+		s, ok := v.(string)
+		if !ok {
+			return nil, arrowValueError(column, v)
+		}
+		i, ok := parseDecimal(s, column.scale)
+		if !ok || i.BitLen() > 127 {
+			return nil, errors.Errorf("databricks: cannot write value %q of column %s to arrow", s, column.name)
+		}
+		if i.Sign() < 0 {
+			// two's complement
+			i.Add(i, new(big.Int).Lsh(big.NewInt(1), 128))
+		}
+		i.FillBytes(b[:])
+		// big-endian to little-endian
+		for l, r := 0, len(b)-1; l < r; l, r = l+1, r-1 {
+			b[l], b[r] = b[r], b[l]
+		}
+	}
+	return append(values, b[:]...), nil
+}
+
+// parseDecimal returns the decimal text s as an integer scaled by 10^scale. It fails
+// when s has more fractional digits than the scale.
+func parseDecimal(s string, scale int64) (*big.Int, bool) {
+	digits, frac, _ := strings.Cut(s, ".")
+	if int64(len(frac)) > scale {
+		return nil, false
+	}
+	digits += frac + strings.Repeat("0", int(scale)-len(frac))
+	return new(big.Int).SetString(digits, 10)
+}
+
+func appendInt32(b []byte, v int32) []byte {
+	return binary.LittleEndian.AppendUint32(b, uint32(v))
+}
+
+func appendInt64(b []byte, v int64) []byte {
+	return binary.LittleEndian.AppendUint64(b, uint64(v))
+}
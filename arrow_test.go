@@ -0,0 +1,248 @@
+package dbsql
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/ipc"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readArrowIPC reads an Arrow IPC stream with the Arrow library and returns its
+// schema and its record batches, which the caller releases
+func readArrowIPC(t *testing.T, stream []byte) (*arrow.Schema, []arrow.Record) {
+	reader, err := ipc.NewReader(bytes.NewReader(stream))
+	require.NoError(t, err)
+	defer reader.Release()
+	var records []arrow.Record
+	for reader.Next() {
+		record := reader.Record()
+		record.Retain()
+		records = append(records, record)
+	}
+	require.NoError(t, reader.Err())
+	return reader.Schema(), records
+}
+
+func getArrowTestRows(t *testing.T) *rows {
+	precision, scale := int32(10), int32(2)
+	primitive := func(name string, typeID cli_service.TTypeId) *cli_service.TColumnDesc {
+		return &cli_service.TColumnDesc{
+			ColumnName: name,
+			TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{{
+				PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: typeID},
+			}}},
+		}
+	}
+	price := primitive("price", cli_service.TTypeId_DECIMAL_TYPE)
+	price.TypeDesc.Types[0].PrimitiveEntry.TypeQualifiers = &cli_service.TTypeQualifiers{
+		Qualifiers: map[string]*cli_service.TTypeQualifierValue{
+			cli_service.PRECISION: {I32Value: &precision},
+			cli_service.SCALE:     {I32Value: &scale},
+		},
+	}
+	metadata := &cli_service.TGetResultSetMetadataResp{
+		Schema: &cli_service.TTableSchema{Columns: []*cli_service.TColumnDesc{
+			primitive("id", cli_service.TTypeId_BIGINT_TYPE),
+			primitive("name", cli_service.TTypeId_STRING_TYPE),
+			primitive("ok", cli_service.TTypeId_BOOLEAN_TYPE),
+			price,
+			primitive("day", cli_service.TTypeId_DATE_TYPE),
+			primitive("at", cli_service.TTypeId_TIMESTAMP_TYPE),
+			primitive("score", cli_service.TTypeId_DOUBLE_TYPE),
+		}},
+	}
+	page := func(start int64, ids []int64, names []string, nameNulls []byte, oks []bool, prices, days, ats []string, scores []float64) *cli_service.TFetchResultsResp {
+		hasMoreRows := start == 0
+		return &cli_service.TFetchResultsResp{
+			Status:      &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			HasMoreRows: &hasMoreRows,
+			Results: &cli_service.TRowSet{StartRowOffset: start, Columns: []*cli_service.TColumn{
+				{I64Val: &cli_service.TI64Column{Values: ids}},
+				{StringVal: &cli_service.TStringColumn{Values: names, Nulls: nameNulls}},
+				{BoolVal: &cli_service.TBoolColumn{Values: oks}},
+				{StringVal: &cli_service.TStringColumn{Values: prices}},
+				{StringVal: &cli_service.TStringColumn{Values: days}},
+				{StringVal: &cli_service.TStringColumn{Values: ats}},
+				{DoubleVal: &cli_service.TDoubleColumn{Values: scores}},
+			}},
+		}
+	}
+	first := page(0, []int64{1, 2}, []string{"a", ""}, []byte{2}, []bool{true, false},
+		[]string{"-1.50", "12.34"}, []string{"1970-01-02", "1969-12-31"}, []string{"1970-01-01 00:00:01.5", "2023-05-06 07:08:09"}, []float64{0.5, 2})
+	second := page(2, []int64{3}, []string{"héllo"}, nil, []bool{true},
+		[]string{"0.01"}, []string{"2000-01-01"}, []string{"1970-01-01 00:00:00"}, []float64{-1})
+	testClient := &client.TestClient{
+		FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+			return second, nil
+		},
+	}
+	return &rows{
+		client:               testClient,
+		config:               config.WithDefaults(),
+		fetchResults:         first,
+		fetchResultsMetadata: metadata,
+		pageSize:             2,
+	}
+}
+
+func TestRowsWriteArrowIPC(t *testing.T) {
+	t.Run("result pages are written as record batches", func(t *testing.T) {
+		r := getArrowTestRows(t)
+		var buf bytes.Buffer
+		n, err := r.WriteArrowIPC(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+		assert.Equal(t, int64(3), r.NextRowNumber())
+
+		schema, records := readArrowIPC(t, buf.Bytes())
+		var types []string
+		for _, field := range schema.Fields() {
+			assert.True(t, field.Nullable)
+			types = append(types, field.Name+" "+field.Type.String())
+		}
+		assert.Equal(t, []string{"id int64", "name utf8", "ok bool", "price decimal(10, 2)", "day date32", "at timestamp[us, tz=UTC]", "score float64"}, types)
+
+		columns := make([][]arrow.Array, 7)
+		var batches []int64
+		for _, record := range records {
+			defer record.Release()
+			batches = append(batches, record.NumRows())
+			for i := range columns {
+				columns[i] = append(columns[i], record.Column(i))
+			}
+		}
+		assert.Equal(t, []int64{2, 1}, batches)
+		assert.Equal(t, 1, records[0].Column(1).NullN())
+		for i, want := range arrowTestValues {
+			assert.Equal(t, want, arrowValues(t, columns[i]...), schema.Field(i).Name)
+		}
+	})
+
+	t.Run("rows already read are not written", func(t *testing.T) {
+		r := getArrowTestRows(t)
+		dest := make([]driver.Value, 7)
+		require.NoError(t, r.Next(dest))
+		require.NoError(t, r.Next(dest))
+		require.NoError(t, r.Next(dest))
+
+		var buf bytes.Buffer
+		n, err := r.WriteArrowIPC(&buf)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		schema, records := readArrowIPC(t, buf.Bytes())
+		assert.Len(t, schema.Fields(), 7)
+		assert.Empty(t, records)
+		assert.Equal(t, io.EOF, r.Next(dest))
+	})
+
+	t.Run("invalid values fail", func(t *testing.T) {
+		r := getArrowTestRows(t)
+		r.fetchResults.Results.Columns[4].StringVal.Values[1] = "yesterday"
+		_, err := r.WriteArrowIPC(io.Discard)
+		assert.ErrorContains(t, err, `cannot write value "yesterday" of column day to arrow`)
+	})
+}
+
+// arrowValues returns the values of arrays read with the Arrow library, with
+// decimals as strings, dates as days and nulls as nil
+func arrowValues(t *testing.T, arrays ...arrow.Array) []any {
+	var values []any
+	for _, a := range arrays {
+		for i := 0; i < a.Len(); i++ {
+			if a.IsNull(i) {
+				values = append(values, nil)
+				continue
+			}
+			switch a := a.(type) {
+			case *array.Int64:
+				values = append(values, a.Value(i))
+			case *array.String:
+				values = append(values, a.Value(i))
+			case *array.Boolean:
+				values = append(values, a.Value(i))
+			case *array.Decimal128:
+				values = append(values, a.Value(i).ToString(a.DataType().(*arrow.Decimal128Type).Scale))
+			case *array.Date32:
+				values = append(values, a.Value(i).ToTime().Format("2006-01-02"))
+			case *array.Timestamp:
+				values = append(values, a.Value(i).ToTime(a.DataType().(*arrow.TimestampType).Unit))
+			case *array.Float64:
+				values = append(values, a.Value(i))
+			default:
+				t.Fatalf("unexpected arrow array %T", a)
+			}
+		}
+	}
+	return values
+}
+
+// arrowTestValues are the values of the columns of getArrowTestRows
+var arrowTestValues = [][]any{
+	{int64(1), int64(2), int64(3)},
+	{"a", nil, "héllo"},
+	{true, false, true},
+	{"-1.50", "12.34", "0.01"},
+	{"1970-01-02", "1969-12-31", "2000-01-01"},
+	{time.Unix(1, 500000000).UTC(), time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC), time.Unix(0, 0).UTC()},
+	{0.5, float64(2), float64(-1)},
+}
+
+func TestWriteArrowIPC(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"name"}, [][]string{{"a"}, {"b"}}, "", &statements)
+	defer db.Close()
+	c, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer c.Close()
+
+	var buf bytes.Buffer
+	n, err := WriteArrowIPC(context.Background(), c, &buf, "SELECT name FROM t")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, []string{"SELECT name FROM t"}, statements)
+	schema, records := readArrowIPC(t, buf.Bytes())
+	assert.Equal(t, "name", schema.Field(0).Name)
+	require.Len(t, records, 1)
+	defer records[0].Release()
+	assert.Equal(t, int64(2), records[0].NumRows())
+}
+
+func TestReadArrowBatches_ReusesBuffers(t *testing.T) {
+	var requests []*cli_service.TFetchResultsReq
+	r := &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(30, &requests),
+	}
+	columns, err := r.arrowColumns()
+	require.NoError(t, err)
+	var values [][]byte
+	var firsts []int32
+	n, err := r.readArrowBatches(columns, func(batch arrowBatch) error {
+		values = append(values, batch.buffers[0][1])
+		firsts = append(firsts, int32(binary.LittleEndian.Uint32(batch.buffers[0][1])))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), n)
+	assert.Equal(t, []int32{0, 10, 20}, firsts)
+	require.Len(t, values, 3)
+	// the pages are decoded in the same buffer
+	assert.Same(t, &values[0][0], &values[1][0])
+	assert.Same(t, &values[0][0], &values[2][0])
+
+	validity := zeroedBuffer([]byte{0xff, 0xff, 0xff}, 2)
+	assert.Equal(t, []byte{0, 0}, validity)
+	assert.Equal(t, []byte{0, 0, 0}, zeroedBuffer(validity, 3))
+}
@@ -0,0 +1,136 @@
+// Package auth defines how requests sent by the driver are authenticated.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/logger"
+	"github.com/pkg/errors"
+)
+
+// Authenticator sets the credentials of every request sent to Databricks.
+// It is called for each request, so implementations can refresh expiring
+// credentials between calls.
+type Authenticator interface {
+	Authenticate(*http.Request) error
+}
+
+// Validator is implemented by authenticators that know when their credentials
+// expire. Pooled connections are discarded once Valid returns false.
+type Validator interface {
+	Valid() bool
+}
+
+// Token is an access token with an optional expiry time.
+type Token struct {
+	AccessToken string
+	// TokenType defaults to Bearer
+	TokenType string
+	// Expiry is the zero value for tokens that do not expire
+	Expiry time.Time
+}
+
+// expiresWithin returns true if the token expires in less than d
+func (t *Token) expiresWithin(d time.Duration) bool {
+	if t == nil || t.AccessToken == "" {
+		return true
+	}
+	if t.Expiry.IsZero() {
+		return false
+	}
+	return time.Until(t.Expiry) < d
+}
+
+// TokenSource returns access tokens, e.g. from an OAuth token endpoint.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc is an adapter to use ordinary functions as TokenSource.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// SecretResolver returns the current value of a secret held in a secret manager,
+// e.g. an access token, so that it can be rotated without restarting the services
+// using it. Implementations for the common secret managers are in package
+// auth/secret.
+type SecretResolver interface {
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+// SecretResolverFunc is an adapter to use ordinary functions as SecretResolver.
+type SecretResolverFunc func(ctx context.Context, name string) (string, error)
+
+func (f SecretResolverFunc) Resolve(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// DefaultRefreshBefore is how long before expiry a token is refreshed
+const DefaultRefreshBefore = 5 * time.Minute
+
+// NewTokenAuthenticator returns an Authenticator that caches the token returned by source
+// and fetches a new one once the cached token expires within refreshBefore.
+// Because the token is checked for every request, long running result fetches keep
+// working past the lifetime of a single token.
+func NewTokenAuthenticator(source TokenSource, refreshBefore time.Duration) Authenticator {
+	if refreshBefore <= 0 {
+		refreshBefore = DefaultRefreshBefore
+	}
+	return &tokenAuthenticator{source: source, refreshBefore: refreshBefore}
+}
+
+type tokenAuthenticator struct {
+	source        TokenSource
+	refreshBefore time.Duration
+	mu            sync.Mutex
+	token         *Token
+	refreshErr    error
+}
+
+var _ Validator = (*tokenAuthenticator)(nil)
+
+// Valid returns false when the cached token has expired and the last attempt
+// to refresh it failed.
+func (a *tokenAuthenticator) Valid() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.token == nil || a.refreshErr == nil || !a.token.expiresWithin(0)
+}
+
+func (a *tokenAuthenticator) Authenticate(r *http.Request) error {
+	token, err := a.getToken(r.Context())
+	if err != nil {
+		return err
+	}
+	tokenType := token.TokenType
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+	r.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+	return nil
+}
+
+func (a *tokenAuthenticator) getToken(ctx context.Context) (*Token, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.token.expiresWithin(a.refreshBefore) {
+		return a.token, nil
+	}
+	logger.Debug().Msg("databricks: refreshing access token")
+	token, err := a.source.Token(ctx)
+	if err == nil && (token == nil || token.AccessToken == "") {
+		err = errors.New("databricks: token source returned an empty token")
+	}
+	a.refreshErr = err
+	if err != nil {
+		return nil, errors.Wrap(err, "databricks: failed to refresh access token")
+	}
+	a.token = token
+	return token, nil
+}
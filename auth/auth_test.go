@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenAuthenticator(t *testing.T) {
+	t.Run("token is cached until it is about to expire", func(t *testing.T) {
+		var calls int
+		source := TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+			calls++
+			return &Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}, nil
+		})
+		a := NewTokenAuthenticator(source, time.Minute)
+		for i := 0; i < 3; i++ {
+			req, _ := http.NewRequest("POST", "https://localhost", nil)
+			require.NoError(t, a.Authenticate(req))
+			assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("authenticator is invalid once the token expired and could not be refreshed", func(t *testing.T) {
+		fail := false
+		source := TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+			if fail {
+				return nil, errors.New("refresh failed")
+			}
+			return &Token{AccessToken: "token", Expiry: time.Now().Add(10 * time.Millisecond)}, nil
+		})
+		a := NewTokenAuthenticator(source, time.Millisecond)
+		v := a.(Validator)
+		req, _ := http.NewRequest("POST", "https://localhost", nil)
+		require.NoError(t, a.Authenticate(req))
+		assert.True(t, v.Valid())
+
+		fail = true
+		time.Sleep(20 * time.Millisecond)
+		assert.True(t, v.Valid(), "a refresh has not been attempted yet")
+		assert.Error(t, a.Authenticate(req))
+		assert.False(t, v.Valid())
+
+		fail = false
+		require.NoError(t, a.Authenticate(req))
+		assert.True(t, v.Valid())
+	})
+
+	t.Run("token is refreshed ahead of expiry", func(t *testing.T) {
+		var calls int
+		source := TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+			calls++
+			return &Token{AccessToken: "token", TokenType: "Custom", Expiry: time.Now().Add(time.Minute)}, nil
+		})
+		a := NewTokenAuthenticator(source, 5*time.Minute)
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest("POST", "https://localhost", nil)
+			require.NoError(t, a.Authenticate(req))
+			assert.Equal(t, "Custom token", req.Header.Get("Authorization"))
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("tokens without expiry are never refreshed", func(t *testing.T) {
+		var calls int
+		source := TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+			calls++
+			return &Token{AccessToken: "token"}, nil
+		})
+		a := NewTokenAuthenticator(source, 0)
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest("POST", "https://localhost", nil)
+			require.NoError(t, a.Authenticate(req))
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("token source errors are returned", func(t *testing.T) {
+		source := TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+			return nil, errors.New("boom")
+		})
+		a := NewTokenAuthenticator(source, 0)
+		req, _ := http.NewRequest("POST", "https://localhost", nil)
+		assert.ErrorContains(t, a.Authenticate(req), "boom")
+
+		empty := NewTokenAuthenticator(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+			return &Token{}, nil
+		}), 0)
+		assert.Error(t, empty.Authenticate(req))
+	})
+}
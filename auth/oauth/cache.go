@@ -0,0 +1,203 @@
+package oauth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/databricks/databricks-sql-go/logger"
+	"github.com/pkg/errors"
+)
+
+// TokenCache stores access tokens across process restarts, so that they are reused
+// until they expire instead of requested again. NewFileTokenCache returns one that
+// stores them in an encrypted file. Only access tokens are stored: there are no
+// refresh tokens, since the client credentials grant does not issue any, and
+// expired tokens are requested again from the token source.
+type TokenCache interface {
+	// Load returns the token stored under key, or nil if there is none
+	Load(key string) (*auth.Token, error)
+	// Store stores token under key, replacing the token stored before
+	Store(key string, token *auth.Token) error
+}
+
+// CacheKey returns the key of the tokens of an OAuth client for a workspace and the
+// scopes they are requested with, DefaultScopes when empty, so that tokens of other
+// scopes are not reused. The order of the scopes does not matter.
+func CacheKey(host, clientID string, scopes []string) string {
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	sorted := append([]string(nil), scopes...)
+	sort.Strings(sorted)
+	return hostURL(host) + "#" + clientID + "#" + strings.Join(sorted, " ")
+}
+
+// CachedTokenSource returns the token stored in Cache under Key while it does not
+// expire within auth.DefaultRefreshBefore, and otherwise gets a new one from Source
+// and stores it. Failing to use the cache is logged and does not fail the request.
+//
+//	source := &oauth.CachedTokenSource{
+//		Cache:  cache,
+//		Key:    oauth.CacheKey(host, clientID, nil),
+//		Source: &oauth.ClientCredentials{Host: host, ClientID: clientID, ClientSecret: clientSecret},
+//	}
+type CachedTokenSource struct {
+	Cache  TokenCache
+	Key    string
+	Source auth.TokenSource
+}
+
+var _ auth.TokenSource = (*CachedTokenSource)(nil)
+
+func (s *CachedTokenSource) Token(ctx context.Context) (*auth.Token, error) {
+	token, err := s.Cache.Load(s.Key)
+	if err != nil {
+		logger.Warn().Msgf("databricks: failed to load cached OAuth token: %v", err)
+	} else if token != nil && token.AccessToken != "" &&
+		(token.Expiry.IsZero() || time.Until(token.Expiry) >= auth.DefaultRefreshBefore) {
+		return token, nil
+	}
+	token, err = s.Source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Cache.Store(s.Key, token); err != nil {
+		logger.Warn().Msgf("databricks: failed to cache OAuth token: %v", err)
+	}
+	return token, nil
+}
+
+// DefaultTokenCachePath returns the path of the token cache file in the cache
+// directory of the user, e.g. ~/.cache/databricks-sql-go/oauth-tokens on Linux.
+func DefaultTokenCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "databricks: no cache directory for OAuth tokens")
+	}
+	return filepath.Join(dir, "databricks-sql-go", "oauth-tokens"), nil
+}
+
+// NewFileTokenCache returns a TokenCache that stores the tokens in the file at path,
+// encrypted with AES-GCM using key, which must be 16, 24 or 32 bytes long. The file
+// is only readable by the user. Keep key out of the file system, e.g. in a secret
+// manager, since anyone who has both can read the tokens.
+func NewFileTokenCache(path string, key []byte) (TokenCache, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "databricks: invalid OAuth token cache key")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "databricks: invalid OAuth token cache key")
+	}
+	return &fileTokenCache{path: path, aead: aead}, nil
+}
+
+type fileTokenCache struct {
+	path string
+	aead cipher.AEAD
+	// serializes the updates of the file by the cache
+	mu sync.Mutex
+}
+
+// cachedToken is a token as stored in the cache file
+type cachedToken struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type,omitempty"`
+	Expiry      time.Time `json:"expiry,omitempty"`
+}
+
+func (c *fileTokenCache) Load(key string) (*auth.Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := tokens[key]
+	if !ok {
+		return nil, nil
+	}
+	return &auth.Token{AccessToken: t.AccessToken, TokenType: t.TokenType, Expiry: t.Expiry}, nil
+}
+
+func (c *fileTokenCache) Store(key string, token *auth.Token) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens, err := c.read()
+	if err != nil {
+		// a cache that can't be decrypted, e.g. after the key changed, is replaced
+		tokens = map[string]cachedToken{}
+	}
+	tokens[key] = cachedToken{AccessToken: token.AccessToken, TokenType: token.TokenType, Expiry: token.Expiry}
+	return c.write(tokens)
+}
+
+// read returns the tokens of the cache file, none if there is no file
+func (c *fileTokenCache) read() (map[string]cachedToken, error) {
+	tokens := map[string]cachedToken{}
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "databricks: failed to read OAuth token cache")
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("databricks: invalid OAuth token cache")
+	}
+	plain, err := c.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "databricks: failed to decrypt OAuth token cache")
+	}
+	if err := json.Unmarshal(plain, &tokens); err != nil {
+		return nil, errors.Wrap(err, "databricks: invalid OAuth token cache")
+	}
+	return tokens, nil
+}
+
+// write replaces the cache file with the encrypted tokens. The file is written next
+// to the cache and renamed, so that readers never see a partial file.
+func (c *fileTokenCache) write(tokens map[string]cachedToken) error {
+	plain, err := json.Marshal(tokens)
+	if err != nil {
+		return errors.Wrap(err, "databricks: failed to write OAuth token cache")
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return errors.Wrap(err, "databricks: failed to write OAuth token cache")
+	}
+	data := c.aead.Seal(nonce, nonce, plain, nil)
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return errors.Wrap(err, "databricks: failed to write OAuth token cache")
+	}
+	f, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "databricks: failed to write OAuth token cache")
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.Wrap(err, "databricks: failed to write OAuth token cache")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "databricks: failed to write OAuth token cache")
+	}
+	if err := os.Rename(f.Name(), c.path); err != nil {
+		return errors.Wrap(err, "databricks: failed to write OAuth token cache")
+	}
+	return nil
+}
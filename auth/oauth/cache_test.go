@@ -0,0 +1,104 @@
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenCache(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	path := filepath.Join(t.TempDir(), "cache", "oauth-tokens")
+	cache, err := NewFileTokenCache(path, key)
+	require.NoError(t, err)
+
+	token, err := cache.Load("a")
+	require.NoError(t, err)
+	assert.Nil(t, token)
+
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	require.NoError(t, cache.Store("a", &auth.Token{AccessToken: "token-a", TokenType: "Bearer", Expiry: expiry}))
+	require.NoError(t, cache.Store("b", &auth.Token{AccessToken: "token-b"}))
+
+	// the tokens are read back by another cache with the same key
+	other, err := NewFileTokenCache(path, key)
+	require.NoError(t, err)
+	token, err = other.Load("a")
+	require.NoError(t, err)
+	assert.Equal(t, "token-a", token.AccessToken)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.True(t, expiry.Equal(token.Expiry))
+	token, err = other.Load("b")
+	require.NoError(t, err)
+	assert.Equal(t, "token-b", token.AccessToken)
+
+	// the file is encrypted and only readable by the user
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "token-a")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// a cache with another key can't read the tokens, and replaces them
+	wrongKey, err := NewFileTokenCache(path, bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+	_, err = wrongKey.Load("a")
+	assert.Error(t, err)
+	require.NoError(t, wrongKey.Store("c", &auth.Token{AccessToken: "token-c"}))
+	token, err = wrongKey.Load("a")
+	require.NoError(t, err)
+	assert.Nil(t, token)
+
+	_, err = NewFileTokenCache(path, []byte("short"))
+	assert.Error(t, err)
+}
+
+// memoryTokenCache is a TokenCache kept in memory
+type memoryTokenCache map[string]*auth.Token
+
+func (c memoryTokenCache) Load(key string) (*auth.Token, error) { return c[key], nil }
+func (c memoryTokenCache) Store(key string, token *auth.Token) error {
+	c[key] = token
+	return nil
+}
+
+func TestCachedTokenSource(t *testing.T) {
+	var calls int
+	source := auth.TokenSourceFunc(func(ctx context.Context) (*auth.Token, error) {
+		calls++
+		return &auth.Token{AccessToken: "fresh", Expiry: time.Now().Add(time.Hour)}, nil
+	})
+	cache := memoryTokenCache{}
+	key := CacheKey("example.cloud.databricks.com", "client", nil)
+	assert.Equal(t, "https://example.cloud.databricks.com#client#all-apis", key)
+	assert.Equal(t, key, CacheKey("example.cloud.databricks.com", "client", DefaultScopes))
+	assert.Equal(t, "https://example.cloud.databricks.com#client#offline_access sql",
+		CacheKey("example.cloud.databricks.com", "client", []string{"sql", "offline_access"}))
+	assert.Equal(t, CacheKey("example.cloud.databricks.com", "client", []string{"sql", "offline_access"}),
+		CacheKey("example.cloud.databricks.com", "client", []string{"offline_access", "sql"}))
+	cached := &CachedTokenSource{Cache: cache, Key: key, Source: source}
+
+	// the first token is requested and stored, then reused
+	for i := 0; i < 2; i++ {
+		token, err := cached.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "fresh", token.AccessToken)
+	}
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "fresh", cache[key].AccessToken)
+
+	// tokens about to expire are requested again
+	cache[key] = &auth.Token{AccessToken: "stale", Expiry: time.Now().Add(time.Minute)}
+	token, err := cached.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", token.AccessToken)
+	assert.Equal(t, 2, calls)
+}
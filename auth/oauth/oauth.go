@@ -0,0 +1,191 @@
+// Package oauth implements authentication with Databricks OAuth, for service
+// principals with the client credentials grant.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/pkg/errors"
+)
+
+// DefaultScopes are the scopes requested when none are set, which allow the
+// tokens to be used with the SQL warehouses and the workspace REST APIs.
+var DefaultScopes = []string{"all-apis"}
+
+// Endpoints are the OAuth endpoints of a workspace used by the client credentials
+// grant. They are discovered from the well-known OpenID configuration of the
+// workspace unless set, e.g. to go through a private link or a custom identity
+// provider.
+type Endpoints struct {
+	// TokenEndpoint is where tokens are issued
+	TokenEndpoint string `json:"token_endpoint"`
+}
+
+// DiscoverEndpoints returns the OAuth endpoints of the workspace at host, from
+// https://<host>/oidc/.well-known/oauth-authorization-server. A nil client uses
+// http.DefaultClient.
+func DiscoverEndpoints(ctx context.Context, client *http.Client, host string) (Endpoints, error) {
+	var endpoints Endpoints
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hostURL(host)+"/oidc/.well-known/oauth-authorization-server", nil)
+	if err != nil {
+		return endpoints, errors.Wrap(err, "databricks: failed to discover OAuth endpoints")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return endpoints, errors.Wrap(err, "databricks: failed to discover OAuth endpoints")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return endpoints, errors.Errorf("databricks: failed to discover OAuth endpoints: HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&endpoints); err != nil {
+		return endpoints, errors.Wrap(err, "databricks: failed to discover OAuth endpoints")
+	}
+	if endpoints.TokenEndpoint == "" {
+		return endpoints, errors.New("databricks: failed to discover OAuth endpoints: no token endpoint")
+	}
+	return endpoints, nil
+}
+
+// ClientCredentials is a token source that gets tokens for a service principal
+// with the client credentials grant. Use it with auth.NewTokenAuthenticator,
+// which caches the tokens until they are about to expire:
+//
+//	authr := auth.NewTokenAuthenticator(&oauth.ClientCredentials{
+//		Host:         host,
+//		ClientID:     clientID,
+//		ClientSecret: clientSecret,
+//	}, 0)
+type ClientCredentials struct {
+	// Host is the hostname of the workspace
+	Host         string
+	ClientID     string
+	ClientSecret string
+	// Scopes are the scopes requested, DefaultScopes when empty
+	Scopes []string
+	// Endpoints override the endpoints discovered from the workspace. Endpoints
+	// that are not set are discovered.
+	Endpoints Endpoints
+	// HTTPClient sends the requests to the endpoints, http.DefaultClient when nil
+	HTTPClient *http.Client
+
+	mu            sync.Mutex
+	tokenEndpoint string
+}
+
+var _ auth.TokenSource = (*ClientCredentials)(nil)
+
+// Token requests a new token from the token endpoint.
+func (c *ClientCredentials) Token(ctx context.Context) (*auth.Token, error) {
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return nil, errors.New("databricks: OAuth client id and secret must be set")
+	}
+	endpoint, err := c.getTokenEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scopes := c.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {strings.Join(scopes, " ")},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "databricks: failed to request OAuth token")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	return requestToken(c.httpClient(), req)
+}
+
+// getTokenEndpoint returns the token endpoint that was set, or discovers it once
+func (c *ClientCredentials) getTokenEndpoint(ctx context.Context) (string, error) {
+	if c.Endpoints.TokenEndpoint != "" {
+		return c.Endpoints.TokenEndpoint, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tokenEndpoint == "" {
+		endpoints, err := DiscoverEndpoints(ctx, c.httpClient(), c.Host)
+		if err != nil {
+			return "", err
+		}
+		c.tokenEndpoint = endpoints.TokenEndpoint
+	}
+	return c.tokenEndpoint, nil
+}
+
+func (c *ClientCredentials) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// tokenResponse is the response of a token endpoint, or its error
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requestToken sends a token request and returns the token of the response
+func requestToken(client *http.Client, req *http.Request) (*auth.Token, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "databricks: failed to request OAuth token")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrap(err, "databricks: failed to request OAuth token")
+	}
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, errors.Wrap(err, "databricks: invalid OAuth token response")
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		msg := fmt.Sprintf("HTTP %d", resp.StatusCode)
+		if token.Error != "" {
+			msg = token.Error
+			if token.ErrorDescription != "" {
+				msg += ": " + token.ErrorDescription
+			}
+		}
+		return nil, errors.Errorf("databricks: failed to request OAuth token: %s", msg)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("databricks: invalid OAuth token response: no access token")
+	}
+	t := &auth.Token{AccessToken: token.AccessToken, TokenType: token.TokenType}
+	if token.ExpiresIn > 0 {
+		t.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return t, nil
+}
+
+// hostURL returns the base url of the workspace at host, which may include the scheme
+func hostURL(host string) string {
+	host = strings.TrimSuffix(host, "/")
+	if strings.HasPrefix(host, "https://") || strings.HasPrefix(host, "http://") {
+		return host
+	}
+	return "https://" + host
+}
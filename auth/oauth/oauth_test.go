@@ -0,0 +1,124 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer returns a workspace that serves its discovery document, and tokens
+// from /oidc/v1/token and /custom/token. The forms of the token requests are recorded.
+func newTestServer(t *testing.T, forms *[]map[string]string, discoveries *int) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oidc/.well-known/oauth-authorization-server":
+			*discoveries++
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"authorization_endpoint": ts.URL + "/oidc/v1/authorize",
+				"token_endpoint":         ts.URL + "/oidc/v1/token",
+			})
+		case "/oidc/v1/token", "/custom/token":
+			require.NoError(t, r.ParseForm())
+			id, secret, ok := r.BasicAuth()
+			if !ok || id != "client" || secret != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client", "error_description": "bad secret"})
+				return
+			}
+			*forms = append(*forms, map[string]string{
+				"path":       r.URL.Path,
+				"grant_type": r.PostForm.Get("grant_type"),
+				"scope":      r.PostForm.Get("scope"),
+			})
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "token_type": "Bearer", "expires_in": 3600})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return ts
+}
+
+func TestClientCredentials(t *testing.T) {
+	t.Run("discovers the token endpoint once and requests the default scopes", func(t *testing.T) {
+		var forms []map[string]string
+		var discoveries int
+		ts := newTestServer(t, &forms, &discoveries)
+		defer ts.Close()
+
+		source := &ClientCredentials{Host: ts.URL, ClientID: "client", ClientSecret: "secret"}
+		for i := 0; i < 2; i++ {
+			token, err := source.Token(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, "token", token.AccessToken)
+			assert.Equal(t, "Bearer", token.TokenType)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Minute)
+		}
+		assert.Equal(t, 1, discoveries)
+		assert.Equal(t, []map[string]string{
+			{"path": "/oidc/v1/token", "grant_type": "client_credentials", "scope": "all-apis"},
+			{"path": "/oidc/v1/token", "grant_type": "client_credentials", "scope": "all-apis"},
+		}, forms)
+	})
+
+	t.Run("uses the scopes and token endpoint that are set", func(t *testing.T) {
+		var forms []map[string]string
+		var discoveries int
+		ts := newTestServer(t, &forms, &discoveries)
+		defer ts.Close()
+
+		source := &ClientCredentials{
+			Host:         "unreachable.example.com",
+			ClientID:     "client",
+			ClientSecret: "secret",
+			Scopes:       []string{"sql", "offline_access"},
+			Endpoints:    Endpoints{TokenEndpoint: ts.URL + "/custom/token"},
+		}
+		_, err := source.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, discoveries)
+		assert.Equal(t, []map[string]string{
+			{"path": "/custom/token", "grant_type": "client_credentials", "scope": "sql offline_access"},
+		}, forms)
+	})
+
+	t.Run("returns the errors of the token endpoint", func(t *testing.T) {
+		var forms []map[string]string
+		var discoveries int
+		ts := newTestServer(t, &forms, &discoveries)
+		defer ts.Close()
+
+		source := &ClientCredentials{Host: ts.URL, ClientID: "client", ClientSecret: "wrong"}
+		_, err := source.Token(context.Background())
+		assert.EqualError(t, err, "databricks: failed to request OAuth token: invalid_client: bad secret")
+
+		_, err = (&ClientCredentials{Host: ts.URL}).Token(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+func TestDiscoverEndpoints(t *testing.T) {
+	var forms []map[string]string
+	var discoveries int
+	ts := newTestServer(t, &forms, &discoveries)
+	defer ts.Close()
+
+	endpoints, err := DiscoverEndpoints(context.Background(), nil, ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, Endpoints{TokenEndpoint: ts.URL + "/oidc/v1/token"}, endpoints)
+
+	_, err = DiscoverEndpoints(context.Background(), nil, ts.URL+"/missing")
+	assert.Error(t, err)
+}
+
+func TestHostURL(t *testing.T) {
+	assert.Equal(t, "https://example.cloud.databricks.com", hostURL("example.cloud.databricks.com"))
+	assert.Equal(t, "https://example.cloud.databricks.com", hostURL("https://example.cloud.databricks.com/"))
+	assert.Equal(t, "http://localhost:8080", hostURL("http://localhost:8080"))
+}
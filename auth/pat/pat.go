@@ -0,0 +1,24 @@
+// Package pat implements authentication with Databricks personal access tokens.
+package pat
+
+import (
+	"net/http"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/pkg/errors"
+)
+
+// PATAuth authenticates requests with a personal access token.
+type PATAuth struct {
+	AccessToken string
+}
+
+var _ auth.Authenticator = (*PATAuth)(nil)
+
+func (a *PATAuth) Authenticate(r *http.Request) error {
+	if a.AccessToken == "" {
+		return errors.New("databricks: empty personal access token")
+	}
+	r.Header.Set("Authorization", "Bearer "+a.AccessToken)
+	return nil
+}
@@ -0,0 +1,18 @@
+package pat
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPATAuth(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://localhost", nil)
+	a := &PATAuth{AccessToken: "dapi123"}
+	assert.NoError(t, a.Authenticate(req))
+	assert.Equal(t, "Bearer dapi123", req.Header.Get("Authorization"))
+
+	empty := &PATAuth{}
+	assert.Error(t, empty.Authenticate(req))
+}
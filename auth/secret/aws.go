@@ -0,0 +1,167 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/pkg/errors"
+)
+
+// AWSCredentials are the credentials of an AWS principal.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials
+	SessionToken string
+}
+
+// AWSSecretsManager resolves secrets from AWS Secrets Manager, with the
+// GetSecretValue API. The name of a secret is its name or ARN, followed by #field
+// for a field of a secret stored as a JSON object, e.g. prod/databricks#token.
+type AWSSecretsManager struct {
+	// Region is the region of the secrets, AWS_REGION when empty
+	Region string
+	// Credentials sign the requests, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+	// AWS_SESSION_TOKEN when empty. Credentials that expire, e.g. of a role, are
+	// returned by CredentialsFunc instead
+	Credentials AWSCredentials
+	// CredentialsFunc returns the credentials for each request when set
+	CredentialsFunc func(ctx context.Context) (AWSCredentials, error)
+	// Endpoint is the URL of the API, https://secretsmanager.<region>.amazonaws.com
+	// when empty, e.g. for VPC endpoints
+	Endpoint string
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+}
+
+var _ auth.SecretResolver = (*AWSSecretsManager)(nil)
+
+func (m *AWSSecretsManager) Resolve(ctx context.Context, name string) (string, error) {
+	id, field := splitField(name)
+	region := orEnv(m.Region, "AWS_REGION")
+	if region == "" {
+		return "", errors.New("aws secrets manager: no region is set")
+	}
+	creds, err := m.credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	endpoint := m.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", errors.Wrap(err, "aws secrets manager")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Wrap(err, "aws secrets manager")
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signV4(req, body, creds, region, "secretsmanager", time.Now())
+	resp, err := send(m.HTTPClient, req, "aws secrets manager")
+	if err != nil {
+		return "", err
+	}
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+		SecretBinary []byte  `json:"SecretBinary"`
+	}
+	if err := json.Unmarshal(resp, &secret); err != nil {
+		return "", errors.Wrap(err, "aws secrets manager: invalid response")
+	}
+	value := string(secret.SecretBinary)
+	if secret.SecretString != nil {
+		value = *secret.SecretString
+	}
+	return jsonField(value, field)
+}
+
+// credentials returns the credentials signing the requests
+func (m *AWSSecretsManager) credentials(ctx context.Context) (AWSCredentials, error) {
+	if m.CredentialsFunc != nil {
+		creds, err := m.CredentialsFunc(ctx)
+		return creds, errors.Wrap(err, "aws secrets manager")
+	}
+	creds := m.Credentials
+	if creds.AccessKeyID == "" {
+		creds = AWSCredentials{
+			AccessKeyID:     orEnv("", "AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: orEnv("", "AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    orEnv("", "AWS_SESSION_TOKEN"),
+		}
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return creds, errors.New("aws secrets manager: no credentials are set")
+	}
+	return creds, nil
+}
+
+// signV4 signs a request with AWS Signature Version 4, signing all its headers
+func signV4(req *http.Request, body []byte, creds AWSCredentials, region string, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
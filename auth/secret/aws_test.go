@@ -0,0 +1,78 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignV4(t *testing.T) {
+	// the example of the AWS Signature Version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, "+
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7", req.Header.Get("Authorization"))
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	var requests []map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request")
+		requests = append(requests, body)
+		switch body["SecretId"] {
+		case "prod/databricks":
+			_ = json.NewEncoder(w).Encode(map[string]string{"Name": "prod/databricks", "SecretString": `{"token":"json-token"}`})
+		case "prod/token":
+			_ = json.NewEncoder(w).Encode(map[string]string{"Name": "prod/token", "SecretString": "token"})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer ts.Close()
+
+	manager := &AWSSecretsManager{Region: "eu-west-1", Credentials: AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, Endpoint: ts.URL}
+	value, err := manager.Resolve(context.Background(), "prod/databricks#token")
+	require.NoError(t, err)
+	assert.Equal(t, "json-token", value)
+
+	value, err = manager.Resolve(context.Background(), "prod/token")
+	require.NoError(t, err)
+	assert.Equal(t, "token", value)
+
+	_, err = manager.Resolve(context.Background(), "prod/missing")
+	assert.ErrorContains(t, err, "aws secrets manager: HTTP 400: ")
+	assert.ErrorContains(t, err, "ResourceNotFoundException")
+	assert.Len(t, requests, 3)
+
+	_, err = (&AWSSecretsManager{Endpoint: ts.URL}).Resolve(context.Background(), "prod/token")
+	assert.EqualError(t, err, "aws secrets manager: no region is set")
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	_, err = (&AWSSecretsManager{Region: "eu-west-1", Endpoint: ts.URL}).Resolve(context.Background(), "prod/token")
+	assert.EqualError(t, err, "aws secrets manager: no credentials are set")
+
+	manager = &AWSSecretsManager{Region: "eu-west-1", Endpoint: ts.URL, CredentialsFunc: func(ctx context.Context) (AWSCredentials, error) {
+		return AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"}, nil
+	}}
+	value, err = manager.Resolve(context.Background(), "prod/token")
+	require.NoError(t, err)
+	assert.Equal(t, "token", value)
+}
@@ -0,0 +1,99 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/pkg/errors"
+)
+
+// gcpMetadataTokenURL returns the tokens of the service account of the GCP
+// instance, workload or function the driver runs in
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCPSecretManager resolves secrets from GCP Secret Manager. The name of a secret is
+// its resource name, e.g. projects/my-project/secrets/databricks-token, which
+// resolves its latest version, or projects/my-project/secrets/databricks-token/versions/3,
+// followed by #field for a field of a secret stored as a JSON object.
+type GCPSecretManager struct {
+	// TokenSource returns the OAuth tokens of the requests. When nil, the tokens of
+	// the service account of the instance are taken from the metadata server
+	TokenSource auth.TokenSource
+	// Endpoint is the URL of the API, https://secretmanager.googleapis.com when empty
+	Endpoint string
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+}
+
+var _ auth.SecretResolver = (*GCPSecretManager)(nil)
+
+func (m *GCPSecretManager) Resolve(ctx context.Context, name string) (string, error) {
+	resource, field := splitField(name)
+	resource = strings.Trim(resource, "/")
+	if !strings.Contains(resource, "/versions/") {
+		resource += "/versions/latest"
+	}
+	endpoint := m.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretmanager.googleapis.com"
+	}
+	token, err := m.token(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/v1/"+resource+":access", nil)
+	if err != nil {
+		return "", errors.Wrap(err, "gcp secret manager")
+	}
+	tokenType := token.TokenType
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+	req.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+	body, err := send(m.HTTPClient, req, "gcp secret manager")
+	if err != nil {
+		return "", err
+	}
+	var version struct {
+		Payload struct {
+			Data []byte `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &version); err != nil {
+		return "", errors.Wrap(err, "gcp secret manager: invalid response")
+	}
+	return jsonField(string(version.Payload.Data), field)
+}
+
+// token returns the token of the requests, from the token source or the metadata server
+func (m *GCPSecretManager) token(ctx context.Context) (*auth.Token, error) {
+	if m.TokenSource != nil {
+		token, err := m.TokenSource.Token(ctx)
+		if err == nil && (token == nil || token.AccessToken == "") {
+			err = errors.New("the token source returned an empty token")
+		}
+		return token, errors.Wrap(err, "gcp secret manager")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "gcp secret manager")
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	body, err := send(m.HTTPClient, req, "gcp secret manager: metadata server")
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.AccessToken == "" {
+		return nil, errors.New("gcp secret manager: invalid metadata server token")
+	}
+	return &auth.Token{AccessToken: resp.AccessToken, TokenType: resp.TokenType, Expiry: time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)}, nil
+}
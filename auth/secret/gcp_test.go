@@ -0,0 +1,46 @@
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCPSecretManager(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gcp-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		paths = append(paths, r.URL.Path)
+		data := base64.StdEncoding.EncodeToString([]byte(`{"token":"json-token"}`))
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": r.URL.Path, "payload": map[string]string{"data": data}})
+	}))
+	defer ts.Close()
+
+	tokens := auth.TokenSourceFunc(func(ctx context.Context) (*auth.Token, error) {
+		return &auth.Token{AccessToken: "gcp-token"}, nil
+	})
+	manager := &GCPSecretManager{TokenSource: tokens, Endpoint: ts.URL}
+	value, err := manager.Resolve(context.Background(), "projects/p/secrets/databricks#token")
+	require.NoError(t, err)
+	assert.Equal(t, "json-token", value)
+
+	value, err = manager.Resolve(context.Background(), "projects/p/secrets/databricks/versions/3")
+	require.NoError(t, err)
+	assert.Equal(t, `{"token":"json-token"}`, value)
+	assert.Equal(t, []string{"/v1/projects/p/secrets/databricks/versions/latest:access", "/v1/projects/p/secrets/databricks/versions/3:access"}, paths)
+
+	_, err = (&GCPSecretManager{Endpoint: ts.URL, TokenSource: auth.TokenSourceFunc(func(ctx context.Context) (*auth.Token, error) {
+		return &auth.Token{AccessToken: "expired"}, nil
+	})}).Resolve(context.Background(), "projects/p/secrets/databricks")
+	assert.EqualError(t, err, "gcp secret manager: HTTP 401")
+}
@@ -0,0 +1,122 @@
+// Package secret implements auth.SecretResolver for the common secret managers, so
+// that the access tokens used by the driver are rotated in the secret manager
+// without restarting the services. Use them with dbsql.WithSecretResolver:
+//
+//	connector, err := dbsql.NewConnector(
+//		dbsql.WithServerHostname(host),
+//		dbsql.WithHTTPPath(httpPath),
+//		dbsql.WithSecretResolver(&secret.Vault{}, "secret/data/databricks#token"),
+//	)
+//
+// The secrets stored as JSON objects, e.g. in AWS Secrets Manager, are resolved to
+// one of their fields by appending #field to the name.
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/pkg/errors"
+)
+
+// maxResponseSize is the max size of the responses of the secret managers
+const maxResponseSize = 1 << 20
+
+// Env resolves secrets from the environment variables named by the secrets.
+type Env struct{}
+
+var _ auth.SecretResolver = Env{}
+
+func (Env) Resolve(ctx context.Context, name string) (string, error) {
+	name, field := splitField(name)
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.Errorf("secret: environment variable %s is not set", name)
+	}
+	return jsonField(value, field)
+}
+
+// Files resolves secrets from the files named by the secrets, e.g. mounted by the
+// Secrets Store CSI driver or written by the Vault agent, which rotate them in place.
+type Files struct {
+	// Dir is the directory of the relative names, the working directory when empty
+	Dir string
+}
+
+var _ auth.SecretResolver = Files{}
+
+func (f Files) Resolve(ctx context.Context, name string) (string, error) {
+	name, field := splitField(name)
+	if !filepath.IsAbs(name) && f.Dir != "" {
+		name = filepath.Join(f.Dir, name)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return "", errors.Wrap(err, "secret")
+	}
+	return jsonField(string(b), field)
+}
+
+// splitField splits the name of a secret from the field of its JSON value, if any
+func splitField(name string) (string, string) {
+	name, field, _ := strings.Cut(name, "#")
+	return name, field
+}
+
+// jsonField returns the field of a secret stored as a JSON object, or the secret
+// itself if no field is named
+func jsonField(value string, field string) (string, error) {
+	if field == "" {
+		return value, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", errors.Wrapf(err, "secret: field %s of a secret that is not a JSON object", field)
+	}
+	return stringField(fields, field)
+}
+
+// stringField returns a string field of a JSON object
+func stringField(fields map[string]any, field string) (string, error) {
+	v, ok := fields[field]
+	if !ok {
+		return "", errors.Errorf("secret: the secret has no field %s", field)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.Errorf("secret: field %s of the secret is not a string", field)
+	}
+	return s, nil
+}
+
+// send sends a request to a secret manager and returns the body of the response,
+// or an error including the message of the response
+func send(client *http.Client, req *http.Request, manager string) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, manager)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, errors.Wrap(err, manager)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := fmt.Sprintf("HTTP %d", resp.StatusCode)
+		if text := strings.TrimSpace(string(body)); text != "" && len(text) < 512 {
+			msg += ": " + text
+		}
+		return nil, errors.Errorf("%s: %s", manager, msg)
+	}
+	return body, nil
+}
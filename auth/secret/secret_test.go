@@ -0,0 +1,49 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv(t *testing.T) {
+	t.Setenv("DATABRICKS_TEST_SECRET", "token")
+	t.Setenv("DATABRICKS_TEST_JSON_SECRET", `{"token": "json-token", "port": 443}`)
+
+	value, err := Env{}.Resolve(context.Background(), "DATABRICKS_TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "token", value)
+
+	value, err = Env{}.Resolve(context.Background(), "DATABRICKS_TEST_JSON_SECRET#token")
+	require.NoError(t, err)
+	assert.Equal(t, "json-token", value)
+
+	_, err = Env{}.Resolve(context.Background(), "DATABRICKS_TEST_JSON_SECRET#port")
+	assert.EqualError(t, err, "secret: field port of the secret is not a string")
+	_, err = Env{}.Resolve(context.Background(), "DATABRICKS_TEST_JSON_SECRET#host")
+	assert.EqualError(t, err, "secret: the secret has no field host")
+	_, err = Env{}.Resolve(context.Background(), "DATABRICKS_TEST_SECRET#token")
+	assert.ErrorContains(t, err, "secret: field token of a secret that is not a JSON object")
+	_, err = Env{}.Resolve(context.Background(), "DATABRICKS_TEST_MISSING_SECRET")
+	assert.EqualError(t, err, "secret: environment variable DATABRICKS_TEST_MISSING_SECRET is not set")
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("token\n"), 0600))
+
+	value, err := Files{Dir: dir}.Resolve(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, "token\n", value)
+
+	value, err = Files{}.Resolve(context.Background(), filepath.Join(dir, "token"))
+	require.NoError(t, err)
+	assert.Equal(t, "token\n", value)
+
+	_, err = Files{Dir: dir}.Resolve(context.Background(), "missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/pkg/errors"
+)
+
+// Vault resolves secrets from the KV secrets engine, version 1 or 2, of HashiCorp
+// Vault. The name of a secret is its API path and field, e.g.
+// secret/data/databricks#token for the token field of the secret databricks of the
+// KV version 2 engine mounted at secret.
+type Vault struct {
+	// Address is the address of the Vault server, VAULT_ADDR when empty
+	Address string
+	// Token is the Vault token, VAULT_TOKEN when empty
+	Token string
+	// Namespace is the Vault Enterprise namespace, VAULT_NAMESPACE when empty
+	Namespace string
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+}
+
+var _ auth.SecretResolver = (*Vault)(nil)
+
+func (v *Vault) Resolve(ctx context.Context, name string) (string, error) {
+	path, field := splitField(name)
+	if field == "" {
+		return "", errors.Errorf("vault: secret %s names no field, append #field", name)
+	}
+	address := orEnv(v.Address, "VAULT_ADDR")
+	if address == "" {
+		return "", errors.New("vault: no address is set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", errors.Wrap(err, "vault")
+	}
+	if token := orEnv(v.Token, "VAULT_TOKEN"); token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := orEnv(v.Namespace, "VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	body, err := send(v.HTTPClient, req, "vault")
+	if err != nil {
+		return "", err
+	}
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", errors.Wrap(err, "vault: invalid response")
+	}
+	fields := secret.Data
+	// the KV version 2 engine nests the fields with the metadata of the version
+	if data, ok := fields["data"].(map[string]any); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = data
+		}
+	}
+	return stringField(fields, field)
+}
+
+// orEnv returns value, or the environment variable if value is empty
+func orEnv(value string, variable string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(variable)
+}
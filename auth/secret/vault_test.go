@@ -0,0 +1,51 @@
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVault(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/secret/data/databricks":
+			_, _ = w.Write([]byte(`{"data":{"data":{"token":"kv2-token"},"metadata":{"version":3}}}`))
+		case "/v1/kv/databricks":
+			_, _ = w.Write([]byte(`{"data":{"token":"kv1-token"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer ts.Close()
+
+	vault := &Vault{Address: ts.URL, Token: "vault-token", Namespace: "team"}
+	value, err := vault.Resolve(context.Background(), "secret/data/databricks#token")
+	require.NoError(t, err)
+	assert.Equal(t, "kv2-token", value)
+
+	value, err = vault.Resolve(context.Background(), "/kv/databricks#token")
+	require.NoError(t, err)
+	assert.Equal(t, "kv1-token", value)
+
+	_, err = vault.Resolve(context.Background(), "secret/data/missing#token")
+	assert.EqualError(t, err, `vault: HTTP 404: {"errors":[]}`)
+	_, err = vault.Resolve(context.Background(), "secret/data/databricks")
+	assert.EqualError(t, err, "vault: secret secret/data/databricks names no field, append #field")
+
+	t.Setenv("VAULT_ADDR", ts.URL)
+	t.Setenv("VAULT_TOKEN", "wrong-token")
+	_, err = (&Vault{Namespace: "team"}).Resolve(context.Background(), "secret/data/databricks#token")
+	assert.EqualError(t, err, `vault: HTTP 403: {"errors":["permission denied"]}`)
+}
@@ -0,0 +1,202 @@
+/*
+Package bench measures the performance of the driver against an endpoint of your own,
+so that regressions between driver versions can be found with your data and warehouse.
+
+The benchmarks take the *testing.B of a benchmark function in your tests:
+
+	func BenchmarkEvents(b *testing.B) {
+		db, err := sql.Open("databricks", os.Getenv("DATABRICKS_DSN"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer db.Close()
+		bench.RowDecode(b, db, "SELECT * FROM events LIMIT 100000")
+	}
+
+The benchmarks of this package run against the DSN in the DATABRICKS_BENCH_DSN
+environment variable:
+
+	DATABRICKS_BENCH_DSN="token:<token>@<host>:443/<path>" go test -bench . ./bench
+
+Results are fetched as Thrift column sets, the only result format the driver reads,
+so there is no Arrow to compare them with yet.
+*/
+package bench
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// RowDecode runs query b.N times and reads all of its rows, reporting the number of
+// rows decoded per second.
+func RowDecode(b *testing.B, db *sql.DB, query string) {
+	ctx := context.Background()
+	var total int
+	start := time.Now()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n, err := readRows(ctx, db, query, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		total += n
+	}
+	b.ReportMetric(float64(total)/time.Since(start).Seconds(), "rows/s")
+}
+
+// PageFetch runs query b.N times and reports the time to the first row, which
+// includes running the statement, and the average time to fetch each following page
+// of pageSize rows. pageSize must be the maxRows of the connection.
+func PageFetch(b *testing.B, db *sql.DB, query string, pageSize int) {
+	if pageSize <= 0 {
+		b.Fatal("bench: pageSize must be positive")
+	}
+	ctx := context.Background()
+	var firstRow, fetch time.Duration
+	var pages int
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		var first time.Time
+		n, err := readRows(ctx, db, query, func(row int) {
+			if row == 0 {
+				first = time.Now()
+			}
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n == 0 {
+			b.Fatal("bench: query returned no rows")
+		}
+		firstRow += first.Sub(start)
+		fetch += time.Since(first)
+		pages += (n - 1) / pageSize
+	}
+	b.ReportMetric(float64(firstRow)/float64(time.Millisecond)/float64(b.N), "ms/first-row")
+	if pages > 0 {
+		b.ReportMetric(float64(fetch)/float64(time.Millisecond)/float64(pages), "ms/page")
+	}
+}
+
+// readRows runs query and scans all of its rows, calling onRow before each row
+func readRows(ctx context.Context, db *sql.DB, query string, onRow func(row int)) (int, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]any, len(cols))
+	dest := make([]any, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var n int
+	for rows.Next() {
+		if onRow != nil {
+			onRow(n)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+// LoadOptions sets how Load runs the query.
+type LoadOptions struct {
+	// Concurrency is the number of goroutines running the query, 1 if not set
+	Concurrency int
+	// Duration is how long the query is run for
+	Duration time.Duration
+}
+
+// LoadResult summarizes the queries run by Load.
+type LoadResult struct {
+	// Queries is the number of queries that read all of their rows
+	Queries int
+	// Errors is the number of queries that failed
+	Errors int
+	// Rows is the number of rows read
+	Rows int
+	// Elapsed is how long the load ran for
+	Elapsed time.Duration
+	// P50, P95 and P99 are percentiles of the latencies of the queries that did not fail
+	P50, P95, P99 time.Duration
+	// FirstError is the first error returned by a query, if any
+	FirstError error
+}
+
+// Load runs query from opts.Concurrency goroutines until opts.Duration has passed or
+// ctx is done, to measure the driver and the warehouse under concurrent load.
+func Load(ctx context.Context, db *sql.DB, query string, opts LoadOptions) *LoadResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var latencies []time.Duration
+	res := &LoadResult{}
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				queryStart := time.Now()
+				n, err := readRows(ctx, db, query, nil)
+				latency := time.Since(queryStart)
+
+				mu.Lock()
+				res.Rows += n
+				switch {
+				case err == nil:
+					res.Queries++
+					latencies = append(latencies, latency)
+				case ctx.Err() == nil:
+					// queries cut short by the end of the load are not errors
+					res.Errors++
+					if res.FirstError == nil {
+						res.FirstError = err
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	res.Elapsed = time.Since(start)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	res.P50 = percentile(latencies, 50)
+	res.P95 = percentile(latencies, 95)
+	res.P99 = percentile(latencies, 99)
+	return res
+}
+
+// percentile returns the p-th percentile of sorted latencies, with the nearest rank method
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,106 @@
+package bench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/databricks/databricks-sql-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// benchDB opens the endpoint in DATABRICKS_BENCH_DSN, skipping the benchmark if it is not set
+func benchDB(b *testing.B) *sql.DB {
+	dsn := os.Getenv("DATABRICKS_BENCH_DSN")
+	if dsn == "" {
+		b.Skip("DATABRICKS_BENCH_DSN is not set")
+	}
+	db, err := sql.Open("databricks", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return db
+}
+
+func BenchmarkRowDecode(b *testing.B) {
+	RowDecode(b, benchDB(b), "SELECT id, cast(id AS string), id * 1.5 FROM range(100000)")
+}
+
+func BenchmarkPageFetch(b *testing.B) {
+	// 10000 is the default maxRows
+	PageFetch(b, benchDB(b), "SELECT id FROM range(100000)", 10000)
+}
+
+// fakeConnector opens connections whose queries return rows rows of one column
+type fakeConnector struct {
+	rows int
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+var errNotSupported = errors.New("fake: not supported")
+
+type fakeConn struct {
+	rows int
+}
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errNotSupported }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errNotSupported }
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{left: c.rows}, nil
+}
+
+type fakeRows struct {
+	left int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.left == 0 {
+		return io.EOF
+	}
+	r.left--
+	dest[0] = int64(r.left)
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	db := sql.OpenDB(fakeConnector{rows: 3})
+	defer db.Close()
+
+	res := Load(context.Background(), db, "select id", LoadOptions{Concurrency: 4, Duration: 50 * time.Millisecond})
+	assert.Greater(t, res.Queries, 0)
+	assert.Equal(t, 0, res.Errors)
+	// rows read by the queries cut short by the end of the load are counted too
+	assert.GreaterOrEqual(t, res.Rows, 3*res.Queries)
+	assert.LessOrEqual(t, res.Rows, 3*res.Queries+3*4)
+	assert.LessOrEqual(t, res.P50, res.P95)
+	assert.LessOrEqual(t, res.P95, res.P99)
+	assert.GreaterOrEqual(t, res.Elapsed, 50*time.Millisecond)
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	assert.Equal(t, time.Millisecond, percentile(latencies[:1], 95))
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+}
+
+func BenchmarkRowDecodeFake(b *testing.B) {
+	db := sql.OpenDB(fakeConnector{rows: 1000})
+	defer db.Close()
+	RowDecode(b, db, "select id")
+}
@@ -0,0 +1,139 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/pkg/errors"
+)
+
+// the protocol version from which the server binds statement parameters, newer
+// than the Thrift definitions of the driver
+const protocolNativeParameters = cli_service.TProtocolVersion_SPARK_CLI_SERVICE_PROTOCOL_V6 + 2
+
+// Capabilities are the features of the endpoint of a connection, derived from the
+// protocol version negotiated when its session was opened and from the name and
+// version of the server, so that applications can branch at runtime, e.g. to store
+// JSON as VARIANT where the endpoint supports it.
+type Capabilities struct {
+	// ProtocolVersion is the Thrift protocol version of the session, e.g. 42246 for
+	// SPARK_CLI_SERVICE_PROTOCOL_V6
+	ProtocolVersion int
+	// ServerName and ServerVersion are the name and version of the SQL engine, e.g.
+	// Spark SQL and 3.5.0
+	ServerName    string
+	ServerVersion string
+	// NativeParameters is set when the server binds statement parameters, from
+	// SPARK_CLI_SERVICE_PROTOCOL_V8. The driver binds them as SQL literals either way.
+	NativeParameters bool
+	// Arrow is set when the server returns results as Arrow batches with native types,
+	// from SPARK_CLI_SERVICE_PROTOCOL_V5
+	Arrow bool
+	// CloudFetch is set when the server returns large results as links to cloud
+	// storage, from SPARK_CLI_SERVICE_PROTOCOL_V3
+	CloudFetch bool
+	// MultipleCatalogs is set when the session can use the catalogs of Unity Catalog
+	MultipleCatalogs bool
+	// VariantType is set when the engine has the VARIANT type. Databricks Runtime has
+	// it before Spark 4.0, and reports the version of Spark it is based on, so the
+	// type is probed with a query.
+	VariantType bool
+	// MultipleResultSets is set when a statement returns several result sets, which
+	// the Thrift protocol does not do
+	MultipleResultSets bool
+}
+
+// CapabilityReporter is implemented by the connections of this driver. Use
+// sql.Conn.Raw to get it, or ConnCapabilities.
+type CapabilityReporter interface {
+	// Capabilities returns the features of the endpoint of the connection. The
+	// server version is requested, and the VARIANT type probed, once per connection.
+	Capabilities(ctx context.Context) (Capabilities, error)
+}
+
+var _ CapabilityReporter = (*conn)(nil)
+
+func (c *conn) Capabilities(ctx context.Context) (Capabilities, error) {
+	if c.capabilities != nil {
+		return *c.capabilities, nil
+	}
+	ctx = driverctx.NewContextWithConnId(ctx, c.id)
+	name, err := c.getInfo(ctx, cli_service.TGetInfoType_CLI_DBMS_NAME)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	version, err := c.getInfo(ctx, cli_service.TGetInfoType_CLI_DBMS_VER)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	protocol := c.session.GetServerProtocolVersion()
+	caps := Capabilities{
+		ProtocolVersion:  int(protocol),
+		ServerName:       name,
+		ServerVersion:    version,
+		NativeParameters: protocol >= protocolNativeParameters,
+		Arrow:            protocol >= cli_service.TProtocolVersion_SPARK_CLI_SERVICE_PROTOCOL_V5,
+		CloudFetch:       protocol >= cli_service.TProtocolVersion_SPARK_CLI_SERVICE_PROTOCOL_V3,
+		MultipleCatalogs: protocol >= cli_service.TProtocolVersion_SPARK_CLI_SERVICE_PROTOCOL_V4 && c.session.GetCanUseMultipleCatalogs(),
+	}
+	if caps.VariantType, err = c.hasVariantType(ctx); err != nil {
+		return Capabilities{}, err
+	}
+	c.capabilities = &caps
+	return caps, nil
+}
+
+// hasVariantType runs a query parsing JSON to a VARIANT, which fails on engines
+// without the type
+func (c *conn) hasVariantType(ctx context.Context) (bool, error) {
+	rows, err := c.QueryContext(driverQuery(ctx), "SELECT typeof(parse_json('1'))", nil)
+	if err != nil {
+		var execErr *ExecutionError
+		if errors.As(err, &execErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer rows.Close()
+	dest := make([]driver.Value, 1)
+	if err := rows.Next(dest); err != nil {
+		return false, wrapErr(err, "failed to read the VARIANT type probe")
+	}
+	return dest[0] == "variant", nil
+}
+
+// getInfo returns the string value of an info of the server
+func (c *conn) getInfo(ctx context.Context, infoType cli_service.TGetInfoType) (string, error) {
+	resp, err := c.client.GetInfo(ctx, &cli_service.TGetInfoReq{
+		SessionHandle: c.session.SessionHandle,
+		InfoType:      infoType,
+	})
+	if err != nil {
+		c.checkBroken(err)
+		return "", wrapErrf(err, "failed to get server info %s", infoType)
+	}
+	return resp.GetInfoValue().GetStringValue(), nil
+}
+
+// ConnCapabilities returns the features of the endpoint of conn.
+//
+//	caps, err := dbsql.ConnCapabilities(ctx, conn)
+//	if err == nil && caps.VariantType {
+//		...
+//	}
+func ConnCapabilities(ctx context.Context, conn *sql.Conn) (Capabilities, error) {
+	var caps Capabilities
+	err := conn.Raw(func(driverConn any) error {
+		r, ok := driverConn.(CapabilityReporter)
+		if !ok {
+			return errors.New(ErrNotImplemented)
+		}
+		var err error
+		caps, err = r.Capabilities(ctx)
+		return err
+	})
+	return caps, err
+}
@@ -0,0 +1,140 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getCapabilitiesTestConn returns a connection to a server of version, whose engine
+// has the VARIANT type when variant is set
+func getCapabilitiesTestConn(protocol cli_service.TProtocolVersion, version string, variant bool, requests *int) *conn {
+	session := getTestSession()
+	session.ServerProtocolVersion = protocol
+	canUseMultipleCatalogs := true
+	session.CanUseMultipleCatalogs = &canUseMultipleCatalogs
+	cfg := config.WithDefaults()
+	cfg.PollInterval = 10 * time.Millisecond
+	return &conn{
+		session: session,
+		client: &client.TestClient{
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				*requests++
+				if !variant {
+					resp := &cli_service.TExecuteStatementResp{
+						Status: &cli_service.TStatus{
+							StatusCode:   cli_service.TStatusCode_ERROR_STATUS,
+							ErrorMessage: strPtr("[UNRESOLVED_ROUTINE] Cannot resolve function `parse_json`"),
+						},
+					}
+					return resp, client.CheckStatus(resp)
+				}
+				return &cli_service.TExecuteStatementResp{
+					Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+					OperationHandle: &cli_service.TOperationHandle{
+						OperationId:  &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+						HasResultSet: true,
+					},
+					DirectResults: &cli_service.TSparkDirectResults{
+						OperationStatus: &cli_service.TGetOperationStatusResp{
+							OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+						},
+						ResultSetMetadata: &cli_service.TGetResultSetMetadataResp{
+							Schema: &cli_service.TTableSchema{Columns: []*cli_service.TColumnDesc{{
+								ColumnName: "typeof(parse_json(1))",
+								TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{{
+									PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_STRING_TYPE},
+								}}},
+							}}},
+						},
+						ResultSet: &cli_service.TFetchResultsResp{
+							Results: &cli_service.TRowSet{Columns: []*cli_service.TColumn{
+								{StringVal: &cli_service.TStringColumn{Values: []string{"variant"}, Nulls: []byte{}}},
+							}},
+						},
+					},
+				}, nil
+			},
+			FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+				return &cli_service.TCloseOperationResp{}, nil
+			},
+			FnGetInfo: func(ctx context.Context, req *cli_service.TGetInfoReq) (*cli_service.TGetInfoResp, error) {
+				*requests++
+				value := "Spark SQL"
+				if req.InfoType == cli_service.TGetInfoType_CLI_DBMS_VER {
+					value = version
+				}
+				return &cli_service.TGetInfoResp{
+					Status:    &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+					InfoValue: &cli_service.TGetInfoValue{StringValue: &value},
+				}, nil
+			},
+		},
+		cfg: cfg,
+	}
+}
+
+func TestConn_Capabilities(t *testing.T) {
+	t.Run("capabilities follow the protocol and server versions", func(t *testing.T) {
+		var requests int
+		testConn := getCapabilitiesTestConn(cli_service.TProtocolVersion_SPARK_CLI_SERVICE_PROTOCOL_V6, "3.5.0", true, &requests)
+
+		caps, err := testConn.Capabilities(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, Capabilities{
+			ProtocolVersion:  42246,
+			ServerName:       "Spark SQL",
+			ServerVersion:    "3.5.0",
+			Arrow:            true,
+			CloudFetch:       true,
+			MultipleCatalogs: true,
+			VariantType:      true,
+		}, caps)
+
+		// the server is asked once per connection
+		_, err = testConn.Capabilities(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("older endpoints have fewer capabilities", func(t *testing.T) {
+		var requests int
+		db := sql.OpenDB(&testConnConnector{getCapabilitiesTestConn(cli_service.TProtocolVersion_SPARK_CLI_SERVICE_PROTOCOL_V3, "3.5.0", false, &requests)})
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+
+		caps, err := ConnCapabilities(context.Background(), c)
+		require.NoError(t, err)
+		assert.True(t, caps.CloudFetch)
+		assert.False(t, caps.Arrow)
+		assert.False(t, caps.MultipleCatalogs)
+		assert.False(t, caps.VariantType)
+		assert.False(t, caps.NativeParameters)
+	})
+
+	t.Run("failures are not cached", func(t *testing.T) {
+		var requests int
+		testConn := getCapabilitiesTestConn(cli_service.TProtocolVersion_SPARK_CLI_SERVICE_PROTOCOL_V6, "3.5.0", false, &requests)
+		getInfo := testConn.client.(*client.TestClient).FnGetInfo
+		testConn.client.(*client.TestClient).FnGetInfo = func(ctx context.Context, req *cli_service.TGetInfoReq) (*cli_service.TGetInfoResp, error) {
+			return nil, errors.New("unavailable")
+		}
+		_, err := testConn.Capabilities(context.Background())
+		assert.Error(t, err)
+
+		testConn.client.(*client.TestClient).FnGetInfo = getInfo
+		caps, err := testConn.Capabilities(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "3.5.0", caps.ServerVersion)
+	})
+}
@@ -0,0 +1,441 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var errCatalogInvalidName = "databricks: invalid object name"
+
+// TableColumn is a column of a table described by DescribeTable.
+type TableColumn struct {
+	Name string
+	// Type is the type text, e.g. DECIMAL(10,2) or ARRAY<INT>
+	Type    string
+	Comment string
+}
+
+// TableDescription is the structure of a table or view, as reported by
+// DESCRIBE TABLE EXTENDED.
+type TableDescription struct {
+	Catalog string
+	Schema  string
+	Name    string
+	// Type is MANAGED, EXTERNAL or VIEW
+	Type string
+	// Provider is the data source format, e.g. delta
+	Provider string
+	Location string
+	Owner    string
+	Comment  string
+	Columns  []TableColumn
+	// PartitionColumns are the names of the columns the table is partitioned by
+	PartitionColumns []string
+	// Details holds all the rows of the detailed table information, by name,
+	// including the ones above, e.g. "Created Time" or "Table Properties"
+	Details map[string]string
+}
+
+// TableInfo is a table or view listed by ShowTables.
+type TableInfo struct {
+	Schema      string
+	Name        string
+	IsTemporary bool
+}
+
+// TableDetail is the detail of a Delta table, as reported by DESCRIBE DETAIL.
+type TableDetail struct {
+	Format      string
+	ID          string
+	Name        string
+	Description string
+	Location    string
+	CreatedAt   time.Time
+	// LastModified is the time of the last write to the table
+	LastModified     time.Time
+	PartitionColumns []string
+	NumFiles         int64
+	SizeInBytes      int64
+	Properties       map[string]string
+	MinReaderVersion int64
+	MinWriterVersion int64
+}
+
+// Partition is a partition listed by ShowPartitions.
+type Partition struct {
+	// Values are the values of the partition columns, by column name
+	Values map[string]string
+}
+
+// TableExists returns true if the table or view exists. name may be qualified with
+// the schema and catalog, e.g. main.default.events. A missing schema or catalog is
+// reported as the table not existing.
+func TableExists(ctx context.Context, db Queryer, name string) (bool, error) {
+	quoted, err := quoteName(name)
+	if err != nil {
+		return false, err
+	}
+	rows, err := db.QueryContext(driverQuery(ctx), "DESCRIBE TABLE "+quoted)
+	if err != nil {
+		switch SQLState(err) {
+		case ErrCodeTableNotFound, ErrCodeSchemaNotFound, ErrCodeCatalogNotFound:
+			return false, nil
+		}
+		return false, wrapErrf(err, "failed to describe %s", name)
+	}
+	return true, rows.Close()
+}
+
+// CreateSchemaIfNotExists creates the schema unless it exists. name may be qualified
+// with the catalog, e.g. main.staging.
+func CreateSchemaIfNotExists(ctx context.Context, db Execer, name string) error {
+	quoted, err := quoteName(name)
+	if err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+quoted); err != nil {
+		return wrapErrf(err, "failed to create schema %s", name)
+	}
+	return nil
+}
+
+// DescribeTable returns the columns and the detailed information of a table or view.
+// name may be qualified with the schema and catalog, e.g. main.default.events.
+func DescribeTable(ctx context.Context, db Queryer, name string) (*TableDescription, error) {
+	lines, err := describeExtended(ctx, db, name)
+	if err != nil {
+		return nil, err
+	}
+	return tableDescription(lines), nil
+}
+
+// describeExtended returns the rows of DESCRIBE TABLE EXTENDED, trimmed
+func describeExtended(ctx context.Context, db Queryer, name string) ([][3]string, error) {
+	quoted, err := quoteName(name)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(driverQuery(ctx), "DESCRIBE TABLE EXTENDED "+quoted)
+	if err != nil {
+		return nil, wrapErrf(err, "failed to describe %s", name)
+	}
+	defer rows.Close()
+
+	var lines [][3]string
+	for rows.Next() {
+		var colName, dataType, comment sql.NullString
+		if err := rows.Scan(&colName, &dataType, &comment); err != nil {
+			return nil, wrapErrf(err, "failed to describe %s", name)
+		}
+		lines = append(lines, [3]string{strings.TrimSpace(colName.String), strings.TrimSpace(dataType.String), comment.String})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapErrf(err, "failed to describe %s", name)
+	}
+	return lines, nil
+}
+
+// ShowTables returns the tables and views of the schema, or of the current schema
+// when schema is "". schema may be qualified with the catalog, e.g. main.default.
+func ShowTables(ctx context.Context, db Queryer, schema string) ([]TableInfo, error) {
+	query, target := "SHOW TABLES", "the current schema"
+	if schema != "" {
+		target = schema
+		quoted, err := quoteName(schema)
+		if err != nil {
+			return nil, err
+		}
+		query += " IN " + quoted
+	}
+	rows, err := queryNamed(ctx, db, query)
+	if err != nil {
+		return nil, wrapErrf(err, "failed to show tables of %s", target)
+	}
+	tables := make([]TableInfo, 0, len(rows))
+	for _, row := range rows {
+		isTemporary, err := asBool(row["isTemporary"])
+		if err != nil {
+			return nil, wrapErrf(err, "failed to show tables of %s", target)
+		}
+		tables = append(tables, TableInfo{
+			Schema:      asString(row["database"]),
+			Name:        asString(row["tableName"]),
+			IsTemporary: isTemporary,
+		})
+	}
+	return tables, nil
+}
+
+// DescribeDetail returns the detail of a Delta table. name may be qualified with the
+// schema and catalog, e.g. main.default.events.
+func DescribeDetail(ctx context.Context, db Queryer, name string) (*TableDetail, error) {
+	quoted, err := quoteName(name)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queryNamed(ctx, db, "DESCRIBE DETAIL "+quoted)
+	if err != nil {
+		return nil, wrapErrf(err, "failed to describe detail of %s", name)
+	}
+	if len(rows) != 1 {
+		return nil, errors.Errorf("databricks: failed to describe detail of %s: got %d rows", name, len(rows))
+	}
+	row := rows[0]
+	detail := &TableDetail{
+		Format:      asString(row["format"]),
+		ID:          asString(row["id"]),
+		Name:        asString(row["name"]),
+		Description: asString(row["description"]),
+		Location:    asString(row["location"]),
+	}
+	for _, field := range []struct {
+		column string
+		parse  func(any) error
+	}{
+		{"createdAt", func(v any) (err error) { detail.CreatedAt, err = asTime(v); return }},
+		{"lastModified", func(v any) (err error) { detail.LastModified, err = asTime(v); return }},
+		{"partitionColumns", func(v any) error { return asJSON(v, &detail.PartitionColumns) }},
+		{"numFiles", func(v any) (err error) { detail.NumFiles, err = asInt64(v); return }},
+		{"sizeInBytes", func(v any) (err error) { detail.SizeInBytes, err = asInt64(v); return }},
+		{"properties", func(v any) error { return asJSON(v, &detail.Properties) }},
+		{"minReaderVersion", func(v any) (err error) { detail.MinReaderVersion, err = asInt64(v); return }},
+		{"minWriterVersion", func(v any) (err error) { detail.MinWriterVersion, err = asInt64(v); return }},
+	} {
+		if err := field.parse(row[field.column]); err != nil {
+			return nil, wrapErrf(err, "failed to describe detail of %s: column %s", name, field.column)
+		}
+	}
+	return detail, nil
+}
+
+// ShowPartitions returns the partitions of a table. name may be qualified with the
+// schema and catalog, e.g. main.default.events.
+func ShowPartitions(ctx context.Context, db Queryer, name string) ([]Partition, error) {
+	quoted, err := quoteName(name)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queryNamed(ctx, db, "SHOW PARTITIONS "+quoted)
+	if err != nil {
+		return nil, wrapErrf(err, "failed to show partitions of %s", name)
+	}
+	partitions := make([]Partition, 0, len(rows))
+	for _, row := range rows {
+		values := make(map[string]string, len(row))
+		// Hive tables list their partitions as a single a=1/b=2 column
+		if spec, ok := row["partition"]; ok && len(row) == 1 {
+			for _, part := range strings.Split(asString(spec), "/") {
+				if k, v, ok := strings.Cut(part, "="); ok {
+					values[k] = v
+				}
+			}
+		} else {
+			for column, v := range row {
+				values[column] = asString(v)
+			}
+		}
+		partitions = append(partitions, Partition{Values: values})
+	}
+	return partitions, nil
+}
+
+// queryNamed runs the query and returns the values of its rows by column name, so
+// that the results of commands are read by name rather than by position. The query
+// is the driver's, so it gets no default LIMIT.
+func queryNamed(ctx context.Context, db Queryer, query string) ([]map[string]any, error) {
+	rows, err := db.QueryContext(driverQuery(ctx), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var named []map[string]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		named = append(named, row)
+	}
+	return named, rows.Err()
+}
+
+// asString returns the text of a value, "" for NULL
+func asString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(TimestampFormat)
+	}
+	return fmt.Sprint(v)
+}
+
+// asInt64 converts an integer value, or its text, 0 for NULL
+func asInt64(v any) (int64, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	}
+	return strconv.ParseInt(asString(v), 10, 64)
+}
+
+// asBool converts a boolean value, or its text, false for NULL
+func asBool(v any) (bool, error) {
+	switch v := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	}
+	return strconv.ParseBool(asString(v))
+}
+
+// asTime converts a TIMESTAMP value, or its text when time parsing is disabled,
+// the zero time for NULL
+func asTime(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	}
+	t, ok := parseTime(asString(v), nil, TimestampFormat, time.UTC)
+	if !ok {
+		return time.Time{}, errors.Errorf("databricks: %q is not a timestamp", asString(v))
+	}
+	return t, nil
+}
+
+// asJSON decodes an ARRAY or MAP value, which is sent as JSON text, leaving dest
+// unchanged for NULL
+func asJSON(v any, dest any) error {
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(asString(v)), dest)
+}
+
+// tableDescription parses the rows of DESCRIBE TABLE EXTENDED: the columns, followed
+// by sections that start with a # line, such as the partition columns and the
+// detailed table information.
+func tableDescription(lines [][3]string) *TableDescription {
+	desc := &TableDescription{Details: map[string]string{}}
+	section := ""
+	for _, line := range lines {
+		colName, dataType, comment := line[0], line[1], line[2]
+		if strings.HasPrefix(colName, "#") {
+			// the column header of the partition columns is also a # line
+			if colName != "# col_name" {
+				section = strings.TrimSpace(strings.TrimPrefix(colName, "#"))
+			}
+			continue
+		}
+		if colName == "" {
+			continue
+		}
+		switch section {
+		case "":
+			desc.Columns = append(desc.Columns, TableColumn{Name: colName, Type: dataType, Comment: comment})
+		case "Partition Information", "Partitioning":
+			desc.PartitionColumns = append(desc.PartitionColumns, colName)
+		case "Detailed Table Information":
+			desc.Details[colName] = dataType
+		}
+	}
+	desc.Catalog = desc.Details["Catalog"]
+	desc.Schema = desc.Details["Database"]
+	desc.Name = desc.Details["Table"]
+	desc.Type = desc.Details["Type"]
+	desc.Provider = desc.Details["Provider"]
+	desc.Location = desc.Details["Location"]
+	desc.Owner = desc.Details["Owner"]
+	desc.Comment = desc.Details["Comment"]
+	return desc
+}
+
+// quoteName quotes the parts of a dot separated object name with backticks. Parts
+// that are already quoted are kept as they are.
+func quoteName(name string) (string, error) {
+	parts, err := quoteNameParts(name)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// quoteNameParts returns the parts of a dot separated object name, quoted with
+// backticks
+func quoteNameParts(name string) ([]string, error) {
+	var parts []string
+	for rest := strings.TrimSpace(name); ; {
+		var part string
+		if strings.HasPrefix(rest, "`") {
+			// a quoted part ends at a backtick that is not doubled
+			end := 1
+			for {
+				i := strings.IndexByte(rest[end:], '`')
+				if i < 0 {
+					return nil, errors.Errorf("%s: %q", errCatalogInvalidName, name)
+				}
+				end += i + 1
+				if !strings.HasPrefix(rest[end:], "`") {
+					break
+				}
+				end++
+			}
+			part, rest = rest[:end], rest[end:]
+		} else {
+			end := strings.IndexByte(rest, '.')
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 || strings.ContainsAny(rest[:end], "`") {
+				return nil, errors.Errorf("%s: %q", errCatalogInvalidName, name)
+			}
+			part, rest = "`"+rest[:end]+"`", rest[end:]
+		}
+		parts = append(parts, part)
+		if rest == "" {
+			break
+		}
+		if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+			return nil, errors.Errorf("%s: %q", errCatalogInvalidName, name)
+		}
+		rest = rest[1:]
+	}
+	if len(parts) > 3 {
+		return nil, errors.Errorf("%s: %q", errCatalogInvalidName, name)
+	}
+	return parts, nil
+}
@@ -0,0 +1,270 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getCatalogTestDB returns a DB whose statements return the rows of DESCRIBE, or
+// fail with the SQLSTATE of sqlState when it is set
+func getCatalogTestDB(lines [][3]string, sqlState string, statements *[]string) *sql.DB {
+	values := make([][]string, len(lines))
+	for i := range lines {
+		values[i] = lines[i][:]
+	}
+	return getStringsTestDB([]string{"col_name", "data_type", "comment"}, values, sqlState, statements)
+}
+
+// getStringsTestDB returns a DB whose statements return the values as STRING columns
+// with the given names, or fail with the SQLSTATE of sqlState when it is set
+func getStringsTestDB(names []string, lines [][]string, sqlState string, statements *[]string) *sql.DB {
+	executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+		*statements = append(*statements, req.Statement)
+		if sqlState != "" {
+			resp := &cli_service.TExecuteStatementResp{
+				Status: &cli_service.TStatus{
+					StatusCode:   cli_service.TStatusCode_ERROR_STATUS,
+					SqlState:     strPtr(sqlState),
+					ErrorMessage: strPtr("statement failed"),
+				},
+			}
+			return resp, client.CheckStatus(resp)
+		}
+		columns := make([]*cli_service.TColumn, len(names))
+		descs := make([]*cli_service.TColumnDesc, len(names))
+		for i, name := range names {
+			values := make([]string, len(lines))
+			for j, line := range lines {
+				values[j] = line[i]
+			}
+			columns[i] = &cli_service.TColumn{StringVal: &cli_service.TStringColumn{Values: values, Nulls: []byte{}}}
+			descs[i] = &cli_service.TColumnDesc{
+				ColumnName: name,
+				Position:   int32(i + 1),
+				TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{{
+					PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_STRING_TYPE},
+				}}},
+			}
+		}
+		return &cli_service.TExecuteStatementResp{
+			Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			OperationHandle: &cli_service.TOperationHandle{
+				OperationId:  &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+				HasResultSet: true,
+			},
+			DirectResults: &cli_service.TSparkDirectResults{
+				OperationStatus: &cli_service.TGetOperationStatusResp{
+					OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+				},
+				ResultSetMetadata: &cli_service.TGetResultSetMetadataResp{
+					Schema: &cli_service.TTableSchema{Columns: descs},
+				},
+				ResultSet: &cli_service.TFetchResultsResp{
+					Results: &cli_service.TRowSet{Columns: columns},
+				},
+			},
+		}, nil
+	}
+	cfg := config.WithDefaults()
+	cfg.PollInterval = 10 * time.Millisecond
+	testConn := &conn{
+		session: getTestSession(),
+		client: &client.TestClient{
+			FnExecuteStatement: executeStatement,
+			FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+				return &cli_service.TCloseOperationResp{}, nil
+			},
+		},
+		cfg: cfg,
+	}
+	return sql.OpenDB(&testConnConnector{testConn})
+}
+
+func TestTableExists(t *testing.T) {
+	t.Run("returns true for existing tables", func(t *testing.T) {
+		var statements []string
+		db := getCatalogTestDB([][3]string{{"id", "int", ""}}, "", &statements)
+		defer db.Close()
+
+		exists, err := TableExists(context.Background(), db, "main.default.events")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, []string{"DESCRIBE TABLE `main`.`default`.`events`"}, statements)
+	})
+
+	t.Run("returns false for missing tables and schemas", func(t *testing.T) {
+		for _, sqlState := range []string{ErrCodeTableNotFound, ErrCodeSchemaNotFound} {
+			var statements []string
+			db := getCatalogTestDB(nil, sqlState, &statements)
+			exists, err := TableExists(context.Background(), db, "events")
+			require.NoError(t, err)
+			assert.False(t, exists)
+			db.Close()
+		}
+	})
+
+	t.Run("returns other errors", func(t *testing.T) {
+		var statements []string
+		db := getCatalogTestDB(nil, ErrCodePermissionDenied, &statements)
+		defer db.Close()
+
+		_, err := TableExists(context.Background(), db, "events")
+		assert.Equal(t, ErrCodePermissionDenied, SQLState(err))
+	})
+}
+
+func TestCreateSchemaIfNotExists(t *testing.T) {
+	var statements []string
+	db := getCatalogTestDB(nil, "", &statements)
+	defer db.Close()
+
+	require.NoError(t, CreateSchemaIfNotExists(context.Background(), db, "main.`staging-2`"))
+	assert.Equal(t, []string{"CREATE SCHEMA IF NOT EXISTS `main`.`staging-2`"}, statements)
+	assert.Error(t, CreateSchemaIfNotExists(context.Background(), db, "main..staging"))
+}
+
+func TestDescribeTable(t *testing.T) {
+	var statements []string
+	db := getCatalogTestDB([][3]string{
+		{"id", "bigint", "event id"},
+		{"payload", "struct<a:int,b:string>", ""},
+		{"day", "date", ""},
+		{"# Partition Information", "", ""},
+		{"# col_name", "data_type", "comment"},
+		{"day", "date", ""},
+		{"", "", ""},
+		{"# Detailed Table Information", "", ""},
+		{"Catalog", "main", ""},
+		{"Database", "default", ""},
+		{"Table", "events", ""},
+		{"Owner", "someone@example.com", ""},
+		{"Type", "MANAGED", ""},
+		{"Provider", "delta", ""},
+		{"Location", "s3://bucket/events", ""},
+		{"Table Properties", "[delta.minReaderVersion=1]", ""},
+	}, "", &statements)
+	defer db.Close()
+
+	desc, err := DescribeTable(context.Background(), db, "main.default.events")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DESCRIBE TABLE EXTENDED `main`.`default`.`events`"}, statements)
+	assert.Equal(t, []TableColumn{
+		{Name: "id", Type: "bigint", Comment: "event id"},
+		{Name: "payload", Type: "struct<a:int,b:string>"},
+		{Name: "day", Type: "date"},
+	}, desc.Columns)
+	assert.Equal(t, []string{"day"}, desc.PartitionColumns)
+	assert.Equal(t, "main", desc.Catalog)
+	assert.Equal(t, "default", desc.Schema)
+	assert.Equal(t, "events", desc.Name)
+	assert.Equal(t, "MANAGED", desc.Type)
+	assert.Equal(t, "delta", desc.Provider)
+	assert.Equal(t, "s3://bucket/events", desc.Location)
+	assert.Equal(t, "someone@example.com", desc.Owner)
+	assert.Equal(t, "[delta.minReaderVersion=1]", desc.Details["Table Properties"])
+}
+
+func TestQuoteName(t *testing.T) {
+	cases := map[string]string{
+		"events":               "`events`",
+		"main.default.events":  "`main`.`default`.`events`",
+		"main.`my-schema`.t":   "`main`.`my-schema`.`t`",
+		"`a``b`.c":             "`a``b`.`c`",
+		" main.default.events": "`main`.`default`.`events`",
+	}
+	for name, expected := range cases {
+		quoted, err := quoteName(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, quoted, name)
+	}
+	for _, name := range []string{"", "a..b", "a.", ".a", "a.b.c.d", "`a", "a`b", "`a`b"} {
+		_, err := quoteName(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestShowTables(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"database", "tableName", "isTemporary"}, [][]string{
+		{"default", "events", "false"},
+		{"", "tmp_view", "true"},
+	}, "", &statements)
+	defer db.Close()
+
+	tables, err := ShowTables(context.Background(), db, "main.default")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SHOW TABLES IN `main`.`default`"}, statements)
+	assert.Equal(t, []TableInfo{
+		{Schema: "default", Name: "events"},
+		{Name: "tmp_view", IsTemporary: true},
+	}, tables)
+
+	_, err = ShowTables(context.Background(), db, "")
+	require.NoError(t, err)
+	assert.Equal(t, "SHOW TABLES", statements[1])
+}
+
+func TestDescribeDetail(t *testing.T) {
+	columns := []string{"format", "id", "name", "description", "location", "createdAt", "lastModified",
+		"partitionColumns", "numFiles", "sizeInBytes", "properties", "minReaderVersion", "minWriterVersion"}
+	var statements []string
+	db := getStringsTestDB(columns, [][]string{{
+		"delta", "b1c2", "main.default.events", "", "s3://bucket/events", "2024-01-02 03:04:05.5", "2024-02-03 04:05:06",
+		`["day"]`, "12", "4096", `{"delta.appendOnly":"true"}`, "1", "2",
+	}}, "", &statements)
+	defer db.Close()
+
+	detail, err := DescribeDetail(context.Background(), db, "main.default.events")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DESCRIBE DETAIL `main`.`default`.`events`"}, statements)
+	assert.Equal(t, &TableDetail{
+		Format:           "delta",
+		ID:               "b1c2",
+		Name:             "main.default.events",
+		Location:         "s3://bucket/events",
+		CreatedAt:        time.Date(2024, 1, 2, 3, 4, 5, 500000000, time.UTC),
+		LastModified:     time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+		PartitionColumns: []string{"day"},
+		NumFiles:         12,
+		SizeInBytes:      4096,
+		Properties:       map[string]string{"delta.appendOnly": "true"},
+		MinReaderVersion: 1,
+		MinWriterVersion: 2,
+	}, detail)
+
+	bad := getStringsTestDB([]string{"numFiles"}, [][]string{{"many"}}, "", &statements)
+	defer bad.Close()
+	_, err = DescribeDetail(context.Background(), bad, "events")
+	assert.ErrorContains(t, err, "column numFiles")
+}
+
+func TestShowPartitions(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"day", "country"}, [][]string{
+		{"2024-01-01", "NL"},
+		{"2024-01-02", "BR"},
+	}, "", &statements)
+	defer db.Close()
+
+	partitions, err := ShowPartitions(context.Background(), db, "events")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SHOW PARTITIONS `events`"}, statements)
+	assert.Equal(t, []Partition{
+		{Values: map[string]string{"day": "2024-01-01", "country": "NL"}},
+		{Values: map[string]string{"day": "2024-01-02", "country": "BR"}},
+	}, partitions)
+
+	hive := getStringsTestDB([]string{"partition"}, [][]string{{"day=2024-01-01/country=NL"}}, "", &statements)
+	defer hive.Close()
+	partitions, err = ShowPartitions(context.Background(), hive, "events")
+	require.NoError(t, err)
+	assert.Equal(t, []Partition{{Values: map[string]string{"day": "2024-01-01", "country": "NL"}}}, partitions)
+}
@@ -0,0 +1,152 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/interceptor"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/pkg/errors"
+)
+
+var errCheckpointNoResultSet = "databricks: the rows have no result set to checkpoint"
+var errCheckpointInvalid = "databricks: invalid checkpoint"
+
+// Checkpoint is the position of a consumer in the result set of a query. It holds
+// the handle of the server operation and the offset of the next row to consume, so
+// that a batch consumer restarted mid-result-set resumes from it with ResumeQuery
+// instead of running the query again.
+//
+// It marshals to an opaque text, to be stored with the output of the consumer. A
+// consumer that stores a checkpoint after the rows it processed gets at-least-once
+// processing: the rows read after the last stored checkpoint are read again.
+//
+// The operation must still hold the results when resuming: it is closed when the
+// rows are closed, and by the server once idle for too long.
+type Checkpoint struct {
+	// QueryID is the id of the operation
+	QueryID string
+	// Row is the zero-based offset of the next row to consume
+	Row    int64
+	handle *cli_service.TOperationHandle
+}
+
+// checkpointText is the content of the text of a checkpoint
+type checkpointText struct {
+	GUID   []byte `json:"g"`
+	Secret []byte `json:"s"`
+	Row    int64  `json:"r"`
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c Checkpoint) MarshalText() ([]byte, error) {
+	if c.handle == nil || c.handle.OperationId == nil {
+		return nil, errors.New(errCheckpointInvalid)
+	}
+	b, err := json.Marshal(checkpointText{GUID: c.handle.OperationId.GUID, Secret: c.handle.OperationId.Secret, Row: c.Row})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.RawURLEncoding.EncodeToString(b)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *Checkpoint) UnmarshalText(text []byte) error {
+	b, err := base64.RawURLEncoding.DecodeString(string(text))
+	if err != nil {
+		return errors.Wrap(err, errCheckpointInvalid)
+	}
+	var t checkpointText
+	if err := json.Unmarshal(b, &t); err != nil {
+		return errors.Wrap(err, errCheckpointInvalid)
+	}
+	if len(t.GUID) == 0 || t.Row < 0 {
+		return errors.New(errCheckpointInvalid)
+	}
+	*c = Checkpoint{
+		QueryID: client.SprintGuid(t.GUID),
+		Row:     t.Row,
+		handle: &cli_service.TOperationHandle{
+			OperationId:   &cli_service.THandleIdentifier{GUID: t.GUID, Secret: t.Secret},
+			OperationType: cli_service.TOperationType_EXECUTE_STATEMENT,
+			HasResultSet:  true,
+		},
+	}
+	return nil
+}
+
+// Resumer is implemented by the connections of this driver, to resume consuming the
+// results of a query from a checkpoint taken with Rows.Checkpoint. The connection
+// may belong to another process than the one that ran the query. Use sql.Conn.Raw:
+//
+//	err := conn.Raw(func(driverConn any) error {
+//		r, err := driverConn.(dbsql.Resumer).ResumeQuery(ctx, checkpoint)
+//		if err != nil {
+//			return err
+//		}
+//		defer r.Close()
+//		dest := make([]driver.Value, len(r.Columns()))
+//		for r.Next(dest) == nil {
+//			...
+//			checkpoint, _ = r.(dbsql.Rows).Checkpoint()
+//		}
+//		...
+//	})
+type Resumer interface {
+	// ResumeQuery returns the rows of the operation of the checkpoint, positioned at
+	// its row. It fails with an error matching ErrResultExpired if the operation no
+	// longer holds the results.
+	ResumeQuery(ctx context.Context, checkpoint Checkpoint) (driver.Rows, error)
+}
+
+var _ Resumer = (*conn)(nil)
+
+// Checkpoint returns the position of the rows, to resume from the next row later.
+func (r *rows) Checkpoint() (Checkpoint, error) {
+	if err := isValidRows(r); err != nil {
+		return Checkpoint{}, err
+	}
+	if r.noResultSet || r.opHandle == nil || r.opHandle.OperationId == nil {
+		return Checkpoint{}, errors.New(errCheckpointNoResultSet)
+	}
+	return Checkpoint{QueryID: r.queryId(), Row: r.nextRowNumber, handle: r.opHandle}, nil
+}
+
+func (c *conn) ResumeQuery(ctx context.Context, checkpoint Checkpoint) (driver.Rows, error) {
+	if checkpoint.handle == nil || checkpoint.Row < 0 {
+		return nil, errors.New(errCheckpointInvalid)
+	}
+	log := statementLogger(ctx, c.id, checkpoint.QueryID)
+	ctx = driverctx.NewContextWithConnId(ctx, c.id)
+	r := &rows{
+		connId:        c.id,
+		correlationId: driverctx.CorrelationIdFromContext(ctx),
+		client:        c.client,
+		opHandle:      checkpoint.handle,
+		pageSize:      int64(c.cfg.MaxRows),
+		location:      c.cfg.Location,
+		config:        c.cfg,
+		ctx:           ctx,
+		conn:          c,
+		query:         interceptor.Query{QueryID: checkpoint.QueryID},
+	}
+	// the absolute fetch fails if the operation no longer holds the results
+	if err := r.SeekRow(checkpoint.Row); err != nil {
+		if err != io.EOF {
+			log.Err(err).Msgf("databricks: failed to resume query at row %d", checkpoint.Row)
+			return nil, wrapErrf(err, "failed to resume query %s", checkpoint.QueryID)
+		}
+		// every row was consumed, Next returns io.EOF
+		r.nextRowNumber = checkpoint.Row
+	}
+	c.ops.add(checkpoint.handle)
+	r.stack = leakStack(c.cfg)
+	r.watchOrphan(ctx)
+	log.Debug().Msgf("databricks: resumed query at row %d", checkpoint.Row)
+	return r, nil
+}
@@ -0,0 +1,134 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getCheckpointTestConn(testClient *client.TestClient) *conn {
+	cfg := config.WithDefaults()
+	cfg.MaxRows = 10
+	cfg.PollInterval = 10 * time.Millisecond
+	return &conn{
+		session: getTestSession(),
+		client:  testClient,
+		cfg:     cfg,
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	handle := &cli_service.TOperationHandle{
+		OperationId:  &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, Secret: []byte("secret")},
+		HasResultSet: true,
+	}
+
+	t.Run("rows are checkpointed at the next row", func(t *testing.T) {
+		var requests []*cli_service.TFetchResultsReq
+		r := &rows{pageSize: 10, client: getRowsTestCursorClient(25, &requests), opHandle: handle}
+		dest := make([]driver.Value, 1)
+		for i := 0; i < 12; i++ {
+			require.NoError(t, r.Next(dest))
+		}
+		checkpoint, err := r.Checkpoint()
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), checkpoint.Row)
+		assert.Equal(t, "01020304-0506-0708-090a-0b0c0d0e0f10", checkpoint.QueryID)
+
+		_, err = (&rows{client: &client.TestClient{}, noResultSet: true, opHandle: handle}).Checkpoint()
+		assert.EqualError(t, err, errCheckpointNoResultSet)
+	})
+
+	t.Run("checkpoints round trip through text and JSON", func(t *testing.T) {
+		checkpoint := Checkpoint{QueryID: "01020304-0506-0708-090a-0b0c0d0e0f10", Row: 12, handle: handle}
+		b, err := json.Marshal(struct{ Checkpoint Checkpoint }{checkpoint})
+		require.NoError(t, err)
+
+		var decoded struct{ Checkpoint Checkpoint }
+		require.NoError(t, json.Unmarshal(b, &decoded))
+		assert.Equal(t, checkpoint.QueryID, decoded.Checkpoint.QueryID)
+		assert.Equal(t, int64(12), decoded.Checkpoint.Row)
+		assert.Equal(t, handle.OperationId, decoded.Checkpoint.handle.OperationId)
+		assert.True(t, decoded.Checkpoint.handle.HasResultSet)
+
+		var invalid Checkpoint
+		assert.Error(t, invalid.UnmarshalText([]byte("not a checkpoint!")))
+		assert.Error(t, invalid.UnmarshalText([]byte("e30")))
+		_, err = Checkpoint{}.MarshalText()
+		assert.EqualError(t, err, errCheckpointInvalid)
+	})
+
+	t.Run("queries are resumed at the row of the checkpoint", func(t *testing.T) {
+		var requests []*cli_service.TFetchResultsReq
+		c := getCheckpointTestConn(getRowsTestCursorClient(25, &requests))
+		var checkpoint Checkpoint
+		text, err := Checkpoint{Row: 12, handle: handle}.MarshalText()
+		require.NoError(t, err)
+		require.NoError(t, checkpoint.UnmarshalText(text))
+
+		dr, err := c.ResumeQuery(context.Background(), checkpoint)
+		require.NoError(t, err)
+		require.Len(t, requests, 1)
+		assert.Equal(t, cli_service.TFetchOrientation_FETCH_ABSOLUTE, requests[0].Orientation)
+		assert.Equal(t, int64(12), *requests[0].StartRowOffset)
+		assert.Equal(t, handle.OperationId, requests[0].OperationHandle.OperationId)
+		assert.Equal(t, []string{"id"}, dr.Columns())
+
+		dest := make([]driver.Value, 1)
+		var ids []int32
+		for dr.Next(dest) == nil {
+			ids = append(ids, dest[0].(int32))
+		}
+		assert.Len(t, ids, 13)
+		assert.Equal(t, int32(12), ids[0])
+		assert.Equal(t, int32(24), ids[12])
+		checkpoint, err = dr.(Rows).Checkpoint()
+		require.NoError(t, err)
+		assert.Equal(t, int64(25), checkpoint.Row)
+	})
+
+	t.Run("queries resumed past their last row have no more rows", func(t *testing.T) {
+		var requests []*cli_service.TFetchResultsReq
+		c := getCheckpointTestConn(getRowsTestCursorClient(25, &requests))
+		dr, err := c.ResumeQuery(context.Background(), Checkpoint{Row: 25, handle: handle})
+		require.NoError(t, err)
+		assert.Equal(t, io.EOF, dr.Next(make([]driver.Value, 1)))
+	})
+
+	t.Run("resuming fails when the operation no longer holds the results", func(t *testing.T) {
+		c := getCheckpointTestConn(&client.TestClient{
+			FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+				return nil, errors.New("invalid handle")
+			},
+		})
+		_, err := c.ResumeQuery(context.Background(), Checkpoint{Row: 3, handle: handle})
+		assert.ErrorContains(t, err, "invalid handle")
+
+		_, err = c.ResumeQuery(context.Background(), Checkpoint{Row: 3})
+		assert.EqualError(t, err, errCheckpointInvalid)
+	})
+
+	t.Run("resuming expired results fails with ErrResultExpired", func(t *testing.T) {
+		for _, fetchErr := range []error{client.ErrInvalidHandle, errors.New("Invalid OperationHandle: OperationHandle [opType=EXECUTE_STATEMENT]")} {
+			c := getCheckpointTestConn(&client.TestClient{
+				FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+					return nil, fetchErr
+				},
+			})
+			_, err := c.ResumeQuery(context.Background(), Checkpoint{Row: 3, handle: handle})
+			assert.ErrorIs(t, err, ErrResultExpired)
+			assert.ErrorIs(t, err, ErrResultSetInvalidated)
+			assert.ErrorIs(t, err, fetchErr)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package dbsql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+)
+
+// Rows is implemented by the driver.Rows returned from this driver. It exposes
+// result set information that database/sql does not surface.
+//
+// Because database/sql does not allow unwrapping *sql.Rows, use sql.Conn.Raw to
+// run the query against the driver connection directly:
+//
+//	err := conn.Raw(func(driverConn any) error {
+//		r, err := driverConn.(driver.QueryerContext).QueryContext(ctx, query, nil)
+//		if err != nil {
+//			return err
+//		}
+//		defer r.Close()
+//		cols, err := r.(dbsql.Rows).ColumnDescriptors()
+//		...
+//	})
+type Rows interface {
+	driver.Rows
+	// ColumnDescriptors returns the full Databricks type information for every
+	// column of the result set.
+	ColumnDescriptors() ([]ColumnDescriptor, error)
+	// SeekRow positions the rows so that the next call to Next returns the row at
+	// the given zero-based offset of the result set. Rows outside the current result
+	// page are fetched directly, without transferring the rows in between, so
+	// paginated readers can jump to page N of a result set.
+	SeekRow(offset int64) error
+	// Rewind positions the rows at the start of the result set so it can be
+	// iterated again without re-running the query. The server must still hold
+	// the results, i.e. the operation must not have been closed.
+	Rewind() error
+	// NextRowNumber returns the zero-based offset in the result set of the row the
+	// next call to Next returns, i.e. the number of rows read so far when iterating
+	// from the start. Checkpointing consumers record it to resume later with SeekRow.
+	NextRowNumber() int64
+	// HasMoreRows reports whether rows remain to be read, in the current result page
+	// or reported pending by the server. It is true until the last page is fetched
+	// and its rows are read.
+	HasMoreRows() bool
+	// TotalRows returns the number of rows of the result set and true once the server
+	// sent its last page, e.g. with the first page of small results. Until then it
+	// returns the number of rows fetched so far, which the result set has at least,
+	// and false, so that UIs can show "1,000 of 250,000+ rows" without a COUNT query.
+	TotalRows() (int64, bool)
+	// Checkpoint returns the position of the rows in the result set, to resume
+	// consuming them from the next row with Resumer.ResumeQuery, e.g. after a restart.
+	Checkpoint() (Checkpoint, error)
+	// WriteArrowIPC writes the rows that were not read yet to w as an Arrow IPC
+	// stream, one record batch per result page, and returns the number of rows.
+	WriteArrowIPC(w io.Writer) (int64, error)
+	// WriteParquet writes the rows that were not read yet to w as a Parquet file,
+	// one row group per result page, and returns the number of rows.
+	WriteParquet(w io.Writer) (int64, error)
+}
+
+// ColumnDescriptor describes a result set column using the type information
+// reported by the server. Nullability is not part of the result set schema
+// and therefore is not reported. Neither are interval qualifiers: interval
+// columns are only described by their family, INTERVAL_YEAR_MONTH or
+// INTERVAL_DAY_TIME.
+type ColumnDescriptor struct {
+	// Name of the column
+	Name string
+	// Position of the column in the result set, starting at 1
+	Position int
+	// TypeName is the full type text, e.g. DECIMAL(10,2) or ARRAY<INT>
+	TypeName string
+	// DatabaseTypeName is the bare type name, e.g. DECIMAL or ARRAY
+	DatabaseTypeName string
+	// Precision and Scale are set for DECIMAL columns
+	Precision int64
+	Scale     int64
+	// Length is the maximum character length of CHAR and VARCHAR columns
+	Length int64
+	// Comment is the column comment, if any
+	Comment string
+	// Fields describes nested types: the element of an ARRAY, the key and
+	// value of a MAP or the fields of a STRUCT
+	Fields []ColumnDescriptor
+}
+
+func newColumnDescriptor(column *cli_service.TColumnDesc) ColumnDescriptor {
+	var types []*cli_service.TTypeEntry
+	if column.TypeDesc != nil {
+		types = column.TypeDesc.Types
+	}
+	desc := newTypeDescriptor(types, 0)
+	desc.Name = column.ColumnName
+	desc.Position = int(column.Position)
+	desc.Comment = column.GetComment()
+	return desc
+}
+
+func newTypeDescriptor(types []*cli_service.TTypeEntry, ptr int) ColumnDescriptor {
+	desc := ColumnDescriptor{}
+	if ptr < 0 || ptr >= len(types) || types[ptr] == nil {
+		desc.TypeName = "UNKNOWN"
+		desc.DatabaseTypeName = "UNKNOWN"
+		return desc
+	}
+
+	entry := types[ptr]
+	switch {
+	case entry.PrimitiveEntry != nil:
+		typeId := entry.PrimitiveEntry.Type
+		desc.DatabaseTypeName = strings.TrimSuffix(typeId.String(), "_TYPE")
+		desc.TypeName = desc.DatabaseTypeName
+		var qualifiers map[string]*cli_service.TTypeQualifierValue
+		if entry.PrimitiveEntry.IsSetTypeQualifiers() {
+			qualifiers = entry.PrimitiveEntry.TypeQualifiers.GetQualifiers()
+		}
+		switch typeId {
+		case cli_service.TTypeId_DECIMAL_TYPE:
+			desc.Precision = typeQualifierInt(qualifiers, cli_service.PRECISION)
+			desc.Scale = typeQualifierInt(qualifiers, cli_service.SCALE)
+			desc.TypeName = fmt.Sprintf("DECIMAL(%d,%d)", desc.Precision, desc.Scale)
+		case cli_service.TTypeId_CHAR_TYPE, cli_service.TTypeId_VARCHAR_TYPE:
+			desc.Length = typeQualifierInt(qualifiers, cli_service.CHARACTER_MAXIMUM_LENGTH)
+			if desc.Length > 0 {
+				desc.TypeName = fmt.Sprintf("%s(%d)", desc.DatabaseTypeName, desc.Length)
+			}
+		}
+	case entry.ArrayEntry != nil:
+		elem := newTypeDescriptor(types, int(entry.ArrayEntry.ObjectTypePtr))
+		elem.Name = "element"
+		desc.DatabaseTypeName = "ARRAY"
+		desc.TypeName = fmt.Sprintf("ARRAY<%s>", elem.TypeName)
+		desc.Fields = []ColumnDescriptor{elem}
+	case entry.MapEntry != nil:
+		key := newTypeDescriptor(types, int(entry.MapEntry.KeyTypePtr))
+		key.Name = "key"
+		value := newTypeDescriptor(types, int(entry.MapEntry.ValueTypePtr))
+		value.Name = "value"
+		desc.DatabaseTypeName = "MAP"
+		desc.TypeName = fmt.Sprintf("MAP<%s,%s>", key.TypeName, value.TypeName)
+		desc.Fields = []ColumnDescriptor{key, value}
+	case entry.StructEntry != nil, entry.UnionEntry != nil:
+		var nameToTypePtr map[string]cli_service.TTypeEntryPtr
+		if entry.StructEntry != nil {
+			nameToTypePtr = entry.StructEntry.NameToTypePtr
+			desc.DatabaseTypeName = "STRUCT"
+		} else {
+			nameToTypePtr = entry.UnionEntry.NameToTypePtr
+			desc.DatabaseTypeName = "UNION"
+		}
+		// thrift maps do not preserve the field order, but the type entries of the
+		// fields follow each other in the order they are declared in
+		names := make([]string, 0, len(nameToTypePtr))
+		for name := range nameToTypePtr {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			pi, pj := nameToTypePtr[names[i]], nameToTypePtr[names[j]]
+			if pi != pj {
+				return pi < pj
+			}
+			return names[i] < names[j]
+		})
+		fieldTypes := make([]string, len(names))
+		for i, name := range names {
+			field := newTypeDescriptor(types, int(nameToTypePtr[name]))
+			field.Name = name
+			field.Position = i + 1
+			desc.Fields = append(desc.Fields, field)
+			fieldTypes[i] = fmt.Sprintf("%s:%s", name, field.TypeName)
+		}
+		desc.TypeName = fmt.Sprintf("%s<%s>", desc.DatabaseTypeName, strings.Join(fieldTypes, ","))
+	case entry.UserDefinedTypeEntry != nil:
+		desc.DatabaseTypeName = "USER_DEFINED"
+		desc.TypeName = entry.UserDefinedTypeEntry.TypeClassName
+		if strings.EqualFold(desc.TypeName, variantTypeName) {
+			desc.DatabaseTypeName = variantTypeName
+			desc.TypeName = variantTypeName
+		} else if name := geospatialTypeName(desc.TypeName); name != "" {
+			desc.DatabaseTypeName = name
+			desc.TypeName = strings.ToUpper(desc.TypeName)
+		}
+	}
+
+	return desc
+}
+
+func typeQualifierInt(qualifiers map[string]*cli_service.TTypeQualifierValue, name string) int64 {
+	if q, ok := qualifiers[name]; ok && q != nil && q.IsSetI32Value() {
+		return int64(*q.I32Value)
+	}
+	return 0
+}
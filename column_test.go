@@ -0,0 +1,152 @@
+package dbsql
+
+import (
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewColumnDescriptor(t *testing.T) {
+	t.Run("decimal columns report precision and scale", func(t *testing.T) {
+		col := &cli_service.TColumnDesc{
+			ColumnName: "price",
+			Position:   1,
+			Comment:    strPtr("unit price"),
+			TypeDesc: &cli_service.TTypeDesc{
+				Types: []*cli_service.TTypeEntry{
+					{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{
+						Type: cli_service.TTypeId_DECIMAL_TYPE,
+						TypeQualifiers: &cli_service.TTypeQualifiers{
+							Qualifiers: map[string]*cli_service.TTypeQualifierValue{
+								cli_service.PRECISION: {I32Value: thriftInt32(10)},
+								cli_service.SCALE:     {I32Value: thriftInt32(2)},
+							},
+						},
+					}},
+				},
+			},
+		}
+		desc := newColumnDescriptor(col)
+		assert.Equal(t, ColumnDescriptor{
+			Name:             "price",
+			Position:         1,
+			TypeName:         "DECIMAL(10,2)",
+			DatabaseTypeName: "DECIMAL",
+			Precision:        10,
+			Scale:            2,
+			Comment:          "unit price",
+		}, desc)
+	})
+
+	t.Run("varchar columns report length", func(t *testing.T) {
+		col := &cli_service.TColumnDesc{
+			ColumnName: "name",
+			TypeDesc: &cli_service.TTypeDesc{
+				Types: []*cli_service.TTypeEntry{
+					{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{
+						Type: cli_service.TTypeId_VARCHAR_TYPE,
+						TypeQualifiers: &cli_service.TTypeQualifiers{
+							Qualifiers: map[string]*cli_service.TTypeQualifierValue{
+								cli_service.CHARACTER_MAXIMUM_LENGTH: {I32Value: thriftInt32(20)},
+							},
+						},
+					}},
+				},
+			},
+		}
+		desc := newColumnDescriptor(col)
+		assert.Equal(t, "VARCHAR(20)", desc.TypeName)
+		assert.Equal(t, int64(20), desc.Length)
+	})
+
+	t.Run("interval columns report their family", func(t *testing.T) {
+		col := &cli_service.TColumnDesc{
+			ColumnName: "i",
+			TypeDesc: &cli_service.TTypeDesc{
+				Types: []*cli_service.TTypeEntry{
+					{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_INTERVAL_YEAR_MONTH_TYPE}},
+				},
+			},
+		}
+		desc := newColumnDescriptor(col)
+		assert.Equal(t, "INTERVAL_YEAR_MONTH", desc.DatabaseTypeName)
+		assert.Equal(t, "INTERVAL_YEAR_MONTH", desc.TypeName)
+	})
+
+	t.Run("union columns match the database type name", func(t *testing.T) {
+		col := &cli_service.TColumnDesc{
+			ColumnName: "u",
+			TypeDesc: &cli_service.TTypeDesc{
+				Types: []*cli_service.TTypeEntry{
+					{UnionEntry: &cli_service.TUnionTypeEntry{NameToTypePtr: map[string]cli_service.TTypeEntryPtr{"a": 1}}},
+					{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_INT_TYPE}},
+				},
+			},
+		}
+		desc := newColumnDescriptor(col)
+		assert.Equal(t, getDBTypeName(col), desc.DatabaseTypeName)
+		assert.Equal(t, "UNION<a:INT>", desc.TypeName)
+	})
+
+	t.Run("nested types are described", func(t *testing.T) {
+		col := &cli_service.TColumnDesc{
+			ColumnName: "nested",
+			TypeDesc: &cli_service.TTypeDesc{
+				Types: []*cli_service.TTypeEntry{
+					{MapEntry: &cli_service.TMapTypeEntry{KeyTypePtr: 1, ValueTypePtr: 2}},
+					{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_STRING_TYPE}},
+					{ArrayEntry: &cli_service.TArrayTypeEntry{ObjectTypePtr: 3}},
+					{StructEntry: &cli_service.TStructTypeEntry{NameToTypePtr: map[string]cli_service.TTypeEntryPtr{"b": 4, "a": 1}}},
+					{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_INT_TYPE}},
+				},
+			},
+		}
+		desc := newColumnDescriptor(col)
+		assert.Equal(t, "MAP", desc.DatabaseTypeName)
+		assert.Equal(t, "MAP<STRING,ARRAY<STRUCT<a:STRING,b:INT>>>", desc.TypeName)
+		assert.Len(t, desc.Fields, 2)
+		assert.Equal(t, "key", desc.Fields[0].Name)
+		assert.Equal(t, "value", desc.Fields[1].Name)
+		assert.Equal(t, "a", desc.Fields[1].Fields[0].Fields[0].Name)
+	})
+
+	t.Run("struct fields keep their declared order", func(t *testing.T) {
+		col := &cli_service.TColumnDesc{
+			ColumnName: "point",
+			TypeDesc: &cli_service.TTypeDesc{
+				Types: []*cli_service.TTypeEntry{
+					{StructEntry: &cli_service.TStructTypeEntry{NameToTypePtr: map[string]cli_service.TTypeEntryPtr{"y": 2, "x": 3, "label": 1}}},
+					{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_STRING_TYPE}},
+					{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_DOUBLE_TYPE}},
+					{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_INT_TYPE}},
+				},
+			},
+		}
+		desc := newColumnDescriptor(col)
+		assert.Equal(t, "STRUCT<label:STRING,y:DOUBLE,x:INT>", desc.TypeName)
+		if assert.Len(t, desc.Fields, 3) {
+			assert.Equal(t, "label", desc.Fields[0].Name)
+			assert.Equal(t, 1, desc.Fields[0].Position)
+			assert.Equal(t, "x", desc.Fields[2].Name)
+			assert.Equal(t, 3, desc.Fields[2].Position)
+		}
+	})
+
+	t.Run("invalid type pointers are unknown", func(t *testing.T) {
+		col := &cli_service.TColumnDesc{
+			ColumnName: "bad",
+			TypeDesc: &cli_service.TTypeDesc{
+				Types: []*cli_service.TTypeEntry{
+					{ArrayEntry: &cli_service.TArrayTypeEntry{ObjectTypePtr: 7}},
+				},
+			},
+		}
+		desc := newColumnDescriptor(col)
+		assert.Equal(t, "ARRAY<UNKNOWN>", desc.TypeName)
+	})
+}
+
+func thriftInt32(i int32) *int32 {
+	return &i
+}
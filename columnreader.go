@@ -0,0 +1,147 @@
+package dbsql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+)
+
+// columnReader reads the values of a column of the current result page. The type of
+// the column and its conversion are resolved once per result set, and the layout of
+// its values once per page, so that Next does not look them up for every value.
+type columnReader struct {
+	// get returns the value of a row as sent by the server, or nil for NULL
+	get func(rowNum int64) any
+	// convert converts the values that are not NULL, nil when they are returned as is
+	convert func(val any) (any, error)
+	// float32 is set for FLOAT columns, whose values are sent as doubles
+	float32 bool
+}
+
+// columnReaders returns the readers of the columns of the current page, built on the
+// first call for the page, and whether the values are returned as raw strings. It
+// fails when the columns of the page do not match the schema of the results.
+func (r *rows) columnReaders(metadata *cli_service.TGetResultSetMetadataResp) ([]columnReader, bool, error) {
+	if r.readersPage == r.fetchResults && r.readersMetadata == metadata {
+		return r.readers, r.readersRaw, nil
+	}
+	columns := r.fetchResults.GetResults().GetColumns()
+	descs := metadata.GetSchema().GetColumns()
+	// a page whose columns do not match the schema can't be decoded
+	if len(columns) != len(descs) {
+		if r.invalidated == nil {
+			r.invalidate(fmt.Sprintf("page has %d columns, the results have %d", len(columns), len(descs)), nil)
+		}
+		return nil, false, r.invalidated
+	}
+	if r.readersMetadata != metadata {
+		r.readers = r.readers[:0]
+		for _, desc := range descs {
+			r.readers = append(r.readers, columnReader{
+				convert: newValueConverter(getDBTypeName(desc), r.location, r.config),
+				float32: getDBTypeID(desc) == cli_service.TTypeId_FLOAT_TYPE,
+			})
+		}
+		r.readersRaw = r.rawStrings()
+	}
+	for i := range r.readers {
+		r.readers[i].get = newValueGetter(columns[i])
+	}
+	r.readersPage, r.readersMetadata = r.fetchResults, metadata
+	return r.readers, r.readersRaw, nil
+}
+
+// newValueConverter returns the function converting the values of a column of type
+// dbtype that are not NULL to the values returned by Next, or nil when they are
+// returned as sent by the server
+func newValueConverter(dbtype string, location *time.Location, cfg *config.Config) func(val any) (any, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	if cfg != nil && cfg.Converters[dbtype] != nil {
+		convert := cfg.Converters[dbtype]
+		return func(val any) (any, error) {
+			converted, err := convert(val)
+			if err != nil {
+				return nil, errors.Wrapf(err, "databricks: cannot convert %s value", dbtype)
+			}
+			return converted, nil
+		}
+	}
+	switch {
+	case dbtype == variantTypeName:
+		// JSON text, which scans into json.RawMessage as well as string
+		return func(val any) (any, error) {
+			if s, ok := val.(string); ok {
+				return []byte(s), nil
+			}
+			return val, nil
+		}
+	case (dbtype == "TIMESTAMP" || dbtype == "DATE") && (cfg == nil || !cfg.DisableTimeParsing):
+		return func(val any) (any, error) {
+			if s, ok := val.(string); ok {
+				return parseTimeValue(s, dbtype, location, cfg)
+			}
+			return val, nil
+		}
+	}
+	return nil
+}
+
+// newValueGetter returns the function returning the value of a row of the column,
+// as rawValue does
+func newValueGetter(tColumn *cli_service.TColumn) func(rowNum int64) any {
+	switch {
+	case tColumn.GetStringVal() != nil:
+		return valueGetter(tColumn.StringVal.Values, tColumn.StringVal.Nulls)
+	case tColumn.GetByteVal() != nil:
+		return valueGetter(tColumn.ByteVal.Values, tColumn.ByteVal.Nulls)
+	case tColumn.GetI16Val() != nil:
+		return valueGetter(tColumn.I16Val.Values, tColumn.I16Val.Nulls)
+	case tColumn.GetI32Val() != nil:
+		return valueGetter(tColumn.I32Val.Values, tColumn.I32Val.Nulls)
+	case tColumn.GetI64Val() != nil:
+		return valueGetter(tColumn.I64Val.Values, tColumn.I64Val.Nulls)
+	case tColumn.GetBoolVal() != nil:
+		return valueGetter(tColumn.BoolVal.Values, tColumn.BoolVal.Nulls)
+	case tColumn.GetDoubleVal() != nil:
+		return valueGetter(tColumn.DoubleVal.Values, tColumn.DoubleVal.Nulls)
+	case tColumn.GetBinaryVal() != nil:
+		return valueGetter(tColumn.BinaryVal.Values, tColumn.BinaryVal.Nulls)
+	}
+	return func(int64) any { return nil }
+}
+
+// valueGetter returns the function returning the value of a row, or nil when the
+// row is NULL or past the values. The null bitmap is only checked when it has a
+// NULL, which most columns don't.
+func valueGetter[T any](values []T, nulls []byte) func(rowNum int64) any {
+	n := int64(len(values))
+	if !hasNull(nulls) {
+		return func(rowNum int64) any {
+			if rowNum < n {
+				return values[rowNum]
+			}
+			return nil
+		}
+	}
+	return func(rowNum int64) any {
+		if rowNum < n && !isNull(nulls, rowNum) {
+			return values[rowNum]
+		}
+		return nil
+	}
+}
+
+// hasNull returns true if the null bitmap has a NULL
+func hasNull(nulls []byte) bool {
+	for _, b := range nulls {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
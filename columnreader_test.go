@@ -0,0 +1,108 @@
+package dbsql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueGetter(t *testing.T) {
+	columns := []*cli_service.TColumn{
+		{StringVal: &cli_service.TStringColumn{Values: []string{"a", "", "c"}, Nulls: []byte{2}}},
+		{I32Val: &cli_service.TI32Column{Values: []int32{1, 2, 3}, Nulls: []byte{0}}},
+		{DoubleVal: &cli_service.TDoubleColumn{Values: []float64{1.5}}},
+		{BinaryVal: &cli_service.TBinaryColumn{Values: [][]byte{{1}, nil}, Nulls: []byte{2}}},
+		{},
+	}
+	for _, column := range columns {
+		get := newValueGetter(column)
+		for row := int64(0); row < 4; row++ {
+			assert.Equal(t, rawValue(column, row), get(row), "row %d", row)
+		}
+	}
+	assert.False(t, hasNull(nil))
+	assert.False(t, hasNull([]byte{0, 0}))
+	assert.True(t, hasNull([]byte{0, 4}))
+}
+
+func TestRows_ColumnReaders(t *testing.T) {
+	var requests []*cli_service.TFetchResultsReq
+	r := &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(20, &requests),
+	}
+	dest := make([]driver.Value, 1)
+	require.NoError(t, r.Next(dest))
+	readers := r.readers
+	require.Len(t, readers, 1)
+	assert.Nil(t, readers[0].convert)
+	assert.Equal(t, r.fetchResults, r.readersPage)
+
+	// the readers are built once per page
+	page := r.fetchResults
+	for i := 1; i < 10; i++ {
+		require.NoError(t, r.Next(dest))
+		assert.Equal(t, int32(i), dest[0])
+	}
+	assert.Same(t, page, r.readersPage)
+	require.NoError(t, r.Next(dest))
+	assert.Equal(t, int32(10), dest[0])
+	assert.NotSame(t, page, r.readersPage)
+	assert.Same(t, r.fetchResults, r.readersPage)
+}
+
+func TestNewValueConverter(t *testing.T) {
+	assert.Nil(t, newValueConverter("INT", nil, nil))
+	assert.Nil(t, newValueConverter("STRING", nil, nil))
+
+	convert := newValueConverter("DATE", nil, nil)
+	require.NotNil(t, convert)
+	v, err := convert("2021-07-01")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC), v)
+
+	cfg := config.WithDefaults()
+	cfg.DisableTimeParsing = true
+	assert.Nil(t, newValueConverter("DATE", nil, cfg))
+
+	cfg.Converters = map[string]func(value any) (any, error){
+		"INT": func(value any) (any, error) { return nil, errors.New("out of range") },
+	}
+	_, err = newValueConverter("INT", nil, cfg)(int32(1))
+	assert.EqualError(t, err, "databricks: cannot convert INT value: out of range")
+}
+
+func TestRows_ColumnReadersPerResultSet(t *testing.T) {
+	var requests []*cli_service.TFetchResultsReq
+	cfg := config.WithDefaults()
+	cfg.Converters = map[string]func(value any) (any, error){
+		"INT": func(value any) (any, error) { return int64(value.(int32)) * 2, nil },
+	}
+	r := &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(20, &requests),
+		config:   cfg,
+	}
+	dest := make([]driver.Value, 1)
+	require.NoError(t, r.Next(dest))
+	assert.Equal(t, int64(0), dest[0])
+	convert := r.readers[0].convert
+	for i := 1; i < 11; i++ {
+		require.NoError(t, r.Next(dest))
+		assert.Equal(t, int64(2*i), dest[0])
+	}
+	// the next page reuses the conversion resolved for the result set
+	assert.Equal(t, fmt.Sprintf("%p", convert), fmt.Sprintf("%p", r.readers[0].convert))
+
+	// pages that do not match the schema invalidate the result set
+	r.fetchResults.Results.Columns = append(r.fetchResults.Results.Columns, r.fetchResults.Results.Columns[0])
+	r.readersPage = nil
+	assert.ErrorIs(t, r.Next(dest), ErrResultSetInvalidated)
+}
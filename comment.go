@@ -0,0 +1,40 @@
+package dbsql
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// statementComment returns the key-value pairs of the connector and of the context
+// as a sqlcommenter comment prefix, or "" if there are none. The pairs of the
+// context override those of the connector.
+func statementComment(connComment, ctxComment map[string]string) string {
+	if len(connComment) == 0 && len(ctxComment) == 0 {
+		return ""
+	}
+	merged := make(map[string]string, len(connComment)+len(ctxComment))
+	for k, v := range connComment {
+		merged[k] = v
+	}
+	for k, v := range ctxComment {
+		merged[k] = v
+	}
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	// sqlcommenter sorts the pairs by key
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = sqlCommenterEscape(k) + "='" + sqlCommenterEscape(merged[k]) + "'"
+	}
+	return "/*" + strings.Join(pairs, ",") + "*/ "
+}
+
+// sqlCommenterEscape url-encodes s as in the sqlcommenter spec. Quotes, * and / are
+// encoded too, so s cannot end the value or the comment.
+func sqlCommenterEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
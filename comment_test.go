@@ -0,0 +1,53 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementComment(t *testing.T) {
+	t.Run("no pairs add no comment", func(t *testing.T) {
+		assert.Equal(t, "", statementComment(nil, map[string]string{}))
+	})
+
+	t.Run("pairs are sorted, escaped and overridden by the context", func(t *testing.T) {
+		comment := statementComment(
+			map[string]string{"application": "monthly reports", "user": "a"},
+			map[string]string{"user": "o'brien */ DROP", "traceparent": "00-abc-01"},
+		)
+		assert.Equal(t, "/*application='monthly%20reports',traceparent='00-abc-01',user='o%27brien%20%2A%2F%20DROP'*/ ", comment)
+	})
+
+	t.Run("the comment is prepended to statements", func(t *testing.T) {
+		var statements []string
+		testClient := &client.TestClient{
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				statements = append(statements, req.Statement)
+				return nil, fmt.Errorf("error")
+			},
+		}
+		cfg := config.WithDefaults()
+		WithStatementComment(map[string]string{"application": "reports"})(cfg)
+		testConn := &conn{
+			session: getTestSession(),
+			client:  testClient,
+			cfg:     cfg,
+		}
+
+		_, _ = testConn.ExecContext(context.Background(), "select 1", []driver.NamedValue{})
+		ctx := driverctx.NewContextWithStatementComment(context.Background(), map[string]string{"traceparent": "00-abc-01"})
+		_, _ = testConn.QueryContext(ctx, "select 2", []driver.NamedValue{})
+		assert.Equal(t, []string{
+			"/*application='reports'*/ select 1",
+			"/*application='reports',traceparent='00-abc-01'*/ select 2",
+		}, statements)
+	})
+}
@@ -0,0 +1,163 @@
+package dbsql
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+)
+
+// Config is the configuration of a connector, an alternative to setting the
+// options one by one. Fields left zero keep their defaults, which are returned
+// by DefaultConfig. Pass it to NewConnector with WithConfig:
+//
+//	connector, err := dbsql.NewConnector(dbsql.WithConfig(dbsql.Config{
+//		Host:        host,
+//		HTTPPath:    "/sql/1.0/warehouses/abc",
+//		AccessToken: token,
+//	}))
+//
+// NewConnector and sql.Open validate the configuration, so that a misconfigured
+// connector fails when it is created instead of on the first connection.
+type Config struct {
+	// Host is the hostname of the workspace. Mandatory
+	Host string
+	// Port is the port of the workspace. Default is 443
+	Port int
+	// HTTPPath is the path of the warehouse, e.g. /sql/1.0/warehouses/abc
+	HTTPPath string
+	// Protocol is https, or http for local test servers. Default is https,
+	// or http when Host is localhost
+	Protocol string
+	// Insecure allows plain http to hosts other than localhost, e.g. to local
+	// proxies and capture tools. Protocol is then http
+	Insecure bool
+
+	// AccessToken is the Personal Access Token
+	AccessToken string
+	// Authenticator authenticates the requests. It takes precedence over AccessToken
+	Authenticator auth.Authenticator
+
+	// Catalog and Schema are the initial namespace of the sessions
+	Catalog string
+	Schema  string
+	// SessionParams are set on the sessions when they are opened
+	SessionParams map[string]string
+	// SessionPreset is the name of a preset of session parameters, see
+	// RegisterSessionPreset
+	SessionPreset string
+	// TimeZone is the session time zone. It can't be time.Local
+	TimeZone *time.Location
+	// UserAgentEntry is appended to the User-Agent header of the requests
+	UserAgentEntry string
+
+	// QueryTimeout is the timeout of the queries on the server. Default is no timeout
+	QueryTimeout time.Duration
+	// ConnectTimeout is the max time to open a session. Default is 60 seconds
+	ConnectTimeout time.Duration
+	// ClientTimeout is the max time of a request. Default is 900 seconds
+	ClientTimeout time.Duration
+
+	// MaxRows is the max rows fetched per request. Default is 10000
+	MaxRows int
+	// MaxConcurrentFetches limits the result pages fetched at the same time by the
+	// connections of the connector. Default is no limit
+	MaxConcurrentFetches int
+
+	// TLSConfig configures the TLS connections. Default requires TLS 1.2
+	TLSConfig *tls.Config
+}
+
+// DefaultConfig returns the configuration used for the fields left zero.
+func DefaultConfig() Config {
+	cfg := config.WithDefaults()
+	return Config{
+		Port:           cfg.Port,
+		Protocol:       cfg.Protocol,
+		ConnectTimeout: cfg.ConnectTimeout,
+		ClientTimeout:  cfg.ClientTimeout,
+		MaxRows:        cfg.MaxRows,
+		TLSConfig:      cfg.TLSConfig,
+	}
+}
+
+// Validate returns an error listing the fields that are missing or out of range.
+func (c Config) Validate() error {
+	cfg := config.WithDefaults()
+	c.apply(cfg)
+	return validateConfig(cfg)
+}
+
+// apply sets the fields that are not zero on cfg
+func (c Config) apply(cfg *config.Config) {
+	if c.Host != "" {
+		WithServerHostname(c.Host)(cfg)
+	}
+	if c.Port != 0 {
+		cfg.Port = c.Port
+	}
+	if c.HTTPPath != "" {
+		cfg.HTTPPath = c.HTTPPath
+	}
+	if c.Insecure {
+		WithInsecure(true)(cfg)
+	}
+	if c.Protocol != "" {
+		cfg.Protocol = c.Protocol
+	}
+	if c.AccessToken != "" {
+		cfg.AccessToken = c.AccessToken
+	}
+	if c.Authenticator != nil {
+		cfg.Authenticator = c.Authenticator
+	}
+	if c.Catalog != "" {
+		cfg.Catalog = c.Catalog
+	}
+	if c.Schema != "" {
+		cfg.Schema = c.Schema
+	}
+	if c.SessionParams != nil {
+		cfg.SessionParams = c.SessionParams
+	}
+	if c.SessionPreset != "" {
+		cfg.SessionPreset = c.SessionPreset
+	}
+	if c.TimeZone != nil {
+		cfg.Location = c.TimeZone
+	}
+	if c.UserAgentEntry != "" {
+		cfg.UserAgentEntry = c.UserAgentEntry
+	}
+	if c.QueryTimeout != 0 {
+		cfg.QueryTimeout = c.QueryTimeout
+	}
+	if c.ConnectTimeout != 0 {
+		cfg.ConnectTimeout = c.ConnectTimeout
+	}
+	if c.ClientTimeout != 0 {
+		cfg.ClientTimeout = c.ClientTimeout
+	}
+	if c.MaxRows != 0 {
+		cfg.MaxRows = c.MaxRows
+	}
+	if c.MaxConcurrentFetches != 0 {
+		cfg.MaxConcurrentFetches = c.MaxConcurrentFetches
+	}
+	if c.TLSConfig != nil {
+		cfg.TLSConfig = c.TLSConfig
+	}
+}
+
+// validateConfig is shared by NewConnector, the DSN parsing and Config.Validate
+func validateConfig(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return errors.WithMessage(err, "databricks")
+	}
+	if _, ok := lookupSessionPreset(cfg.SessionPreset); cfg.SessionPreset != "" && !ok {
+		return errors.Errorf(errSessionPresetUnknown, cfg.SessionPreset)
+	}
+	return nil
+}
@@ -3,9 +3,14 @@ package dbsql
 import (
 	"context"
 	"database/sql/driver"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/databricks/databricks-sql-go/auth"
 	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/interceptor"
 	"github.com/databricks/databricks-sql-go/internal/cli_service"
 	"github.com/databricks/databricks-sql-go/internal/client"
 	"github.com/databricks/databricks-sql-go/internal/config"
@@ -15,23 +20,91 @@ import (
 )
 
 type conn struct {
-	id      string
-	cfg     *config.Config
-	client  cli_service.TCLIService
-	session *cli_service.TOpenSessionResp
+	id       string
+	cfg      *config.Config
+	client   cli_service.TCLIService
+	session  *cli_service.TOpenSessionResp
+	openedAt time.Time
+	// the time it took to open the session, reported in the timings of failed statements
+	connectTime time.Duration
+	// set when the session or the transport can no longer be used
+	broken bool
+	// newCloseClient creates the client used to close operations in the background.
+	// The connection's own client can't be used for that since it is not safe for
+	// concurrent use and the connection may already run the next query.
+	newCloseClient func() (cli_service.TCLIService, error)
+	closeClient    cli_service.TCLIService
+	// the current catalog and schema of the session, empty when unknown
+	catalog, schema string
+	// the quoted names of the temporary views created with CreateTempView
+	tempViews map[string]struct{}
+	// the features of the endpoint, once requested
+	capabilities *Capabilities
+	// the access token of the end user the session was opened on behalf of, empty
+	// when it was opened with the credentials of the connector
+	accessToken string
+	// the user the session runs as, empty when it runs as the authenticated principal
+	impersonationUser string
+	// set for the connections of UserPools, which all belong to the same user
+	userPool bool
+	// serializes the use of closeClient
+	closeMu sync.Mutex
+	// set once the connection is closed, guarded by closeMu
+	closed bool
+	// tracks the background closes of the connections of the connector, may be nil
+	background *sync.WaitGroup
+	// limits the result pages fetched at the same time by the connections of the connector
+	fetchSem fetchSemaphore
+	// queues the statements of the connections of the connector beyond its limit of
+	// concurrent executions, may be nil
+	stmtLimiter *statementLimiter
+	// the result schemas of the statements run by the connections of the connector,
+	// may be nil
+	schemaCache *schemaCache
+	// accounts for the memory held by the results of the connection, may be nil
+	memory *memoryAccount
+	// the operations that may still be running or hold results, canceled by Shutdown
+	ops operations
+	// set once the connection was shut down
+	shutdown atomic.Bool
+	// removes the connection from the open connections of the connector, may be nil
+	release func()
+	// closes the session once the connection is idle in the pool for the session
+	// idle timeout, guarded by idleMu with idleClosed
+	idleTimer  *time.Timer
+	idleClosed bool
+	idleMu     sync.Mutex
 }
 
-// The driver does not really implement prepared statements.
+// Prepare returns a prepared statement. The protocol has no server-side prepared
+// statements, so the statement is only prepared by the driver: with parameter
+// interpolation enabled its placeholders are found once and bound on every execution.
+// The conn implements driver.ExecerContext and driver.QueryerContext, so database/sql
+// only prepares statements when asked to with Prepare, and runs other queries directly.
 func (c *conn) Prepare(query string) (driver.Stmt, error) {
-	return &stmt{conn: c, query: query}, nil
+	return c.PrepareContext(context.Background(), query)
 }
 
-// The driver does not really implement prepared statements.
+// PrepareContext is the same as Prepare.
 func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
-	return &stmt{conn: c, query: query}, nil
+	s := &stmt{conn: c, query: query}
+	if c.cfg.InterpolateParams {
+		s.params = parseParams(query)
+	}
+	s.watchLeak()
+	return s, nil
 }
 
 func (c *conn) Close() error {
+	if c.release != nil {
+		c.release()
+	}
+	c.stopIdleTimer()
+	// the session was already closed by Shutdown
+	if c.shutdown.Load() {
+		closeIdleConnections(c.client)
+		return nil
+	}
 	log := logger.WithContext(c.id, "", "")
 	ctx := driverctx.NewContextWithConnId(context.Background(), c.id)
 	sentinel := sentinel.Sentinel{
@@ -42,6 +115,16 @@ func (c *conn) Close() error {
 		},
 	}
 	_, _, err := sentinel.Watch(ctx, c.cfg.PollInterval, 15*time.Second)
+
+	// closing the session closes its operations, so pending background closes are
+	// skipped once they get the lock
+	c.closeMu.Lock()
+	c.closed = true
+	closeIdleConnections(c.closeClient)
+	c.closeClient = nil
+	c.closeMu.Unlock()
+	closeIdleConnections(c.client)
+
 	if err != nil {
 		log.Err(err).Msg("databricks: failed to close connection")
 		return wrapErr(err, "failed to close connection")
@@ -49,6 +132,14 @@ func (c *conn) Close() error {
 	return nil
 }
 
+// closeIdleConnections closes the idle network connections kept by the http
+// transport of client, if it has one
+func closeIdleConnections(client cli_service.TCLIService) {
+	if c, ok := client.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
 // Not supported in Databricks
 func (c *conn) Begin() (driver.Tx, error) {
 	return nil, errors.New(ErrTransactionsNotSupported)
@@ -64,22 +155,115 @@ func (c *conn) Ping(ctx context.Context) error {
 	ctx = driverctx.NewContextWithConnId(ctx, c.id)
 	ctx1, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
-	_, err := c.QueryContext(ctx1, "select 1", nil)
+	rows, err := c.QueryContext(ctx1, "select 1", nil)
 	if err != nil {
 		log.Err(err).Msg("databricks: failed to ping")
 		return driver.ErrBadConn
 	}
+	// the operation would otherwise stay open on the server
+	if err := rows.Close(); err != nil {
+		log.Err(err).Msg("databricks: failed to close the ping operation")
+	}
 	return nil
 }
 
-// Implementation of SessionResetter
+// Implementation of SessionResetter, called by the connection pool before reusing
+// the connection. Connections whose session was closed while they were idle, or
+// was opened on behalf of another user than the one of ctx, are discarded, and the
+// pool opens a new session instead.
 func (c *conn) ResetSession(ctx context.Context) error {
-	// For now our session does not have any important state to reset before re-use
+	if !c.sameUser(ctx) {
+		return driver.ErrBadConn
+	}
+	if !c.stopIdleTimer() {
+		return driver.ErrBadConn
+	}
 	return nil
 }
 
+// sameUser returns true when the session of the connection was opened with the access
+// token and runs as the impersonated user of ctx, if any. database/sql takes any idle
+// connection of the pool, so the statements run on behalf of an end user are sent to
+// another connection when the session belongs to someone else. This discards the idle
+// sessions of the other users of a shared pool, which UserPools avoids.
+func (c *conn) sameUser(ctx context.Context) bool {
+	token := driverctx.AccessTokenFromContext(ctx)
+	user := driverctx.ImpersonationUserFromContext(ctx)
+	if c.userPool {
+		// the statements of the pool run as its user without one on their contexts
+		return (token == "" || token == c.accessToken) && (user == "" || user == c.impersonationUser)
+	}
+	if user == "" && c.cfg != nil {
+		user = c.cfg.ImpersonationUser
+	}
+	return c.accessToken == token && c.impersonationUser == user
+}
+
+// IsValid is called by the connection pool when the connection is returned to it.
+// Connections are discarded when the session failed to open, when a previous
+// request showed the session or transport to be broken, when the session
+// is older than the configured max age, or when the authenticator reports
+// that its credentials expired and could not be refreshed. Valid connections
+// close their session once they are idle for the session idle timeout.
 func (c *conn) IsValid() bool {
-	return c.session.GetStatus().StatusCode == cli_service.TStatusCode_SUCCESS_STATUS
+	if !c.isValid() {
+		return false
+	}
+	c.startIdleTimer()
+	return true
+}
+
+func (c *conn) isValid() bool {
+	if c.broken || c.shutdown.Load() {
+		return false
+	}
+	if v, ok := c.cfg.Authenticator.(auth.Validator); ok && !v.Valid() {
+		return false
+	}
+	if c.cfg.SessionMaxAge > 0 && !c.openedAt.IsZero() && time.Since(c.openedAt) > c.cfg.SessionMaxAge {
+		return false
+	}
+	if status := c.session.GetStatus(); status != nil && status.StatusCode != cli_service.TStatusCode_SUCCESS_STATUS {
+		return false
+	}
+	return true
+}
+
+// closeOperationAsync runs closeOperation in the background with the close client of
+// the connection. Background closes run one at a time.
+func (c *conn) closeOperationAsync(closeOperation func(cli_service.TCLIService) error, log *logger.DBSQLLogger) {
+	if c.background != nil {
+		c.background.Add(1)
+	}
+	go func() {
+		if c.background != nil {
+			defer c.background.Done()
+		}
+		c.closeMu.Lock()
+		defer c.closeMu.Unlock()
+		if c.closed {
+			return
+		}
+		if c.closeClient == nil {
+			closeClient, err := c.newCloseClient()
+			if err != nil {
+				log.Err(err).Msg("databricks: failed to create client to close operation")
+				return
+			}
+			c.closeClient = closeClient
+		}
+		if err := closeOperation(c.closeClient); err != nil {
+			log.Err(err).Msg("databricks: failed to close operation")
+		}
+	}()
+}
+
+// checkBroken flags the connection as broken when err shows that the session
+// or the transport can no longer be used
+func (c *conn) checkBroken(err error) {
+	if isSessionError(err) {
+		c.broken = true
+	}
 }
 
 // ExecContext executes a query that doesn't return rows, such
@@ -87,27 +271,67 @@ func (c *conn) IsValid() bool {
 //
 // ExecContext honors the context timeout and return when it is canceled.
 // Statement ExecContext is the same as connection ExecContext
-func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
-	log := logger.WithContext(c.id, driverctx.CorrelationIdFromContext(ctx), "")
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (_ driver.Result, err error) {
+	// the statement would run on another session, or fail, so nothing is sent and
+	// database/sql retries statements of the pool on another connection
+	if c.broken || !c.sameUser(ctx) {
+		return nil, driver.ErrBadConn
+	}
+	log := statementLogger(ctx, c.id, "")
 	msg, start := logger.Track("ExecContext")
 	ctx = driverctx.NewContextWithConnId(ctx, c.id)
-	if len(args) > 0 {
-		return nil, errors.New(ErrParametersNotSupported)
+	ctx, timer := c.startStatementTimer(ctx)
+	query, err = c.bindParams(query, args)
+	if err != nil {
+		return nil, err
+	}
+	intercepted := interceptor.Query{Statement: query, Exec: true, Catalog: c.catalog, Schema: c.schema}
+	defer func() { interceptEnd(ctx, c.cfg, intercepted, start, err) }()
+	if err := interceptStart(ctx, c.cfg, &intercepted); err != nil {
+		return nil, err
+	}
+	query = intercepted.Statement
+	if readOnlyFromContext(ctx) && !isQuery(query) {
+		return nil, errors.New(ErrReadOnly)
+	}
+	query = explainPrefix(ctx) + query
+	exStmtResp, opStatusResp, err := c.runQuery(ctx, query, nil)
+	if exStmtResp != nil {
+		// the statement is done, there are no results to read
+		c.ops.remove(exStmtResp.OperationHandle)
 	}
-	exStmtResp, opStatusResp, err := c.runQuery(ctx, query, args)
 
 	if exStmtResp != nil && exStmtResp.OperationHandle != nil {
-		log = logger.WithContext(c.id, driverctx.CorrelationIdFromContext(ctx), client.SprintGuid(exStmtResp.OperationHandle.OperationId.GUID))
+		intercepted.QueryID = client.SprintGuid(exStmtResp.OperationHandle.OperationId.GUID)
+		log = statementLogger(ctx, c.id, intercepted.QueryID)
 	}
 	defer log.Duration(msg, start)
 
 	if err != nil {
-		log.Err(err).Msgf("databricks: failed to execute query: query %s", query)
-		return nil, wrapErrf(err, "failed to execute query")
+		c.checkBroken(err)
+		log.Err(err).Msgf("databricks: failed to execute query: query %s", loggableQuery(query))
+		return nil, timer.wrapErr(wrapQueryTag(ctx, wrapErrf(err, "failed to execute query")))
+	}
+	if !explainOnlyFromContext(ctx) {
+		c.setNamespace(intercepted.Statement)
 	}
-	res := result{AffectedRows: opStatusResp.GetNumModifiedRows()}
+	return newResult(exStmtResp, opStatusResp), nil
+}
 
-	return &res, nil
+// setSession runs a statement setting up the session, e.g. a SET of the connector. It
+// skips the per-statement options of ctx and the interceptors, which apply to the
+// statements of the callers only.
+func (c *conn) setSession(ctx context.Context, query string) error {
+	ctx = driverctx.NewContextWithConnId(ctx, c.id)
+	exStmtResp, _, err := c.runQuery(ctx, query, nil)
+	if exStmtResp != nil {
+		c.ops.remove(exStmtResp.OperationHandle)
+	}
+	if err != nil {
+		c.checkBroken(err)
+		return wrapErrf(err, "failed to set up session: query %s", loggableQuery(query))
+	}
+	return nil
 }
 
 // QueryContext executes a query that may return rows, such as a
@@ -115,27 +339,59 @@ func (c *conn) ExecContext(ctx context.Context, query string, args []driver.Name
 //
 // QueryContext honors the context timeout and return when it is canceled.
 // Statement QueryContext is the same as connection QueryContext
-func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (_ driver.Rows, err error) {
+	if c.broken || !c.sameUser(ctx) {
+		return nil, driver.ErrBadConn
+	}
 	corrId := driverctx.CorrelationIdFromContext(ctx)
-	log := logger.WithContext(c.id, corrId, "")
+	log := statementLogger(ctx, c.id, "")
 	msg, start := log.Track("QueryContext")
 
 	ctx = driverctx.NewContextWithConnId(ctx, c.id)
-	if len(args) > 0 {
-		return nil, errors.New(ErrParametersNotSupported)
+	ctx, timer := c.startStatementTimer(ctx)
+	query, err = c.bindParams(query, args)
+	if err != nil {
+		return nil, err
+	}
+	intercepted := interceptor.Query{Statement: query, Catalog: c.catalog, Schema: c.schema}
+	defer func() { interceptEnd(ctx, c.cfg, intercepted, start, err) }()
+	if err := interceptStart(ctx, c.cfg, &intercepted); err != nil {
+		return nil, err
+	}
+	query = intercepted.Statement
+	if readOnlyFromContext(ctx) && !isQuery(query) {
+		return nil, errors.New(ErrReadOnly)
+	}
+	if !isDriverQuery(ctx) {
+		query = addDefaultLimit(query, c.cfg.DefaultLimit)
+	}
+	if schemaOnlyFromContext(ctx) {
+		var ok bool
+		if query, ok = schemaOnlyQuery(query); !ok {
+			return nil, errors.New(ErrSchemaOnly)
+		}
 	}
+	query = explainPrefix(ctx) + query
 	// first we try to get the results synchronously.
 	// at any point in time that the context is done we must cancel and return
-	exStmtResp, _, err := c.runQuery(ctx, query, args)
+	exStmtResp, _, err := c.runQuery(ctx, query, nil)
 
 	if exStmtResp != nil && exStmtResp.OperationHandle != nil {
-		log = logger.WithContext(c.id, driverctx.CorrelationIdFromContext(ctx), client.SprintGuid(exStmtResp.OperationHandle.OperationId.GUID))
+		intercepted.QueryID = client.SprintGuid(exStmtResp.OperationHandle.OperationId.GUID)
+		log = statementLogger(ctx, c.id, intercepted.QueryID)
 	}
 	defer log.Duration(msg, start)
 
 	if err != nil {
-		log.Err(err).Msgf("databricks: failed to run query: query %s", query)
-		return nil, wrapErrf(err, "failed to run query")
+		if exStmtResp != nil {
+			c.ops.remove(exStmtResp.OperationHandle)
+		}
+		c.checkBroken(err)
+		log.Err(err).Msgf("databricks: failed to run query: query %s", loggableQuery(query))
+		return nil, timer.wrapErr(wrapQueryTag(ctx, wrapErrf(err, "failed to run query")))
+	}
+	if !explainOnlyFromContext(ctx) && !schemaOnlyFromContext(ctx) {
+		c.setNamespace(intercepted.Statement)
 	}
 	// hold on to the operation handle
 	opHandle := exStmtResp.OperationHandle
@@ -147,20 +403,38 @@ func (c *conn) QueryContext(ctx context.Context, query string, args []driver.Nam
 		opHandle:      opHandle,
 		pageSize:      int64(c.cfg.MaxRows),
 		location:      c.cfg.Location,
+		config:        c.cfg,
+		ctx:           ctx,
+		conn:          c,
+		noResultSet:   opHandle != nil && !opHandle.HasResultSet,
+		query:         intercepted,
 	}
 
 	if exStmtResp.DirectResults != nil {
 		// return results
-		rows.fetchResults = exStmtResp.DirectResults.ResultSet
 		rows.fetchResultsMetadata = exStmtResp.DirectResults.ResultSetMetadata
+		if err := rows.setPage(exStmtResp.DirectResults.ResultSet); err != nil {
+			rows.Close()
+			return nil, wrapErr(err, "failed to run query")
+		}
 
 	}
+	if c.schemaCache != nil && !rows.noResultSet {
+		rows.useSchemaCache(c.schemaCache, schemaCacheKey(intercepted.Catalog, intercepted.Schema, query))
+	}
+	rows.stack = leakStack(c.cfg)
+	rows.watchOrphan(ctx)
 	return &rows, nil
 
 }
 
 func (c *conn) runQuery(ctx context.Context, query string, args []driver.NamedValue) (*cli_service.TExecuteStatementResp, *cli_service.TGetOperationStatusResp, error) {
-	log := logger.WithContext(c.id, driverctx.CorrelationIdFromContext(ctx), "")
+	log := statementLogger(ctx, c.id, "")
+	release, err := c.acquireStatement(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
 	// first we try to get the results synchronously.
 	// at any point in time that the context is done we must cancel and return
 	exStmtResp, err := c.executeStatement(ctx, query, args)
@@ -170,8 +444,9 @@ func (c *conn) runQuery(ctx context.Context, query string, args []driver.NamedVa
 	}
 	// hold on to the operation handle
 	opHandle := exStmtResp.OperationHandle
+	c.ops.add(opHandle)
 	if opHandle != nil && opHandle.OperationId != nil {
-		log = logger.WithContext(c.id, driverctx.CorrelationIdFromContext(ctx), client.SprintGuid(opHandle.OperationId.GUID))
+		log = statementLogger(ctx, c.id, client.SprintGuid(opHandle.OperationId.GUID))
 	}
 
 	if exStmtResp.DirectResults != nil {
@@ -187,7 +462,7 @@ func (c *conn) runQuery(ctx context.Context, query string, args []driver.NamedVa
 		case cli_service.TOperationState_CANCELED_STATE, cli_service.TOperationState_CLOSED_STATE, cli_service.TOperationState_ERROR_STATE, cli_service.TOperationState_TIMEDOUT_STATE:
 			// do we need to close the operation in these cases?
 			logBadQueryState(log, opStatus)
-			return exStmtResp, opStatus, errors.New(opStatus.GetDisplayMessage())
+			return exStmtResp, opStatus, operationError(opStatus)
 		// live states
 		case cli_service.TOperationState_INITIALIZED_STATE, cli_service.TOperationState_PENDING_STATE, cli_service.TOperationState_RUNNING_STATE:
 			statusResp, err := c.pollOperation(ctx, opHandle)
@@ -203,7 +478,7 @@ func (c *conn) runQuery(ctx context.Context, query string, args []driver.NamedVa
 			// bad
 			case cli_service.TOperationState_CANCELED_STATE, cli_service.TOperationState_CLOSED_STATE, cli_service.TOperationState_ERROR_STATE, cli_service.TOperationState_TIMEDOUT_STATE:
 				logBadQueryState(log, statusResp)
-				return exStmtResp, opStatus, errors.New(statusResp.GetDisplayMessage())
+				return exStmtResp, opStatus, operationError(statusResp)
 				// live states
 			default:
 				logBadQueryState(log, statusResp)
@@ -229,7 +504,7 @@ func (c *conn) runQuery(ctx context.Context, query string, args []driver.NamedVa
 		// bad
 		case cli_service.TOperationState_CANCELED_STATE, cli_service.TOperationState_CLOSED_STATE, cli_service.TOperationState_ERROR_STATE, cli_service.TOperationState_TIMEDOUT_STATE:
 			logBadQueryState(log, statusResp)
-			return exStmtResp, statusResp, errors.New(statusResp.GetDisplayMessage())
+			return exStmtResp, statusResp, operationError(statusResp)
 			// live states
 		default:
 			logBadQueryState(log, statusResp)
@@ -238,14 +513,41 @@ func (c *conn) runQuery(ctx context.Context, query string, args []driver.NamedVa
 	}
 }
 
+// statementLogger returns a logger with the connection, correlation id, query id
+// and statement tag fields set
+func statementLogger(ctx context.Context, connId string, queryId string) *logger.DBSQLLogger {
+	log := logger.WithContext(connId, driverctx.CorrelationIdFromContext(ctx), queryId)
+	if tag := driverctx.QueryTagFromContext(ctx); tag != "" {
+		log = log.WithQueryTag(tag)
+	}
+	return log
+}
+
+// loggableQuery returns query with its literals redacted when the redaction
+// policy asks for it
+func loggableQuery(query string) string {
+	if logger.GetRedactionPolicy().SQLLiterals {
+		return redactLiterals(query)
+	}
+	return query
+}
+
+// queryTagComment returns the statement tag as a SQL comment prefix
+func queryTagComment(tag string) string {
+	return fmt.Sprintf("/* %s */ ", SanitizeComment(tag))
+}
+
 func logBadQueryState(log *logger.DBSQLLogger, opStatus *cli_service.TGetOperationStatusResp) {
 	log.Error().Msgf("databricks: query state: %s", opStatus.GetOperationState())
 	log.Error().Msg(opStatus.GetErrorMessage())
 }
 
 func (c *conn) executeStatement(ctx context.Context, query string, args []driver.NamedValue) (*cli_service.TExecuteStatementResp, error) {
-	corrId := driverctx.CorrelationIdFromContext(ctx)
-	log := logger.WithContext(c.id, corrId, "")
+	log := statementLogger(ctx, c.id, "")
+	if tag := driverctx.QueryTagFromContext(ctx); tag != "" && c.cfg.QueryTagComment {
+		query = queryTagComment(tag) + query
+	}
+	query = statementComment(c.cfg.StatementComment, driverctx.StatementCommentFromContext(ctx)) + query
 	sentinel := sentinel.Sentinel{
 		OnDoneFn: func(statusResp any) (any, error) {
 			req := cli_service.TExecuteStatementReq{
@@ -261,8 +563,16 @@ func (c *conn) executeStatement(ctx context.Context, query string, args []driver
 				// CanDecompressLZ4Result_: &f,
 				// CanDownloadResult_: &t,
 			}
+			if c.cfg.ResultByteLimit > 0 {
+				req.GetDirectResults.MaxBytes = &c.cfg.ResultByteLimit
+			}
 			ctx = driverctx.NewContextWithConnId(ctx, c.id)
+			done := statementTimerFromContext(ctx).execute()
 			resp, err := c.client.ExecuteStatement(ctx, &req)
+			done()
+			if err != nil && resp != nil && resp.Status != nil && resp.Status.StatusCode == cli_service.TStatusCode_ERROR_STATUS {
+				err = errors.WithStack(newExecutionError(resp.Status.GetErrorMessage(), resp.Status.GetSqlState()))
+			}
 			return resp, wrapErr(err, "failed to execute statement")
 		},
 		OnCancelFn: func() (any, error) {
@@ -270,7 +580,11 @@ func (c *conn) executeStatement(ctx context.Context, query string, args []driver
 			return nil, nil
 		},
 	}
-	_, res, err := sentinel.Watch(ctx, c.cfg.PollInterval, c.cfg.QueryTimeout)
+	timeout := c.cfg.QueryTimeout
+	if c.cfg.ExecuteTimeout > 0 {
+		timeout = c.cfg.ExecuteTimeout
+	}
+	_, res, err := sentinel.Watch(ctx, c.cfg.PollInterval, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -285,6 +599,7 @@ func (c *conn) pollOperation(ctx context.Context, opHandle *cli_service.TOperati
 	corrId := driverctx.CorrelationIdFromContext(ctx)
 	log := logger.WithContext(c.id, corrId, client.SprintGuid(opHandle.OperationId.GUID))
 	var statusResp *cli_service.TGetOperationStatusResp
+	timer := statementTimerFromContext(ctx)
 	ctx = driverctx.NewContextWithConnId(ctx, c.id)
 	newCtx := driverctx.NewContextWithCorrelationId(driverctx.NewContextWithConnId(context.Background(), c.id), corrId)
 	pollSentinel := sentinel.Sentinel{
@@ -294,9 +609,11 @@ func (c *conn) pollOperation(ctx context.Context, opHandle *cli_service.TOperati
 		StatusFn: func() (sentinel.Done, any, error) {
 			var err error
 			log.Debug().Msg("databricks: polling status")
+			done := timer.poll()
 			statusResp, err = c.client.GetOperationStatus(newCtx, &cli_service.TGetOperationStatusReq{
 				OperationHandle: opHandle,
 			})
+			done()
 			if statusResp != nil && statusResp.OperationState != nil {
 				log.Debug().Msgf("databricks: status %s", statusResp.GetOperationState().String())
 			}
@@ -321,8 +638,10 @@ func (c *conn) pollOperation(ctx context.Context, opHandle *cli_service.TOperati
 			})
 			return ret, err
 		},
+		Multiplier:  c.cfg.PollBackoffMultiplier,
+		MaxInterval: c.cfg.PollMaxInterval,
 	}
-	_, resp, err := pollSentinel.Watch(ctx, c.cfg.PollInterval, 0)
+	_, resp, err := pollSentinel.Watch(ctx, c.cfg.PollInterval, c.cfg.PollTimeout)
 	if err != nil {
 		return nil, wrapErr(err, "failed to poll query state")
 	}
@@ -2,17 +2,22 @@ package dbsql
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"net/http"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/apache/thrift/lib/go/thrift"
 
+	"github.com/databricks/databricks-sql-go/driverctx"
 	"github.com/databricks/databricks-sql-go/internal/cli_service"
 	"github.com/databricks/databricks-sql-go/internal/client"
 	"github.com/databricks/databricks-sql-go/internal/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConn_executeStatement(t *testing.T) {
@@ -873,6 +878,82 @@ func TestConn_ExecContext(t *testing.T) {
 		assert.Equal(t, int64(10), rowsAffected)
 		assert.Equal(t, 1, executeStatementCount)
 	})
+
+	t.Run("ExecContext returns num_affected_rows of the results when the status has no count", func(t *testing.T) {
+		executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (r *cli_service.TExecuteStatementResp, err error) {
+			return &cli_service.TExecuteStatementResp{
+				Status: &cli_service.TStatus{
+					StatusCode: cli_service.TStatusCode_SUCCESS_STATUS,
+				},
+				OperationHandle: &cli_service.TOperationHandle{
+					OperationId: &cli_service.THandleIdentifier{
+						GUID:   []byte{1, 2, 3, 4, 2, 23, 4, 2, 3, 2, 3, 4, 4, 223, 34, 54},
+						Secret: []byte("b"),
+					},
+				},
+				DirectResults: &cli_service.TSparkDirectResults{
+					OperationStatus: &cli_service.TGetOperationStatusResp{
+						OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+					},
+					ResultSetMetadata: &cli_service.TGetResultSetMetadataResp{
+						Schema: &cli_service.TTableSchema{Columns: []*cli_service.TColumnDesc{
+							{ColumnName: "num_affected_rows"},
+							{ColumnName: "num_inserted_rows"},
+						}},
+					},
+					ResultSet: &cli_service.TFetchResultsResp{
+						Results: &cli_service.TRowSet{Columns: []*cli_service.TColumn{
+							{I64Val: &cli_service.TI64Column{Values: []int64{3}}},
+							{I64Val: &cli_service.TI64Column{Values: []int64{0}}},
+						}},
+					},
+				},
+			}, nil
+		}
+
+		testConn := &conn{
+			session: getTestSession(),
+			client:  &client.TestClient{FnExecuteStatement: executeStatement},
+			cfg:     config.WithDefaults(),
+		}
+		res, err := testConn.ExecContext(context.Background(), "delete from t where id < 4", []driver.NamedValue{})
+		require.NoError(t, err)
+		rowsAffected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), rowsAffected)
+	})
+}
+
+func TestConn_QueryTag(t *testing.T) {
+	t.Parallel()
+	t.Run("statement tag is added to errors and optionally as a comment", func(t *testing.T) {
+		var statements []string
+		executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (r *cli_service.TExecuteStatementResp, err error) {
+			statements = append(statements, req.Statement)
+			return nil, fmt.Errorf("error")
+		}
+
+		testClient := &client.TestClient{
+			FnExecuteStatement: executeStatement,
+		}
+		cfg := config.WithDefaults()
+		cfg.QueryTagComment = true
+		testConn := &conn{
+			session: getTestSession(),
+			client:  testClient,
+			cfg:     cfg,
+		}
+		ctx := driverctx.NewContextWithQueryTag(context.Background(), "nightly-report:step3 */")
+		_, err := testConn.ExecContext(ctx, "select 1", []driver.NamedValue{})
+
+		assert.ErrorContains(t, err, "statement nightly-report:step3 */")
+		assert.Equal(t, []string{"/* nightly-report:step3 * / */ select 1"}, statements)
+
+		testConn.cfg.QueryTagComment = false
+		_, err = testConn.QueryContext(ctx, "select 1", []driver.NamedValue{})
+		assert.ErrorContains(t, err, "statement nightly-report:step3 */")
+		assert.Equal(t, "select 1", statements[1])
+	})
 }
 
 func TestConn_QueryContext(t *testing.T) {
@@ -972,6 +1053,88 @@ func TestConn_QueryContext(t *testing.T) {
 	})
 }
 
+// prepareCountingConn counts the statements database/sql prepares
+type prepareCountingConn struct {
+	*conn
+	prepared int
+}
+
+func (c *prepareCountingConn) Prepare(query string) (driver.Stmt, error) {
+	c.prepared++
+	return c.conn.Prepare(query)
+}
+
+func (c *prepareCountingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	c.prepared++
+	return c.conn.PrepareContext(ctx, query)
+}
+
+type testConnConnector struct {
+	conn driver.Conn
+}
+
+func (c *testConnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.conn, nil
+}
+
+func (c *testConnConnector) Driver() driver.Driver {
+	return &databricksDriver{}
+}
+
+func TestConn_QueryerExecer(t *testing.T) {
+	t.Run("database/sql runs queries and statements without preparing them", func(t *testing.T) {
+		var statements []string
+		executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (r *cli_service.TExecuteStatementResp, err error) {
+			statements = append(statements, req.Statement)
+			return &cli_service.TExecuteStatementResp{
+				Status: &cli_service.TStatus{
+					StatusCode: cli_service.TStatusCode_SUCCESS_STATUS,
+				},
+				OperationHandle: &cli_service.TOperationHandle{
+					OperationId: &cli_service.THandleIdentifier{
+						GUID:   []byte{1, 2, 3, 4, 2, 23, 4, 2, 3, 2, 3, 4, 4, 223, 34, 54},
+						Secret: []byte("b"),
+					},
+				},
+			}, nil
+		}
+		getOperationStatus := func(ctx context.Context, req *cli_service.TGetOperationStatusReq) (r *cli_service.TGetOperationStatusResp, err error) {
+			return &cli_service.TGetOperationStatusResp{
+				OperationState:  cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+				NumModifiedRows: thrift.Int64Ptr(1),
+			}, nil
+		}
+		cfg := config.WithDefaults()
+		cfg.InterpolateParams = true
+		cfg.PollInterval = 10 * time.Millisecond
+		testConn := &prepareCountingConn{conn: &conn{
+			session: getTestSession(),
+			client: &client.TestClient{
+				FnExecuteStatement:   executeStatement,
+				FnGetOperationStatus: getOperationStatus,
+				FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+					return &cli_service.TCloseOperationResp{}, nil
+				},
+			},
+			cfg: cfg,
+		}}
+		db := sql.OpenDB(&testConnConnector{testConn})
+		defer db.Close()
+
+		res, err := db.ExecContext(context.Background(), "insert into t values (?)", 1)
+		require.NoError(t, err)
+		n, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		rows, err := db.QueryContext(context.Background(), "select * from t where id in (?)", []int{1, 2})
+		require.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		assert.Equal(t, 0, testConn.prepared)
+		assert.Equal(t, []string{"insert into t values (1)", "select * from t where id in (1, 2)"}, statements)
+	})
+}
+
 func TestConn_Ping(t *testing.T) {
 	t.Run("ping returns ErrBadConn when executeStatement fails", func(t *testing.T) {
 		var executeStatementCount int
@@ -1032,9 +1195,14 @@ func TestConn_Ping(t *testing.T) {
 			return getOperationStatusResp, nil
 		}
 
+		var closeOperationCount int
 		testClient := &client.TestClient{
 			FnExecuteStatement:   executeStatement,
 			FnGetOperationStatus: getOperationStatus,
+			FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+				closeOperationCount++
+				return &cli_service.TCloseOperationResp{}, nil
+			},
 		}
 
 		testConn := &conn{
@@ -1046,6 +1214,7 @@ func TestConn_Ping(t *testing.T) {
 
 		assert.Nil(t, err)
 		assert.Equal(t, 1, executeStatementCount)
+		assert.Equal(t, 1, closeOperationCount)
 	})
 }
 
@@ -1087,6 +1256,107 @@ func TestConn_ResetSession(t *testing.T) {
 	})
 }
 
+func TestConn_IsValid(t *testing.T) {
+	t.Run("IsValid is true for an open session", func(t *testing.T) {
+		testConn := &conn{
+			session:  getTestSession(),
+			client:   &client.TestClient{},
+			cfg:      config.WithDefaults(),
+			openedAt: time.Now(),
+		}
+		assert.True(t, testConn.IsValid())
+	})
+
+	t.Run("IsValid is false when the session is older than max age", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.SessionMaxAge = time.Minute
+		testConn := &conn{
+			session:  getTestSession(),
+			client:   &client.TestClient{},
+			cfg:      cfg,
+			openedAt: time.Now().Add(-2 * time.Minute),
+		}
+		assert.False(t, testConn.IsValid())
+	})
+
+	t.Run("IsValid is false after a session error", func(t *testing.T) {
+		executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (r *cli_service.TExecuteStatementResp, err error) {
+			return nil, client.ErrInvalidHandle
+		}
+		testConn := &conn{
+			session:  getTestSession(),
+			client:   &client.TestClient{FnExecuteStatement: executeStatement},
+			cfg:      config.WithDefaults(),
+			openedAt: time.Now(),
+		}
+		_, err := testConn.ExecContext(context.Background(), "select 1", []driver.NamedValue{})
+		assert.Error(t, err)
+		assert.False(t, testConn.IsValid())
+	})
+
+	t.Run("IsValid is false after a session error while fetching rows", func(t *testing.T) {
+		testConn := &conn{
+			session: getTestSession(),
+			client: &client.TestClient{
+				FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+					return nil, client.ErrInvalidHandle
+				},
+			},
+			cfg:      config.WithDefaults(),
+			openedAt: time.Now(),
+		}
+		r := &rows{client: testConn.client, conn: testConn}
+		err := r.Next(make([]driver.Value, 1))
+		assert.Error(t, err)
+		assert.False(t, testConn.IsValid())
+	})
+
+	t.Run("IsValid is false when the authenticator credentials expired", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.Authenticator = &testValidator{valid: false}
+		testConn := &conn{
+			session:  getTestSession(),
+			client:   &client.TestClient{},
+			cfg:      cfg,
+			openedAt: time.Now(),
+		}
+		assert.False(t, testConn.IsValid())
+	})
+
+	t.Run("IsValid stays true after a query error", func(t *testing.T) {
+		executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (r *cli_service.TExecuteStatementResp, err error) {
+			return nil, fmt.Errorf("table not found")
+		}
+		testConn := &conn{
+			session:  getTestSession(),
+			client:   &client.TestClient{FnExecuteStatement: executeStatement},
+			cfg:      config.WithDefaults(),
+			openedAt: time.Now(),
+		}
+		_, err := testConn.QueryContext(context.Background(), "select 1", []driver.NamedValue{})
+		assert.Error(t, err)
+		assert.True(t, testConn.IsValid())
+	})
+}
+
+func TestIsSessionError(t *testing.T) {
+	assert.False(t, isSessionError(nil))
+	assert.False(t, isSessionError(fmt.Errorf("error")))
+	assert.False(t, isSessionError(thrift.NewTTransportExceptionFromError(context.DeadlineExceeded)))
+	assert.True(t, isSessionError(wrapErr(client.ErrInvalidHandle, "failed")))
+	assert.True(t, isSessionError(thrift.NewTTransportException(thrift.NOT_OPEN, "closed")))
+	assert.False(t, isSessionError(thrift.NewTTransportExceptionFromError(fmt.Errorf("HTTP 429: %w", ErrThrottled))))
+}
+
+func TestIsResultExpired(t *testing.T) {
+	assert.False(t, isResultExpired(nil))
+	assert.False(t, isResultExpired(fmt.Errorf("error")))
+	assert.True(t, isResultExpired(wrapErr(client.ErrInvalidHandle, "failed")))
+	assert.True(t, isResultExpired(fmt.Errorf("Invalid OperationHandle: OperationHandle [opType=EXECUTE_STATEMENT]")))
+	assert.True(t, isResultExpired(fmt.Errorf("The operation has expired")))
+	assert.False(t, isResultExpired(thrift.NewTTransportException(thrift.NOT_OPEN, "operation has been closed")))
+}
+
 func TestConn_Close(t *testing.T) {
 	t.Run("Close will call CloseSession", func(t *testing.T) {
 		var closeSessionCount int
@@ -1178,3 +1448,93 @@ func getTestSession() *cli_service.TOpenSessionResp {
 		},
 	}}
 }
+
+type testValidator struct {
+	valid bool
+}
+
+func (v *testValidator) Authenticate(r *http.Request) error {
+	return nil
+}
+
+func (v *testValidator) Valid() bool {
+	return v.valid
+}
+
+func TestConn_AsyncClose(t *testing.T) {
+	// the connection client is not safe for concurrent use, fail if it is
+	var inUse int32
+	exclusive := func() func() {
+		if !atomic.CompareAndSwapInt32(&inUse, 0, 1) {
+			t.Error("connection client used concurrently")
+		}
+		return func() { atomic.StoreInt32(&inUse, 0) }
+	}
+	executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (r *cli_service.TExecuteStatementResp, err error) {
+		defer exclusive()()
+		return &cli_service.TExecuteStatementResp{
+			Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			OperationHandle: &cli_service.TOperationHandle{
+				OperationId: &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4, 2, 23, 4, 2, 3, 2, 3, 4, 4, 223, 34, 54}, Secret: []byte("b")},
+			},
+			DirectResults: &cli_service.TSparkDirectResults{
+				OperationStatus: &cli_service.TGetOperationStatusResp{
+					OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+				},
+			},
+		}, nil
+	}
+	getOperationStatus := func(ctx context.Context, req *cli_service.TGetOperationStatusReq) (r *cli_service.TGetOperationStatusResp, err error) {
+		defer exclusive()()
+		return &cli_service.TGetOperationStatusResp{
+			OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+		}, nil
+	}
+	closeOperation := func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+		defer exclusive()()
+		t.Error("operation closed with the connection client")
+		return &cli_service.TCloseOperationResp{}, nil
+	}
+
+	release := make(chan struct{})
+	closed := make(chan struct{}, 2)
+	var closeClients int
+	cfg := config.WithDefaults()
+	cfg.AsyncClose = true
+	testConn := &conn{
+		session: getTestSession(),
+		client: &client.TestClient{
+			FnExecuteStatement:   executeStatement,
+			FnGetOperationStatus: getOperationStatus,
+			FnCloseOperation:     closeOperation,
+		},
+		cfg: cfg,
+		newCloseClient: func() (cli_service.TCLIService, error) {
+			closeClients++
+			return &client.TestClient{
+				FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+					<-release
+					closed <- struct{}{}
+					return &cli_service.TCloseOperationResp{}, nil
+				},
+			}, nil
+		},
+	}
+
+	// the second query runs while the first close is still pending
+	for i := 0; i < 2; i++ {
+		r, err := testConn.QueryContext(context.Background(), "select 1", []driver.NamedValue{})
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("operation was not closed")
+		}
+	}
+	assert.Equal(t, 1, closeClients)
+}
@@ -2,27 +2,82 @@ package dbsql
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql/driver"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/databricks/databricks-sql-go/auth/pat"
 	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/interceptor"
 	"github.com/databricks/databricks-sql-go/internal/cli_service"
 	"github.com/databricks/databricks-sql-go/internal/client"
 	"github.com/databricks/databricks-sql-go/internal/config"
 	"github.com/databricks/databricks-sql-go/internal/sentinel"
 	"github.com/databricks/databricks-sql-go/logger"
+	"github.com/databricks/databricks-sql-go/metrics"
 	"github.com/pkg/errors"
 )
 
 type connector struct {
 	cfg *config.Config
+	// the driver the connector was opened with, if registered with Register
+	driver *databricksDriver
+	// shared by the connections of the connector, created on the first connect
+	fetchSem     fetchSemaphore
+	fetchSemOnce sync.Once
+	// shared by the connections of the connector, created on the first connect
+	stmtLimiter     *statementLimiter
+	stmtLimiterOnce sync.Once
+	// shared by the connections of the connector, created on the first connect
+	schemaCache     *schemaCache
+	schemaCacheOnce sync.Once
+	// sets the TLS session cache shared by the connections of the connector
+	tlsSessionOnce sync.Once
+	// tracks the operations closed in the background by the connections
+	background sync.WaitGroup
+	closeOnce  sync.Once
+	// the open connections, shut down by Shutdown
+	conns   map[*conn]struct{}
+	connsMu sync.Mutex
 }
 
 func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	// the server has no notion of the client's local time zone
+	if c.cfg.Location == time.Local {
+		return nil, errors.New(ErrLocalTimeZone)
+	}
 
-	tclient, err := client.InitThriftClient(c.cfg)
+	c.initTLSSessionCache()
+	connectStart := time.Now()
+	cfg := c.cfg
+	accessToken := driverctx.AccessTokenFromContext(ctx)
+	if accessToken != "" {
+		// the session is opened on behalf of the end user, and all the requests of the
+		// connection are sent with their token
+		cfg = c.cfg.DeepCopy()
+		cfg.Authenticator = &pat.PATAuth{AccessToken: accessToken}
+	}
+	if cfg.Protocol == "https" && cfg.AccessToken == "" && cfg.Authenticator == nil {
+		// the connector has no credentials of its own, e.g. when it only serves the
+		// statements of end users run with their tokens
+		return nil, errors.New(ErrNoCredentials)
+	}
+	// the session runs as the user of ctx rather than the one of the connector
+	impersonationUser := driverctx.ImpersonationUserFromContext(ctx)
+	if impersonationUser == "" {
+		impersonationUser = cfg.ImpersonationUser
+	}
+	if err := resolveSecrets(ctx, cfg); err != nil {
+		return nil, err
+	}
+	tclient, err := client.InitThriftClient(cfg)
 	if err != nil {
 		return nil, wrapErr(err, "error initializing thrift client")
 	}
@@ -40,7 +95,7 @@ func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
 		OnDoneFn: func(statusResp any) (any, error) {
 			return tclient.OpenSession(ctx, &cli_service.TOpenSessionReq{
 				ClientProtocol: c.cfg.ThriftProtocolVersion,
-				Configuration:  make(map[string]string),
+				Configuration:  sessionConfiguration(impersonationUser),
 				InitialNamespace: &cli_service.TNamespace{
 					CatalogName: catalogName,
 					SchemaName:  schemaName,
@@ -60,36 +115,169 @@ func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
 	}
 
 	conn := &conn{
-		id:      client.SprintGuid(session.SessionHandle.GetSessionId().GUID),
-		cfg:     c.cfg,
-		client:  tclient,
-		session: session,
+		id:          client.SprintGuid(session.SessionHandle.GetSessionId().GUID),
+		cfg:         cfg,
+		client:      tclient,
+		session:     session,
+		openedAt:    time.Now(),
+		connectTime: time.Since(connectStart),
+		newCloseClient: func() (cli_service.TCLIService, error) {
+			return client.InitThriftClient(cfg)
+		},
+		accessToken:       accessToken,
+		impersonationUser: impersonationUser,
+		fetchSem:          c.getFetchSemaphore(),
+		stmtLimiter:       c.getStatementLimiter(),
+		schemaCache:       c.getSchemaCache(),
+		memory:            newMemoryAccount(c.cfg.MaxResultMemory),
+		background:        &c.background,
+		catalog:           c.cfg.Catalog,
+		schema:            c.cfg.Schema,
+	}
+	if ns := session.GetInitialNamespace(); ns != nil {
+		// the server reports the namespace the session starts in
+		if ns.IsSetCatalogName() {
+			conn.catalog = string(ns.GetCatalogName())
+		}
+		if ns.IsSetSchemaName() {
+			conn.schema = string(ns.GetSchemaName())
+		}
 	}
 	log := logger.WithContext(conn.id, driverctx.CorrelationIdFromContext(ctx), "")
 
 	log.Info().Msgf("connect: host=%s port=%d httpPath=%s", c.cfg.Host, c.cfg.Port, c.cfg.HTTPPath)
+	if c.cfg.Insecure {
+		log.Warn().Msgf("databricks: insecure connection to %s, requests are sent over plain http", c.cfg.Host)
+	}
 
-	for k, v := range c.cfg.SessionParams {
-		setStmt := fmt.Sprintf("SET `%s` = `%s`;", k, v)
-		_, err := conn.ExecContext(ctx, setStmt, []driver.NamedValue{})
-		if err != nil {
-			return nil, err
+	if err := c.setUpSession(ctx, conn, log); err != nil {
+		// the session would otherwise stay open on the server
+		_ = conn.Close()
+		return nil, err
+	}
+	c.trackConn(conn)
+	return conn, nil
+}
+
+// setUpSession sets the session parameters, the time zone and the warehouse settings
+// of the connector on the session of conn. ctx is the context of the statement the
+// connection is opened for, so they are run without its per-statement options, e.g.
+// read-only or explain-only, and without the interceptors.
+func (c *connector) setUpSession(ctx context.Context, conn *conn, log *logger.DBSQLLogger) error {
+	for k, v := range sessionParams(c.cfg) {
+		// the time zone is set below from the location, so both always agree
+		if c.cfg.Location != nil && strings.ToLower(k) == "timezone" {
+			continue
+		}
+		if err := conn.setSession(ctx, fmt.Sprintf("SET `%s` = `%s`;", k, v)); err != nil {
+			return err
 		}
 		log.Info().Msgf("set session parameter: param=%s value=%s", k, v)
 	}
-	return conn, nil
+	if c.cfg.Location != nil {
+		setStmt := fmt.Sprintf("SET TIME ZONE %s;", quoteStringLiteral(c.cfg.Location.String()))
+		if err := conn.setSession(ctx, setStmt); err != nil {
+			return err
+		}
+		log.Info().Msgf("set session time zone: %s", c.cfg.Location)
+	}
+	for _, setStmt := range warehouseSettings(c.cfg) {
+		if err := conn.setSession(ctx, setStmt); err != nil {
+			return err
+		}
+		log.Info().Msgf("set warehouse setting: %s", setStmt)
+	}
+	return nil
+}
+
+// warehouseSettings returns the SET statements of the SQL warehouse settings of cfg
+func warehouseSettings(cfg *config.Config) []string {
+	var statements []string
+	if cfg.StatementTimeout > 0 {
+		seconds := (cfg.StatementTimeout + time.Second - 1) / time.Second
+		statements = append(statements, fmt.Sprintf("SET STATEMENT_TIMEOUT = %d;", seconds))
+	}
+	if cfg.DisableResultCache {
+		statements = append(statements, "SET use_cached_result = false;")
+	}
+	return statements
+}
+
+func (c *connector) getFetchSemaphore() fetchSemaphore {
+	c.fetchSemOnce.Do(func() {
+		c.fetchSem = newFetchSemaphore(c.cfg.MaxConcurrentFetches)
+	})
+	return c.fetchSem
+}
+
+func (c *connector) getStatementLimiter() *statementLimiter {
+	c.stmtLimiterOnce.Do(func() {
+		c.stmtLimiter = newStatementLimiter(c.cfg.MaxConcurrentStatements)
+	})
+	return c.stmtLimiter
+}
+
+func (c *connector) getSchemaCache() *schemaCache {
+	c.schemaCacheOnce.Do(func() {
+		c.schemaCache = newSchemaCache(c.cfg.SchemaCacheSize, c.cfg.SchemaCacheTTL)
+	})
+	return c.schemaCache
+}
+
+// initTLSSessionCache sets a session cache on the TLS config of the connector, unless it
+// has one, so that the connections resume the TLS sessions of the others instead of
+// completing a full handshake
+func (c *connector) initTLSSessionCache() {
+	c.tlsSessionOnce.Do(func() {
+		if c.cfg.TLSConfig == nil || c.cfg.TLSConfig.ClientSessionCache != nil || c.cfg.TLSSessionCacheSize <= 0 {
+			return
+		}
+		// the TLS config may be shared with other connectors
+		tlsConfig := c.cfg.TLSConfig.Clone()
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(c.cfg.TLSSessionCacheSize)
+		c.cfg.TLSConfig = tlsConfig
+	})
+}
+
+// Close is called by sql.DB.Close once all connections are closed. It waits for the
+// operations still being closed in the background, within the close timeout, and
+// closes the authenticator if it implements io.Closer. This lets long running
+// services rebuild their sql.DB without leaking goroutines.
+func (c *connector) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		done := make(chan struct{})
+		go func() {
+			c.background.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(c.cfg.CloseOperationTimeout):
+			logger.Warn().Msg("databricks: operations still being closed when closing connector")
+		}
+		if closer, ok := c.cfg.Authenticator.(io.Closer); ok {
+			err = closer.Close()
+		}
+	})
+	return err
 }
 
 func (c *connector) Driver() driver.Driver {
+	if c.driver != nil {
+		return c.driver
+	}
 	return &databricksDriver{}
 }
 
 var _ driver.Connector = (*connector)(nil)
+var _ io.Closer = (*connector)(nil)
 
 type connOption func(*config.Config)
 
 // NewConnector creates a connection that can be used with sql.OpenDB().
 // This is an easier way to set up the DB instead of having to construct a DSN string.
+// It returns an error listing the settings that are missing or out of range.
 func NewConnector(options ...connOption) (driver.Connector, error) {
 	// config with default options
 	cfg := config.WithDefaults()
@@ -97,9 +285,11 @@ func NewConnector(options ...connOption) (driver.Connector, error) {
 	for _, opt := range options {
 		opt(cfg)
 	}
-	// validate config?
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
 
-	return &connector{cfg}, nil
+	return &connector{cfg: cfg}, nil
 }
 
 // WithServerHostname sets up the server hostname. Mandatory.
@@ -112,7 +302,7 @@ func WithServerHostname(host string) connOption {
 	}
 }
 
-// WithPort sets up the server port. Mandatory.
+// WithPort sets up the server port. Default is 443.
 func WithPort(port int) connOption {
 	return func(c *config.Config) {
 		c.Port = port
@@ -126,6 +316,26 @@ func WithAccessToken(token string) connOption {
 	}
 }
 
+// WithSecretResolver authenticates the requests with the access token stored as the
+// secret name of a secret manager. The secret is resolved again every time a
+// connection is opened, so a token rotated in the secret manager is used from the
+// next connection on, without restarting the service. Implementations for Vault,
+// AWS Secrets Manager and GCP Secret Manager are in package auth/secret. It takes
+// precedence over WithAccessToken, and is replaced by WithAuthenticator.
+func WithSecretResolver(resolver auth.SecretResolver, name string) connOption {
+	return func(c *config.Config) {
+		c.Authenticator = &secretAuthenticator{resolver: resolver, name: name}
+	}
+}
+
+// WithAuthenticator sets up the authenticator used for every request, e.g. one created with
+// auth.NewTokenAuthenticator for tokens that expire. It takes precedence over WithAccessToken.
+func WithAuthenticator(authr auth.Authenticator) connOption {
+	return func(c *config.Config) {
+		c.Authenticator = authr
+	}
+}
+
 // WithHTTPPath sets up the endpoint to the warehouse. Mandatory.
 func WithHTTPPath(path string) connOption {
 	return func(c *config.Config) {
@@ -133,6 +343,50 @@ func WithHTTPPath(path string) connOption {
 	}
 }
 
+// WithPathPrefix sets the base path the workspace is served under, for endpoints behind
+// reverse proxies or private link gateways that rewrite the paths, e.g. /databricks.
+// It is prepended to the HTTP path and to the paths of the workspace REST APIs. Default
+// is no prefix.
+func WithPathPrefix(prefix string) connOption {
+	return func(c *config.Config) {
+		c.PathPrefix = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// WithHTTPHeaders sets headers on every request, e.g. those required by a gateway. A
+// Host header sets the host the requests are sent for, for gateways reached by
+// another name than the workspace. The credentials set by the authenticator take
+// precedence. Default is no headers.
+func WithHTTPHeaders(headers map[string]string) connOption {
+	return func(c *config.Config) {
+		if c.HTTPHeaders == nil {
+			c.HTTPHeaders = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			c.HTTPHeaders[k] = v
+		}
+	}
+}
+
+// WithOrgID sets the X-Databricks-Org-Id header to the id of the workspace, which
+// some gateways, e.g. of Azure Private Link, require to route the requests. Default is
+// no header.
+func WithOrgID(id string) connOption {
+	return WithHTTPHeaders(map[string]string{config.OrgIDHeader: id})
+}
+
+// WithRequestHook adds a hook called with every request sent to the workspace, once its
+// headers and credentials are set, e.g. to sign it for a zero-trust gateway or an API
+// gateway fronting the workspace with AWS SigV4. The hook can read the body of the
+// request with req.GetBody, and is called again when a request is retried. A hook
+// returning an error fails the request. Hooks are called in the order they are added.
+// Default is no hooks.
+func WithRequestHook(hook func(req *http.Request) error) connOption {
+	return func(c *config.Config) {
+		c.RequestHooks = append(c.RequestHooks, hook)
+	}
+}
+
 // WithMaxRows sets up the max rows fetched per request. Default is 10000
 func WithMaxRows(n int) connOption {
 	return func(c *config.Config) {
@@ -182,3 +436,436 @@ func WithSessionParams(params map[string]string) connOption {
 		c.SessionParams = params
 	}
 }
+
+// WithSessionPreset sets the parameters of a preset registered with
+// RegisterSessionPreset, or of a built-in one, on the sessions when they are opened.
+// The parameters set with WithSessionParams override those of the preset. Default is
+// no preset.
+func WithSessionPreset(name string) connOption {
+	return func(c *config.Config) {
+		c.SessionPreset = name
+	}
+}
+
+// WithTimeZone sets the location DATE and TIMESTAMP values are parsed in, and sets the
+// same time zone on the session with SET TIME ZONE, so the server renders values in the
+// zone the driver parses them in. The location must be loaded by name, e.g. with
+// time.LoadLocation("Europe/Amsterdam"); time.Local cannot be set on the session.
+func WithTimeZone(loc *time.Location) connOption {
+	return func(c *config.Config) {
+		c.Location = loc
+	}
+}
+
+// WithQueryTagComment prepends the statement tag set with driverctx.NewContextWithQueryTag
+// to every statement as a SQL comment, so it also shows up in the query history.
+func WithQueryTagComment(enabled bool) connOption {
+	return func(c *config.Config) {
+		c.QueryTagComment = enabled
+	}
+}
+
+// WithStatementComment prepends the key-value pairs to every statement as a comment in
+// the sqlcommenter format, e.g. /*application='reports',team='finance'*/, so the statements
+// can be attributed in the query history. Pairs set on the context with
+// driverctx.NewContextWithStatementComment, e.g. a trace id, are added to them.
+// Default is no comment.
+func WithStatementComment(comment map[string]string) connOption {
+	return func(c *config.Config) {
+		if c.StatementComment == nil {
+			c.StatementComment = make(map[string]string, len(comment))
+		}
+		for k, v := range comment {
+			c.StatementComment[k] = v
+		}
+	}
+}
+
+// WithSessionMaxAge sets the max age of a session. Connections with older sessions
+// are discarded by the connection pool instead of being reused. Default is no limit.
+func WithSessionMaxAge(d time.Duration) connOption {
+	return func(c *config.Config) {
+		c.SessionMaxAge = d
+	}
+}
+
+// WithSessionIdleTimeout closes the session, and the network connections, of a connection
+// left idle in the connection pool for d, so long-lived services with little traffic do
+// not hold sessions on the server. The pool then opens a new session on the next use.
+// Default is 0, sessions are kept open.
+func WithSessionIdleTimeout(d time.Duration) connOption {
+	return func(c *config.Config) {
+		c.SessionIdleTimeout = d
+	}
+}
+
+// WithTimeParsing enables or disables parsing of DATE and TIMESTAMP values into time.Time.
+// When disabled the values are returned, and their scan type reported, as the strings
+// sent by the server. Default is enabled.
+func WithTimeParsing(enabled bool) connOption {
+	return func(c *config.Config) {
+		c.DisableTimeParsing = !enabled
+	}
+}
+
+// WithTimeParsingMode sets what is returned for DATE and TIMESTAMP values that do not
+// match the default layout: TimeParsingLenient returns them as strings, and
+// TimeParsingStrict fails with a *ConversionError. Values matching none of the
+// layouts set with WithDateLayouts or WithTimestampLayouts are always an error.
+// Default is TimeParsingLenient.
+func WithTimeParsingMode(mode TimeParsingMode) connOption {
+	return func(c *config.Config) {
+		c.StrictTimeParsing = mode == TimeParsingStrict
+	}
+}
+
+// WithRawStrings makes queries return every value as a string, e.g. for CSV exporters
+// and SQL consoles that pass the values through: the strings sent by the server for
+// the STRING, DATE, TIMESTAMP, DECIMAL, INTERVAL and complex types, and numbers and
+// booleans formatted as the server casts them to strings. NULL values stay nil. The
+// converters set with WithConverter are not called. It is overridden per query with
+// driverctx.NewContextWithRawStrings. Default is disabled.
+func WithRawStrings(enabled bool) connOption {
+	return func(c *config.Config) {
+		c.RawStrings = enabled
+	}
+}
+
+// WithTimestampLayouts sets the time.Parse layouts tried in order for TIMESTAMP values.
+// A value matching none of them is an error. Default is the JDBC compliant TimestampFormat.
+func WithTimestampLayouts(layouts ...string) connOption {
+	return func(c *config.Config) {
+		c.TimestampLayouts = layouts
+	}
+}
+
+// WithDateLayouts sets the time.Parse layouts tried in order for DATE values.
+// A value matching none of them is an error. Default is DateFormat.
+func WithDateLayouts(layouts ...string) connOption {
+	return func(c *config.Config) {
+		c.DateLayouts = layouts
+	}
+}
+
+// WithAsyncClose makes rows.Close return right away and close the server operation
+// in the background, within the given timeout. This removes a round trip per query
+// in tight loops. A timeout of zero keeps the default of 15 seconds.
+func WithAsyncClose(enabled bool, timeout time.Duration) connOption {
+	return func(c *config.Config) {
+		c.AsyncClose = enabled
+		if timeout > 0 {
+			c.CloseOperationTimeout = timeout
+		}
+	}
+}
+
+// WithLeakDetection records where rows and statements are created, and reports those
+// garbage collected without being closed, with the stack that created them, to find
+// the code exhausting the connection pool. It is a debugging aid: recording the stacks
+// slows down every query. With panicOnLeak, e.g. in tests, a leak panics instead of
+// being logged as a warning.
+func WithLeakDetection(panicOnLeak bool) connOption {
+	return func(c *config.Config) {
+		c.LeakDetection = true
+		c.PanicOnLeak = panicOnLeak
+	}
+}
+
+// WithImpersonation opens the sessions as user, so that the statements run with the
+// permissions of user and the audit logs attribute them to user rather than to the
+// authenticated principal, e.g. a service principal. The workspace must allow the
+// principal to impersonate users, otherwise opening the sessions fails. The user is
+// set when a session is opened, so the statements run as other users set them on
+// their contexts with driverctx.NewContextWithImpersonationUser, or use the pools of
+// NewImpersonationPools.
+func WithImpersonation(user string) connOption {
+	return func(c *config.Config) {
+		c.ImpersonationUser = user
+	}
+}
+
+// WithRPCTimeouts sets the max times of the requests made to the server by type,
+// instead of a single timeout for all of them, e.g. a short timeout for each fetch
+// and a long one for polling the status of ETL statements. Zero values keep the
+// defaults. The requests are also bounded by Config.ClientTimeout, the max time of
+// any request.
+func WithRPCTimeouts(timeouts RPCTimeouts) connOption {
+	return func(c *config.Config) {
+		if timeouts.OpenSession > 0 {
+			c.ConnectTimeout = timeouts.OpenSession
+		}
+		if timeouts.ExecuteStatement > 0 {
+			c.ExecuteTimeout = timeouts.ExecuteStatement
+		}
+		if timeouts.Polling > 0 {
+			c.PollTimeout = timeouts.Polling
+		}
+		if timeouts.FetchResults > 0 {
+			c.FetchTimeout = timeouts.FetchResults
+		}
+		if timeouts.CloseOperation > 0 {
+			c.CloseOperationTimeout = timeouts.CloseOperation
+		}
+	}
+}
+
+// WithPolling sets how often the status of a running query is checked: first after
+// interval, then after intervals that grow by multiplier up to maxInterval. A multiplier
+// of 1 checks at a fixed interval. Zero values keep the defaults of 1 second, 5 seconds
+// and 1.5. Short intervals suit interactive queries, long ones save requests for ETL.
+func WithPolling(interval, maxInterval time.Duration, multiplier float64) connOption {
+	return func(c *config.Config) {
+		if interval > 0 {
+			c.PollInterval = interval
+		}
+		if maxInterval > 0 {
+			c.PollMaxInterval = maxInterval
+		}
+		if multiplier > 0 {
+			c.PollBackoffMultiplier = multiplier
+		}
+	}
+}
+
+// WithParameterInterpolation binds query arguments by inlining them into the query
+// text as SQL literals, since the protocol has no native parameters. Slices and
+// maps become ARRAY and MAP literals, and a slice bound to IN (?) is expanded into
+// the list. Without it, queries with arguments fail with ErrParametersNotSupported.
+func WithParameterInterpolation(enabled bool) connOption {
+	return func(c *config.Config) {
+		c.InterpolateParams = enabled
+	}
+}
+
+// WithMaxConcurrentFetches limits the number of result pages fetched at the same time
+// by all connections of the connector, i.e. of the sql.DB. Fetches wait for a free slot
+// or for their context to be done. Default is no limit. SetMaxConcurrentFetches sets a
+// limit for the whole process.
+func WithMaxConcurrentFetches(n int) connOption {
+	return func(c *config.Config) {
+		c.MaxConcurrentFetches = n
+	}
+}
+
+// WithDialer sets the function that opens the network connections to the workspace,
+// e.g. to route them through a tunnel or to bind a local address. Default is a net.Dialer.
+func WithDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) connOption {
+	return func(c *config.Config) {
+		c.Dialer = dial
+	}
+}
+
+// WithIPVersion restricts the network connections to the workspace to IPv4 or IPv6
+// addresses, e.g. for environments where one of them is broken. Zero, the default,
+// uses either. It also applies to connections opened with WithDialer. Other versions
+// are rejected by NewConnector.
+func WithIPVersion(version int) connOption {
+	return func(c *config.Config) {
+		c.IPVersion = version
+		switch version {
+		case 4:
+			c.Network = "tcp4"
+		case 6:
+			c.Network = "tcp6"
+		default:
+			c.Network = ""
+		}
+	}
+}
+
+// WithMetrics sets the collector that receives the measurements of the driver, such
+// as the time spent in each phase of fetching a result page. Default is none.
+func WithMetrics(collector metrics.Collector) connOption {
+	return func(c *config.Config) {
+		c.Metrics = collector
+	}
+}
+
+// WithDefaultLimit appends LIMIT n to the queries that have no LIMIT clause, to protect
+// interactive tools from runaway result sets. Statements run with Exec are left alone,
+// and LIMIT clauses of subqueries do not count. Default is 0, no limit.
+func WithDefaultLimit(n int) connOption {
+	return func(c *config.Config) {
+		c.DefaultLimit = n
+	}
+}
+
+// WithMaxConcurrentStatements limits the number of statements run at the same time
+// by all connections of the connector, i.e. of the sql.DB, to match the concurrency
+// of the warehouse. Statements beyond the limit wait in the driver for a running
+// statement to finish or for their context to be done, instead of piling up on the
+// warehouse queue. A statement runs until it finishes executing, reading its results
+// does not count. The waits are reported to metrics collectors implementing
+// metrics.StatementQueueCollector. Default is no limit.
+func WithMaxConcurrentStatements(n int) connOption {
+	return func(c *config.Config) {
+		c.MaxConcurrentStatements = n
+	}
+}
+
+// WithRetries sets how requests rate limited by the gateway, with 429 Too Many Requests or
+// 503 Service Unavailable, are retried: up to retryMax times, waiting for the time asked
+// by the Retry-After header of the response, or backing off from waitMin to waitMax, with
+// jitter. Requests are not retried when the server asks to wait longer than waitMax.
+// A retryMax of zero disables the retries, zero waits keep the defaults of 1 second and
+// 30 seconds. Default is 4 retries. Requests still throttled fail with ErrThrottled.
+func WithRetries(retryMax int, waitMin, waitMax time.Duration) connOption {
+	return func(c *config.Config) {
+		c.RetryMax = retryMax
+		if waitMin > 0 {
+			c.RetryWaitMin = waitMin
+		}
+		if waitMax > 0 {
+			c.RetryWaitMax = waitMax
+		}
+	}
+}
+
+// WithConfig sets up the fields of cfg that are not zero. Options after it override
+// them. See Config for the defaults.
+func WithConfig(cfg Config) connOption {
+	return func(c *config.Config) {
+		cfg.apply(c)
+	}
+}
+
+// WithInsecure sends the requests over plain http, e.g. to a local proxy, emulator or
+// capture tool set with WithServerHostname and WithPort. Never use it to reach a
+// workspace, the credentials would be sent unencrypted. Plain http is always allowed
+// to localhost. Default is false.
+func WithInsecure(enabled bool) connOption {
+	return func(c *config.Config) {
+		c.Insecure = enabled
+		if enabled {
+			c.Protocol = "http"
+		}
+	}
+}
+
+// WithKeepAlive enables or disables HTTP keep-alive, which reuses the network connections
+// between requests. Disabled, every request opens a new connection and completes a TLS
+// handshake. period sets the interval of the TCP keep-alive probes of the connections,
+// zero keeps the default of 15 seconds and a negative period disables the probes. It
+// does not apply to connections opened with WithDialer. Default is enabled.
+func WithKeepAlive(enabled bool, period time.Duration) connOption {
+	return func(c *config.Config) {
+		c.DisableKeepAlives = !enabled
+		c.KeepAlive = period
+	}
+}
+
+// WithIdleConnections sets how many idle network connections each connection keeps
+// to the workspace, and the time after which they are closed. Zero maxPerHost keeps
+// the default of 2, zero idleTimeout never closes them. Default is 2 connections
+// closed after 90 seconds.
+func WithIdleConnections(maxPerHost int, idleTimeout time.Duration) connOption {
+	return func(c *config.Config) {
+		c.MaxIdleConnsPerHost = maxPerHost
+		c.IdleConnTimeout = idleTimeout
+	}
+}
+
+// WithTLSSessionCache sets how many TLS sessions the connections of the connector
+// cache, so that new network connections resume a session with an abbreviated
+// handshake instead of a full one. Zero disables the cache. It is not used when the
+// TLS config set with Config.TLSConfig has its own ClientSessionCache. Default is 64.
+func WithTLSSessionCache(size int) connOption {
+	return func(c *config.Config) {
+		c.TLSSessionCacheSize = size
+	}
+}
+
+// WithSchemaCache caches the result schemas of up to size statements, shared by the
+// connections of the connector, so that repeated queries, e.g. of dashboards, skip
+// the request for the schema when the server does not return it with the results.
+// The schemas are cached by statement text and current namespace, and fetched again
+// after ttl, so that the changes made to the tables are picked up. Zero ttl keeps
+// them until they are evicted. Default is no cache.
+func WithSchemaCache(size int, ttl time.Duration) connOption {
+	return func(c *config.Config) {
+		c.SchemaCacheSize = size
+		c.SchemaCacheTTL = ttl
+	}
+}
+
+// WithStatementTimeout sets the STATEMENT_TIMEOUT of the sessions, after which the SQL
+// warehouse cancels a statement, in whole seconds rounded up. Unlike WithTimeout, it is
+// enforced by the warehouse, e.g. by serverless warehouses that limit the statements of
+// a workspace. Clusters don't support it, so connectors to a cluster HTTP path fail
+// validation. Default is the setting of the warehouse.
+func WithStatementTimeout(timeout time.Duration) connOption {
+	return func(c *config.Config) {
+		c.StatementTimeout = timeout
+	}
+}
+
+// WithResultCache enables or disables the result cache of the SQL warehouse for the
+// sessions, by setting use_cached_result, e.g. to benchmark statements. Clusters don't
+// support it, so disabling it for a cluster HTTP path fails validation. Default is
+// enabled.
+func WithResultCache(enabled bool) connOption {
+	return func(c *config.Config) {
+		c.DisableResultCache = !enabled
+	}
+}
+
+// WithResultByteLimit sets the max bytes of the results returned with the response to
+// a statement, the rest being fetched by the following requests. Zero means the server
+// default.
+func WithResultByteLimit(n int64) connOption {
+	return func(c *config.Config) {
+		c.ResultByteLimit = n
+	}
+}
+
+// WithMaxResultMemory limits the bytes held by the results of each connection: the
+// result pages buffered by its rows, with their strings and binary values, and the
+// Arrow batches being written, so that services embedding the driver can bound their
+// memory. Reading results that would exceed it fails with an error matching
+// ErrMemoryLimit. MemoryUsage reports the bytes in use. Default is no limit.
+func WithMaxResultMemory(bytes int64) connOption {
+	return func(c *config.Config) {
+		c.MaxResultMemory = bytes
+	}
+}
+
+// WithAdaptivePageSize tunes the rows fetched per request while reading results,
+// instead of always fetching the rows of WithMaxRows: the next pages hold about
+// targetBytes of rows as wide as the ones already fetched, with fewer rows when full
+// pages take longer than targetLatency to fetch. The pages grow at most 4 times per
+// fetch, from 100 to 1,000,000 rows. Zero disables a target. Default is no adaptive
+// page size.
+func WithAdaptivePageSize(targetBytes int64, targetLatency time.Duration) connOption {
+	return func(c *config.Config) {
+		c.TargetPageBytes = targetBytes
+		c.TargetPageLatency = targetLatency
+	}
+}
+
+// WithConverter registers the converter of the values of the columns of a Databricks
+// type, by type name, e.g. DECIMAL, TIMESTAMP or ARRAY. It is used instead of the
+// default conversion, so that type policy is set per application. A nil converter
+// restores the default. Default is none.
+func WithConverter(typeName string, convert Converter) connOption {
+	name := strings.ToUpper(typeName)
+	return func(c *config.Config) {
+		if convert == nil {
+			delete(c.Converters, name)
+			return
+		}
+		if c.Converters == nil {
+			c.Converters = map[string]func(value any) (any, error){}
+		}
+		c.Converters[name] = convert
+	}
+}
+
+// WithInterceptors adds interceptors called around the statements run by the
+// connections, e.g. for audit logging, query rewriting or policy enforcement. They
+// are called in the order they are added. OnQueryStart hooks get the statement as
+// rewritten by the ones before them. Default is none.
+func WithInterceptors(interceptors ...interceptor.Interceptor) connOption {
+	return func(c *config.Config) {
+		c.Interceptors = append(c.Interceptors, interceptors...)
+	}
+}
@@ -2,11 +2,23 @@ package dbsql
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
 	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/databricks/databricks-sql-go/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"testing"
-	"time"
 )
 
 func TestConnector_Connect(t *testing.T) {
@@ -21,6 +33,267 @@ func TestConnector_Connect(t *testing.T) {
 		assert.Nil(t, conn)
 		assert.Error(t, err)
 	})
+
+	t.Run("Connect sets the session time zone to the parsing location", func(t *testing.T) {
+		var openSessionResp cli_service.TOpenSessionResp
+		var executeStatementResp cli_service.TExecuteStatementResp
+		loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+		loadTestData(t, "ExecuteStatement1.json", &executeStatementResp)
+		var statements []string
+		ts := initThriftTestServer(&client.TestClient{
+			FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+				return &openSessionResp, nil
+			},
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				statements = append(statements, req.Statement)
+				return &executeStatementResp, nil
+			},
+		})
+		defer ts.Close()
+		r, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(r.Port())
+		require.NoError(t, err)
+
+		loc, err := time.LoadLocation("America/Sao_Paulo")
+		require.NoError(t, err)
+		for _, opt := range []connOption{
+			WithTimeZone(loc),
+			WithSessionParams(map[string]string{"timezone": "America/Sao_Paulo"}),
+		} {
+			statements = nil
+			testConnector, err := NewConnector(WithServerHostname("localhost"), WithPort(port), opt)
+			require.NoError(t, err)
+			c, err := testConnector.Connect(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, []string{"SET TIME ZONE 'America/Sao_Paulo';"}, statements)
+			assert.Equal(t, loc.String(), c.(*conn).cfg.Location.String())
+		}
+	})
+
+	t.Run("Connect sets up the session without the options of the statement", func(t *testing.T) {
+		var openSessionResp cli_service.TOpenSessionResp
+		var executeStatementResp cli_service.TExecuteStatementResp
+		loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+		loadTestData(t, "ExecuteStatement1.json", &executeStatementResp)
+		var statements []string
+		ts := initThriftTestServer(&client.TestClient{
+			FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+				return &openSessionResp, nil
+			},
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				statements = append(statements, req.Statement)
+				return &executeStatementResp, nil
+			},
+			FnCloseSession: func(ctx context.Context, req *cli_service.TCloseSessionReq) (*cli_service.TCloseSessionResp, error) {
+				return &cli_service.TCloseSessionResp{Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS}}, nil
+			},
+		})
+		defer ts.Close()
+		r, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(r.Port())
+		require.NoError(t, err)
+
+		testConnector, err := NewConnector(
+			WithServerHostname("localhost"),
+			WithPort(port),
+			WithSessionParams(map[string]string{"ansi_mode": "true"}),
+		)
+		require.NoError(t, err)
+		for _, ctx := range []context.Context{WithReadOnly(context.Background()), WithExplainOnly(context.Background())} {
+			statements = nil
+			c, err := testConnector.Connect(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"SET `ansi_mode` = `true`;"}, statements)
+			assert.NoError(t, c.Close())
+		}
+	})
+
+	t.Run("Connect closes the session when it fails to set it up", func(t *testing.T) {
+		var openSessionResp cli_service.TOpenSessionResp
+		loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+		var closed int
+		ts := initThriftTestServer(&client.TestClient{
+			FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+				return &openSessionResp, nil
+			},
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				return &cli_service.TExecuteStatementResp{Status: &cli_service.TStatus{
+					StatusCode:   cli_service.TStatusCode_ERROR_STATUS,
+					ErrorMessage: strPtr("invalid parameter"),
+				}}, nil
+			},
+			FnCloseSession: func(ctx context.Context, req *cli_service.TCloseSessionReq) (*cli_service.TCloseSessionResp, error) {
+				closed++
+				return &cli_service.TCloseSessionResp{Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS}}, nil
+			},
+		})
+		defer ts.Close()
+		r, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(r.Port())
+		require.NoError(t, err)
+
+		testConnector, err := NewConnector(
+			WithServerHostname("localhost"),
+			WithPort(port),
+			WithSessionParams(map[string]string{"ansi_mode": "maybe"}),
+		)
+		require.NoError(t, err)
+		c, err := testConnector.Connect(context.Background())
+		assert.Nil(t, c)
+		assert.ErrorContains(t, err, "invalid parameter")
+		assert.Equal(t, 1, closed)
+	})
+
+	t.Run("Connect sets the warehouse settings", func(t *testing.T) {
+		var openSessionResp cli_service.TOpenSessionResp
+		var executeStatementResp cli_service.TExecuteStatementResp
+		loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+		loadTestData(t, "ExecuteStatement1.json", &executeStatementResp)
+		var statements []string
+		var byteLimits []int64
+		ts := initThriftTestServer(&client.TestClient{
+			FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+				return &openSessionResp, nil
+			},
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				statements = append(statements, req.Statement)
+				byteLimits = append(byteLimits, req.GetDirectResults.GetMaxBytes())
+				return &executeStatementResp, nil
+			},
+		})
+		defer ts.Close()
+		r, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(r.Port())
+		require.NoError(t, err)
+
+		testConnector, err := NewConnector(
+			WithServerHostname("localhost"),
+			WithPort(port),
+			WithStatementTimeout(90*time.Minute+time.Millisecond),
+			WithResultCache(false),
+			WithResultByteLimit(1<<20),
+		)
+		require.NoError(t, err)
+		_, err = testConnector.Connect(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"SET STATEMENT_TIMEOUT = 5401;", "SET use_cached_result = false;"}, statements)
+		assert.Equal(t, []int64{1 << 20, 1 << 20}, byteLimits)
+
+		_, err = NewConnector(
+			WithServerHostname("localhost"),
+			WithHTTPPath("/sql/protocolv1/o/123/0123-456789-abcdef"),
+			WithStatementTimeout(time.Hour),
+		)
+		assert.EqualError(t, err, `databricks: invalid config: statement timeout only applies to SQL warehouses, http path "/sql/protocolv1/o/123/0123-456789-abcdef" is a cluster`)
+	})
+
+	t.Run("Connect sends the requests under the path prefix with the gateway headers", func(t *testing.T) {
+		var openSessionResp cli_service.TOpenSessionResp
+		loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+		ts := initThriftTestServer(&client.TestClient{
+			FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+				return &openSessionResp, nil
+			},
+		})
+		defer ts.Close()
+		var paths, hosts, orgIds []string
+		thriftHandler := ts.Config.Handler
+		ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			hosts = append(hosts, r.Host)
+			orgIds = append(orgIds, r.Header.Get("X-Databricks-Org-Id"))
+			thriftHandler.ServeHTTP(w, r)
+		})
+		r, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(r.Port())
+		require.NoError(t, err)
+
+		testConnector, err := NewConnector(
+			WithServerHostname("localhost"),
+			WithPort(port),
+			WithHTTPPath("/sql/1.0/warehouses/abc"),
+			WithPathPrefix("/databricks/"),
+			WithOrgID("1234"),
+			WithHTTPHeaders(map[string]string{"Host": "workspace.example.com"}),
+		)
+		require.NoError(t, err)
+		_, err = testConnector.Connect(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/databricks/sql/1.0/warehouses/abc"}, paths)
+		assert.Equal(t, []string{"workspace.example.com"}, hosts)
+		assert.Equal(t, []string{"1234"}, orgIds)
+	})
+
+	t.Run("Connect rejects the local time zone", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.Location = time.Local
+		testConnector := connector{cfg: cfg}
+		conn, err := testConnector.Connect(context.Background())
+		assert.Nil(t, conn)
+		assert.EqualError(t, err, ErrLocalTimeZone)
+
+		// NewConnector already rejects it
+		_, err = NewConnector(WithServerHostname("localhost"), WithTimeZone(time.Local))
+		assert.EqualError(t, err, "databricks: invalid config: time zone is time.Local, use a time zone name")
+	})
+
+	t.Run("Connect requires a token on the context of connectors without credentials", func(t *testing.T) {
+		testConnector, err := NewConnector(WithServerHostname("example.cloud.databricks.com"), WithHTTPPath("/sql/1.0/warehouses/abc"))
+		require.NoError(t, err)
+		conn, err := testConnector.Connect(context.Background())
+		assert.Nil(t, conn)
+		assert.EqualError(t, err, ErrNoCredentials)
+	})
+
+	t.Run("Connect opens the session on behalf of the user of the context", func(t *testing.T) {
+		var openSessionResp cli_service.TOpenSessionResp
+		loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+		ts := initThriftTestServer(&client.TestClient{
+			FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+				return &openSessionResp, nil
+			},
+		})
+		defer ts.Close()
+		var authorizations []string
+		thriftHandler := ts.Config.Handler
+		ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorizations = append(authorizations, r.Header.Get("Authorization"))
+			thriftHandler.ServeHTTP(w, r)
+		})
+		r, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(r.Port())
+		require.NoError(t, err)
+
+		testConnector, err := NewConnector(
+			WithServerHostname("localhost"),
+			WithPort(port),
+			WithAccessToken("service-token"),
+		)
+		require.NoError(t, err)
+		userCtx := driverctx.NewContextWithAccessToken(context.Background(), "user-token")
+		userConn, err := testConnector.Connect(userCtx)
+		require.NoError(t, err)
+		_, err = testConnector.Connect(context.Background())
+		require.NoError(t, err)
+		// the access token of the connector is sent as the credentials of the endpoint URL
+		assert.Equal(t, []string{"Bearer user-token", "Basic dG9rZW46c2VydmljZS10b2tlbg=="}, authorizations)
+		assert.Nil(t, testConnector.(*connector).cfg.Authenticator)
+
+		// the connection is only used by the same user
+		assert.NoError(t, userConn.(*conn).ResetSession(userCtx))
+		assert.Equal(t, driver.ErrBadConn, userConn.(*conn).ResetSession(context.Background()))
+		_, err = userConn.(*conn).ExecContext(context.Background(), "SELECT 1", nil)
+		assert.Equal(t, driver.ErrBadConn, err)
+		_, err = userConn.(*conn).QueryContext(driverctx.NewContextWithAccessToken(context.Background(), "other-token"), "SELECT 1", nil)
+		assert.Equal(t, driver.ErrBadConn, err)
+		assert.Len(t, authorizations, 2)
+	})
 }
 
 func TestNewConnector(t *testing.T) {
@@ -28,7 +301,7 @@ func TestNewConnector(t *testing.T) {
 		host := "databricks-host"
 		port := 1
 		accessToken := "token"
-		httpPath := "http-path"
+		httpPath := "/http-path"
 		maxRows := 100
 		timeout := 100 * time.Second
 		catalog := "catalog-name"
@@ -66,4 +339,236 @@ func TestNewConnector(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, expectedCfg, coni.cfg)
 	})
+
+	t.Run("WithPolling sets the poll backoff and keeps defaults for zero values", func(t *testing.T) {
+		con, err := NewConnector(WithServerHostname("localhost"), WithPolling(200*time.Millisecond, time.Minute, 2))
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.Equal(t, 200*time.Millisecond, cfg.PollInterval)
+		assert.Equal(t, time.Minute, cfg.PollMaxInterval)
+		assert.Equal(t, 2.0, cfg.PollBackoffMultiplier)
+
+		con, err = NewConnector(WithServerHostname("localhost"), WithPolling(0, 0, 1))
+		require.NoError(t, err)
+		cfg = con.(*connector).cfg
+		assert.Equal(t, time.Second, cfg.PollInterval)
+		assert.Equal(t, 5*time.Second, cfg.PollMaxInterval)
+		assert.Equal(t, 1.0, cfg.PollBackoffMultiplier)
+	})
+
+	t.Run("WithRPCTimeouts sets the timeouts by request type and keeps defaults for zero values", func(t *testing.T) {
+		con, err := NewConnector(WithServerHostname("localhost"), WithRPCTimeouts(RPCTimeouts{
+			ExecuteStatement: 10 * time.Second,
+			Polling:          time.Hour,
+			FetchResults:     time.Minute,
+		}))
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.Equal(t, 60*time.Second, cfg.ConnectTimeout)
+		assert.Equal(t, 10*time.Second, cfg.ExecuteTimeout)
+		assert.Equal(t, time.Hour, cfg.PollTimeout)
+		assert.Equal(t, time.Minute, cfg.FetchTimeout)
+		assert.Equal(t, 15*time.Second, cfg.CloseOperationTimeout)
+	})
+
+	t.Run("WithLeakDetection enables leak detection", func(t *testing.T) {
+		con, err := NewConnector(WithServerHostname("localhost"), WithLeakDetection(true))
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.True(t, cfg.LeakDetection)
+		assert.True(t, cfg.PanicOnLeak)
+	})
+
+	t.Run("WithImpersonation sets the user of the sessions", func(t *testing.T) {
+		con, err := NewConnector(WithServerHostname("localhost"), WithImpersonation("someone@example.com"))
+		require.NoError(t, err)
+		assert.Equal(t, "someone@example.com", con.(*connector).cfg.ImpersonationUser)
+	})
+
+	t.Run("WithRetries sets the retries of throttled requests and keeps defaults for zero waits", func(t *testing.T) {
+		con, err := NewConnector(WithServerHostname("localhost"), WithRetries(2, 100*time.Millisecond, time.Minute))
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.Equal(t, 2, cfg.RetryMax)
+		assert.Equal(t, 100*time.Millisecond, cfg.RetryWaitMin)
+		assert.Equal(t, time.Minute, cfg.RetryWaitMax)
+
+		con, err = NewConnector(WithServerHostname("localhost"), WithRetries(0, 0, 0))
+		require.NoError(t, err)
+		cfg = con.(*connector).cfg
+		assert.Equal(t, 0, cfg.RetryMax)
+		assert.Equal(t, time.Second, cfg.RetryWaitMin)
+		assert.Equal(t, 30*time.Second, cfg.RetryWaitMax)
+	})
+
+	t.Run("WithMetrics sets the metrics collector", func(t *testing.T) {
+		var recorder pageMetricsRecorder
+		con, err := NewConnector(WithServerHostname("localhost"), WithMetrics(&recorder))
+		require.NoError(t, err)
+		assert.Same(t, &recorder, con.(*connector).cfg.Metrics)
+	})
+
+	t.Run("WithRequestHook adds hooks in order", func(t *testing.T) {
+		var calls []string
+		con, err := NewConnector(
+			WithServerHostname("localhost"),
+			WithRequestHook(func(req *http.Request) error { calls = append(calls, "first"); return nil }),
+			WithRequestHook(func(req *http.Request) error { calls = append(calls, "second"); return nil }),
+		)
+		require.NoError(t, err)
+		for _, hook := range con.(*connector).cfg.RequestHooks {
+			require.NoError(t, hook(nil))
+		}
+		assert.Equal(t, []string{"first", "second"}, calls)
+	})
+
+	t.Run("WithKeepAlive, WithIdleConnections and WithTLSSessionCache tune connection reuse", func(t *testing.T) {
+		con, err := NewConnector(WithServerHostname("localhost"))
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.False(t, cfg.DisableKeepAlives)
+		assert.Equal(t, 90*time.Second, cfg.IdleConnTimeout)
+		assert.Equal(t, 64, cfg.TLSSessionCacheSize)
+
+		con, err = NewConnector(
+			WithServerHostname("localhost"),
+			WithKeepAlive(false, 30*time.Second),
+			WithIdleConnections(16, time.Minute),
+			WithTLSSessionCache(0),
+		)
+		require.NoError(t, err)
+		cfg = con.(*connector).cfg
+		assert.True(t, cfg.DisableKeepAlives)
+		assert.Equal(t, 30*time.Second, cfg.KeepAlive)
+		assert.Equal(t, 16, cfg.MaxIdleConnsPerHost)
+		assert.Equal(t, time.Minute, cfg.IdleConnTimeout)
+		assert.Equal(t, 0, cfg.TLSSessionCacheSize)
+
+		_, err = NewConnector(WithServerHostname("localhost"), WithIdleConnections(-1, 0))
+		assert.EqualError(t, err, "databricks: invalid config: max idle connections per host -1 is negative")
+	})
+
+	t.Run("WithSchemaCache caches result schemas for the connections", func(t *testing.T) {
+		con, err := NewConnector(WithServerHostname("localhost"))
+		require.NoError(t, err)
+		assert.Nil(t, con.(*connector).getSchemaCache())
+
+		con, err = NewConnector(WithServerHostname("localhost"), WithSchemaCache(100, time.Minute))
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.Equal(t, 100, cfg.SchemaCacheSize)
+		assert.Equal(t, time.Minute, cfg.SchemaCacheTTL)
+		assert.Same(t, con.(*connector).getSchemaCache(), con.(*connector).getSchemaCache())
+
+		_, err = NewConnector(WithServerHostname("localhost"), WithSchemaCache(-1, 0))
+		assert.EqualError(t, err, "databricks: invalid config: schema cache size -1 is negative")
+	})
+
+	t.Run("WithIPVersion restricts the network", func(t *testing.T) {
+		con, err := NewConnector(WithServerHostname("localhost"), WithIPVersion(4))
+		require.NoError(t, err)
+		assert.Equal(t, "tcp4", con.(*connector).cfg.Network)
+
+		con, err = NewConnector(WithServerHostname("localhost"), WithIPVersion(6))
+		require.NoError(t, err)
+		assert.Equal(t, "tcp6", con.(*connector).cfg.Network)
+
+		_, err = NewConnector(WithServerHostname("localhost"), WithIPVersion(5))
+		assert.EqualError(t, err, "databricks: invalid config: ip version 5 is not 4 or 6")
+	})
+}
+
+func TestConnector_initTLSSessionCache(t *testing.T) {
+	t.Run("the connections of a connector share a session cache", func(t *testing.T) {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		c := &connector{cfg: config.WithDefaults()}
+		c.cfg.TLSConfig = tlsConfig
+		c.initTLSSessionCache()
+		require.NotNil(t, c.cfg.TLSConfig.ClientSessionCache)
+		cache := c.cfg.TLSConfig.ClientSessionCache
+		c.initTLSSessionCache()
+		assert.Same(t, cache, c.cfg.TLSConfig.ClientSessionCache)
+		// the config passed in is left alone
+		assert.Nil(t, tlsConfig.ClientSessionCache)
+		assert.Equal(t, uint16(tls.VersionTLS12), c.cfg.TLSConfig.MinVersion)
+	})
+
+	t.Run("a cache set on the TLS config is kept", func(t *testing.T) {
+		cache := tls.NewLRUClientSessionCache(1)
+		c := &connector{cfg: config.WithDefaults()}
+		c.cfg.TLSConfig = &tls.Config{ClientSessionCache: cache}
+		c.initTLSSessionCache()
+		assert.Equal(t, cache, c.cfg.TLSConfig.ClientSessionCache)
+	})
+
+	t.Run("a zero size disables the cache", func(t *testing.T) {
+		c := &connector{cfg: config.WithDefaults()}
+		c.cfg.TLSSessionCacheSize = 0
+		c.initTLSSessionCache()
+		assert.Nil(t, c.cfg.TLSConfig.ClientSessionCache)
+	})
+}
+
+type testClosingAuthenticator struct {
+	closed int
+}
+
+func (a *testClosingAuthenticator) Authenticate(r *http.Request) error {
+	return nil
+}
+
+func (a *testClosingAuthenticator) Close() error {
+	a.closed++
+	return nil
+}
+
+func TestConnector_Close(t *testing.T) {
+	t.Run("Close waits for background closes and closes the authenticator", func(t *testing.T) {
+		authr := &testClosingAuthenticator{}
+		c, err := NewConnector(WithServerHostname("databricks-host"), WithAuthenticator(authr))
+		require.NoError(t, err)
+		testConnector := c.(*connector)
+		testConn := &conn{
+			background:  &testConnector.background,
+			closeClient: &client.TestClient{},
+		}
+
+		var closedOperation int32
+		testConn.closeOperationAsync(func(cli_service.TCLIService) error {
+			time.Sleep(50 * time.Millisecond)
+			atomic.StoreInt32(&closedOperation, 1)
+			return nil
+		}, logger.WithContext("", "", ""))
+
+		assert.NoError(t, testConnector.Close())
+		assert.Equal(t, int32(1), atomic.LoadInt32(&closedOperation))
+		assert.Equal(t, 1, authr.closed)
+
+		// closing again does nothing
+		assert.NoError(t, testConnector.Close())
+		assert.Equal(t, 1, authr.closed)
+	})
+
+	t.Run("background closes are skipped once the connection is closed", func(t *testing.T) {
+		testConn := &conn{
+			session: getTestSession(),
+			client: &client.TestClient{
+				FnCloseSession: func(ctx context.Context, req *cli_service.TCloseSessionReq) (*cli_service.TCloseSessionResp, error) {
+					return &cli_service.TCloseSessionResp{}, nil
+				},
+			},
+			cfg:        config.WithDefaults(),
+			background: &sync.WaitGroup{},
+			newCloseClient: func() (cli_service.TCLIService, error) {
+				t.Error("close client created after the connection was closed")
+				return &client.TestClient{}, nil
+			},
+		}
+		assert.NoError(t, testConn.Close())
+		testConn.closeOperationAsync(func(cli_service.TCLIService) error {
+			t.Error("operation closed after the connection was closed")
+			return nil
+		}, logger.WithContext("", "", ""))
+		testConn.background.Wait()
+	})
 }
@@ -0,0 +1,15 @@
+package dbsql
+
+// Converter converts the values of the columns of a Databricks type, registered with
+// WithConverter, e.g. to convert DECIMAL values to a decimal type of the application.
+// The value is passed as sent by the server: a string for DECIMAL, DATE, TIMESTAMP,
+// INTERVAL, complex and VARIANT values, and an int8, int16, int32, int64, bool,
+// float64 or []byte for the other types. NULL values are not converted.
+//
+//	dbsql.WithConverter("DECIMAL", func(value any) (any, error) {
+//		return decimal.NewFromString(value.(string))
+//	})
+//
+// The values returned are scanned by database/sql as they are, so scan them into a
+// destination of the same type. ColumnTypeScanType still reports the default type.
+type Converter func(value any) (any, error)
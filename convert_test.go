@@ -0,0 +1,86 @@
+package dbsql
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getConvertTestColumnDesc(typeId cli_service.TTypeId) *cli_service.TColumnDesc {
+	return &cli_service.TColumnDesc{
+		ColumnName: "c",
+		TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{{
+			PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: typeId},
+		}}},
+	}
+}
+
+func TestWithConverter(t *testing.T) {
+	cfg := config.WithDefaults()
+	WithConverter("decimal", func(value any) (any, error) {
+		r, ok := new(big.Rat).SetString(value.(string))
+		if !ok {
+			return nil, errors.Errorf("invalid decimal %q", value)
+		}
+		return r, nil
+	})(cfg)
+	var timestamps []any
+	WithConverter("TIMESTAMP", func(value any) (any, error) {
+		timestamps = append(timestamps, value)
+		return "converted", nil
+	})(cfg)
+	WithConverter("INT", func(value any) (any, error) {
+		return int64(value.(int32)) * 2, nil
+	})(cfg)
+	require.Len(t, cfg.Converters, 3)
+
+	t.Run("converters replace the default conversion", func(t *testing.T) {
+		decimals := &cli_service.TColumn{StringVal: &cli_service.TStringColumn{Values: []string{"12.50", "", "x"}, Nulls: []byte{2}}}
+		desc := getConvertTestColumnDesc(cli_service.TTypeId_DECIMAL_TYPE)
+
+		v, err := value(decimals, desc, 0, nil, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewRat(25, 2), v)
+
+		v, err = value(decimals, desc, 1, nil, cfg)
+		assert.NoError(t, err)
+		assert.Nil(t, v, "NULL values are not converted")
+
+		_, err = value(decimals, desc, 2, nil, cfg)
+		assert.EqualError(t, err, `databricks: cannot convert DECIMAL value: invalid decimal "x"`)
+
+		ints := &cli_service.TColumn{I32Val: &cli_service.TI32Column{Values: []int32{21}}}
+		v, err = value(ints, getConvertTestColumnDesc(cli_service.TTypeId_INT_TYPE), 0, nil, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), v)
+	})
+
+	t.Run("converters get the values as sent by the server", func(t *testing.T) {
+		col := &cli_service.TColumn{StringVal: &cli_service.TStringColumn{Values: []string{"2021-07-01 05:43:28"}}}
+		v, err := value(col, getConvertTestColumnDesc(cli_service.TTypeId_TIMESTAMP_TYPE), 0, time.UTC, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "converted", v)
+		assert.Equal(t, []any{"2021-07-01 05:43:28"}, timestamps)
+
+		// other types keep the default conversion
+		dates := &cli_service.TColumn{StringVal: &cli_service.TStringColumn{Values: []string{"2021-07-01"}}}
+		v, err = value(dates, getConvertTestColumnDesc(cli_service.TTypeId_DATE_TYPE), 0, time.UTC, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC), v)
+	})
+
+	t.Run("a nil converter restores the default", func(t *testing.T) {
+		WithConverter("Decimal", nil)(cfg)
+		assert.NotContains(t, cfg.Converters, "DECIMAL")
+		col := &cli_service.TColumn{StringVal: &cli_service.TStringColumn{Values: []string{"12.50"}}}
+		v, err := value(col, getConvertTestColumnDesc(cli_service.TTypeId_DECIMAL_TYPE), 0, nil, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "12.50", v)
+	})
+}
@@ -0,0 +1,123 @@
+package dbsql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errCostNoEstimates = "databricks: EXPLAIN COST returned no estimates"
+
+// CostEstimate is the size of the results of a statement, and of each node of its
+// plan, as estimated by the optimizer without executing it. The estimates come from
+// the table statistics, so they are only as good as these are, e.g. after ANALYZE
+// TABLE ... COMPUTE STATISTICS.
+type CostEstimate struct {
+	// SizeInBytes is the estimated size of the result of the statement
+	SizeInBytes float64
+	// Rows is the estimated number of rows of the result, -1 when unknown
+	Rows float64
+	// Nodes are the estimates of the nodes of the optimized logical plan, from its root
+	Nodes []PlanNodeCost
+	// Plan is the plan with its statistics, as rendered by the server
+	Plan string
+}
+
+// PlanNodeCost is the estimated size of the output of a node of a plan.
+type PlanNodeCost struct {
+	// Operator is the name of the node, e.g. Join or Relation
+	Operator string
+	// SizeInBytes is the estimated size of the output of the node
+	SizeInBytes float64
+	// Rows is the estimated number of rows of the output of the node, -1 when unknown
+	Rows float64
+}
+
+// EstimateCost plans the statement with EXPLAIN COST, without executing it, and
+// returns the estimates of the optimizer, e.g. for a scheduler to hold back queries
+// that would scan too much data:
+//
+//	cost, err := dbsql.EstimateCost(ctx, db, "SELECT * FROM sales WHERE day > ?", since)
+//	if err == nil && cost.SizeInBytes > 100<<30 {
+//		return errTooExpensive
+//	}
+//
+// A statement that cannot be planned returns an *ExplainError. Unknown sizes are
+// estimated as the largest size the server knows of, about 8 EiB.
+func EstimateCost(ctx context.Context, db Queryer, query string, args ...any) (*CostEstimate, error) {
+	plan, err := explain(context.WithValue(ctx, explainOnlyContextKey{}, "EXPLAIN COST "), db, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return parseCostEstimate(plan.Text)
+}
+
+// parseCostEstimate reads the Statistics(...) of the nodes of the optimized logical
+// plan rendered by EXPLAIN COST, e.g.
+//
+//	== Optimized Logical Plan ==
+//	Aggregate [count(1) AS count#1L], Statistics(sizeInBytes=16.0 B, rowCount=1)
+//	+- Relation main.sales.orders[id#2L] parquet, Statistics(sizeInBytes=1.5 MiB, rowCount=2.50E+4)
+func parseCostEstimate(plan string) (*CostEstimate, error) {
+	estimate := &CostEstimate{Plan: plan}
+	inPlan := false
+	for _, line := range strings.Split(plan, "\n") {
+		if strings.HasPrefix(line, "== ") {
+			inPlan = strings.TrimSpace(line) == "== Optimized Logical Plan =="
+			continue
+		}
+		i := strings.LastIndex(line, "Statistics(")
+		if !inPlan || i < 0 {
+			continue
+		}
+		node := PlanNodeCost{Operator: planOperator(line[:i]), SizeInBytes: -1, Rows: -1}
+		stats := strings.TrimSuffix(strings.TrimSpace(line[i+len("Statistics("):]), ")")
+		for _, stat := range strings.Split(stats, ", ") {
+			key, value, _ := strings.Cut(stat, "=")
+			switch key {
+			case "sizeInBytes":
+				node.SizeInBytes = parseByteSize(value)
+			case "rowCount":
+				if rows, err := strconv.ParseFloat(value, 64); err == nil {
+					node.Rows = rows
+				}
+			}
+		}
+		estimate.Nodes = append(estimate.Nodes, node)
+	}
+	if len(estimate.Nodes) == 0 || estimate.Nodes[0].SizeInBytes < 0 {
+		return nil, errors.New(errCostNoEstimates)
+	}
+	estimate.SizeInBytes = estimate.Nodes[0].SizeInBytes
+	estimate.Rows = estimate.Nodes[0].Rows
+	return estimate, nil
+}
+
+// planOperator returns the name of the operator of a plan tree line, without the
+// tree drawing that precedes it
+func planOperator(line string) string {
+	line = strings.TrimLeft(line, " :+-")
+	if i := strings.IndexAny(line, " ["); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSuffix(line, ",")
+}
+
+// parseByteSize parses a size rendered with binary units, e.g. 1.5 MiB, and returns
+// -1 if it is not one
+func parseByteSize(s string) float64 {
+	number, unit, _ := strings.Cut(strings.TrimSpace(s), " ")
+	size, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return -1
+	}
+	for _, u := range []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"} {
+		if unit == u {
+			return size
+		}
+		size *= 1024
+	}
+	return -1
+}
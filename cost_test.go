@@ -0,0 +1,66 @@
+package dbsql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateCost(t *testing.T) {
+	t.Run("estimates of the optimized plan", func(t *testing.T) {
+		lines := []string{
+			"== Optimized Logical Plan ==",
+			"Aggregate [region#3], [region#3, sum(amount#4) AS total#1], Statistics(sizeInBytes=2.0 KiB, rowCount=50)",
+			"+- Join Inner, (id#2L = order_id#5L), Statistics(sizeInBytes=1.5 MiB, rowCount=2.50E+4)",
+			"   :- Relation main.sales.orders[id#2L,region#3] parquet, Statistics(sizeInBytes=8.0 EiB)",
+			"   +- Filter isnotnull(order_id#5L), Statistics(sizeInBytes=16.0 B, rowCount=1)",
+			"",
+			"== Physical Plan ==",
+			"AdaptiveSparkPlan isFinalPlan=false, Statistics(sizeInBytes=1.0 B)",
+		}
+		var statements []string
+		db := getExplainTestDB(lines, &statements)
+		defer db.Close()
+
+		cost, err := EstimateCost(context.Background(), db, "SELECT region, sum(amount) FROM orders WHERE day > ?", "2024-01-01")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"EXPLAIN COST SELECT region, sum(amount) FROM orders WHERE day > '2024-01-01'"}, statements)
+		assert.Equal(t, 2048.0, cost.SizeInBytes)
+		assert.Equal(t, 50.0, cost.Rows)
+		assert.Equal(t, []PlanNodeCost{
+			{Operator: "Aggregate", SizeInBytes: 2048, Rows: 50},
+			{Operator: "Join", SizeInBytes: 1.5 * 1024 * 1024, Rows: 25000},
+			{Operator: "Relation", SizeInBytes: 8 * (1 << 60), Rows: -1},
+			{Operator: "Filter", SizeInBytes: 16, Rows: 1},
+		}, cost.Nodes)
+		assert.Contains(t, cost.Plan, "== Physical Plan ==")
+	})
+
+	t.Run("planning errors", func(t *testing.T) {
+		var statements []string
+		db := getExplainTestDB([]string{"Error occurred during query planning: ", "[TABLE_OR_VIEW_NOT_FOUND] nope"}, &statements)
+		defer db.Close()
+
+		_, err := EstimateCost(context.Background(), db, "SELECT * FROM nope")
+		var explainErr *ExplainError
+		require.ErrorAs(t, err, &explainErr)
+	})
+
+	t.Run("plans without estimates", func(t *testing.T) {
+		var statements []string
+		db := getExplainTestDB([]string{"== Physical Plan ==", "LocalTableScan [x#1]"}, &statements)
+		defer db.Close()
+
+		_, err := EstimateCost(context.Background(), db, "SELECT 1")
+		assert.EqualError(t, err, errCostNoEstimates)
+	})
+}
+
+func TestParseByteSize(t *testing.T) {
+	assert.Equal(t, 16.0, parseByteSize("16.0 B"))
+	assert.Equal(t, 3.5*(1<<30), parseByteSize("3.5 GiB"))
+	assert.Equal(t, -1.0, parseByteSize("3.5 GB"))
+	assert.Equal(t, -1.0, parseByteSize("lots"))
+}
@@ -0,0 +1,166 @@
+package dbsql
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/databricks/databricks-sql-go/logger"
+	"github.com/pkg/errors"
+)
+
+// max bytes per DBFS API read or add-block call
+const dbfsBlockSize = 1 << 20
+
+// dbfsCloseTimeout bounds closing a write handle after a failed write
+const dbfsCloseTimeout = 10 * time.Second
+
+var errDBFSInvalidConnector = "databricks: DBFS requires a connector created by this driver"
+
+// DBFS reads and writes small files in DBFS through the workspace REST API, using
+// the same host and credentials as the connector. It is meant for exchanging
+// configs and artifacts, not for bulk data.
+type DBFS struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewDBFS returns a DBFS client for the workspace of the connector.
+func NewDBFS(c driver.Connector) (*DBFS, error) {
+	dbc, ok := c.(*connector)
+	if !ok {
+		return nil, errors.New(errDBFSInvalidConnector)
+	}
+	return newDBFS(dbc.cfg), nil
+}
+
+func newDBFS(cfg *config.Config) *DBFS {
+	return &DBFS{
+		baseURL:    cfg.ToWorkspaceURL(),
+		httpClient: client.NewHTTPClient(cfg),
+	}
+}
+
+// ReadFile reads the whole content of the file at path, e.g. dbfs:/tmp/config.json.
+func (d *DBFS) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	path = dbfsPath(path)
+	var buf bytes.Buffer
+	for {
+		var resp struct {
+			BytesRead int64  `json:"bytes_read"`
+			Data      string `json:"data"`
+		}
+		query := url.Values{}
+		query.Set("path", path)
+		query.Set("offset", fmt.Sprint(buf.Len()))
+		query.Set("length", fmt.Sprint(dbfsBlockSize))
+		if err := d.do(ctx, http.MethodGet, "/api/2.0/dbfs/read?"+query.Encode(), nil, &resp); err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", path)
+		}
+		data, err := base64.StdEncoding.DecodeString(resp.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", path)
+		}
+		buf.Write(data)
+		if resp.BytesRead < dbfsBlockSize {
+			return buf.Bytes(), nil
+		}
+	}
+}
+
+// WriteFile writes data to the file at path. An existing file is replaced only if overwrite is true.
+func (d *DBFS) WriteFile(ctx context.Context, path string, data []byte, overwrite bool) error {
+	path = dbfsPath(path)
+	var handle struct {
+		Handle int64 `json:"handle"`
+	}
+	err := d.do(ctx, http.MethodPost, "/api/2.0/dbfs/create", map[string]any{"path": path, "overwrite": overwrite}, &handle)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	for len(data) > 0 {
+		n := len(data)
+		if n > dbfsBlockSize {
+			n = dbfsBlockSize
+		}
+		block := map[string]any{"handle": handle.Handle, "data": base64.StdEncoding.EncodeToString(data[:n])}
+		if err := d.do(ctx, http.MethodPost, "/api/2.0/dbfs/add-block", block, nil); err != nil {
+			d.closeHandle(handle.Handle)
+			return errors.Wrapf(err, "failed to write %s", path)
+		}
+		data = data[n:]
+	}
+	err = d.do(ctx, http.MethodPost, "/api/2.0/dbfs/close", map[string]any{"handle": handle.Handle}, nil)
+	return errors.Wrapf(err, "failed to write %s", path)
+}
+
+// closeHandle makes a best effort to release a write handle after a failed write.
+// It does not use the caller's context since that may be what made the write fail.
+func (d *DBFS) closeHandle(handle int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbfsCloseTimeout)
+	defer cancel()
+	if err := d.do(ctx, http.MethodPost, "/api/2.0/dbfs/close", map[string]any{"handle": handle}, nil); err != nil {
+		logger.Debug().Msgf("databricks: failed to close DBFS handle %d: %s", handle, err)
+	}
+}
+
+// Delete removes the file or directory at path.
+func (d *DBFS) Delete(ctx context.Context, path string, recursive bool) error {
+	path = dbfsPath(path)
+	err := d.do(ctx, http.MethodPost, "/api/2.0/dbfs/delete", map[string]any{"path": path, "recursive": recursive}, nil)
+	return errors.Wrapf(err, "failed to delete %s", path)
+}
+
+func (d *DBFS) do(ctx context.Context, method string, endpoint string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.baseURL+endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			ErrorCode string `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Message != "" {
+			return errors.Errorf("%s: %s", apiErr.ErrorCode, apiErr.Message)
+		}
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+// dbfsPath strips the dbfs: scheme, the API expects absolute paths
+func dbfsPath(path string) string {
+	return "/" + strings.TrimLeft(strings.TrimPrefix(path, "dbfs:"), "/")
+}
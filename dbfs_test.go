@@ -0,0 +1,113 @@
+package dbsql
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBFS(t *testing.T) {
+	files := map[string][]byte{}
+	handles := map[int64]string{}
+	var authHeaders []string
+	var closed []int64
+	failAddBlock := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/api/2.0/dbfs/create":
+			path := body["path"].(string)
+			if _, ok := files[path]; ok && !body["overwrite"].(bool) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error_code":"RESOURCE_ALREADY_EXISTS","message":"exists"}`))
+				return
+			}
+			handles[1] = path
+			files[path] = nil
+			_, _ = w.Write([]byte(`{"handle":1}`))
+		case "/api/2.0/dbfs/add-block":
+			if failAddBlock {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"error_code":"INTERNAL_ERROR","message":"add-block failed"}`))
+				return
+			}
+			data, _ := base64.StdEncoding.DecodeString(body["data"].(string))
+			path := handles[int64(body["handle"].(float64))]
+			files[path] = append(files[path], data...)
+			_, _ = w.Write([]byte(`{}`))
+		case "/api/2.0/dbfs/close":
+			closed = append(closed, int64(body["handle"].(float64)))
+			_, _ = w.Write([]byte(`{}`))
+		case "/api/2.0/dbfs/read":
+			data, ok := files[r.URL.Query().Get("path")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error_code":"RESOURCE_DOES_NOT_EXIST","message":"not found"}`))
+				return
+			}
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			length, _ := strconv.Atoi(r.URL.Query().Get("length"))
+			end := offset + length
+			if end > len(data) {
+				end = len(data)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"bytes_read": end - offset,
+				"data":       base64.StdEncoding.EncodeToString(data[offset:end]),
+			})
+		}
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+	cfg := config.WithDefaults()
+	cfg.Protocol = "http"
+	cfg.Host = u.Hostname()
+	cfg.Port = port
+	cfg.AccessToken = "dapi123"
+	fs := newDBFS(cfg)
+	ctx := context.Background()
+
+	big := make([]byte, dbfsBlockSize+10)
+	for i := range big {
+		big[i] = byte(i)
+	}
+	require.NoError(t, fs.WriteFile(ctx, "dbfs:/tmp/big.bin", big, false))
+	assert.Equal(t, big, files["/tmp/big.bin"])
+
+	data, err := fs.ReadFile(ctx, "/tmp/big.bin")
+	require.NoError(t, err)
+	assert.Equal(t, big, data)
+
+	err = fs.WriteFile(ctx, "dbfs:/tmp/big.bin", []byte("x"), false)
+	assert.ErrorContains(t, err, "RESOURCE_ALREADY_EXISTS: exists")
+
+	// the handle is closed when a block can't be written
+	failAddBlock = true
+	closed = nil
+	err = fs.WriteFile(ctx, "dbfs:/tmp/failed.bin", []byte("x"), true)
+	assert.ErrorContains(t, err, "add-block failed")
+	assert.Equal(t, []int64{1}, closed)
+
+	_, err = fs.ReadFile(ctx, "dbfs:/tmp/missing")
+	assert.ErrorContains(t, err, "not found")
+
+	for _, h := range authHeaders {
+		assert.Equal(t, "Bearer dapi123", h)
+	}
+
+	_, err = NewDBFS(nil)
+	assert.EqualError(t, err, errDBFSInvalidConnector)
+}
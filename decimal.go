@@ -0,0 +1,129 @@
+package dbsql
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Decimal implements sql.Scanner for DECIMAL columns and other numbers that may
+// not fit a Go numeric type. DECIMAL values are sent by the server as strings and
+// are held here exactly, whatever their precision.
+//
+// Scanning a DECIMAL value directly into a float64 silently rounds it. Decimal
+// instead converts with Int64, Uint64 and Float64, which return a *ConversionError
+// when the value has a fractional part or is out of range for the target type.
+// Uint64 covers unsigned 64 bit values, which are usually stored as DECIMAL(20,0).
+//
+//	var d dbsql.Decimal
+//	if err := row.Scan(&d); err != nil {
+//		...
+//	}
+//	id, err := d.Uint64()
+type Decimal struct {
+	r *big.Rat
+	s string
+	// Valid is false when the value is NULL
+	Valid bool
+}
+
+// Scan implements the sql.Scanner interface. NaN and infinite doubles are
+// rejected, since they have no decimal representation.
+func (d *Decimal) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		d.r, d.s, d.Valid = nil, "", false
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int8:
+		s = strconv.FormatInt(int64(v), 10)
+	case int16:
+		s = strconv.FormatInt(int64(v), 10)
+	case int32:
+		s = strconv.FormatInt(int64(v), 10)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return &ConversionError{Value: strconv.FormatFloat(v, 'g', -1, 64), Type: "Decimal", Reason: "not a finite number"}
+		}
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return errors.Errorf("databricks: cannot scan %T into Decimal", src)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return &ConversionError{Value: s, Type: "Decimal", Reason: "not a decimal number"}
+	}
+	d.r, d.s, d.Valid = r, s, true
+	return nil
+}
+
+// String returns the value as sent by the server, or NULL.
+func (d Decimal) String() string {
+	if !d.Valid {
+		return "NULL"
+	}
+	return d.s
+}
+
+// Rat returns the exact value, or nil when the value is NULL.
+func (d Decimal) Rat() *big.Rat {
+	if !d.Valid {
+		return nil
+	}
+	return new(big.Rat).Set(d.r)
+}
+
+// Int64 returns the value as an int64. It fails when the value is NULL, has a
+// fractional part or is out of the int64 range.
+func (d Decimal) Int64() (int64, error) {
+	if err := d.checkInt("int64"); err != nil {
+		return 0, err
+	}
+	if !d.r.Num().IsInt64() {
+		return 0, &ConversionError{Value: d.s, Type: "int64", Reason: "out of range"}
+	}
+	return d.r.Num().Int64(), nil
+}
+
+// Uint64 returns the value as a uint64. It fails when the value is NULL, has a
+// fractional part or is out of the uint64 range, which includes negative values.
+func (d Decimal) Uint64() (uint64, error) {
+	if err := d.checkInt("uint64"); err != nil {
+		return 0, err
+	}
+	if !d.r.Num().IsUint64() {
+		return 0, &ConversionError{Value: d.s, Type: "uint64", Reason: "out of range"}
+	}
+	return d.r.Num().Uint64(), nil
+}
+
+// Float64 returns the nearest float64 to the value. It fails when the value is
+// NULL or out of the float64 range.
+func (d Decimal) Float64() (float64, error) {
+	if !d.Valid {
+		return 0, &ConversionError{Value: "NULL", Type: "float64", Reason: "value is NULL"}
+	}
+	f, _ := d.r.Float64()
+	if math.IsInf(f, 0) {
+		return 0, &ConversionError{Value: d.s, Type: "float64", Reason: "out of range"}
+	}
+	return f, nil
+}
+
+func (d Decimal) checkInt(typ string) error {
+	if !d.Valid {
+		return &ConversionError{Value: "NULL", Type: typ, Reason: "value is NULL"}
+	}
+	if !d.r.IsInt() {
+		return &ConversionError{Value: d.s, Type: typ, Reason: "has a fractional part"}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package dbsql
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimal(t *testing.T) {
+	t.Run("holds large decimals exactly", func(t *testing.T) {
+		var d Decimal
+		require.NoError(t, d.Scan("12345678901234567890123456789.0123456789"))
+		assert.True(t, d.Valid)
+		assert.Equal(t, "12345678901234567890123456789.0123456789", d.String())
+		expected, _ := new(big.Rat).SetString("12345678901234567890123456789.0123456789")
+		assert.Equal(t, 0, expected.Cmp(d.Rat()))
+
+		_, err := d.Int64()
+		assert.EqualError(t, err, "databricks: cannot convert 12345678901234567890123456789.0123456789 to int64: has a fractional part")
+		f, err := d.Float64()
+		require.NoError(t, err)
+		assert.InDelta(t, 1.2345678901234568e28, f, 1e13)
+	})
+
+	t.Run("converts to integers within range only", func(t *testing.T) {
+		var d Decimal
+		require.NoError(t, d.Scan([]byte("18446744073709551615")))
+		u, err := d.Uint64()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(math.MaxUint64), u)
+		_, err = d.Int64()
+		var convErr *ConversionError
+		require.ErrorAs(t, err, &convErr)
+		assert.Equal(t, ConversionError{Value: "18446744073709551615", Type: "int64", Reason: "out of range"}, *convErr)
+
+		require.NoError(t, d.Scan("-1"))
+		i, err := d.Int64()
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), i)
+		_, err = d.Uint64()
+		assert.EqualError(t, err, "databricks: cannot convert -1 to uint64: out of range")
+
+		require.NoError(t, d.Scan("12.000"))
+		i, err = d.Int64()
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), i)
+	})
+
+	t.Run("rejects values out of the float64 range", func(t *testing.T) {
+		var d Decimal
+		require.NoError(t, d.Scan("1e400"))
+		_, err := d.Float64()
+		assert.EqualError(t, err, "databricks: cannot convert 1e400 to float64: out of range")
+	})
+
+	t.Run("scans integers and finite doubles", func(t *testing.T) {
+		var d Decimal
+		require.NoError(t, d.Scan(int8(-5)))
+		assert.Equal(t, "-5", d.String())
+		require.NoError(t, d.Scan(int64(math.MaxInt64)))
+		i, err := d.Int64()
+		require.NoError(t, err)
+		assert.Equal(t, int64(math.MaxInt64), i)
+		require.NoError(t, d.Scan(0.1))
+		assert.Equal(t, "0.1", d.String())
+
+		assert.EqualError(t, d.Scan(math.NaN()), "databricks: cannot convert NaN to Decimal: not a finite number")
+		assert.EqualError(t, d.Scan(math.Inf(1)), "databricks: cannot convert +Inf to Decimal: not a finite number")
+		assert.EqualError(t, d.Scan("abc"), "databricks: cannot convert abc to Decimal: not a decimal number")
+		assert.EqualError(t, d.Scan(true), "databricks: cannot scan bool into Decimal")
+	})
+
+	t.Run("null values are invalid", func(t *testing.T) {
+		d := Decimal{}
+		require.NoError(t, d.Scan("1"))
+		require.NoError(t, d.Scan(nil))
+		assert.False(t, d.Valid)
+		assert.Equal(t, "NULL", d.String())
+		assert.Nil(t, d.Rat())
+		_, err := d.Uint64()
+		assert.EqualError(t, err, "databricks: cannot convert NULL to uint64: value is NULL")
+		_, err = d.Float64()
+		assert.EqualError(t, err, "databricks: cannot convert NULL to float64: value is NULL")
+	})
+}
@@ -0,0 +1,234 @@
+package dbsql
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+)
+
+// DiagnosticLayer is a layer of the connection checked by Diagnose.
+type DiagnosticLayer string
+
+const (
+	// DiagnoseConfig validates the configuration
+	DiagnoseConfig DiagnosticLayer = "config"
+	// DiagnoseProxy looks up the proxy set in the environment for the workspace
+	DiagnoseProxy DiagnosticLayer = "proxy"
+	// DiagnoseDNS resolves the host
+	DiagnoseDNS DiagnosticLayer = "dns"
+	// DiagnoseTCP opens a TCP connection to the host and port
+	DiagnoseTCP DiagnosticLayer = "tcp"
+	// DiagnoseTLS completes a TLS handshake with the host
+	DiagnoseTLS DiagnosticLayer = "tls"
+	// DiagnoseAuth opens a session, which is the first authenticated request
+	DiagnoseAuth DiagnosticLayer = "auth"
+	// DiagnoseQuery runs SELECT 1 and reads its result
+	DiagnoseQuery DiagnosticLayer = "query"
+)
+
+// DiagnosticStatus is the outcome of a check.
+type DiagnosticStatus string
+
+const (
+	DiagnosticOK      DiagnosticStatus = "ok"
+	DiagnosticWarning DiagnosticStatus = "warning"
+	DiagnosticFailed  DiagnosticStatus = "failed"
+	DiagnosticSkipped DiagnosticStatus = "skipped"
+)
+
+// DiagnosticCheck is the outcome of the check of a layer.
+type DiagnosticCheck struct {
+	Layer    DiagnosticLayer
+	Status   DiagnosticStatus
+	Duration time.Duration
+	// Detail tells what was checked, e.g. the addresses the host resolved to
+	Detail string
+	// Err is the error of a failed check
+	Err error
+}
+
+func (c DiagnosticCheck) String() string {
+	s := fmt.Sprintf("%-6s %-7s %v", c.Layer, c.Status, c.Duration.Round(time.Millisecond))
+	if c.Detail != "" {
+		s += " " + c.Detail
+	}
+	if c.Err != nil {
+		s += ": " + c.Err.Error()
+	}
+	return s
+}
+
+// DiagnosticReport holds the checks run by Diagnose, in order. The checks after a
+// failed one are skipped.
+type DiagnosticReport struct {
+	Checks []DiagnosticCheck
+}
+
+// OK returns true when no check failed.
+func (r *DiagnosticReport) OK() bool {
+	return r.Failed() == nil
+}
+
+// Failed returns the check that failed, or nil if none did.
+func (r *DiagnosticReport) Failed() *DiagnosticCheck {
+	for i := range r.Checks {
+		if r.Checks[i].Status == DiagnosticFailed {
+			return &r.Checks[i]
+		}
+	}
+	return nil
+}
+
+func (r *DiagnosticReport) String() string {
+	lines := make([]string, len(r.Checks))
+	for i, c := range r.Checks {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diagnose checks the connection to the workspace layer by layer: the configuration,
+// the proxy, DNS, TCP, TLS, authentication and a trivial query. It returns a report
+// telling which layer failed, to troubleshoot connections that can't be opened.
+// ctx bounds the time of all the checks.
+func Diagnose(ctx context.Context, cfg Config) *DiagnosticReport {
+	c := config.WithDefaults()
+	cfg.apply(c)
+	d := &diagnosis{}
+
+	d.check(DiagnoseConfig, func() (string, error) {
+		return "", validateConfig(c)
+	})
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	d.check(DiagnoseProxy, func() (string, error) {
+		req, err := http.NewRequest(http.MethodPost, c.ToWorkspaceURL(), nil)
+		if err != nil {
+			return "", err
+		}
+		proxy, err := http.ProxyFromEnvironment(req)
+		if err != nil {
+			return "", err
+		}
+		if proxy != nil {
+			// the driver dials the workspace directly
+			d.warning = true
+			return fmt.Sprintf("%s is set in the environment but not used, set a dialer with WithDialer to go through it", proxy.Redacted()), nil
+		}
+		return "no proxy", nil
+	})
+	var addrs []string
+	d.check(DiagnoseDNS, func() (detail string, err error) {
+		addrs, err = net.DefaultResolver.LookupHost(ctx, c.Host)
+		return strings.Join(addrs, " "), err
+	})
+	var tcpConn net.Conn
+	d.check(DiagnoseTCP, func() (detail string, err error) {
+		network := c.Network
+		if network == "" {
+			network = "tcp"
+		}
+		tcpConn, err = (&net.Dialer{}).DialContext(ctx, network, addr)
+		if err != nil {
+			return addr, err
+		}
+		return tcpConn.RemoteAddr().String(), nil
+	})
+	if tcpConn != nil {
+		defer tcpConn.Close()
+	}
+	if c.Protocol == "https" {
+		d.check(DiagnoseTLS, func() (string, error) {
+			tlsConfig := c.TLSConfig.Clone()
+			if tlsConfig == nil {
+				tlsConfig = &tls.Config{}
+			}
+			if tlsConfig.ServerName == "" {
+				tlsConfig.ServerName = c.Host
+			}
+			tlsConn := tls.Client(tcpConn, tlsConfig)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				return "", err
+			}
+			state := tlsConn.ConnectionState()
+			detail := tls.VersionName(state.Version)
+			if len(state.PeerCertificates) > 0 {
+				cert := state.PeerCertificates[0]
+				detail += fmt.Sprintf(", certificate %s expires %s", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+			}
+			return detail, nil
+		})
+	} else {
+		d.skip(DiagnoseTLS, "plain http")
+	}
+
+	var dc driver.Conn
+	d.check(DiagnoseAuth, func() (detail string, err error) {
+		dc, err = (&connector{cfg: c}).Connect(ctx)
+		if err != nil {
+			msg := err.Error()
+			if strings.Contains(msg, "HTTP Response code: 401") || strings.Contains(msg, "HTTP Response code: 403") {
+				return "the credentials were rejected", err
+			}
+			return "failed to open a session", err
+		}
+		return "session opened", nil
+	})
+	if dc != nil {
+		defer dc.Close()
+	}
+	d.check(DiagnoseQuery, func() (string, error) {
+		rows, err := dc.(*conn).QueryContext(driverQuery(ctx), "SELECT 1", nil)
+		if err != nil {
+			return "", err
+		}
+		defer rows.Close()
+		if err := rows.Next(make([]driver.Value, len(rows.Columns()))); err != nil {
+			if err == io.EOF {
+				err = errors.New("databricks: SELECT 1 returned no rows")
+			}
+			return "", err
+		}
+		return "SELECT 1", nil
+	})
+	return &d.report
+}
+
+// diagnosis runs the checks of Diagnose until one fails
+type diagnosis struct {
+	report DiagnosticReport
+	failed bool
+	// set by a check that passed with a warning
+	warning bool
+}
+
+func (d *diagnosis) check(layer DiagnosticLayer, fn func() (string, error)) {
+	if d.failed {
+		d.skip(layer, "")
+		return
+	}
+	start := time.Now()
+	d.warning = false
+	detail, err := fn()
+	check := DiagnosticCheck{Layer: layer, Status: DiagnosticOK, Duration: time.Since(start), Detail: detail, Err: err}
+	if err != nil {
+		check.Status = DiagnosticFailed
+		d.failed = true
+	} else if d.warning {
+		check.Status = DiagnosticWarning
+	}
+	d.report.Checks = append(d.report.Checks, check)
+}
+
+func (d *diagnosis) skip(layer DiagnosticLayer, detail string) {
+	d.report.Checks = append(d.report.Checks, DiagnosticCheck{Layer: layer, Status: DiagnosticSkipped, Detail: detail})
+}
@@ -0,0 +1,99 @@
+package dbsql
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statuses returns the status of the checks by layer
+func statuses(report *DiagnosticReport) map[DiagnosticLayer]DiagnosticStatus {
+	s := map[DiagnosticLayer]DiagnosticStatus{}
+	for _, c := range report.Checks {
+		s[c.Layer] = c.Status
+	}
+	return s
+}
+
+func TestDiagnose(t *testing.T) {
+	t.Run("all layers pass", func(t *testing.T) {
+		var openSessionResp cli_service.TOpenSessionResp
+		var executeStatementResp cli_service.TExecuteStatementResp
+		loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+		loadTestData(t, "ExecuteStatement1.json", &executeStatementResp)
+		ts := initThriftTestServer(&client.TestClient{
+			FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+				return &openSessionResp, nil
+			},
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				return &executeStatementResp, nil
+			},
+			FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+				return &cli_service.TCloseOperationResp{}, nil
+			},
+			FnCloseSession: func(ctx context.Context, req *cli_service.TCloseSessionReq) (*cli_service.TCloseSessionResp, error) {
+				return &cli_service.TCloseSessionResp{}, nil
+			},
+		})
+		defer ts.Close()
+		u, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(u.Port())
+		require.NoError(t, err)
+
+		report := Diagnose(context.Background(), Config{Host: "localhost", Port: port})
+		assert.True(t, report.OK(), report.String())
+		assert.Equal(t, map[DiagnosticLayer]DiagnosticStatus{
+			DiagnoseConfig: DiagnosticOK,
+			DiagnoseProxy:  DiagnosticOK,
+			DiagnoseDNS:    DiagnosticOK,
+			DiagnoseTCP:    DiagnosticOK,
+			DiagnoseTLS:    DiagnosticSkipped,
+			DiagnoseAuth:   DiagnosticOK,
+			DiagnoseQuery:  DiagnosticOK,
+		}, statuses(report))
+	})
+
+	t.Run("invalid configurations fail first", func(t *testing.T) {
+		report := Diagnose(context.Background(), Config{})
+		require.NotNil(t, report.Failed())
+		assert.Equal(t, DiagnoseConfig, report.Failed().Layer)
+		assert.Len(t, report.Checks, 7)
+		assert.Equal(t, DiagnosticSkipped, statuses(report)[DiagnoseQuery])
+	})
+
+	t.Run("closed ports fail the tcp layer", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := l.Addr().(*net.TCPAddr).Port
+		l.Close()
+
+		report := Diagnose(context.Background(), Config{Host: "localhost", Port: port})
+		require.NotNil(t, report.Failed())
+		assert.Equal(t, DiagnoseTCP, report.Failed().Layer)
+		assert.Error(t, report.Failed().Err)
+	})
+
+	t.Run("rejected credentials fail the auth layer", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer ts.Close()
+		port := ts.Listener.Addr().(*net.TCPAddr).Port
+
+		report := Diagnose(context.Background(), Config{Host: "localhost", Port: port, AccessToken: "wrong"})
+		require.NotNil(t, report.Failed())
+		assert.Equal(t, DiagnoseAuth, report.Failed().Layer)
+		assert.Equal(t, "the credentials were rejected", report.Failed().Detail)
+	})
+
+}
@@ -4,38 +4,81 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"sync"
 
 	"github.com/databricks/databricks-sql-go/internal/config"
 	_ "github.com/databricks/databricks-sql-go/logger"
+	"github.com/pkg/errors"
 )
 
+// DriverName is the name the driver is registered with in database/sql.
+// Use it with sql.Open and with libraries such as sqlx that look up drivers by name.
+const DriverName = "databricks"
+
 func init() {
-	sql.Register("databricks", &databricksDriver{})
+	sql.Register(DriverName, &databricksDriver{})
+}
+
+var errRegisterDuplicate = "databricks: a driver is already registered as %q"
+
+var registerMu sync.Mutex
+
+// Register registers the driver in database/sql under another name, with options
+// applied to the connections opened with that name, so that a program can keep
+// differently tuned driver profiles, e.g. for several workspaces:
+//
+//	err := dbsql.Register("databricks-prod",
+//		dbsql.WithServerHostname(prodHost),
+//		dbsql.WithHTTPPath(prodHTTPPath),
+//		dbsql.WithAccessToken(prodToken),
+//		dbsql.WithMaxRows(50000),
+//	)
+//	db, err := sql.Open("databricks-prod", "")
+//
+// The options are applied after the DSN passed to sql.Open, whose settings they
+// override; the DSN may be empty when the options set up the workspace. It returns
+// an error if a driver is already registered with the name.
+func Register(name string, options ...connOption) error {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	for _, registered := range sql.Drivers() {
+		if registered == name {
+			return errors.Errorf(errRegisterDuplicate, name)
+		}
+	}
+	sql.Register(name, &databricksDriver{options: options})
+	return nil
 }
 
-type databricksDriver struct{}
+type databricksDriver struct {
+	// options are applied to the config parsed from the DSN
+	options []connOption
+}
 
 func (d *databricksDriver) Open(dsn string) (driver.Conn, error) {
-	cfg := config.WithDefaults()
-	userCfg, err := config.ParseDSN(dsn)
+	c, err := d.OpenConnector(dsn)
 	if err != nil {
 		return nil, err
 	}
-	cfg.UserConfig = userCfg
-	c := &connector{
-		cfg: cfg,
-	}
 	return c.Connect(context.Background())
 }
 
 func (d *databricksDriver) OpenConnector(dsn string) (driver.Connector, error) {
 	cfg := config.WithDefaults()
-	ucfg, err := config.ParseDSN(dsn)
-	if err != nil {
+	if dsn != "" || len(d.options) == 0 {
+		ucfg, err := config.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		cfg.UserConfig = ucfg
+	}
+	for _, opt := range d.options {
+		opt(cfg)
+	}
+	if err := validateConfig(cfg); err != nil {
 		return nil, err
 	}
-	cfg.UserConfig = ucfg
-	return &connector{cfg}, nil
+	return &connector{cfg: cfg, driver: d}, nil
 }
 
 var _ driver.Driver = (*databricksDriver)(nil)
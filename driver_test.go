@@ -0,0 +1,51 @@
+package dbsql
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	err := Register("databricks-test-prod",
+		WithServerHostname("prod.cloud.databricks.com"),
+		WithHTTPPath("/sql/1.0/warehouses/prod"),
+		WithAccessToken("dapi-prod"),
+		WithMaxRows(50000),
+	)
+	require.NoError(t, err)
+	assert.Contains(t, sql.Drivers(), "databricks-test-prod")
+
+	err = Register("databricks-test-prod")
+	assert.EqualError(t, err, `databricks: a driver is already registered as "databricks-test-prod"`)
+	err = Register(DriverName)
+	assert.EqualError(t, err, `databricks: a driver is already registered as "databricks"`)
+
+	t.Run("the options configure the connections", func(t *testing.T) {
+		db, err := sql.Open("databricks-test-prod", "")
+		require.NoError(t, err)
+		defer db.Close()
+		c, err := db.Driver().(*databricksDriver).OpenConnector("")
+		require.NoError(t, err)
+		ccfg := c.(*connector).cfg
+		assert.Equal(t, "prod.cloud.databricks.com", ccfg.Host)
+		assert.Equal(t, "/sql/1.0/warehouses/prod", ccfg.HTTPPath)
+		assert.Equal(t, "dapi-prod", ccfg.AccessToken)
+		assert.Equal(t, 50000, ccfg.MaxRows)
+	})
+
+	t.Run("the options override the DSN", func(t *testing.T) {
+		d := &databricksDriver{options: []connOption{WithMaxRows(50000)}}
+		c, err := d.OpenConnector("token:dapi-dsn@dev.cloud.databricks.com:443/sql/1.0/warehouses/dev?maxRows=100")
+		require.NoError(t, err)
+		cfg := c.(*connector).cfg
+		assert.Equal(t, "dev.cloud.databricks.com", cfg.Host)
+		assert.Equal(t, "dapi-dsn", cfg.AccessToken)
+		assert.Equal(t, 50000, cfg.MaxRows)
+
+		_, err = (&databricksDriver{}).OpenConnector("")
+		assert.Error(t, err)
+	})
+}
@@ -11,6 +11,11 @@ type contextKey int
 const (
 	CorrelationIdContextKey contextKey = iota
 	ConnIdContextKey
+	QueryTagContextKey
+	StatementCommentContextKey
+	RawStringsContextKey
+	AccessTokenContextKey
+	ImpersonationUserContextKey
 )
 
 // NewContextWithCorrelationId creates a new context with correlationId value. Used by Logger to populate field corrId.
@@ -40,3 +45,90 @@ func ConnIdFromContext(ctx context.Context) string {
 	}
 	return connId
 }
+
+// NewContextWithQueryTag creates a new context with a human readable statement label, e.g. "nightly-report:step3".
+// The tag is added to logs and errors of statements run with this context.
+func NewContextWithQueryTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, QueryTagContextKey, tag)
+}
+
+// QueryTagFromContext retrieves the statement label stored in context.
+func QueryTagFromContext(ctx context.Context) string {
+	tag, ok := ctx.Value(QueryTagContextKey).(string)
+	if !ok {
+		return ""
+	}
+	return tag
+}
+
+// NewContextWithStatementComment creates a new context with key-value pairs, e.g. a trace id or the end user,
+// prepended to the statements run with this context as a sqlcommenter-style comment. They are merged with the
+// pairs of the parent context and with those set on the connector, overriding them on conflict.
+func NewContextWithStatementComment(ctx context.Context, comment map[string]string) context.Context {
+	merged := make(map[string]string, len(comment))
+	for k, v := range StatementCommentFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range comment {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, StatementCommentContextKey, merged)
+}
+
+// StatementCommentFromContext retrieves the statement comment key-value pairs stored in context.
+func StatementCommentFromContext(ctx context.Context) map[string]string {
+	comment, ok := ctx.Value(StatementCommentContextKey).(map[string]string)
+	if !ok {
+		return nil
+	}
+	return comment
+}
+
+// NewContextWithRawStrings creates a new context that makes the queries run with it return every value as a string,
+// when enabled, or with the types of its columns otherwise, overriding the setting of the connector.
+func NewContextWithRawStrings(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, RawStringsContextKey, enabled)
+}
+
+// RawStringsFromContext retrieves the raw strings setting stored in context, ok is false when there is none.
+func RawStringsFromContext(ctx context.Context) (enabled, ok bool) {
+	enabled, ok = ctx.Value(RawStringsContextKey).(bool)
+	return enabled, ok
+}
+
+// NewContextWithAccessToken creates a new context with the OAuth access token of an end user, e.g. taken from
+// the Authorization header of an incoming request, so that the statements run with this context run on behalf of
+// that user instead of with the credentials of the connector. The sessions of the connection pool are opened with
+// the credentials they are first used with, and a connection is only reused for the same token: the idle
+// connections of other users are closed and a new session is opened instead, so a pool shared by many users opens
+// a session for most statements. Use dbsql.UserPools to keep a pool per user instead.
+func NewContextWithAccessToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, AccessTokenContextKey, token)
+}
+
+// AccessTokenFromContext retrieves the access token stored in context.
+func AccessTokenFromContext(ctx context.Context) string {
+	token, ok := ctx.Value(AccessTokenContextKey).(string)
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+// NewContextWithImpersonationUser creates a new context with the user the statements run with this context run as,
+// e.g. the end user of an incoming request, so that the audit logs attribute them to that user rather than to the
+// principal of the connector, which the workspace must allow to impersonate users. As with access tokens, the
+// user is set when a session is opened, so a connection is only reused for the same user and a pool shared by many
+// users opens a session for most statements. Use dbsql.NewImpersonationPools to keep a pool per user instead.
+func NewContextWithImpersonationUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, ImpersonationUserContextKey, user)
+}
+
+// ImpersonationUserFromContext retrieves the impersonated user stored in context.
+func ImpersonationUserFromContext(ctx context.Context) string {
+	user, ok := ctx.Value(ImpersonationUserContextKey).(string)
+	if !ok {
+		return ""
+	}
+	return user
+}
@@ -36,3 +36,50 @@ func TestNewContextWithCorrelationId(t *testing.T) {
 	})
 
 }
+
+func TestNewContextWithQueryTag(t *testing.T) {
+	t.Run("base case", func(t *testing.T) {
+		ctx := NewContextWithQueryTag(context.Background(), "nightly-report:step3")
+		assert.Equal(t, "nightly-report:step3", QueryTagFromContext(ctx))
+		assert.Equal(t, "", QueryTagFromContext(context.Background()))
+	})
+}
+
+func TestNewContextWithStatementComment(t *testing.T) {
+	t.Run("pairs are merged with the parent context", func(t *testing.T) {
+		ctx := NewContextWithStatementComment(context.Background(), map[string]string{"application": "reports", "user": "a"})
+		ctx1 := NewContextWithStatementComment(ctx, map[string]string{"user": "b", "traceparent": "00-1-2-01"})
+		assert.Equal(t, map[string]string{"application": "reports", "user": "a"}, StatementCommentFromContext(ctx))
+		assert.Equal(t, map[string]string{"application": "reports", "user": "b", "traceparent": "00-1-2-01"}, StatementCommentFromContext(ctx1))
+		assert.Nil(t, StatementCommentFromContext(context.Background()))
+	})
+}
+
+func TestNewContextWithRawStrings(t *testing.T) {
+	t.Run("base case", func(t *testing.T) {
+		enabled, ok := RawStringsFromContext(NewContextWithRawStrings(context.Background(), true))
+		assert.True(t, enabled)
+		assert.True(t, ok)
+		enabled, ok = RawStringsFromContext(NewContextWithRawStrings(context.Background(), false))
+		assert.False(t, enabled)
+		assert.True(t, ok)
+		_, ok = RawStringsFromContext(context.Background())
+		assert.False(t, ok)
+	})
+}
+
+func TestNewContextWithAccessToken(t *testing.T) {
+	t.Run("base case", func(t *testing.T) {
+		ctx := NewContextWithAccessToken(context.Background(), "token")
+		assert.Equal(t, "token", AccessTokenFromContext(ctx))
+		assert.Equal(t, "", AccessTokenFromContext(context.Background()))
+	})
+}
+
+func TestNewContextWithImpersonationUser(t *testing.T) {
+	t.Run("base case", func(t *testing.T) {
+		ctx := NewContextWithImpersonationUser(context.Background(), "alice@example.com")
+		assert.Equal(t, "alice@example.com", ImpersonationUserFromContext(ctx))
+		assert.Equal(t, "", ImpersonationUserFromContext(context.Background()))
+	})
+}
@@ -0,0 +1,183 @@
+package dbsql
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/databricks/databricks-sql-go/auth/oauth"
+	"github.com/pkg/errors"
+)
+
+var errEnvAuthAmbiguous = "databricks: both a token and OAuth client credentials are configured, set DATABRICKS_AUTH_TYPE to pat or oauth-m2m"
+var errEnvAuthType = "databricks: unsupported auth type %q, use pat or oauth-m2m"
+var errEnvProfile = "databricks: profile %q not found in %s"
+
+// envAttributes are the settings read by NewConnectorFromEnv, by environment
+// variable, and their names in the profiles of the configuration file
+var envAttributes = []struct {
+	env, profile string
+}{
+	{"DATABRICKS_HOST", "host"},
+	{"DATABRICKS_HTTP_PATH", "http_path"},
+	{"DATABRICKS_WAREHOUSE_ID", "warehouse_id"},
+	{"DATABRICKS_TOKEN", "token"},
+	{"DATABRICKS_CLIENT_ID", "client_id"},
+	{"DATABRICKS_CLIENT_SECRET", "client_secret"},
+	{"DATABRICKS_AUTH_TYPE", "auth_type"},
+}
+
+// NewConnectorFromEnv creates a connector configured as the other Databricks SDKs
+// and tools are, so that deployments configure them all the same way. Each setting is
+// read from its environment variable, or else from the profile of the Databricks
+// configuration file:
+//
+//   - DATABRICKS_HOST (host): the workspace hostname, with or without https://
+//   - DATABRICKS_HTTP_PATH (http_path): the HTTP path of the warehouse, or else
+//     DATABRICKS_WAREHOUSE_ID (warehouse_id): its id
+//   - DATABRICKS_TOKEN (token): a personal access token
+//   - DATABRICKS_CLIENT_ID and DATABRICKS_CLIENT_SECRET (client_id and client_secret):
+//     the OAuth credentials of a service principal
+//   - DATABRICKS_AUTH_TYPE (auth_type): pat or oauth-m2m, to choose when both a token
+//     and client credentials are set
+//
+// The configuration file is ~/.databrickscfg, or DATABRICKS_CONFIG_FILE, and the
+// profile is DEFAULT, or DATABRICKS_CONFIG_PROFILE. The file is optional unless a
+// profile is named. The options are applied after the configuration, so that they
+// override it.
+func NewConnectorFromEnv(options ...connOption) (driver.Connector, error) {
+	settings, err := loadEnvSettings()
+	if err != nil {
+		return nil, err
+	}
+	envOptions, err := envConnOptions(settings)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnector(append(envOptions, options...)...)
+}
+
+// loadEnvSettings returns the settings of the environment variables, completed by
+// the ones of the profile of the configuration file, by profile attribute name
+func loadEnvSettings() (map[string]string, error) {
+	profile := os.Getenv("DATABRICKS_CONFIG_PROFILE")
+	path := os.Getenv("DATABRICKS_CONFIG_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			path = filepath.Join(home, ".databrickscfg")
+		}
+	}
+	settings := map[string]string{}
+	if path != "" {
+		profiles, err := readConfigFile(path)
+		switch {
+		case os.IsNotExist(err) && profile == "":
+		case err != nil:
+			return nil, errors.Wrapf(err, "databricks: failed to read %s", path)
+		case profile != "" && profiles[profile] == nil:
+			return nil, errors.Errorf(errEnvProfile, profile, path)
+		case profile == "" && profiles["DEFAULT"] != nil:
+			settings = profiles["DEFAULT"]
+		case profile != "":
+			settings = profiles[profile]
+		}
+	}
+	for _, a := range envAttributes {
+		if v := os.Getenv(a.env); v != "" {
+			settings[a.profile] = v
+		}
+	}
+	return settings, nil
+}
+
+// readConfigFile reads the profiles of a Databricks configuration file, an INI file
+// with a section per profile
+func readConfigFile(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	profiles := map[string]map[string]string{}
+	var section map[string]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[' && line[len(line)-1] == ']':
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if profiles[name] == nil {
+				profiles[name] = map[string]string{}
+			}
+			section = profiles[name]
+		case section != nil:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				section[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return profiles, scanner.Err()
+}
+
+// envConnOptions returns the options setting up the connector with the settings
+func envConnOptions(settings map[string]string) ([]connOption, error) {
+	var options []connOption
+	var host string
+	if host = settings["host"]; host != "" {
+		if !strings.Contains(host, "://") {
+			host = "https://" + host
+		}
+		u, err := url.Parse(host)
+		if err != nil {
+			return nil, errors.Errorf("databricks: invalid host %q", settings["host"])
+		}
+		options = append(options, WithServerHostname(u.Hostname()))
+		if u.Port() != "" {
+			port, err := strconv.Atoi(u.Port())
+			if err != nil {
+				return nil, errors.Errorf("databricks: invalid host %q", settings["host"])
+			}
+			options = append(options, WithPort(port))
+		}
+		host = u.Host
+	}
+	switch {
+	case settings["http_path"] != "":
+		options = append(options, WithHTTPPath(settings["http_path"]))
+	case settings["warehouse_id"] != "":
+		options = append(options, WithHTTPPath("/sql/1.0/warehouses/"+settings["warehouse_id"]))
+	}
+
+	token := settings["token"]
+	clientCredentials := settings["client_id"] != "" || settings["client_secret"] != ""
+	authType := settings["auth_type"]
+	switch {
+	case authType == "" && token != "" && clientCredentials:
+		return nil, errors.New(errEnvAuthAmbiguous)
+	case authType == "":
+	case authType == "pat":
+		clientCredentials = false
+	case authType == "oauth-m2m":
+		token = ""
+	default:
+		return nil, errors.Errorf(errEnvAuthType, authType)
+	}
+	if token != "" {
+		options = append(options, WithAccessToken(token))
+	}
+	if clientCredentials {
+		options = append(options, WithAuthenticator(auth.NewTokenAuthenticator(&oauth.ClientCredentials{
+			Host:         host,
+			ClientID:     settings["client_id"],
+			ClientSecret: settings["client_secret"],
+		}, 0)))
+	}
+	return options, nil
+}
@@ -0,0 +1,125 @@
+package dbsql
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestEnv clears the Databricks environment variables and points the
+// configuration file to a temporary file with the content, if any
+func setTestEnv(t *testing.T, configFile string) {
+	for _, a := range envAttributes {
+		t.Setenv(a.env, "")
+	}
+	t.Setenv("DATABRICKS_CONFIG_PROFILE", "")
+	path := filepath.Join(t.TempDir(), ".databrickscfg")
+	if configFile != "" {
+		require.NoError(t, os.WriteFile(path, []byte(configFile), 0600))
+	}
+	t.Setenv("DATABRICKS_CONFIG_FILE", path)
+}
+
+func TestNewConnectorFromEnv(t *testing.T) {
+	t.Run("environment variables", func(t *testing.T) {
+		setTestEnv(t, "")
+		t.Setenv("DATABRICKS_HOST", "https://example.cloud.databricks.com/")
+		t.Setenv("DATABRICKS_HTTP_PATH", "/sql/1.0/endpoints/12346a5b5b0e123a")
+		t.Setenv("DATABRICKS_TOKEN", "dapi-env")
+
+		con, err := NewConnectorFromEnv()
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.Equal(t, "example.cloud.databricks.com", cfg.Host)
+		assert.Equal(t, 443, cfg.Port)
+		assert.Equal(t, "/sql/1.0/endpoints/12346a5b5b0e123a", cfg.HTTPPath)
+		assert.Equal(t, "dapi-env", cfg.AccessToken)
+		assert.Nil(t, cfg.Authenticator)
+	})
+
+	t.Run("the environment overrides the profile and options override both", func(t *testing.T) {
+		setTestEnv(t, `
+; defaults
+[DEFAULT]
+host = default.cloud.databricks.com
+token = dapi-default
+
+[staging]
+host     = staging.cloud.databricks.com:8443
+warehouse_id = abc123
+token    = dapi-staging
+`)
+		t.Setenv("DATABRICKS_CONFIG_PROFILE", "staging")
+		t.Setenv("DATABRICKS_TOKEN", "dapi-env")
+
+		con, err := NewConnectorFromEnv(WithPort(444))
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.Equal(t, "staging.cloud.databricks.com", cfg.Host)
+		assert.Equal(t, 444, cfg.Port)
+		assert.Equal(t, "/sql/1.0/warehouses/abc123", cfg.HTTPPath)
+		assert.Equal(t, "dapi-env", cfg.AccessToken)
+	})
+
+	t.Run("the DEFAULT profile", func(t *testing.T) {
+		setTestEnv(t, "[DEFAULT]\nhost = default.cloud.databricks.com\nhttp_path = /sql/1.0/warehouses/def\ntoken = dapi-default\n")
+
+		con, err := NewConnectorFromEnv()
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.Equal(t, "default.cloud.databricks.com", cfg.Host)
+		assert.Equal(t, "/sql/1.0/warehouses/def", cfg.HTTPPath)
+	})
+
+	t.Run("OAuth client credentials", func(t *testing.T) {
+		setTestEnv(t, "")
+		t.Setenv("DATABRICKS_HOST", "example.cloud.databricks.com")
+		t.Setenv("DATABRICKS_WAREHOUSE_ID", "abc123")
+		t.Setenv("DATABRICKS_CLIENT_ID", "client")
+		t.Setenv("DATABRICKS_CLIENT_SECRET", "secret")
+
+		con, err := NewConnectorFromEnv()
+		require.NoError(t, err)
+		cfg := con.(*connector).cfg
+		assert.NotNil(t, cfg.Authenticator)
+		assert.Empty(t, cfg.AccessToken)
+	})
+
+	t.Run("the auth type chooses between a token and client credentials", func(t *testing.T) {
+		setTestEnv(t, "")
+		t.Setenv("DATABRICKS_HOST", "example.cloud.databricks.com")
+		t.Setenv("DATABRICKS_TOKEN", "dapi-env")
+		t.Setenv("DATABRICKS_CLIENT_ID", "client")
+		t.Setenv("DATABRICKS_CLIENT_SECRET", "secret")
+
+		_, err := NewConnectorFromEnv()
+		assert.EqualError(t, err, errEnvAuthAmbiguous)
+
+		t.Setenv("DATABRICKS_AUTH_TYPE", "pat")
+		con, err := NewConnectorFromEnv()
+		require.NoError(t, err)
+		assert.Equal(t, "dapi-env", con.(*connector).cfg.AccessToken)
+		assert.Nil(t, con.(*connector).cfg.Authenticator)
+
+		t.Setenv("DATABRICKS_AUTH_TYPE", "oauth-m2m")
+		con, err = NewConnectorFromEnv()
+		require.NoError(t, err)
+		assert.Empty(t, con.(*connector).cfg.AccessToken)
+		assert.NotNil(t, con.(*connector).cfg.Authenticator)
+
+		t.Setenv("DATABRICKS_AUTH_TYPE", "azure-cli")
+		_, err = NewConnectorFromEnv()
+		assert.EqualError(t, err, `databricks: unsupported auth type "azure-cli", use pat or oauth-m2m`)
+	})
+
+	t.Run("a missing profile", func(t *testing.T) {
+		setTestEnv(t, "[DEFAULT]\nhost = default.cloud.databricks.com\n")
+		t.Setenv("DATABRICKS_CONFIG_PROFILE", "prod")
+
+		_, err := NewConnectorFromEnv()
+		assert.ErrorContains(t, err, `databricks: profile "prod" not found in`)
+	})
+}
@@ -1,12 +1,127 @@
 package dbsql
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/logger"
 	"github.com/pkg/errors"
 )
 
 var ErrNotImplemented = "databricks: not implemented"
 var ErrTransactionsNotSupported = "databricks: transactions are not supported"
 var ErrParametersNotSupported = "databricks: query parameters are not supported"
+var ErrLocalTimeZone = "databricks: time.Local cannot be set as the session time zone, load the location by name"
+var ErrReadOnly = "databricks: only queries can run with a read-only context"
+var ErrNoCredentials = "databricks: no access token or authenticator is set, and the context has no access token"
+var ErrSchemaOnly = "databricks: only SELECT, WITH, FROM, VALUES and TABLE queries can run with a schema-only context"
+
+// ConversionError is returned when a value cannot be converted to a Go type
+// without losing information, e.g. when it is out of range for the type.
+type ConversionError struct {
+	// Value is the value being converted, as text
+	Value string
+	// Type is the Go type the value was converted to
+	Type string
+	// Reason tells why the conversion failed
+	Reason string
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("databricks: cannot convert %s to %s: %s", e.Value, e.Type, e.Reason)
+}
+
+// ExecutionError is returned when the server fails to run a statement.
+type ExecutionError struct {
+	// Message is the error message reported by the server
+	Message string
+	// ErrorClass is the Databricks error class, e.g. TABLE_OR_VIEW_NOT_FOUND, if any
+	ErrorClass string
+	// SQLState is the SQLSTATE of the error, e.g. ErrCodeTableNotFound, or "" if unknown
+	SQLState string
+}
+
+func (e *ExecutionError) Error() string {
+	return logger.Redact(e.Message)
+}
+
+// ErrThrottled is matched, with errors.Is, by the errors returned when the gateway
+// still rate limits the requests, with 429 Too Many Requests or 503 Service
+// Unavailable, once the retries set with WithRetries are used up, or when it asks
+// to wait longer than their max wait.
+var ErrThrottled = client.ErrThrottled
+
+// ErrResultSetInvalidated is matched, with errors.Is, by the errors returned while
+// iterating rows whose result set is no longer the one being read, e.g. because the
+// server invalidated the operation handle or the schema of the results changed
+// between pages. The rows cannot be read any further, run the query again.
+var ErrResultSetInvalidated = errors.New("databricks: result set invalidated")
+
+// ErrResultExpired is matched, with errors.Is, by the errors returned when the server
+// no longer holds the results of a query, e.g. because their operation was closed or
+// cleaned up once idle for too long, when fetching them or resuming them with
+// ResumeQuery. These errors also match ErrResultSetInvalidated. The results cannot be
+// read any more, run the query again.
+var ErrResultExpired = errors.New("databricks: query results expired")
+
+// resultExpiredMessages are parts of the messages, in lower case, of the errors the
+// server returns when fetching the results of an operation it closed or cleaned up
+var resultExpiredMessages = []string{
+	"invalid operationhandle",
+	"operation not found",
+	"operation has been closed",
+	"operation has expired",
+	"results have expired",
+}
+
+// isResultExpired returns true when err shows that the server no longer holds the
+// results of the operation being fetched
+func isResultExpired(err error) bool {
+	if errors.Is(err, client.ErrInvalidHandle) {
+		return true
+	}
+	var transportErr thrift.TTransportException
+	if err == nil || errors.As(err, &transportErr) {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range resultExpiredMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// resultSetInvalidatedError tells why the result set of a query was invalidated
+type resultSetInvalidatedError struct {
+	queryId string
+	reason  string
+	// expired is true when the server no longer holds the results
+	expired bool
+	// err is the error returned by the server, if any
+	err error
+}
+
+func (e *resultSetInvalidatedError) Error() string {
+	msg := fmt.Sprintf("%s: query %s: %s", ErrResultSetInvalidated, e.queryId, e.reason)
+	if e.err != nil {
+		msg += ": " + e.err.Error()
+	}
+	return msg
+}
+
+func (e *resultSetInvalidatedError) Is(target error) bool {
+	return target == ErrResultSetInvalidated || (e.expired && target == ErrResultExpired)
+}
+
+func (e *resultSetInvalidatedError) Unwrap() error {
+	return e.err
+}
 
 type stackTracer interface {
 	StackTrace() errors.StackTrace
@@ -30,6 +145,31 @@ func wrapErrf(err error, format string, args ...interface{}) error {
 	return errors.Wrapf(err, format, args...)
 }
 
+// adds the statement tag from the context to the error message, if any
+func wrapQueryTag(ctx context.Context, err error) error {
+	if tag := driverctx.QueryTagFromContext(ctx); tag != "" && err != nil {
+		return errors.WithMessagef(err, "statement %s", tag)
+	}
+	return err
+}
+
+// isSessionError returns true when err shows that the session is gone or that
+// the transport failed. Context cancellation and timeouts are not session errors.
+func isSessionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, client.ErrInvalidHandle) {
+		return true
+	}
+	// the gateway rate limited the request, the session is fine
+	if errors.Is(err, ErrThrottled) {
+		return false
+	}
+	var transportErr thrift.TTransportException
+	return errors.As(err, &transportErr)
+}
+
 type causer interface {
 	Cause() error
 }
@@ -0,0 +1,91 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+type explainOnlyContextKey struct{}
+
+// explainErrorPrefix starts the plan of a statement that failed analysis.
+// EXPLAIN reports such failures as its result instead of failing.
+const explainErrorPrefix = "Error occurred during query planning:"
+
+// WithExplainOnly returns a context that makes the statements run with it only be
+// planned: they are wrapped in EXPLAIN and not executed. Queries return the plan as
+// rows of a single plan column. Use Explain to get the plan and planning errors.
+func WithExplainOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, explainOnlyContextKey{}, "EXPLAIN ")
+}
+
+func explainOnlyFromContext(ctx context.Context) bool {
+	return explainPrefix(ctx) != ""
+}
+
+// explainPrefix returns the EXPLAIN keywords to prefix the statements with, if any
+func explainPrefix(ctx context.Context) string {
+	prefix, _ := ctx.Value(explainOnlyContextKey{}).(string)
+	return prefix
+}
+
+// Queryer is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// ExplainPlan is the plan of a statement that was explained but not executed.
+type ExplainPlan struct {
+	// Text is the plan as rendered by the server
+	Text string
+}
+
+// ExplainError is returned by Explain when the statement could not be planned,
+// e.g. because it refers to a table or column that does not exist.
+type ExplainError struct {
+	// Message is the planning error reported by the server
+	Message string
+}
+
+func (e *ExplainError) Error() string {
+	return "databricks: statement could not be planned: " + e.Message
+}
+
+// Explain plans the statement against the schemas of the workspace without executing
+// it, e.g. to validate SQL in a CI pipeline. A statement that cannot be planned
+// returns an *ExplainError.
+func Explain(ctx context.Context, db Queryer, query string, args ...any) (*ExplainPlan, error) {
+	return explain(WithExplainOnly(ctx), db, query, args)
+}
+
+// explain runs the query with an explain-only context and returns its plan
+func explain(ctx context.Context, db Queryer, query string, args []any) (*ExplainPlan, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return explainPlan(lines)
+}
+
+// explainPlan joins the rows returned by EXPLAIN, which are the lines of the
+// plan or of the planning error
+func explainPlan(lines []string) (*ExplainPlan, error) {
+	text := strings.Join(lines, "\n")
+	if strings.HasPrefix(text, explainErrorPrefix) {
+		return nil, &ExplainError{Message: strings.TrimSpace(strings.TrimPrefix(text, explainErrorPrefix))}
+	}
+	return &ExplainPlan{Text: text}, nil
+}
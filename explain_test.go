@@ -0,0 +1,100 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getExplainTestDB returns a DB whose statements return lines as a single plan column
+func getExplainTestDB(lines []string, statements *[]string) *sql.DB {
+	executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+		*statements = append(*statements, req.Statement)
+		return &cli_service.TExecuteStatementResp{
+			Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			OperationHandle: &cli_service.TOperationHandle{
+				OperationId:  &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+				HasResultSet: true,
+			},
+			DirectResults: &cli_service.TSparkDirectResults{
+				OperationStatus: &cli_service.TGetOperationStatusResp{
+					OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+				},
+				ResultSetMetadata: &cli_service.TGetResultSetMetadataResp{
+					Schema: &cli_service.TTableSchema{Columns: []*cli_service.TColumnDesc{{
+						ColumnName: "plan",
+						TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{{
+							PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_STRING_TYPE},
+						}}},
+					}}},
+				},
+				ResultSet: &cli_service.TFetchResultsResp{
+					Results: &cli_service.TRowSet{
+						Columns: []*cli_service.TColumn{{StringVal: &cli_service.TStringColumn{Values: lines}}},
+					},
+				},
+			},
+		}, nil
+	}
+	cfg := config.WithDefaults()
+	cfg.InterpolateParams = true
+	cfg.PollInterval = 10 * time.Millisecond
+	testConn := &conn{
+		session: getTestSession(),
+		client: &client.TestClient{
+			FnExecuteStatement: executeStatement,
+			FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+				return &cli_service.TCloseOperationResp{}, nil
+			},
+		},
+		cfg: cfg,
+	}
+	return sql.OpenDB(&testConnConnector{testConn})
+}
+
+func TestExplain(t *testing.T) {
+	t.Run("returns the plan of the statement without running it", func(t *testing.T) {
+		var statements []string
+		db := getExplainTestDB([]string{"== Physical Plan ==\n*(1) Scan t"}, &statements)
+		defer db.Close()
+
+		plan, err := Explain(context.Background(), db, "select * from t where id = ?", 1)
+		require.NoError(t, err)
+		assert.Equal(t, "== Physical Plan ==\n*(1) Scan t", plan.Text)
+		assert.Equal(t, []string{"EXPLAIN select * from t where id = 1"}, statements)
+	})
+
+	t.Run("returns planning errors", func(t *testing.T) {
+		var statements []string
+		db := getExplainTestDB([]string{
+			"Error occurred during query planning: ",
+			"[TABLE_OR_VIEW_NOT_FOUND] The table or view `t` cannot be found.",
+		}, &statements)
+		defer db.Close()
+
+		_, err := Explain(context.Background(), db, "select * from t")
+		var explainErr *ExplainError
+		require.ErrorAs(t, err, &explainErr)
+		assert.Equal(t, "[TABLE_OR_VIEW_NOT_FOUND] The table or view `t` cannot be found.", explainErr.Message)
+	})
+
+	t.Run("statements run with WithExplainOnly are only planned", func(t *testing.T) {
+		var statements []string
+		db := getExplainTestDB([]string{"== Physical Plan =="}, &statements)
+		defer db.Close()
+
+		ctx := WithExplainOnly(context.Background())
+		_, err := db.ExecContext(ctx, "delete from t")
+		require.NoError(t, err)
+		_, err = db.ExecContext(context.Background(), "delete from t")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"EXPLAIN delete from t", "delete from t"}, statements)
+	})
+}
@@ -0,0 +1,188 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"sync"
+)
+
+// DefaultFanOutBatchSize is the number of rows of the batches of QueryFanOut when no
+// size is set, the default number of rows of a result page.
+const DefaultFanOutBatchSize = 10000
+
+// FanOutOptions configures QueryFanOut.
+type FanOutOptions struct {
+	// Workers is the number of batches processed at the same time, GOMAXPROCS when
+	// zero
+	Workers int
+	// BatchSize is the number of rows of a batch, DefaultFanOutBatchSize when zero
+	BatchSize int
+	// Ordered delivers the results of the batches in the order of the rows,
+	// otherwise they are delivered as soon as processed
+	Ordered bool
+}
+
+// QueryFanOut runs the query and splits its rows, mapped to T by mapper, into batches
+// processed by work on up to opts.Workers goroutines at the same time, for CPU-bound
+// processing of large results. The results of work are passed to deliver, which is
+// called from the goroutine of QueryFanOut, one result at a time, in the order of the
+// rows when opts.Ordered is set. deliver may be nil.
+//
+// The rows are read and mapped on a single goroutine, as the result pages are fetched
+// one after the other, and at most two batches per worker are held in memory: the
+// query waits for the workers and deliver to keep up. The first error of the query,
+// mapper, work or deliver cancels the context passed to work, stops the query and is
+// returned once the workers are done.
+//
+//	err := dbsql.QueryFanOut(ctx, db, "SELECT id, doc FROM docs", scanDoc,
+//		func(ctx context.Context, docs []Doc) ([]Embedding, error) {
+//			return embed(docs), nil
+//		},
+//		func(embeddings []Embedding) error {
+//			return store(embeddings)
+//		},
+//		dbsql.FanOutOptions{Workers: 8, Ordered: true})
+func QueryFanOut[T, R any](ctx context.Context, db Queryer, query string, mapper func(*sql.Rows) (T, error),
+	work func(ctx context.Context, batch []T) (R, error), deliver func(R) error, opts FanOutOptions, args ...any) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultFanOutBatchSize
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		index int
+		batch []T
+	}
+	type result struct {
+		index  int
+		result R
+		err    error
+	}
+	jobs := make(chan job)
+	results := make(chan result, workers)
+	// a batch holds a slot from when it is read until its result is delivered
+	slots := make(chan struct{}, 2*workers)
+
+	var readErr error
+	go func() {
+		defer close(jobs)
+		readErr = fanOutBatches(ctx, db, query, mapper, args, batchSize, func(index int, batch []T) error {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			select {
+			case jobs <- job{index, batch}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if readErr != nil {
+			cancel()
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				r, err := work(ctx, j.batch)
+				results <- result{j.index, r, err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	pending := map[int]R{}
+	next := 0
+	for res := range results {
+		if res.err != nil {
+			fail(res.err)
+		}
+		if firstErr != nil {
+			<-slots
+			continue
+		}
+		if !opts.Ordered {
+			if deliver != nil {
+				if err := deliver(res.result); err != nil {
+					fail(err)
+				}
+			}
+			<-slots
+			continue
+		}
+		pending[res.index] = res.result
+		for {
+			r, ok := pending[next]
+			if !ok || firstErr != nil {
+				break
+			}
+			delete(pending, next)
+			next++
+			if deliver != nil {
+				if err := deliver(r); err != nil {
+					fail(err)
+				}
+			}
+			<-slots
+		}
+	}
+	// the reader is done once the workers are, as they wait for its jobs
+	if firstErr != nil {
+		return firstErr
+	}
+	return readErr
+}
+
+// fanOutBatches runs the query and calls send with the batches of its mapped rows
+func fanOutBatches[T any](ctx context.Context, db Queryer, query string, mapper func(*sql.Rows) (T, error), args []any, batchSize int, send func(int, []T) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	index := 0
+	batch := make([]T, 0, batchSize)
+	for rows.Next() {
+		v, err := mapper(rows)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, v)
+		if len(batch) == batchSize {
+			if err := send(index, batch); err != nil {
+				return err
+			}
+			index++
+			batch = make([]T, 0, batchSize)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return send(index, batch)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package dbsql
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFanOut(t *testing.T) {
+	var lines [][]string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, []string{strconv.Itoa(i), "user" + strconv.Itoa(i)})
+	}
+	// later batches are processed faster, so that they complete out of order
+	sumIDs := func(ctx context.Context, users []streamTestUser) (int, error) {
+		sum := 0
+		for _, u := range users {
+			id, _ := strconv.Atoi(u.ID)
+			sum += id
+		}
+		time.Sleep(time.Duration(10-sum) * time.Millisecond)
+		return sum, nil
+	}
+
+	t.Run("ordered delivery follows the rows", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id", "name"}, lines, "", &statements)
+		defer db.Close()
+
+		var sums []int
+		err := QueryFanOut(context.Background(), db, "SELECT id, name FROM users", scanStreamTestUser, sumIDs,
+			func(sum int) error {
+				sums = append(sums, sum)
+				return nil
+			}, FanOutOptions{Workers: 4, BatchSize: 3, Ordered: true})
+		require.NoError(t, err)
+		assert.Equal(t, []int{0 + 1 + 2, 3 + 4 + 5, 6 + 7 + 8, 9}, sums)
+		assert.Equal(t, []string{"SELECT id, name FROM users"}, statements)
+	})
+
+	t.Run("unordered delivery gets all the batches", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id", "name"}, lines, "", &statements)
+		defer db.Close()
+
+		var sums []int
+		err := QueryFanOut(context.Background(), db, "SELECT id, name FROM users", scanStreamTestUser, sumIDs,
+			func(sum int) error {
+				sums = append(sums, sum)
+				return nil
+			}, FanOutOptions{Workers: 2, BatchSize: 1})
+		require.NoError(t, err)
+		sort.Ints(sums)
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, sums)
+	})
+
+	t.Run("the first error of a worker stops the query", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id", "name"}, lines, "", &statements)
+		defer db.Close()
+
+		var calls int32
+		err := QueryFanOut(context.Background(), db, "SELECT id, name FROM users", scanStreamTestUser,
+			func(ctx context.Context, users []streamTestUser) (int, error) {
+				atomic.AddInt32(&calls, 1)
+				if users[0].ID == "2" {
+					return 0, errors.New("boom")
+				}
+				return 0, nil
+			}, nil, FanOutOptions{Workers: 1, BatchSize: 1})
+		assert.EqualError(t, err, "boom")
+		assert.Less(t, atomic.LoadInt32(&calls), int32(10))
+	})
+
+	t.Run("errors of deliver and of the query are returned", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id", "name"}, lines, "", &statements)
+		defer db.Close()
+
+		err := QueryFanOut(context.Background(), db, "SELECT id, name FROM users", scanStreamTestUser, sumIDs,
+			func(int) error { return errors.New("full") }, FanOutOptions{BatchSize: 2, Ordered: true})
+		assert.EqualError(t, err, "full")
+
+		failing := getStringsTestDB([]string{"id", "name"}, nil, "42P01", &statements)
+		defer failing.Close()
+		err = QueryFanOut(context.Background(), failing, "SELECT id, name FROM users", scanStreamTestUser, sumIDs, nil, FanOutOptions{})
+		assert.Equal(t, "42P01", SQLState(err))
+	})
+}
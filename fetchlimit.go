@@ -0,0 +1,56 @@
+package dbsql
+
+import (
+	"context"
+	"sync"
+)
+
+// fetchSemaphore limits the number of result pages fetched at the same time.
+// A nil fetchSemaphore does not limit them.
+type fetchSemaphore chan struct{}
+
+func newFetchSemaphore(n int) fetchSemaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(fetchSemaphore, n)
+}
+
+// acquire waits for a free slot, or until ctx is done
+func (s fetchSemaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s fetchSemaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+var globalFetchSemaphore fetchSemaphore
+var globalFetchSemaphoreMu sync.RWMutex
+
+// SetMaxConcurrentFetches limits the number of result pages fetched at the same time
+// by all connections of the process, so many open result sets can't exhaust sockets
+// or memory. Fetches wait for a free slot or for their context to be done. Zero or
+// less removes the limit, which is the default. Fetches already running when the
+// limit changes are not counted against the new limit.
+func SetMaxConcurrentFetches(n int) {
+	globalFetchSemaphoreMu.Lock()
+	defer globalFetchSemaphoreMu.Unlock()
+	globalFetchSemaphore = newFetchSemaphore(n)
+}
+
+func getGlobalFetchSemaphore() fetchSemaphore {
+	globalFetchSemaphoreMu.RLock()
+	defer globalFetchSemaphoreMu.RUnlock()
+	return globalFetchSemaphore
+}
@@ -0,0 +1,72 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchSemaphore(t *testing.T) {
+	t.Run("nil semaphore does not limit", func(t *testing.T) {
+		var sem fetchSemaphore
+		assert.Nil(t, newFetchSemaphore(0))
+		assert.NoError(t, sem.acquire(context.Background()))
+		sem.release()
+	})
+
+	t.Run("acquire waits until the context is done", func(t *testing.T) {
+		sem := newFetchSemaphore(1)
+		require.NoError(t, sem.acquire(context.Background()))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, sem.acquire(ctx), context.DeadlineExceeded)
+		sem.release()
+		assert.NoError(t, sem.acquire(context.Background()))
+	})
+
+	t.Run("rows fetch within the limit of the connection", func(t *testing.T) {
+		var requests []*cli_service.TFetchResultsReq
+		sem := newFetchSemaphore(1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		rowSet := &rows{
+			pageSize: 10,
+			client:   getRowsTestCursorClient(20, &requests),
+			conn:     &conn{fetchSem: sem},
+			ctx:      ctx,
+		}
+		dest := make([]driver.Value, 1)
+
+		// another result set is fetching
+		require.NoError(t, sem.acquire(context.Background()))
+		assert.ErrorIs(t, rowSet.Next(dest), context.DeadlineExceeded)
+		assert.Empty(t, requests)
+
+		sem.release()
+		rowSet.ctx = context.Background()
+		assert.NoError(t, rowSet.Next(dest))
+		assert.Len(t, requests, 1)
+		// the slot is released after the fetch
+		assert.Len(t, sem, 0)
+	})
+
+	t.Run("connections of a connector share its limit", func(t *testing.T) {
+		c, err := NewConnector(WithServerHostname("localhost"), WithMaxConcurrentFetches(3))
+		require.NoError(t, err)
+		sem := c.(*connector).getFetchSemaphore()
+		assert.Equal(t, 3, cap(sem))
+		assert.Equal(t, sem, c.(*connector).getFetchSemaphore())
+	})
+
+	t.Run("the process limit can be changed", func(t *testing.T) {
+		SetMaxConcurrentFetches(2)
+		assert.Equal(t, 2, cap(getGlobalFetchSemaphore()))
+		SetMaxConcurrentFetches(0)
+		assert.Nil(t, getGlobalFetchSemaphore())
+	})
+}
@@ -0,0 +1,598 @@
+package dbsql
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/pkg/errors"
+)
+
+// the class names of geospatial columns, which the protocol describes as user
+// defined types, optionally followed by the SRID, e.g. GEOMETRY(4326)
+const (
+	geometryTypeName  = "GEOMETRY"
+	geographyTypeName = "GEOGRAPHY"
+)
+
+var errGeoInvalidWKT = "databricks: invalid WKT"
+var errGeoInvalidWKB = "databricks: invalid WKB"
+
+// Geometry implements sql.Scanner for GEOMETRY and GEOGRAPHY columns. Values are
+// read from WKT, EWKT (SRID=4326;POINT(1 2)), WKB or EWKB, and can be written back
+// as WKT or as ISO WKB whatever the server sent.
+//
+//	var g dbsql.Geometry
+//	if err := row.Scan(&g); err != nil {
+//		...
+//	}
+//	fmt.Println(g.SRID, g.WKT())
+//
+// The WKB can be decoded by geometry libraries, e.g. with github.com/paulmach/orb:
+//
+//	geom, err := wkb.Unmarshal(g.WKB())
+type Geometry struct {
+	// SRID is the spatial reference id of the value, 0 if unknown
+	SRID int
+	g    geom
+	// Valid is false when the value is NULL
+	Valid bool
+}
+
+// Scan implements the sql.Scanner interface. Strings are read as WKT or EWKT, or as
+// hex encoded WKB, and bytes as WKB or EWKB.
+func (g *Geometry) Scan(src any) error {
+	var err error
+	switch v := src.(type) {
+	case nil:
+		*g = Geometry{}
+		return nil
+	case string:
+		if b, hexErr := hex.DecodeString(v); hexErr == nil && len(b) > 0 {
+			g.g, g.SRID, err = parseWKB(b)
+		} else {
+			g.g, g.SRID, err = parseWKT(v)
+		}
+	case []byte:
+		g.g, g.SRID, err = parseWKB(v)
+	default:
+		return errors.Errorf("databricks: cannot scan %T into Geometry", src)
+	}
+	g.Valid = err == nil
+	return err
+}
+
+// Type returns the geometry type, e.g. POINT or MULTIPOLYGON, or NULL.
+func (g Geometry) Type() string {
+	if !g.Valid {
+		return "NULL"
+	}
+	return geomTypeNames[g.g.kind]
+}
+
+// WKT returns the value as Well-Known Text, without the SRID, or "" when the value
+// is NULL.
+func (g Geometry) WKT() string {
+	if !g.Valid {
+		return ""
+	}
+	var b strings.Builder
+	g.g.writeWKT(&b, true)
+	return b.String()
+}
+
+// WKB returns the value as little endian ISO Well-Known Binary, or nil when the
+// value is NULL.
+func (g Geometry) WKB() []byte {
+	if !g.Valid {
+		return nil
+	}
+	return g.g.appendWKB(nil)
+}
+
+// String returns the value as EWKT when the SRID is known and WKT otherwise, or NULL.
+func (g Geometry) String() string {
+	if !g.Valid {
+		return "NULL"
+	}
+	if g.SRID != 0 {
+		return "SRID=" + strconv.Itoa(g.SRID) + ";" + g.WKT()
+	}
+	return g.WKT()
+}
+
+// getGeospatialTypeName returns GEOMETRY or GEOGRAPHY when the column is described as one,
+// and "" otherwise
+func getGeospatialTypeName(column *cli_service.TColumnDesc) string {
+	if column == nil || column.TypeDesc == nil || len(column.TypeDesc.Types) == 0 || column.TypeDesc.Types[0] == nil {
+		return ""
+	}
+	if udt := column.TypeDesc.Types[0].UserDefinedTypeEntry; udt != nil {
+		return geospatialTypeName(udt.TypeClassName)
+	}
+	return ""
+}
+
+// geospatialTypeName returns the bare name of a geospatial class name, e.g.
+// GEOMETRY for geometry(4326), and "" for other class names
+func geospatialTypeName(className string) string {
+	name := strings.ToUpper(strings.TrimSpace(className))
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = name[:i]
+	}
+	if name == geometryTypeName || name == geographyTypeName {
+		return name
+	}
+	return ""
+}
+
+// geometry type codes of WKB
+const (
+	geomPoint uint32 = iota + 1
+	geomLineString
+	geomPolygon
+	geomMultiPoint
+	geomMultiLineString
+	geomMultiPolygon
+	geomCollection
+)
+
+var geomTypeNames = map[uint32]string{
+	geomPoint:           "POINT",
+	geomLineString:      "LINESTRING",
+	geomPolygon:         "POLYGON",
+	geomMultiPoint:      "MULTIPOINT",
+	geomMultiLineString: "MULTILINESTRING",
+	geomMultiPolygon:    "MULTIPOLYGON",
+	geomCollection:      "GEOMETRYCOLLECTION",
+}
+
+// geom is a parsed geometry. Points have one coordinate, or none when empty, and
+// line strings and polygon rings a list of them. Polygons have their rings as
+// parts, and multi geometries and collections their members.
+type geom struct {
+	kind   uint32
+	z, m   bool
+	coords [][]float64
+	parts  []geom
+}
+
+func (g geom) dims() int {
+	d := 2
+	if g.z {
+		d++
+	}
+	if g.m {
+		d++
+	}
+	return d
+}
+
+func (g geom) isEmpty() bool {
+	if g.kind == geomPoint || g.kind == geomLineString {
+		return len(g.coords) == 0
+	}
+	return len(g.parts) == 0
+}
+
+// writeWKT writes g as WKT, with its type name when named is true
+func (g geom) writeWKT(b *strings.Builder, named bool) {
+	if named {
+		b.WriteString(geomTypeNames[g.kind])
+		switch {
+		case g.z && g.m:
+			b.WriteString(" ZM")
+		case g.z:
+			b.WriteString(" Z")
+		case g.m:
+			b.WriteString(" M")
+		}
+		b.WriteByte(' ')
+	}
+	if g.isEmpty() {
+		b.WriteString("EMPTY")
+		return
+	}
+	b.WriteByte('(')
+	switch g.kind {
+	case geomPoint, geomLineString:
+		for i, c := range g.coords {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			for j, f := range c {
+				if j > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+			}
+		}
+	default:
+		for i, p := range g.parts {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			// only the members of collections are named
+			p.writeWKT(b, g.kind == geomCollection)
+		}
+	}
+	b.WriteByte(')')
+}
+
+// appendWKB appends g as little endian ISO WKB
+func (g geom) appendWKB(b []byte) []byte {
+	code := g.kind
+	if g.z {
+		code += 1000
+	}
+	if g.m {
+		code += 2000
+	}
+	b = append(b, 1)
+	b = binary.LittleEndian.AppendUint32(b, code)
+	switch g.kind {
+	case geomPoint:
+		// empty points have NaN coordinates
+		c := g.coords
+		if len(c) == 0 {
+			c = [][]float64{make([]float64, g.dims())}
+			for i := range c[0] {
+				c[0][i] = math.NaN()
+			}
+		}
+		b = appendCoords(b, c[:1])
+	case geomLineString:
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(g.coords)))
+		b = appendCoords(b, g.coords)
+	case geomPolygon:
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(g.parts)))
+		for _, ring := range g.parts {
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(ring.coords)))
+			b = appendCoords(b, ring.coords)
+		}
+	default:
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(g.parts)))
+		for _, p := range g.parts {
+			b = p.appendWKB(b)
+		}
+	}
+	return b
+}
+
+func appendCoords(b []byte, coords [][]float64) []byte {
+	for _, c := range coords {
+		for _, f := range c {
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(f))
+		}
+	}
+	return b
+}
+
+// parseWKT parses WKT, or EWKT with a SRID=n; prefix
+func parseWKT(s string) (geom, int, error) {
+	var srid int
+	text := strings.TrimSpace(s)
+	if len(text) > 5 && strings.EqualFold(text[:5], "SRID=") {
+		i := strings.IndexByte(text, ';')
+		if i < 0 {
+			return geom{}, 0, errors.Errorf("%s: %q", errGeoInvalidWKT, s)
+		}
+		n, err := strconv.Atoi(text[5:i])
+		if err != nil {
+			return geom{}, 0, errors.Errorf("%s: %q", errGeoInvalidWKT, s)
+		}
+		srid, text = n, text[i+1:]
+	}
+	p := &wktParser{s: text}
+	g, err := p.geometry()
+	if err == nil && p.next() != "" {
+		err = errors.New("unexpected text after the geometry")
+	}
+	if err != nil {
+		return geom{}, 0, errors.Errorf("%s: %q: %s", errGeoInvalidWKT, s, err)
+	}
+	return g, srid, nil
+}
+
+type wktParser struct {
+	s   string
+	pos int
+}
+
+// next returns the next token, a word or number, or a parenthesis or comma, or ""
+// at the end of the text
+func (p *wktParser) next() string {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+	if p.pos == len(p.s) {
+		return ""
+	}
+	start := p.pos
+	if c := p.s[p.pos]; c == '(' || c == ')' || c == ',' {
+		p.pos++
+		return p.s[start:p.pos]
+	}
+	for p.pos < len(p.s) && !strings.ContainsRune(" \t\n\r(),", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *wktParser) peek() string {
+	pos := p.pos
+	t := p.next()
+	p.pos = pos
+	return t
+}
+
+func (p *wktParser) expect(token string) error {
+	if t := p.next(); t != token {
+		return errors.Errorf("expected %q, got %q", token, t)
+	}
+	return nil
+}
+
+func (p *wktParser) geometry() (geom, error) {
+	name := strings.ToUpper(p.next())
+	g := geom{}
+	for code, n := range geomTypeNames {
+		if n == name {
+			g.kind = code
+		}
+	}
+	if g.kind == 0 {
+		return g, errors.Errorf("unknown geometry type %q", name)
+	}
+	switch strings.ToUpper(p.peek()) {
+	case "Z":
+		g.z = true
+	case "M":
+		g.m = true
+	case "ZM":
+		g.z, g.m = true, true
+	}
+	if g.z || g.m {
+		p.next()
+	}
+	// without a dimension tag the dimensions follow from the first coordinate
+	inferDims := !g.z && !g.m
+	return g, p.body(&g, inferDims)
+}
+
+// body parses the coordinates or members of g after its type name
+func (p *wktParser) body(g *geom, inferDims bool) error {
+	if strings.EqualFold(p.peek(), "EMPTY") {
+		p.next()
+		return nil
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for {
+		var err error
+		switch g.kind {
+		case geomPoint, geomLineString:
+			err = p.coord(g, inferDims)
+		case geomCollection:
+			var member geom
+			member, err = p.geometry()
+			g.parts = append(g.parts, member)
+		default:
+			member := geom{kind: memberKind(g.kind), z: g.z, m: g.m}
+			// members of multi points may leave out their parentheses
+			if g.kind == geomMultiPoint && p.peek() != "(" && !strings.EqualFold(p.peek(), "EMPTY") {
+				err = p.coord(&member, inferDims && len(g.parts) == 0)
+			} else {
+				err = p.body(&member, inferDims && len(g.parts) == 0)
+			}
+			g.z, g.m = member.z, member.m
+			g.parts = append(g.parts, member)
+		}
+		if err != nil {
+			return err
+		}
+		inferDims = false
+		switch t := p.next(); t {
+		case ",":
+			if g.kind == geomPoint {
+				return errors.New("a point has one coordinate")
+			}
+		case ")":
+			return nil
+		default:
+			return errors.Errorf("expected \",\" or \")\", got %q", t)
+		}
+	}
+}
+
+// coord parses a coordinate of g, setting its dimensions from the number of
+// ordinates when inferDims is true
+func (p *wktParser) coord(g *geom, inferDims bool) error {
+	var c []float64
+	for {
+		t := p.peek()
+		if t == "," || t == ")" || t == "" {
+			break
+		}
+		f, err := strconv.ParseFloat(p.next(), 64)
+		if err != nil {
+			return errors.Errorf("invalid number %q", t)
+		}
+		c = append(c, f)
+	}
+	if inferDims {
+		switch len(c) {
+		case 3:
+			g.z = true
+		case 4:
+			g.z, g.m = true, true
+		}
+	}
+	if len(c) != g.dims() {
+		return errors.Errorf("expected %d ordinates, got %d", g.dims(), len(c))
+	}
+	g.coords = append(g.coords, c)
+	return nil
+}
+
+// memberKind returns the kind of the members of a polygon or multi geometry
+func memberKind(kind uint32) uint32 {
+	switch kind {
+	case geomPolygon, geomMultiLineString:
+		return geomLineString
+	case geomMultiPoint:
+		return geomPoint
+	case geomMultiPolygon:
+		return geomPolygon
+	}
+	return 0
+}
+
+// EWKB flags of the geometry type code
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// parseWKB parses ISO WKB or EWKB
+func parseWKB(b []byte) (geom, int, error) {
+	r := &wkbReader{b: b}
+	g, err := r.geometry(true)
+	if err == nil && r.pos != len(b) {
+		err = errors.New("unexpected bytes after the geometry")
+	}
+	if err != nil {
+		return geom{}, 0, errors.Errorf("%s: %s", errGeoInvalidWKB, err)
+	}
+	return g, r.srid, nil
+}
+
+type wkbReader struct {
+	b     []byte
+	pos   int
+	order binary.ByteOrder
+	srid  int
+}
+
+func (r *wkbReader) uint32() (uint32, error) {
+	if r.pos+4 > len(r.b) {
+		return 0, errors.New("unexpected end of data")
+	}
+	v := r.order.Uint32(r.b[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *wkbReader) coords(n uint32, dims int) ([][]float64, error) {
+	if uint64(r.pos)+uint64(n)*uint64(dims)*8 > uint64(len(r.b)) {
+		return nil, errors.New("unexpected end of data")
+	}
+	coords := make([][]float64, n)
+	for i := range coords {
+		coords[i] = make([]float64, dims)
+		for j := range coords[i] {
+			coords[i][j] = math.Float64frombits(r.order.Uint64(r.b[r.pos:]))
+			r.pos += 8
+		}
+	}
+	return coords, nil
+}
+
+// geometry reads a geometry. The SRID of EWKB is only allowed on the top level one.
+func (r *wkbReader) geometry(top bool) (geom, error) {
+	if r.pos >= len(r.b) {
+		return geom{}, errors.New("unexpected end of data")
+	}
+	switch r.b[r.pos] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return geom{}, errors.Errorf("invalid byte order %d", r.b[r.pos])
+	}
+	r.pos++
+	code, err := r.uint32()
+	if err != nil {
+		return geom{}, err
+	}
+	g := geom{z: code&ewkbZ != 0, m: code&ewkbM != 0}
+	if code&ewkbSRID != 0 {
+		srid, err := r.uint32()
+		if err != nil {
+			return geom{}, err
+		}
+		if top {
+			r.srid = int(int32(srid))
+		}
+	}
+	code &^= ewkbZ | ewkbM | ewkbSRID
+	switch code / 1000 {
+	case 1:
+		g.z = true
+	case 2:
+		g.m = true
+	case 3:
+		g.z, g.m = true, true
+	}
+	g.kind = code % 1000
+	if _, ok := geomTypeNames[g.kind]; !ok || code >= 4000 {
+		return geom{}, errors.Errorf("unknown geometry type %d", code)
+	}
+
+	switch g.kind {
+	case geomPoint:
+		coords, err := r.coords(1, g.dims())
+		if err != nil {
+			return geom{}, err
+		}
+		if !math.IsNaN(coords[0][0]) {
+			g.coords = coords
+		}
+	case geomLineString:
+		n, err := r.uint32()
+		if err != nil {
+			return geom{}, err
+		}
+		if g.coords, err = r.coords(n, g.dims()); err != nil {
+			return geom{}, err
+		}
+	case geomPolygon:
+		n, err := r.uint32()
+		if err != nil {
+			return geom{}, err
+		}
+		for i := uint32(0); i < n; i++ {
+			ring := geom{kind: geomLineString, z: g.z, m: g.m}
+			count, err := r.uint32()
+			if err != nil {
+				return geom{}, err
+			}
+			if ring.coords, err = r.coords(count, g.dims()); err != nil {
+				return geom{}, err
+			}
+			g.parts = append(g.parts, ring)
+		}
+	default:
+		n, err := r.uint32()
+		if err != nil {
+			return geom{}, err
+		}
+		for i := uint32(0); i < n; i++ {
+			member, err := r.geometry(false)
+			if err != nil {
+				return geom{}, err
+			}
+			if g.kind != geomCollection && member.kind != memberKind(g.kind) {
+				return geom{}, errors.Errorf("%s in a %s", geomTypeNames[member.kind], geomTypeNames[g.kind])
+			}
+			g.parts = append(g.parts, member)
+		}
+	}
+	return g, nil
+}
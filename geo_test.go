@@ -0,0 +1,82 @@
+package dbsql
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeometry(t *testing.T) {
+	t.Run("round trips WKT through WKB", func(t *testing.T) {
+		cases := map[string]string{
+			"POINT(1 2)":                       "POINT (1 2)",
+			"point z (1 2 3)":                  "POINT Z (1 2 3)",
+			"POINT (1.5 -2 3 4)":               "POINT ZM (1.5 -2 3 4)",
+			"POINT EMPTY":                      "POINT EMPTY",
+			"LINESTRING (30 10, 10 30, 40 40)": "LINESTRING (30 10, 10 30, 40 40)",
+			"POLYGON ((30 10, 40 40, 20 40, 30 10), (1 1, 2 2, 3 1, 1 1))":        "POLYGON ((30 10, 40 40, 20 40, 30 10), (1 1, 2 2, 3 1, 1 1))",
+			"MULTIPOINT (10 40, 40 30)":                                           "MULTIPOINT ((10 40), (40 30))",
+			"MULTILINESTRING ((10 10, 20 20), (40 40, 30 30))":                    "MULTILINESTRING ((10 10, 20 20), (40 40, 30 30))",
+			"MULTIPOLYGON (((30 20, 45 40, 10 40, 30 20)), EMPTY)":                "MULTIPOLYGON (((30 20, 45 40, 10 40, 30 20)), EMPTY)",
+			"GEOMETRYCOLLECTION (POINT (40 10), LINESTRING M (10 10 1, 20 20 2))": "GEOMETRYCOLLECTION (POINT (40 10), LINESTRING M (10 10 1, 20 20 2))",
+		}
+		for wkt, expected := range cases {
+			var g Geometry
+			require.NoError(t, g.Scan(wkt), wkt)
+			assert.Equal(t, expected, g.WKT(), wkt)
+
+			var fromWKB Geometry
+			require.NoError(t, fromWKB.Scan(g.WKB()), wkt)
+			assert.Equal(t, expected, fromWKB.WKT(), wkt)
+		}
+	})
+
+	t.Run("reads the SRID of EWKT and EWKB", func(t *testing.T) {
+		var g Geometry
+		require.NoError(t, g.Scan("SRID=4326;POINT(1 2)"))
+		assert.Equal(t, 4326, g.SRID)
+		assert.Equal(t, "POINT", g.Type())
+		assert.Equal(t, "SRID=4326;POINT (1 2)", g.String())
+
+		// big endian EWKB of SRID=4326;POINT(1 2), as hex text
+		ewkb := "0020000001000010e63ff00000000000004000000000000000"
+		require.NoError(t, g.Scan(ewkb))
+		assert.Equal(t, 4326, g.SRID)
+		assert.Equal(t, "POINT (1 2)", g.WKT())
+		assert.Equal(t, "0101000000000000000000f03f0000000000000040", hex.EncodeToString(g.WKB()))
+	})
+
+	t.Run("scans NULL", func(t *testing.T) {
+		g := Geometry{SRID: 4326, Valid: true}
+		require.NoError(t, g.Scan(nil))
+		assert.False(t, g.Valid)
+		assert.Equal(t, 0, g.SRID)
+		assert.Nil(t, g.WKB())
+		assert.Equal(t, "NULL", g.String())
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		var g Geometry
+		for _, s := range []string{"", "CIRCLE (1 2)", "POINT (1)", "POINT (1 2, 3 4)", "LINESTRING (1 2, 3)", "POINT (1 2) x", "SRID=x;POINT (1 2)"} {
+			assert.Error(t, g.Scan(s), s)
+			assert.False(t, g.Valid, s)
+		}
+		assert.Error(t, g.Scan([]byte{1, 1, 0, 0, 0}))
+		assert.Error(t, g.Scan(42))
+	})
+}
+
+func TestGeospatialColumns(t *testing.T) {
+	desc := &cli_service.TColumnDesc{TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{
+		{UserDefinedTypeEntry: &cli_service.TUserDefinedTypeEntry{TypeClassName: "geography(4326)"}},
+	}}}
+	assert.Equal(t, "GEOGRAPHY", getDBTypeName(desc))
+	assert.Equal(t, scanTypeGeometry, getScanType(desc))
+
+	cd := newColumnDescriptor(desc)
+	assert.Equal(t, "GEOGRAPHY", cd.DatabaseTypeName)
+	assert.Equal(t, "GEOGRAPHY(4326)", cd.TypeName)
+}
@@ -0,0 +1,128 @@
+package dbsql
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultHealthSlowLatency is the time after which a healthcheck that succeeded is
+// reported as HealthSlow.
+const DefaultHealthSlowLatency = 5 * time.Second
+
+// HealthState is the outcome of a healthcheck.
+type HealthState string
+
+const (
+	// HealthOK is a warehouse that ran the check query in time
+	HealthOK HealthState = "ok"
+	// HealthSlow is a warehouse that ran the check query, but took longer than
+	// DefaultHealthSlowLatency, e.g. while it scales up or is overloaded
+	HealthSlow HealthState = "slow"
+	// HealthAuthFailed is credentials rejected by the workspace
+	HealthAuthFailed HealthState = "auth_failed"
+	// HealthWarehouseUnavailable is a warehouse that is stopped, starting or still
+	// throttling the requests once the retries are used up
+	HealthWarehouseUnavailable HealthState = "warehouse_unavailable"
+	// HealthNetworkError is a workspace that can't be reached: DNS, connection or TLS
+	// failures, or connections dropped before a response
+	HealthNetworkError HealthState = "network_error"
+	// HealthTimeout is a check that did not complete before the deadline of its
+	// context, e.g. while the warehouse starts
+	HealthTimeout HealthState = "timeout"
+	// HealthFailed is any other failure, e.g. an invalid configuration
+	HealthFailed HealthState = "failed"
+)
+
+// HealthStatus is the result of Healthcheck.
+type HealthStatus struct {
+	State HealthState
+	// Latency is the time of the check
+	Latency time.Duration
+	// Err is the error of a failed check
+	Err error
+}
+
+// Ready returns true when the warehouse runs queries, even slowly, e.g. for a
+// readiness probe.
+func (s HealthStatus) Ready() bool {
+	return s.State == HealthOK || s.State == HealthSlow
+}
+
+func (s HealthStatus) String() string {
+	if s.Err != nil {
+		return string(s.State) + ": " + s.Err.Error()
+	}
+	return string(s.State) + " in " + s.Latency.Round(time.Millisecond).String()
+}
+
+// Healthcheck runs SELECT 1 on db and tells why it failed or whether it was slow, to
+// wire the driver into the readiness probes of a service:
+//
+//	status := dbsql.Healthcheck(ctx, db)
+//	if !status.Ready() {
+//		http.Error(w, status.String(), http.StatusServiceUnavailable)
+//	}
+//
+// ctx bounds the time of the check, which opens a session when the pool of db has no
+// idle connection.
+func Healthcheck(ctx context.Context, db Queryer) HealthStatus {
+	start := time.Now()
+	err := selectOne(ctx, db)
+	return healthStatus(time.Since(start), err, DefaultHealthSlowLatency)
+}
+
+// selectOne runs SELECT 1 and reads its row
+func selectOne(ctx context.Context, db Queryer) error {
+	rows, err := db.QueryContext(driverQuery(ctx), "SELECT 1")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return errors.New("databricks: SELECT 1 returned no rows")
+	}
+	return rows.Close()
+}
+
+// healthStatus classifies the outcome of a check
+func healthStatus(latency time.Duration, err error, slow time.Duration) HealthStatus {
+	status := HealthStatus{Latency: latency, Err: err}
+	msg := ""
+	if err != nil {
+		msg = strings.ToLower(err.Error())
+	}
+	var netErr net.Error
+	var recordErr tls.RecordHeaderError
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	switch {
+	case err == nil && latency >= slow:
+		status.State = HealthSlow
+	case err == nil:
+		status.State = HealthOK
+	case strings.Contains(msg, "http response code: 401") || strings.Contains(msg, "http response code: 403"):
+		status.State = HealthAuthFailed
+	case errors.Is(err, ErrThrottled) || strings.Contains(msg, "warehouse") &&
+		(strings.Contains(msg, "stopped") || strings.Contains(msg, "not running") || strings.Contains(msg, "starting")):
+		status.State = HealthWarehouseUnavailable
+	case errors.Is(err, context.DeadlineExceeded) || strings.Contains(msg, "sentinel timed out"):
+		status.State = HealthTimeout
+	case errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &recordErr) || errors.As(err, &unknownAuthority) || errors.As(err, &invalidCert) || errors.As(err, &hostnameErr):
+		status.State = HealthNetworkError
+	default:
+		status.State = HealthFailed
+	}
+	return status
+}
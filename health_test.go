@@ -0,0 +1,83 @@
+package dbsql
+
+import (
+	"context"
+	"crypto/x509"
+	"database/sql"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthcheck(t *testing.T) {
+	t.Run("warehouses running SELECT 1 are ready", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"1"}, [][]string{{"1"}}, "", &statements)
+		defer db.Close()
+
+		status := Healthcheck(context.Background(), db)
+		assert.Equal(t, HealthOK, status.State, status.String())
+		assert.True(t, status.Ready())
+		assert.NoError(t, status.Err)
+		assert.Equal(t, []string{"SELECT 1"}, statements)
+	})
+
+	t.Run("rejected credentials are auth failures", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer ts.Close()
+		connector, err := NewConnector(WithServerHostname("localhost"), WithPort(ts.Listener.Addr().(*net.TCPAddr).Port), WithAccessToken("wrong"))
+		require.NoError(t, err)
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		status := Healthcheck(context.Background(), db)
+		assert.Equal(t, HealthAuthFailed, status.State, status.String())
+		assert.False(t, status.Ready())
+	})
+
+	t.Run("unreachable workspaces are network errors", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := l.Addr().(*net.TCPAddr).Port
+		l.Close()
+		connector, err := NewConnector(WithServerHostname("localhost"), WithPort(port), WithAccessToken("token"))
+		require.NoError(t, err)
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		status := Healthcheck(context.Background(), db)
+		assert.Equal(t, HealthNetworkError, status.State, status.String())
+	})
+
+	t.Run("outcomes are classified", func(t *testing.T) {
+		for _, tc := range []struct {
+			name    string
+			latency time.Duration
+			err     error
+			want    HealthState
+		}{
+			{"fast", 10 * time.Millisecond, nil, HealthOK},
+			{"slow", 6 * time.Second, nil, HealthSlow},
+			{"forbidden", time.Second, errors.New("open session request error: HTTP Response code: 403"), HealthAuthFailed},
+			{"throttled", time.Second, errors.Wrap(ErrThrottled, "HTTP 503 after 5 attempts, retry after 0s"), HealthWarehouseUnavailable},
+			{"stopped", time.Second, errors.New("The SQL warehouse is stopped"), HealthWarehouseUnavailable},
+			{"deadline", time.Second, errors.Wrap(context.DeadlineExceeded, "sentinel context done"), HealthTimeout},
+			{"connect timeout", time.Minute, errors.New("error connecting: sentinel timed out"), HealthTimeout},
+			{"dial", time.Second, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, HealthNetworkError},
+			{"certificate", time.Second, errors.Wrap(x509.UnknownAuthorityError{}, "tls"), HealthNetworkError},
+			{"other", time.Second, errors.New("databricks: invalid config"), HealthFailed},
+		} {
+			status := healthStatus(tc.latency, tc.err, DefaultHealthSlowLatency)
+			assert.Equal(t, tc.want, status.State, tc.name)
+			assert.Equal(t, tc.latency, status.Latency, tc.name)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package dbsql
+
+import (
+	"context"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/logger"
+)
+
+// startIdleTimer is called when the connection is returned to the pool. Once the
+// connection stays idle for the session idle timeout its session is closed.
+func (c *conn) startIdleTimer() {
+	if c.cfg == nil || c.cfg.SessionIdleTimeout <= 0 {
+		return
+	}
+	c.idleMu.Lock()
+	defer c.idleMu.Unlock()
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+	}
+	c.idleTimer = time.AfterFunc(c.cfg.SessionIdleTimeout, c.closeIdleSession)
+}
+
+// stopIdleTimer is called when the connection is taken from the pool. It returns
+// false if the session was closed while the connection was idle.
+func (c *conn) stopIdleTimer() bool {
+	c.idleMu.Lock()
+	defer c.idleMu.Unlock()
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+		c.idleTimer = nil
+	}
+	return !c.idleClosed
+}
+
+// closeIdleSession shuts the connection down, which closes its session and its
+// network connections. The lock is held meanwhile, so the pool can't take the
+// connection until it knows that it must open a new one.
+func (c *conn) closeIdleSession() {
+	c.idleMu.Lock()
+	defer c.idleMu.Unlock()
+	// the connection was taken from the pool since the timer fired
+	if c.idleTimer == nil {
+		return
+	}
+	c.idleTimer = nil
+	c.idleClosed = true
+
+	log := logger.WithContext(c.id, "", "")
+	log.Debug().Msgf("databricks: closing session idle for %v", c.cfg.SessionIdleTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := c.Shutdown(ctx); err != nil {
+		log.Err(err).Msg("databricks: failed to close idle session")
+	}
+	closeIdleConnections(c.client)
+}
@@ -0,0 +1,105 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idleTestConnector opens connections from getShutdownTestConn with a session idle
+// timeout. The sessions closed by closing the connections are counted in closed.
+type idleTestConnector struct {
+	t        *testing.T
+	recorder *shutdownRecorder
+	timeout  time.Duration
+	mu       sync.Mutex
+	conns    []*conn
+	closed   map[*conn]int
+}
+
+func (c *idleTestConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dc := getShutdownTestConn(c.t, c.recorder)
+	dc.cfg.SessionIdleTimeout = c.timeout
+	dc.client.(*client.TestClient).FnCloseSession = func(ctx context.Context, req *cli_service.TCloseSessionReq) (*cli_service.TCloseSessionResp, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.closed == nil {
+			c.closed = map[*conn]int{}
+		}
+		c.closed[dc]++
+		return &cli_service.TCloseSessionResp{}, nil
+	}
+	c.conns = append(c.conns, dc)
+	return dc, nil
+}
+
+func (c *idleTestConnector) Driver() driver.Driver {
+	return &databricksDriver{}
+}
+
+func (c *idleTestConnector) opened() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.conns)
+}
+
+func TestConn_SessionIdleTimeout(t *testing.T) {
+	t.Run("idle sessions are closed and reopened on the next use", func(t *testing.T) {
+		recorder := &shutdownRecorder{}
+		connector := &idleTestConnector{t: t, recorder: recorder, timeout: 20 * time.Millisecond}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		_, err := db.ExecContext(context.Background(), "insert into t values (1)")
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			recorder.mu.Lock()
+			defer recorder.mu.Unlock()
+			return recorder.sessions == 1
+		}, time.Second, 5*time.Millisecond)
+
+		_, err = db.ExecContext(context.Background(), "insert into t values (2)")
+		require.NoError(t, err)
+		assert.Equal(t, 2, connector.opened())
+		assert.False(t, connector.conns[0].IsValid())
+
+		// the pool closed the connection without closing its session again
+		connector.mu.Lock()
+		defer connector.mu.Unlock()
+		assert.Zero(t, connector.closed[connector.conns[0]])
+	})
+
+	t.Run("sessions used within the timeout are kept", func(t *testing.T) {
+		recorder := &shutdownRecorder{}
+		connector := &idleTestConnector{t: t, recorder: recorder, timeout: time.Minute}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		for i := 0; i < 3; i++ {
+			_, err := db.ExecContext(context.Background(), "insert into t values (1)")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 1, connector.opened())
+		assert.Equal(t, 0, recorder.sessions)
+		// the timer is stopped while the connection is in use
+		dc, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		connector.conns[0].idleMu.Lock()
+		assert.Nil(t, connector.conns[0].idleTimer)
+		connector.conns[0].idleMu.Unlock()
+		require.NoError(t, dc.Close())
+		connector.conns[0].idleMu.Lock()
+		assert.NotNil(t, connector.conns[0].idleTimer)
+		connector.conns[0].idleMu.Unlock()
+	})
+}
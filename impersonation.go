@@ -0,0 +1,15 @@
+package dbsql
+
+// impersonationConf is the configuration key of the user a session runs as, honored
+// by the server when the session is opened and the principal may impersonate users
+const impersonationConf = "hive.server2.proxy.user"
+
+// sessionConfiguration returns the configuration of the sessions run as user, or as
+// the authenticated principal when user is empty
+func sessionConfiguration(user string) map[string]string {
+	conf := make(map[string]string)
+	if user != "" {
+		conf[impersonationConf] = user
+	}
+	return conf
+}
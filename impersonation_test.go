@@ -0,0 +1,75 @@
+package dbsql
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithImpersonation(t *testing.T) {
+	var openSessionResp cli_service.TOpenSessionResp
+	loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+	var configurations []map[string]string
+	ts := initThriftTestServer(&client.TestClient{
+		FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+			configurations = append(configurations, req.Configuration)
+			return &openSessionResp, nil
+		},
+	})
+	defer ts.Close()
+	r, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(r.Port())
+	require.NoError(t, err)
+
+	for _, opts := range [][]connOption{
+		{WithServerHostname("localhost"), WithPort(port), WithImpersonation("someone@example.com")},
+		{WithServerHostname("localhost"), WithPort(port)},
+	} {
+		testConnector, err := NewConnector(opts...)
+		require.NoError(t, err)
+		_, err = testConnector.Connect(context.Background())
+		require.NoError(t, err)
+		// the user of the context takes precedence over the one of the connector
+		c, err := testConnector.Connect(driverctx.NewContextWithImpersonationUser(context.Background(), "alice@example.com"))
+		require.NoError(t, err)
+		assert.Equal(t, "alice@example.com", c.(*conn).impersonationUser)
+	}
+	assert.Equal(t, []map[string]string{
+		{"hive.server2.proxy.user": "someone@example.com"},
+		{"hive.server2.proxy.user": "alice@example.com"},
+		{},
+		{"hive.server2.proxy.user": "alice@example.com"},
+	}, configurations)
+}
+
+func TestConn_SameUser(t *testing.T) {
+	alice := driverctx.NewContextWithImpersonationUser(context.Background(), "alice@example.com")
+	bob := driverctx.NewContextWithImpersonationUser(context.Background(), "bob@example.com")
+
+	c := &conn{cfg: config.WithDefaults(), impersonationUser: "alice@example.com"}
+	assert.True(t, c.sameUser(alice))
+	assert.False(t, c.sameUser(bob))
+	// the statements without a user run as the principal of the connector
+	assert.False(t, c.sameUser(context.Background()))
+
+	cfg := config.WithDefaults()
+	cfg.ImpersonationUser = "alice@example.com"
+	c = &conn{cfg: cfg, impersonationUser: "alice@example.com"}
+	assert.True(t, c.sameUser(context.Background()))
+	c = &conn{cfg: cfg, impersonationUser: "bob@example.com"}
+	assert.False(t, c.sameUser(context.Background()))
+
+	c = &conn{cfg: config.WithDefaults(), impersonationUser: "alice@example.com", userPool: true}
+	assert.True(t, c.sameUser(context.Background()))
+	assert.True(t, c.sameUser(alice))
+	assert.False(t, c.sameUser(bob))
+}
@@ -0,0 +1,110 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errInListNotSlice = "databricks: %T is not a slice to bind to an IN list"
+
+// InListArray binds a slice to an IN list as a subquery exploding an ARRAY
+// literal, rather than expanding its items into the list:
+//
+//	db.Query("SELECT * FROM t WHERE id IN (?)", dbsql.InListArray(ids))
+//	// SELECT * FROM t WHERE id IN (SELECT explode(array(1, 2, 3)))
+//
+// The server plans the subquery as a semi join, which scales better than long lists of
+// OR-ed comparisons for large slices. Elsewhere the slice is bound as an ARRAY literal.
+func InListArray(values any) any {
+	return inListArray{values}
+}
+
+// InListValues binds a slice to an IN list as a subquery of an inline table with a
+// row per item, planned as a semi join as with InListArray:
+//
+//	db.Query("SELECT * FROM t WHERE id IN (?)", dbsql.InListValues(ids))
+//	// SELECT * FROM t WHERE id IN (SELECT * FROM VALUES (1), (2), (3))
+//
+// Elsewhere the slice is bound as an ARRAY literal.
+func InListValues(values any) any {
+	return inListValues{values}
+}
+
+type inListArray struct {
+	values any
+}
+
+type inListValues struct {
+	values any
+}
+
+// CreateInListView creates a temporary view with a single value column holding the
+// items of values, see CreateTempView, for lists used by several statements or too
+// large to send with each of them:
+//
+//	err := dbsql.CreateInListView(ctx, conn, "ids", ids)
+//	...
+//	rows, err := conn.QueryContext(ctx, "SELECT * FROM t WHERE id IN (SELECT value FROM ids)")
+func CreateInListView(ctx context.Context, conn *sql.Conn, name string, values any) error {
+	rv, err := inListItems(values)
+	if err != nil {
+		return err
+	}
+	rows := make([][]any, rv.Len())
+	for i := range rows {
+		rows[i] = []any{rv.Index(i).Interface()}
+	}
+	return CreateTempView(ctx, conn, name, []string{"value"}, rows)
+}
+
+// formatInListSubquery formats the items of an InListArray or InListValues slice as a
+// subquery for an IN list
+func formatInListSubquery(v any) (string, error) {
+	var values any
+	switch v := v.(type) {
+	case inListArray:
+		values = v.values
+	case inListValues:
+		values = v.values
+	}
+	rv, err := inListItems(values)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := v.(inListArray); ok {
+		items, err := formatItems(rv)
+		if err != nil {
+			return "", err
+		}
+		return "SELECT explode(array(" + items + "))", nil
+	}
+	var b strings.Builder
+	b.WriteString("SELECT * FROM VALUES ")
+	for i := 0; i < rv.Len(); i++ {
+		item, err := formatLiteral(rv.Index(i).Interface())
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(" + item + ")")
+	}
+	return b.String(), nil
+}
+
+// inListItems returns the non-empty slice or array of values
+func inListItems(values any) (reflect.Value, error) {
+	rv := reflect.ValueOf(values)
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || isBytes(values) {
+		return rv, errors.Errorf(errInListNotSlice, values)
+	}
+	if rv.Len() == 0 {
+		return rv, errors.New(errParamsEmptyInList)
+	}
+	return rv, nil
+}
@@ -0,0 +1,50 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInList(t *testing.T) {
+	t.Run("slices are bound as subqueries in IN lists", func(t *testing.T) {
+		query, err := interpolateParams("select * from t where id in (?) and name IN (?)", namedValues(InListArray([]int{1, 2}), InListValues([]string{"a", "b"})))
+		require.NoError(t, err)
+		assert.Equal(t, "select * from t where id in (SELECT explode(array(1, 2))) and name IN (SELECT * FROM VALUES ('a'), ('b'))", query)
+	})
+
+	t.Run("slices are bound as arrays elsewhere", func(t *testing.T) {
+		query, err := interpolateParams("select array_contains(?, 1), ?", namedValues(InListArray([]int{1, 2}), InListValues([]int{3})))
+		require.NoError(t, err)
+		assert.Equal(t, "select array_contains(array(1, 2), 1), array(3)", query)
+	})
+
+	t.Run("values must be non-empty slices", func(t *testing.T) {
+		_, err := interpolateParams("select * from t where id in (?)", namedValues(InListArray([]int{})))
+		assert.EqualError(t, err, errParamsEmptyInList)
+		_, err = interpolateParams("select * from t where id in (?)", namedValues(InListValues(1)))
+		assert.EqualError(t, err, "databricks: int is not a slice to bind to an IN list")
+	})
+
+	t.Run("arguments reach the driver", func(t *testing.T) {
+		c := &conn{}
+		assert.NoError(t, c.CheckNamedValue(&driver.NamedValue{Value: InListArray([]int{1})}))
+		assert.NoError(t, c.CheckNamedValue(&driver.NamedValue{Value: InListValues([]int{1})}))
+	})
+
+	t.Run("a view holds the items", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+
+		require.NoError(t, CreateInListView(context.Background(), c, "ids", []int64{1, 2}))
+		assert.EqualError(t, CreateInListView(context.Background(), c, "ids", "1, 2"), "databricks: string is not a slice to bind to an IN list")
+		assert.Equal(t, []string{"CREATE OR REPLACE TEMPORARY VIEW `ids` (`value`) AS VALUES (1), (2)"}, statements)
+	})
+}
@@ -0,0 +1,293 @@
+package dbsql
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultInsertMaxStatementBytes is the size of the INSERT statements of InsertRows
+// when no size is set, well below the limit of the warehouses on the statement text.
+const DefaultInsertMaxStatementBytes = 4 << 20
+
+// DefaultInsertMaxValues is the number of values in an INSERT statement of
+// InsertRows when no number is set.
+const DefaultInsertMaxValues = 32768
+
+var errInsertNoColumns = "databricks: an insert needs at least one column"
+var errInsertRowLength = "databricks: row %d has %d values, the insert has %d columns"
+
+// InsertOptions configures InsertRows.
+type InsertOptions struct {
+	// MaxStatementBytes is the size of the statement text after which the rows are
+	// split into another statement, DefaultInsertMaxStatementBytes when zero. A row
+	// larger than that is inserted alone.
+	MaxStatementBytes int
+	// MaxValues is the number of values, rows times columns, after which the rows
+	// are split into another statement, DefaultInsertMaxValues when zero
+	MaxValues int
+	// AllOrNothing inserts the chunks into a staging table created next to the
+	// table, then moves them into the table with a single MERGE, so that the table
+	// gets either all the rows or none of them
+	AllOrNothing bool
+	// Progress is called after each chunk is inserted
+	Progress func(InsertProgress)
+}
+
+// InsertProgress is the progress of InsertRows, reported after each chunk.
+type InsertProgress struct {
+	// Chunk is the number of the chunk inserted, starting at 1
+	Chunk  int
+	Chunks int
+	// Rows is the number of rows inserted so far, in the staging table when
+	// AllOrNothing is set
+	Rows      int64
+	TotalRows int64
+}
+
+// InsertRows inserts the rows into the columns of table, splitting them into as many
+// INSERT ... VALUES statements as needed to keep each below the size and value
+// limits of opts, and returns the number of rows inserted. The values are formatted
+// as SQL literals, as with parameter interpolation. The statements run one after the
+// other on conn, so that they share its session.
+//
+// Without AllOrNothing, the chunks inserted before a failure stay in the table and
+// their rows are counted in the number returned. With it, the rows go through a
+// staging table created with CREATE TABLE ... LIKE next to the table, which needs the
+// privilege to create tables in its schema, and the staging table is dropped once
+// the rows are merged or the insert failed.
+//
+//	n, err := dbsql.InsertRows(ctx, conn, "main.default.events", []string{"id", "label"}, rows, dbsql.InsertOptions{
+//		AllOrNothing: true,
+//		Progress: func(p dbsql.InsertProgress) {
+//			log.Printf("chunk %d/%d: %d/%d rows", p.Chunk, p.Chunks, p.Rows, p.TotalRows)
+//		},
+//	})
+func InsertRows(ctx context.Context, conn *sql.Conn, table string, columns []string, rows [][]any, opts InsertOptions) (int64, error) {
+	target, err := quoteName(table)
+	if err != nil {
+		return 0, err
+	}
+	quotedColumns, err := quoteInsertColumns(columns)
+	if err != nil {
+		return 0, err
+	}
+	chunks, err := insertChunks(quotedColumns, rows, opts)
+	if err != nil {
+		return 0, err
+	}
+	if len(chunks) == 0 {
+		return 0, nil
+	}
+	if !opts.AllOrNothing {
+		return execInsertChunks(ctx, conn, target, chunks, len(rows), opts.Progress)
+	}
+
+	n, err := stageRows(ctx, conn, table, target, chunks, len(rows), opts.Progress, func(stage string) error {
+		_, err := conn.ExecContext(ctx, mergeStatement(target, stage, quotedColumns, nil))
+		return err
+	})
+	if err != nil {
+		// nothing was merged
+		return 0, err
+	}
+	return n, nil
+}
+
+// stagingDropTimeout bounds dropping the staging table, which is done even once the
+// context of the insert is done
+const stagingDropTimeout = 30 * time.Second
+
+// stageRows inserts the chunks into a staging table like target, then calls merge
+// with its name to move its rows into target. The staging table is dropped once
+// merge returns, including when ctx is cancelled.
+func stageRows(ctx context.Context, conn *sql.Conn, table, target string, chunks []insertChunk, total int, progress func(InsertProgress), merge func(stage string) error) (int64, error) {
+	stage, err := stagingTableName(table)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE "+stage+" LIKE "+target); err != nil {
+		return 0, wrapErrf(err, "failed to create staging table %s", stage)
+	}
+	n, err := execInsertChunks(ctx, conn, stage, chunks, total, progress)
+	if err == nil {
+		err = wrapErrf(merge(stage), "failed to merge staging table %s into %s", stage, table)
+	}
+	dropCtx, cancel := context.WithTimeout(detachedContext{ctx}, stagingDropTimeout)
+	defer cancel()
+	if _, dropErr := conn.ExecContext(dropCtx, "DROP TABLE IF EXISTS "+stage); dropErr != nil && err == nil {
+		err = wrapErrf(dropErr, "failed to drop staging table %s", stage)
+	}
+	return n, err
+}
+
+// detachedContext keeps the values of its parent, e.g. the correlation id, but not
+// its deadline or cancellation, like context.WithoutCancel of Go 1.21
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
+
+// insertChunk is the VALUES of an INSERT statement
+type insertChunk struct {
+	columns string
+	values  string
+	rows    int
+}
+
+// insertChunks formats the rows and groups them into chunks within the limits of opts
+func insertChunks(quotedColumns []string, rows [][]any, opts InsertOptions) ([]insertChunk, error) {
+	maxBytes := opts.MaxStatementBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultInsertMaxStatementBytes
+	}
+	maxValues := opts.MaxValues
+	if maxValues <= 0 {
+		maxValues = DefaultInsertMaxValues
+	}
+	columns := " (" + strings.Join(quotedColumns, ", ") + ") VALUES "
+	var chunks []insertChunk
+	var b strings.Builder
+	var row strings.Builder
+	n := 0
+	flush := func() {
+		if n > 0 {
+			chunks = append(chunks, insertChunk{columns: columns, values: b.String(), rows: n})
+			b.Reset()
+			n = 0
+		}
+	}
+	for i, values := range rows {
+		if len(values) != len(quotedColumns) {
+			return nil, errors.Errorf(errInsertRowLength, i, len(values), len(quotedColumns))
+		}
+		row.Reset()
+		row.WriteByte('(')
+		for j, v := range values {
+			literal, err := formatLiteral(v)
+			if err != nil {
+				return nil, err
+			}
+			if j > 0 {
+				row.WriteString(", ")
+			}
+			row.WriteString(literal)
+		}
+		row.WriteByte(')')
+		// the INSERT INTO and table name are left out of the size, the limit is
+		// well below the one of the server
+		if n > 0 && (len(columns)+b.Len()+2+row.Len() > maxBytes || (n+1)*len(quotedColumns) > maxValues) {
+			flush()
+		}
+		if n > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(row.String())
+		n++
+	}
+	flush()
+	return chunks, nil
+}
+
+// execInsertChunks inserts the chunks into table and returns the number of rows
+// inserted
+func execInsertChunks(ctx context.Context, conn *sql.Conn, table string, chunks []insertChunk, total int, progress func(InsertProgress)) (int64, error) {
+	var n int64
+	for i, chunk := range chunks {
+		if _, err := conn.ExecContext(ctx, "INSERT INTO "+table+chunk.columns+chunk.values); err != nil {
+			return n, wrapErrf(err, "failed to insert chunk %d of %d into %s", i+1, len(chunks), table)
+		}
+		n += int64(chunk.rows)
+		if progress != nil {
+			progress(InsertProgress{Chunk: i + 1, Chunks: len(chunks), Rows: n, TotalRows: int64(total)})
+		}
+	}
+	return n, nil
+}
+
+// mergeStatement merges the columns of the rows of stage into target. Rows matching
+// on the keys update the row of target, the other rows are inserted. Without keys,
+// all the rows are inserted.
+func mergeStatement(target, stage string, quotedColumns, quotedKeys []string) string {
+	var b strings.Builder
+	b.WriteString("MERGE INTO ")
+	b.WriteString(target)
+	b.WriteString(" AS t USING ")
+	b.WriteString(stage)
+	b.WriteString(" AS s ON ")
+	if len(quotedKeys) == 0 {
+		b.WriteString("FALSE")
+	}
+	for i, key := range quotedKeys {
+		if i > 0 {
+			b.WriteString(" AND ")
+		}
+		b.WriteString("t." + key + " = s." + key)
+	}
+	if len(quotedKeys) > 0 {
+		keys := map[string]bool{}
+		for _, key := range quotedKeys {
+			keys[key] = true
+		}
+		var set []string
+		for _, column := range quotedColumns {
+			if !keys[column] {
+				set = append(set, "t."+column+" = s."+column)
+			}
+		}
+		if len(set) > 0 {
+			b.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+			b.WriteString(strings.Join(set, ", "))
+		}
+	}
+	values := make([]string, len(quotedColumns))
+	for i, column := range quotedColumns {
+		values[i] = "s." + column
+	}
+	b.WriteString(" WHEN NOT MATCHED THEN INSERT (")
+	b.WriteString(strings.Join(quotedColumns, ", "))
+	b.WriteString(") VALUES (")
+	b.WriteString(strings.Join(values, ", "))
+	b.WriteByte(')')
+	return b.String()
+}
+
+// quoteInsertColumns quotes the column names, which cannot be qualified
+func quoteInsertColumns(columns []string) ([]string, error) {
+	if len(columns) == 0 {
+		return nil, errors.New(errInsertNoColumns)
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		q, err := quoteTempViewName(column)
+		if err != nil {
+			return nil, err
+		}
+		quoted[i] = q
+	}
+	return quoted, nil
+}
+
+// stagingTableName returns a new name for a staging table in the schema of table
+var stagingTableName = func(table string) (string, error) {
+	parts, err := quoteNameParts(table)
+	if err != nil {
+		return "", err
+	}
+	var suffix [8]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		return "", err
+	}
+	last := parts[len(parts)-1]
+	parts[len(parts)-1] = last[:len(last)-1] + "_dbsql_stage_" + hex.EncodeToString(suffix[:]) + "`"
+	return strings.Join(parts, "."), nil
+}
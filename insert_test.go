@@ -0,0 +1,173 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getInsertTestConn returns a connection whose statements containing fail, when set,
+// fail
+func getInsertTestConn(t *testing.T, fail string, statements *[]string) (*sql.DB, *sql.Conn) {
+	db := getStringsTestDB([]string{"id"}, nil, "", statements)
+	c, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	err = c.Raw(func(dc any) error {
+		testClient := dc.(*conn).client.(*client.TestClient)
+		executeStatement := testClient.FnExecuteStatement
+		testClient.FnExecuteStatement = func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+			if fail == "" || !strings.Contains(req.Statement, fail) {
+				return executeStatement(ctx, req)
+			}
+			*statements = append(*statements, req.Statement)
+			resp := &cli_service.TExecuteStatementResp{
+				Status: &cli_service.TStatus{
+					StatusCode:   cli_service.TStatusCode_ERROR_STATUS,
+					ErrorMessage: strPtr("statement failed"),
+				},
+			}
+			return resp, client.CheckStatus(resp)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return db, c
+}
+
+func TestInsertRows(t *testing.T) {
+	defer func(f func(string) (string, error)) { stagingTableName = f }(stagingTableName)
+	stagingTableName = func(table string) (string, error) {
+		return "`main`.`default`.`events_dbsql_stage_1`", nil
+	}
+	rows := [][]any{{1, "a"}, {2, "o'b"}, {3, nil}}
+
+	t.Run("rows are split into chunks", func(t *testing.T) {
+		var statements []string
+		db, c := getInsertTestConn(t, "", &statements)
+		defer db.Close()
+		defer c.Close()
+
+		var progress []InsertProgress
+		n, err := InsertRows(context.Background(), c, "main.default.events", []string{"id", "label"}, rows, InsertOptions{
+			MaxValues: 4,
+			Progress:  func(p InsertProgress) { progress = append(progress, p) },
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+		assert.Equal(t, []string{
+			"INSERT INTO `main`.`default`.`events` (`id`, `label`) VALUES (1, 'a'), (2, 'o\\'b')",
+			"INSERT INTO `main`.`default`.`events` (`id`, `label`) VALUES (3, NULL)",
+		}, statements)
+		assert.Equal(t, []InsertProgress{{1, 2, 2, 3}, {2, 2, 3, 3}}, progress)
+	})
+
+	t.Run("statements are kept below the size", func(t *testing.T) {
+		var statements []string
+		db, c := getInsertTestConn(t, "", &statements)
+		defer db.Close()
+		defer c.Close()
+
+		n, err := InsertRows(context.Background(), c, "t", []string{"s"}, [][]any{{"aaaaaaaaaa"}, {"b"}, {"c"}}, InsertOptions{MaxStatementBytes: 30})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+		assert.Equal(t, []string{
+			"INSERT INTO `t` (`s`) VALUES ('aaaaaaaaaa')",
+			"INSERT INTO `t` (`s`) VALUES ('b'), ('c')",
+		}, statements)
+	})
+
+	t.Run("chunks inserted before a failure are counted", func(t *testing.T) {
+		var statements []string
+		db, c := getInsertTestConn(t, "(3, NULL)", &statements)
+		defer db.Close()
+		defer c.Close()
+
+		n, err := InsertRows(context.Background(), c, "main.default.events", []string{"id", "label"}, rows, InsertOptions{MaxValues: 4})
+		assert.Error(t, err)
+		assert.Equal(t, int64(2), n)
+	})
+
+	t.Run("all or nothing merges a staging table", func(t *testing.T) {
+		var statements []string
+		db, c := getInsertTestConn(t, "", &statements)
+		defer db.Close()
+		defer c.Close()
+
+		n, err := InsertRows(context.Background(), c, "main.default.events", []string{"id", "label"}, rows, InsertOptions{AllOrNothing: true, MaxValues: 4})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+		assert.Equal(t, []string{
+			"CREATE TABLE `main`.`default`.`events_dbsql_stage_1` LIKE `main`.`default`.`events`",
+			"INSERT INTO `main`.`default`.`events_dbsql_stage_1` (`id`, `label`) VALUES (1, 'a'), (2, 'o\\'b')",
+			"INSERT INTO `main`.`default`.`events_dbsql_stage_1` (`id`, `label`) VALUES (3, NULL)",
+			"MERGE INTO `main`.`default`.`events` AS t USING `main`.`default`.`events_dbsql_stage_1` AS s ON FALSE " +
+				"WHEN NOT MATCHED THEN INSERT (`id`, `label`) VALUES (s.`id`, s.`label`)",
+			"DROP TABLE IF EXISTS `main`.`default`.`events_dbsql_stage_1`",
+		}, statements)
+	})
+
+	t.Run("all or nothing inserts nothing on failure", func(t *testing.T) {
+		var statements []string
+		db, c := getInsertTestConn(t, "(3, NULL)", &statements)
+		defer db.Close()
+		defer c.Close()
+
+		n, err := InsertRows(context.Background(), c, "main.default.events", []string{"id", "label"}, rows, InsertOptions{AllOrNothing: true, MaxValues: 4})
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), n)
+		assert.Equal(t, "DROP TABLE IF EXISTS `main`.`default`.`events_dbsql_stage_1`", statements[len(statements)-1])
+		for _, s := range statements {
+			assert.False(t, strings.HasPrefix(s, "MERGE"), s)
+		}
+	})
+
+	t.Run("all or nothing drops the staging table once the context is cancelled", func(t *testing.T) {
+		var statements []string
+		db, c := getInsertTestConn(t, "", &statements)
+		defer db.Close()
+		defer c.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		n, err := InsertRows(ctx, c, "main.default.events", []string{"id", "label"}, rows, InsertOptions{
+			AllOrNothing: true,
+			MaxValues:    4,
+			Progress: func(p InsertProgress) {
+				if p.Rows == p.TotalRows {
+					cancel()
+				}
+			},
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, int64(0), n)
+		assert.Equal(t, "DROP TABLE IF EXISTS `main`.`default`.`events_dbsql_stage_1`", statements[len(statements)-1])
+		for _, s := range statements {
+			assert.False(t, strings.HasPrefix(s, "MERGE"), s)
+		}
+	})
+
+	t.Run("invalid inserts are rejected", func(t *testing.T) {
+		var statements []string
+		db, c := getInsertTestConn(t, "", &statements)
+		defer db.Close()
+		defer c.Close()
+
+		ctx := context.Background()
+		_, err := InsertRows(ctx, c, "t", nil, rows, InsertOptions{})
+		assert.EqualError(t, err, errInsertNoColumns)
+		_, err = InsertRows(ctx, c, "t", []string{"id", "label"}, [][]any{{1, "a"}, {2}}, InsertOptions{})
+		assert.EqualError(t, err, "databricks: row 1 has 1 values, the insert has 2 columns")
+		_, err = InsertRows(ctx, c, "t", []string{"a.b"}, rows, InsertOptions{})
+		assert.Error(t, err)
+		n, err := InsertRows(ctx, c, "t", []string{"id"}, nil, InsertOptions{})
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Empty(t, statements)
+	})
+}
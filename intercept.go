@@ -0,0 +1,39 @@
+package dbsql
+
+import (
+	"context"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/interceptor"
+	"github.com/databricks/databricks-sql-go/internal/config"
+)
+
+// interceptStart runs the OnQueryStart hooks in order, each getting the statement
+// as rewritten by the ones before it
+func interceptStart(ctx context.Context, cfg *config.Config, query *interceptor.Query) error {
+	if cfg == nil {
+		return nil
+	}
+	for _, i := range cfg.Interceptors {
+		statement, err := i.OnQueryStart(ctx, *query)
+		if err != nil {
+			return wrapErr(err, "statement rejected by an interceptor")
+		}
+		query.Statement = statement
+	}
+	return nil
+}
+
+// interceptEnd runs the OnError hooks when the statement failed, then the OnQueryEnd hooks
+func interceptEnd(ctx context.Context, cfg *config.Config, query interceptor.Query, start time.Time, err error) {
+	if cfg == nil {
+		return
+	}
+	end := interceptor.QueryEnd{Duration: time.Since(start), Err: err}
+	for _, i := range cfg.Interceptors {
+		if err != nil {
+			i.OnError(ctx, query, err)
+		}
+		i.OnQueryEnd(ctx, query, end)
+	}
+}
@@ -0,0 +1,143 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/interceptor"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingInterceptor records the hooks it is called with
+type recordingInterceptor struct {
+	interceptor.Base
+	name    string
+	calls   *[]string
+	rewrite func(string) (string, error)
+}
+
+func (i *recordingInterceptor) OnQueryStart(ctx context.Context, query interceptor.Query) (string, error) {
+	*i.calls = append(*i.calls, fmt.Sprintf("%s start %s", i.name, query.Statement))
+	if i.rewrite != nil {
+		return i.rewrite(query.Statement)
+	}
+	return query.Statement, nil
+}
+
+func (i *recordingInterceptor) OnQueryEnd(ctx context.Context, query interceptor.Query, end interceptor.QueryEnd) {
+	*i.calls = append(*i.calls, fmt.Sprintf("%s end %s %s err=%v", i.name, query.Statement, query.QueryID, end.Err != nil))
+}
+
+func (i *recordingInterceptor) OnFetchPage(ctx context.Context, query interceptor.Query, page interceptor.Page) {
+	*i.calls = append(*i.calls, fmt.Sprintf("%s page %s %d", i.name, query.QueryID, page.Rows))
+}
+
+func (i *recordingInterceptor) OnError(ctx context.Context, query interceptor.Query, err error) {
+	*i.calls = append(*i.calls, fmt.Sprintf("%s error %s", i.name, err))
+}
+
+func TestInterceptors(t *testing.T) {
+	t.Run("interceptors are chained around queries", func(t *testing.T) {
+		var statements, calls []string
+		db := getStringsTestDB([]string{"id"}, [][]string{{"1"}}, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+		require.NoError(t, c.Raw(func(dc any) error {
+			dc.(*conn).cfg.Interceptors = []interceptor.Interceptor{
+				&recordingInterceptor{name: "audit", calls: &calls},
+				&recordingInterceptor{name: "rewrite", calls: &calls, rewrite: func(s string) (string, error) {
+					return "/* app */ " + s, nil
+				}},
+			}
+			return nil
+		}))
+
+		var id string
+		require.NoError(t, c.QueryRowContext(context.Background(), "SELECT id FROM t").Scan(&id))
+		assert.Equal(t, []string{"/* app */ SELECT id FROM t"}, statements)
+		assert.Equal(t, []string{
+			"audit start SELECT id FROM t",
+			"rewrite start SELECT id FROM t",
+			"audit end /* app */ SELECT id FROM t 01020304 err=false",
+			"rewrite end /* app */ SELECT id FROM t 01020304 err=false",
+		}, calls)
+	})
+
+	t.Run("statements rejected by an interceptor are not sent", func(t *testing.T) {
+		var statements, calls []string
+		db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+		require.NoError(t, c.Raw(func(dc any) error {
+			dc.(*conn).cfg.Interceptors = []interceptor.Interceptor{
+				&recordingInterceptor{name: "policy", calls: &calls, rewrite: func(s string) (string, error) {
+					if strings.HasPrefix(s, "DROP") {
+						return "", fmt.Errorf("DROP is not allowed")
+					}
+					return s, nil
+				}},
+			}
+			return nil
+		}))
+
+		_, err = c.ExecContext(context.Background(), "DROP TABLE t")
+		assert.EqualError(t, err, "statement rejected by an interceptor: DROP is not allowed")
+		assert.Empty(t, statements)
+		assert.Equal(t, []string{
+			"policy start DROP TABLE t",
+			"policy error statement rejected by an interceptor: DROP is not allowed",
+			"policy end DROP TABLE t  err=true",
+		}, calls)
+	})
+
+	t.Run("failed statements are reported", func(t *testing.T) {
+		var statements, calls []string
+		db := getStringsTestDB([]string{"id"}, nil, "42P01", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+		require.NoError(t, c.Raw(func(dc any) error {
+			dc.(*conn).cfg.Interceptors = []interceptor.Interceptor{&recordingInterceptor{name: "audit", calls: &calls}}
+			return nil
+		}))
+
+		_, err = c.ExecContext(context.Background(), "DELETE FROM missing")
+		require.Error(t, err)
+		require.Len(t, calls, 3)
+		assert.Equal(t, "audit error "+err.Error(), calls[1])
+		assert.Equal(t, "audit end DELETE FROM missing  err=true", calls[2])
+	})
+
+	t.Run("pages and fetch errors are reported", func(t *testing.T) {
+		var requests []*cli_service.TFetchResultsReq
+		var calls []string
+		cfg := config.WithDefaults()
+		cfg.Interceptors = []interceptor.Interceptor{&recordingInterceptor{name: "metrics", calls: &calls}}
+		testClient := getRowsTestCursorClient(15, &requests)
+		r := &rows{pageSize: 10, client: testClient, config: cfg, query: interceptor.Query{QueryID: "q1"}}
+		dest := make([]driver.Value, 1)
+		for r.Next(dest) == nil {
+		}
+		assert.Equal(t, []string{"metrics page q1 10", "metrics page q1 5"}, calls)
+
+		calls = nil
+		testClient.FnFetchResults = func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+			return nil, errors.New("connection reset")
+		}
+		r = &rows{pageSize: 10, client: testClient, config: cfg, query: interceptor.Query{QueryID: "q2"}}
+		assert.Error(t, r.Next(dest))
+		assert.Equal(t, []string{"metrics error connection reset"}, calls)
+	})
+}
@@ -0,0 +1,91 @@
+// Package interceptor defines the hooks the driver calls around the statements it
+// runs, e.g. for audit logging, query rewriting, metrics or policy enforcement.
+package interceptor
+
+import (
+	"context"
+	"time"
+)
+
+// Interceptor is called around the lifecycle of the statements run by the
+// connections of a connector. Its methods are called synchronously from the
+// goroutine that uses the connection. Embed Base to only implement some of them.
+type Interceptor interface {
+	// OnQueryStart is called before a statement is sent to the server. It returns
+	// the statement to run, which may be rewritten, or an error that rejects it.
+	OnQueryStart(ctx context.Context, query Query) (string, error)
+	// OnQueryEnd is called once the statement ran, or failed or was rejected. For
+	// queries it is called when the rows are returned, before they are read.
+	OnQueryEnd(ctx context.Context, query Query, end QueryEnd)
+	// OnFetchPage is called for every result page fetched from the server while
+	// the rows of a query are read.
+	OnFetchPage(ctx context.Context, query Query, page Page)
+	// OnError is called for every error returned for a statement, including the
+	// errors reading its rows.
+	OnError(ctx context.Context, query Query, err error)
+}
+
+// Query is a statement run by the driver.
+type Query struct {
+	// Statement is the text of the statement, with its parameters inlined when
+	// they are interpolated. OnQueryStart gets the statement as rewritten by the
+	// interceptors before it.
+	Statement string
+	// Exec is true for statements run with Exec, which return no rows
+	Exec bool
+	// QueryID is the id of the query, empty until it is sent to the server
+	QueryID string
+	// Catalog and Schema are the current namespace of the session the statement
+	// runs in, as set by the initial namespace of the connector and by the USE
+	// statements run on the connection. They are empty when unknown, e.g. when
+	// the server default applies.
+	Catalog string
+	Schema  string
+}
+
+// QueryEnd is the outcome of a statement.
+type QueryEnd struct {
+	// Duration is the time from the start of the statement
+	Duration time.Duration
+	// Err is the error of the statement, nil when it succeeded
+	Err error
+}
+
+// Page is a result page fetched from the server.
+type Page struct {
+	// Rows is the number of rows of the page
+	Rows int64
+	// Duration is the time the fetch took
+	Duration time.Duration
+}
+
+// RewriteFunc is an Interceptor that only rewrites statements, e.g. to qualify the
+// tables of a tenant with its catalog or to add row-level security predicates.
+type RewriteFunc func(ctx context.Context, query Query) (string, error)
+
+var _ Interceptor = RewriteFunc(nil)
+
+func (f RewriteFunc) OnQueryStart(ctx context.Context, query Query) (string, error) {
+	return f(ctx, query)
+}
+
+func (RewriteFunc) OnQueryEnd(ctx context.Context, query Query, end QueryEnd) {}
+
+func (RewriteFunc) OnFetchPage(ctx context.Context, query Query, page Page) {}
+
+func (RewriteFunc) OnError(ctx context.Context, query Query, err error) {}
+
+// Base implements Interceptor with methods that do nothing.
+type Base struct{}
+
+var _ Interceptor = Base{}
+
+func (Base) OnQueryStart(ctx context.Context, query Query) (string, error) {
+	return query.Statement, nil
+}
+
+func (Base) OnQueryEnd(ctx context.Context, query Query, end QueryEnd) {}
+
+func (Base) OnFetchPage(ctx context.Context, query Query, page Page) {}
+
+func (Base) OnError(ctx context.Context, query Query, err error) {}
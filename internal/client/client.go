@@ -5,10 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/databricks/databricks-sql-go/auth/pat"
 	"github.com/databricks/databricks-sql-go/driverctx"
 	"github.com/databricks/databricks-sql-go/internal/cli_service"
 	"github.com/databricks/databricks-sql-go/internal/config"
@@ -25,6 +29,13 @@ type ThriftServiceClient struct {
 	transport *Transport
 }
 
+// CloseIdleConnections closes the idle network connections kept by the http transport
+func (tsc *ThriftServiceClient) CloseIdleConnections() {
+	if tsc.transport != nil {
+		tsc.transport.CloseIdleConnections()
+	}
+}
+
 func (tsc *ThriftServiceClient) OpenSession(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
 	msg, start := logger.Track("OpenSession")
 	resp, err := tsc.TCLIServiceClient.OpenSession(ctx, req)
@@ -162,15 +173,55 @@ func (tsc *ThriftServiceClient) CancelOperation(ctx context.Context, req *cli_se
 // It is important to know the code and headers to know if we need to retry or not
 type Transport struct {
 	*http.Transport
-	response *http.Response
+	response      *http.Response
+	authenticator auth.Authenticator
+	retry         retryPolicy
+	// headers are set on every request, before the credentials
+	headers map[string]string
+	// hooks are called with every request, after the credentials are set
+	hooks []func(req *http.Request) error
 }
 
+// RoundTrip sends the request, and sends it again while it is throttled by the gateway
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
-	resp, err := t.Transport.RoundTrip(req)
+	resp, err := t.retry.roundTripRetried(req, t.roundTrip)
 	t.response = resp
 	return resp, err
 }
 
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.headers) > 0 || t.authenticator != nil || len(t.hooks) > 0 {
+		req = req.Clone(req.Context())
+	}
+	for k, v := range t.headers {
+		// the Host header is ignored by the http client, the host of the request is sent
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+		} else {
+			req.Header.Set(k, v)
+		}
+	}
+	if t.authenticator != nil {
+		// credentials are set for every request so expiring tokens are refreshed in time
+		if err := t.authenticator.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+	for _, hook := range t.hooks {
+		if err := hook(req); err != nil {
+			return nil, errors.Wrap(err, "databricks: request hook failed")
+		}
+	}
+	var resp *http.Response
+	var err error
+	if timings := timingsFromContext(req.Context()); timings != nil {
+		resp, err = roundTripTimed(t.Transport, req, timings)
+	} else {
+		resp, err = t.Transport.RoundTrip(req)
+	}
+	return resp, err
+}
+
 func InitThriftClient(cfg *config.Config) (*ThriftServiceClient, error) {
 	endpoint := cfg.ToEndpointURL()
 	tcfg := &thrift.TConfiguration{
@@ -203,9 +254,11 @@ func InitThriftClient(cfg *config.Config) (*ThriftServiceClient, error) {
 	switch cfg.ThriftTransport {
 	case "http":
 		tr = &Transport{
-			Transport: &http.Transport{
-				TLSClientConfig: cfg.TLSConfig,
-			},
+			Transport:     newHTTPTransport(cfg),
+			authenticator: cfg.Authenticator,
+			retry:         newRetryPolicy(cfg),
+			headers:       cfg.HTTPHeaders,
+			hooks:         cfg.RequestHooks,
 		}
 		httpclient := &http.Client{
 			Transport: tr,
@@ -232,7 +285,7 @@ func InitThriftClient(cfg *config.Config) (*ThriftServiceClient, error) {
 		return nil, err
 	}
 	if err = tTrans.Open(); err != nil {
-		return nil, errors.Wrapf(err, "failed to open http transport for endpoint %s", endpoint)
+		return nil, errors.Wrapf(err, "failed to open http transport for endpoint %s", logger.Redact(endpoint))
 	}
 	iprot := protocolFactory.GetProtocol(tTrans)
 	oprot := protocolFactory.GetProtocol(tTrans)
@@ -241,6 +294,51 @@ func InitThriftClient(cfg *config.Config) (*ThriftServiceClient, error) {
 	return tsClient, nil
 }
 
+// ErrInvalidHandle is returned when the server no longer recognizes the session or operation handle
+var ErrInvalidHandle = errors.New("thrift: invalid handle")
+
+// NewHTTPClient returns an http client for the workspace REST APIs that authenticates
+// requests with the configured authenticator, or with the access token if none is set
+func NewHTTPClient(cfg *config.Config) *http.Client {
+	authr := cfg.Authenticator
+	if authr == nil && cfg.AccessToken != "" {
+		authr = &pat.PATAuth{AccessToken: cfg.AccessToken}
+	}
+	return &http.Client{
+		Transport: &Transport{
+			Transport:     newHTTPTransport(cfg),
+			authenticator: authr,
+			retry:         newRetryPolicy(cfg),
+			headers:       cfg.HTTPHeaders,
+			hooks:         cfg.RequestHooks,
+		},
+		Timeout: cfg.ClientTimeout,
+	}
+}
+
+// newHTTPTransport returns an http transport that dials with the configured dialer,
+// restricted to the configured network, and keeps the network connections idle
+// between requests as configured
+func newHTTPTransport(cfg *config.Config) *http.Transport {
+	dial := cfg.Dialer
+	if dial == nil {
+		dial = (&net.Dialer{KeepAlive: cfg.KeepAlive}).DialContext
+	}
+	if network := cfg.Network; network != "" && network != "tcp" {
+		next := dial
+		dial = func(ctx context.Context, _ string, addr string) (net.Conn, error) {
+			return next(ctx, network, addr)
+		}
+	}
+	return &http.Transport{
+		TLSClientConfig:     cfg.TLSConfig,
+		DialContext:         dial,
+		DisableKeepAlives:   cfg.DisableKeepAlives,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+	}
+}
+
 // ThriftResponse respresents thrift rpc response
 type ThriftResponse interface {
 	GetStatus() *cli_service.TStatus
@@ -254,7 +352,7 @@ func CheckStatus(resp interface{}) error {
 			return errors.New(status.GetErrorMessage())
 		}
 		if status.StatusCode == cli_service.TStatusCode_INVALID_HANDLE_STATUS {
-			return errors.New("thrift: invalid handle")
+			return ErrInvalidHandle
 		}
 
 		// SUCCESS, SUCCESS_WITH_INFO, STILL_EXECUTING are ok
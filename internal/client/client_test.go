@@ -1,6 +1,22 @@
 package client
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/databricks/databricks-sql-go/internal/config"
+)
 
 func TestSprintByteId(t *testing.T) {
 	type args struct {
@@ -35,3 +51,190 @@ func TestSprintByteId(t *testing.T) {
 		})
 	}
 }
+
+func TestTransportAuthenticator(t *testing.T) {
+	var authHeaders []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	var n int
+	source := auth.TokenSourceFunc(func(ctx context.Context) (*auth.Token, error) {
+		n++
+		// tokens expire right away so each request gets a new one
+		return &auth.Token{AccessToken: fmt.Sprintf("token%d", n), Expiry: time.Now()}, nil
+	})
+	tr := &Transport{
+		Transport:     &http.Transport{},
+		authenticator: auth.NewTokenAuthenticator(source, time.Minute),
+	}
+	c := &http.Client{Transport: tr}
+	for i := 0; i < 2; i++ {
+		resp, err := c.Post(ts.URL, "application/x-thrift", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if !reflect.DeepEqual(authHeaders, []string{"Bearer token1", "Bearer token2"}) {
+		t.Errorf("Authorization headers = %v", authHeaders)
+	}
+}
+
+func TestTransportHeaders(t *testing.T) {
+	var hosts, orgIds, authHeaders []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		orgIds = append(orgIds, r.Header.Get("X-Databricks-Org-Id"))
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	cfg := config.WithDefaults()
+	cfg.AccessToken = "token"
+	cfg.HTTPHeaders = map[string]string{
+		"X-Databricks-Org-Id": "1234",
+		"Host":                "workspace.example.com",
+		"Authorization":       "Bearer overridden",
+	}
+	c := NewHTTPClient(cfg)
+	resp, err := c.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !reflect.DeepEqual(hosts, []string{"workspace.example.com"}) {
+		t.Errorf("hosts = %v", hosts)
+	}
+	if !reflect.DeepEqual(orgIds, []string{"1234"}) {
+		t.Errorf("X-Databricks-Org-Id headers = %v", orgIds)
+	}
+	if !reflect.DeepEqual(authHeaders, []string{"Bearer token"}) {
+		t.Errorf("Authorization headers = %v", authHeaders)
+	}
+}
+
+func TestTransportRequestHooks(t *testing.T) {
+	var signatures []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signatures = append(signatures, r.Header.Get("X-Signature"))
+	}))
+	defer ts.Close()
+
+	cfg := config.WithDefaults()
+	cfg.AccessToken = "token"
+	cfg.RequestHooks = []func(req *http.Request) error{
+		func(req *http.Request) error {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			b, err := io.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("X-Signature", req.Header.Get("Authorization")+"|"+string(b))
+			return nil
+		},
+		func(req *http.Request) error {
+			if req.Header.Get("X-Fail") != "" {
+				return errors.New("unsigned")
+			}
+			return nil
+		},
+	}
+	c := NewHTTPClient(cfg)
+	resp, err := c.Post(ts.URL, "application/x-thrift", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !reflect.DeepEqual(signatures, []string{"Bearer token|body"}) {
+		t.Errorf("X-Signature headers = %v", signatures)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Fail", "true")
+	if _, err := c.Do(req); err == nil || !strings.Contains(err.Error(), "databricks: request hook failed: unsigned") {
+		t.Errorf("Do() error = %v", err)
+	}
+}
+
+func TestNewHTTPTransportConnectionReuse(t *testing.T) {
+	cfg := config.WithDefaults()
+	tr := newHTTPTransport(cfg)
+	if tr.DisableKeepAlives || tr.IdleConnTimeout != 90*time.Second || tr.MaxIdleConnsPerHost != 0 {
+		t.Errorf("default transport = keep-alives disabled %v, idle timeout %v, max idle per host %d", tr.DisableKeepAlives, tr.IdleConnTimeout, tr.MaxIdleConnsPerHost)
+	}
+
+	cfg.DisableKeepAlives = true
+	cfg.MaxIdleConnsPerHost = 16
+	cfg.IdleConnTimeout = time.Minute
+	tr = newHTTPTransport(cfg)
+	if !tr.DisableKeepAlives || tr.IdleConnTimeout != time.Minute || tr.MaxIdleConnsPerHost != 16 {
+		t.Errorf("transport = keep-alives disabled %v, idle timeout %v, max idle per host %d", tr.DisableKeepAlives, tr.IdleConnTimeout, tr.MaxIdleConnsPerHost)
+	}
+
+	// requests reuse the network connection unless keep-alives are disabled
+	for _, disable := range []bool{false, true} {
+		var conns int32
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt32(&conns, 1)
+			}
+		}
+		ts.Start()
+		cfg := config.WithDefaults()
+		cfg.DisableKeepAlives = disable
+		httpClient := &http.Client{Transport: newHTTPTransport(cfg)}
+		for i := 0; i < 3; i++ {
+			resp, err := httpClient.Get(ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}
+		ts.Close()
+		want := int32(1)
+		if disable {
+			want = 3
+		}
+		if got := atomic.LoadInt32(&conns); got != want {
+			t.Errorf("keep-alives disabled %v: %d connections, want %d", disable, got, want)
+		}
+	}
+}
+
+func TestNewHTTPTransportDialer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var networks []string
+	cfg := config.WithDefaults()
+	cfg.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		networks = append(networks, network)
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	httpClient := &http.Client{Transport: newHTTPTransport(cfg)}
+	resp, err := httpClient.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// the test server listens on an IPv4 address
+	cfg.Network = "tcp6"
+	httpClient = &http.Client{Transport: newHTTPTransport(cfg)}
+	if _, err = httpClient.Get(ts.URL); err == nil {
+		t.Error("expected an IPv4 address to be rejected when dialing IPv6 only")
+	}
+
+	if !reflect.DeepEqual(networks, []string{"tcp", "tcp6"}) {
+		t.Errorf("dialed networks = %v, want [tcp tcp6]", networks)
+	}
+}
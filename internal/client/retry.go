@@ -0,0 +1,135 @@
+package client
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/databricks/databricks-sql-go/logger"
+	"github.com/databricks/databricks-sql-go/metrics"
+	"github.com/pkg/errors"
+)
+
+// ErrThrottled is returned when the requests are still rate limited by the gateway,
+// with 429 Too Many Requests or 503 Service Unavailable, once the retries are used up
+// or the server asks to wait longer than the max retry wait.
+var ErrThrottled = errors.New("databricks: request throttled")
+
+// retryPolicy tells how throttled requests are retried
+type retryPolicy struct {
+	// max is the number of retries, zero disables them
+	max     int
+	waitMin time.Duration
+	waitMax time.Duration
+	metrics metrics.Collector
+}
+
+func newRetryPolicy(cfg *config.Config) retryPolicy {
+	return retryPolicy{
+		max:     cfg.RetryMax,
+		waitMin: cfg.RetryWaitMin,
+		waitMax: cfg.RetryWaitMax,
+		metrics: cfg.Metrics,
+	}
+}
+
+func isThrottled(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// roundTripRetried sends req with roundTrip, and sends it again while the response is
+// throttled. It waits for the time asked by the Retry-After header of the response, or
+// backs off exponentially from the min retry wait, with jitter so that the clients
+// throttled together do not all come back at the same time.
+func (p retryPolicy) roundTripRetried(req *http.Request, roundTrip func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	ctx := req.Context()
+	log := logger.WithContext(driverctx.ConnIdFromContext(ctx), driverctx.CorrelationIdFromContext(ctx), "")
+	for attempt := 1; ; attempt++ {
+		resp, err := roundTrip(req)
+		if err != nil || !isThrottled(resp) {
+			return resp, err
+		}
+
+		retryAfter, hasRetryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		event := metrics.Throttle{StatusCode: resp.StatusCode, Attempt: attempt, RetryAfter: retryAfter}
+		// requests whose body can't be sent again are not retried
+		canRetry := attempt <= p.max && (req.Body == nil || req.GetBody != nil) &&
+			(!hasRetryAfter || retryAfter <= p.waitMax)
+		if canRetry {
+			event.Wait = p.wait(attempt, retryAfter)
+		}
+		if collector, ok := p.metrics.(metrics.ThrottleCollector); ok {
+			collector.Throttled(ctx, event)
+		}
+
+		// the body is drained so the network connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		if !canRetry {
+			log.Warn().Msgf("databricks: request throttled with HTTP %d after %d attempts, retry after %v", resp.StatusCode, attempt, retryAfter)
+			return nil, errors.Wrapf(ErrThrottled, "HTTP %d after %d attempts, retry after %v", resp.StatusCode, attempt, retryAfter)
+		}
+		log.Warn().Msgf("databricks: request throttled with HTTP %d, retrying in %v", resp.StatusCode, event.Wait)
+
+		timer := time.NewTimer(event.Wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// wait returns the time to wait before the retry of the attempt: at least the time
+// asked by the server, or the exponential backoff for the attempt, plus jitter
+func (p retryPolicy) wait(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter + time.Duration(rand.Int63n(int64(retryAfter)/10+1))
+	}
+	backoff := p.waitMin
+	for i := 1; i < attempt && backoff < p.waitMax; i++ {
+		backoff *= 2
+	}
+	if backoff > p.waitMax {
+		backoff = p.waitMax
+	}
+	// half of the backoff is random
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff)/2+1))
+}
+
+// parseRetryAfter returns the wait asked by a Retry-After header, given either in
+// seconds or as an http date, and whether the header was valid
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/metrics"
+)
+
+type throttleRecorder struct {
+	mu        sync.Mutex
+	throttles []metrics.Throttle
+}
+
+func (r *throttleRecorder) PageFetched(ctx context.Context, page metrics.PageFetch) {}
+
+func (r *throttleRecorder) Throttled(ctx context.Context, throttle metrics.Throttle) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.throttles = append(r.throttles, throttle)
+}
+
+func TestTransportRetries(t *testing.T) {
+	// newServer returns a server that throttles the first n requests with status and
+	// the Retry-After header, and records the bodies of all requests
+	newServer := func(n int, status int, retryAfter string, bodies *[]string) *httptest.Server {
+		var mu sync.Mutex
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			body, _ := io.ReadAll(r.Body)
+			*bodies = append(*bodies, string(body))
+			if len(*bodies) <= n {
+				if retryAfter != "" {
+					w.Header().Set("Retry-After", retryAfter)
+				}
+				w.WriteHeader(status)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		}))
+	}
+	post := func(c *http.Client, url string) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString("request"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Do(req)
+	}
+
+	t.Run("retries throttled requests with their body", func(t *testing.T) {
+		for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+			var bodies []string
+			ts := newServer(2, status, "", &bodies)
+			recorder := &throttleRecorder{}
+			c := &http.Client{Transport: &Transport{
+				Transport: &http.Transport{},
+				retry:     retryPolicy{max: 4, waitMin: time.Millisecond, waitMax: 10 * time.Millisecond, metrics: recorder},
+			}}
+
+			resp, err := post(c, ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			ts.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+			}
+			if want := []string{"request", "request", "request"}; !reflect.DeepEqual(bodies, want) {
+				t.Errorf("bodies = %v, want %v", bodies, want)
+			}
+			if len(recorder.throttles) != 2 {
+				t.Fatalf("throttles = %v, want 2", recorder.throttles)
+			}
+			for i, throttle := range recorder.throttles {
+				if throttle.StatusCode != status || throttle.Attempt != i+1 || throttle.Wait <= 0 {
+					t.Errorf("throttle %d = %+v", i, throttle)
+				}
+			}
+		}
+	})
+
+	t.Run("honors Retry-After", func(t *testing.T) {
+		var bodies []string
+		ts := newServer(1, http.StatusTooManyRequests, "1", &bodies)
+		defer ts.Close()
+		recorder := &throttleRecorder{}
+		c := &http.Client{Transport: &Transport{
+			Transport: &http.Transport{},
+			retry:     retryPolicy{max: 4, waitMin: time.Millisecond, waitMax: 2 * time.Second, metrics: recorder},
+		}}
+
+		start := time.Now()
+		resp, err := post(c, ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("retried after %v, want at least 1s", elapsed)
+		}
+		if len(recorder.throttles) != 1 || recorder.throttles[0].RetryAfter != time.Second {
+			t.Errorf("throttles = %+v", recorder.throttles)
+		}
+	})
+
+	t.Run("gives up after the retries", func(t *testing.T) {
+		var bodies []string
+		ts := newServer(10, http.StatusServiceUnavailable, "", &bodies)
+		defer ts.Close()
+		recorder := &throttleRecorder{}
+		c := &http.Client{Transport: &Transport{
+			Transport: &http.Transport{},
+			retry:     retryPolicy{max: 2, waitMin: time.Millisecond, waitMax: time.Millisecond, metrics: recorder},
+		}}
+
+		_, err := post(c, ts.URL)
+		if !errors.Is(err, ErrThrottled) {
+			t.Errorf("err = %v, want ErrThrottled", err)
+		}
+		if len(bodies) != 3 {
+			t.Errorf("requests = %d, want 3", len(bodies))
+		}
+		if last := recorder.throttles[len(recorder.throttles)-1]; last.Attempt != 3 || last.Wait != 0 {
+			t.Errorf("last throttle = %+v", last)
+		}
+	})
+
+	t.Run("gives up when asked to wait longer than the max wait", func(t *testing.T) {
+		var bodies []string
+		ts := newServer(10, http.StatusTooManyRequests, "120", &bodies)
+		defer ts.Close()
+		c := &http.Client{Transport: &Transport{
+			Transport: &http.Transport{},
+			retry:     retryPolicy{max: 4, waitMin: time.Millisecond, waitMax: time.Second},
+		}}
+
+		_, err := post(c, ts.URL)
+		if !errors.Is(err, ErrThrottled) {
+			t.Errorf("err = %v, want ErrThrottled", err)
+		}
+		if len(bodies) != 1 {
+			t.Errorf("requests = %d, want 1", len(bodies))
+		}
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		var bodies []string
+		ts := newServer(10, http.StatusTooManyRequests, "1", &bodies)
+		defer ts.Close()
+		c := &http.Client{Transport: &Transport{
+			Transport: &http.Transport{},
+			retry:     retryPolicy{max: 4, waitMin: time.Millisecond, waitMax: time.Minute},
+		}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL, bytes.NewBufferString("request"))
+		_, err := c.Do(req)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("err = %v, want context.DeadlineExceeded", err)
+		}
+	})
+
+	t.Run("does not retry when disabled", func(t *testing.T) {
+		var bodies []string
+		ts := newServer(1, http.StatusTooManyRequests, "", &bodies)
+		defer ts.Close()
+		c := &http.Client{Transport: &Transport{Transport: &http.Transport{}}}
+
+		_, err := post(c, ts.URL)
+		if !errors.Is(err, ErrThrottled) {
+			t.Errorf("err = %v, want ErrThrottled", err)
+		}
+		if len(bodies) != 1 {
+			t.Errorf("requests = %d, want 1", len(bodies))
+		}
+	})
+}
+
+func TestRetryPolicyWait(t *testing.T) {
+	p := retryPolicy{waitMin: 100 * time.Millisecond, waitMax: time.Second}
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		wait := p.wait(attempt, 0)
+		if wait < max/2 || wait > max {
+			t.Errorf("wait(%d) = %v, want between %v and %v", attempt, wait, max/2, max)
+		}
+	}
+	if wait := p.wait(1, 2*time.Second); wait < 2*time.Second || wait > 2200*time.Millisecond {
+		t.Errorf("wait with Retry-After = %v, want between 2s and 2.2s", wait)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		header string
+		wait   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second, true},
+		{"Mon, 01 Jan 2024 11:59:00 GMT", 0, true},
+	}
+	for _, c := range cases {
+		wait, ok := parseRetryAfter(c.header, now)
+		if wait != c.wait || ok != c.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", c.header, wait, ok, c.wait, c.ok)
+		}
+	}
+}
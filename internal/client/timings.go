@@ -0,0 +1,109 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type timingsContextKey struct{}
+
+// Timings collects the time spent in the phases of the http requests made with
+// a context returned by NewContextWithTimings.
+type Timings struct {
+	// Wait is the time from sending the request until the response headers arrived
+	Wait time.Duration
+	// Transfer is the time spent reading the response body from the network
+	Transfer time.Duration
+	// Decompress is the time spent decompressing gzip encoded response bodies
+	Decompress time.Duration
+	// Bytes is the size of the response bodies as received
+	Bytes int64
+}
+
+// NewContextWithTimings returns a context that makes the transport add the timings
+// of the requests made with it to t. t must not be shared by concurrent requests.
+func NewContextWithTimings(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, timingsContextKey{}, t)
+}
+
+func timingsFromContext(ctx context.Context) *Timings {
+	t, _ := ctx.Value(timingsContextKey{}).(*Timings)
+	return t
+}
+
+// roundTripTimed sends req with next and measures the phases of the response into t.
+// Compressed responses are requested and decompressed here rather than by the http
+// transport, so that decompression is not counted as transfer time.
+func roundTripTimed(next http.RoundTripper, req *http.Request, t *Timings) (*http.Response, error) {
+	decompress := false
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip")
+		decompress = true
+	}
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	t.Wait += time.Since(start)
+	if err != nil {
+		return resp, err
+	}
+
+	body := &timedReader{r: resp.Body, t: t}
+	resp.Body = body
+	if decompress && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		resp.Body = &gzipReader{body: body, t: t}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	return resp, nil
+}
+
+// timedReader adds the time spent reading r and the bytes read to the transfer timings
+type timedReader struct {
+	r io.ReadCloser
+	t *Timings
+}
+
+func (tr *timedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := tr.r.Read(p)
+	tr.t.Transfer += time.Since(start)
+	tr.t.Bytes += int64(n)
+	return n, err
+}
+
+func (tr *timedReader) Close() error {
+	return tr.r.Close()
+}
+
+// gzipReader decompresses body, adding the time spent that was not spent reading
+// body to the decompression timings. The gzip reader is created on the first read
+// so that no body is read before the caller asks for it.
+type gzipReader struct {
+	body *timedReader
+	zr   *gzip.Reader
+	t    *Timings
+}
+
+func (gr *gzipReader) Read(p []byte) (n int, err error) {
+	start, transfer := time.Now(), gr.t.Transfer
+	defer func() {
+		gr.t.Decompress += time.Since(start) - (gr.t.Transfer - transfer)
+	}()
+	if gr.zr == nil {
+		if gr.zr, err = gzip.NewReader(gr.body); err != nil {
+			return 0, err
+		}
+	}
+	return gr.zr.Read(p)
+}
+
+func (gr *gzipReader) Close() error {
+	return gr.body.Close()
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransportTimings(t *testing.T) {
+	payload := bytes.Repeat([]byte("databricks"), 1000)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(payload)
+	_ = zw.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		if r.Header.Get("Accept-Encoding") == "gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(compressed.Bytes())
+			return
+		}
+		_, _ = w.Write(payload)
+	}))
+	defer ts.Close()
+	c := &http.Client{Transport: &Transport{Transport: &http.Transport{}}}
+
+	get := func(t *testing.T, ctx context.Context) []byte {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	t.Run("measures compressed responses", func(t *testing.T) {
+		var timings Timings
+		body := get(t, NewContextWithTimings(context.Background(), &timings))
+		if !bytes.Equal(body, payload) {
+			t.Errorf("body was not decompressed")
+		}
+		if timings.Wait < 20*time.Millisecond {
+			t.Errorf("Wait = %v, want at least 20ms", timings.Wait)
+		}
+		if timings.Bytes != int64(compressed.Len()) {
+			t.Errorf("Bytes = %d, want %d", timings.Bytes, compressed.Len())
+		}
+	})
+
+	t.Run("leaves requests without timings alone", func(t *testing.T) {
+		body := get(t, context.Background())
+		if !bytes.Equal(body, payload) {
+			t.Errorf("body was not decompressed")
+		}
+	})
+}
@@ -1,15 +1,21 @@
 package config
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/databricks/databricks-sql-go/interceptor"
 	"github.com/databricks/databricks-sql-go/internal/cli_service"
 	"github.com/databricks/databricks-sql-go/logger"
+	"github.com/databricks/databricks-sql-go/metrics"
 	"github.com/pkg/errors"
 )
 
@@ -17,14 +23,42 @@ import (
 // Only UserConfig are currently exposed to users
 type Config struct {
 	UserConfig
-	TLSConfig     *tls.Config // nil disables TLS
-	Authenticator string      //TODO for oauth
+	TLSConfig     *tls.Config        // nil disables TLS
+	Authenticator auth.Authenticator // nil uses the access token from UserConfig
+	// Dialer opens the network connections to the workspace. nil uses a net.Dialer
+	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Metrics receives the measurements of the driver. nil disables them
+	Metrics metrics.Collector
+	// Interceptors are called in order around the statements
+	Interceptors []interceptor.Interceptor
+	// Converters convert the values of the columns of a type, by type name, e.g. DECIMAL,
+	// instead of the default conversion
+	Converters map[string]func(value any) (any, error)
+	// RequestHooks are called in order with every request, once its credentials
+	// are set, e.g. to sign it for a gateway
+	RequestHooks []func(req *http.Request) error
 
 	RunAsync                  bool // TODO
 	PollInterval              time.Duration
+	PollMaxInterval           time.Duration // max time between query status checks, which back off from PollInterval
+	PollBackoffMultiplier     float64
 	ConnectTimeout            time.Duration // max time to open session
 	ClientTimeout             time.Duration // max time the http request can last
 	PingTimeout               time.Duration //max time allowed for ping
+	CloseOperationTimeout     time.Duration // max time to close an operation when rows are closed
+	ExecuteTimeout            time.Duration // max time of the request submitting a statement, zero uses QueryTimeout
+	PollTimeout               time.Duration // max time polling the status of a statement, zero means no limit
+	FetchTimeout              time.Duration // max time of each request fetching results, zero means no limit
+	RetryMax                  int           // max retries of requests throttled with 429 or 503, zero disables them
+	RetryWaitMin              time.Duration // min time between the retries of throttled requests, which back off to RetryWaitMax
+	RetryWaitMax              time.Duration // max time between the retries of throttled requests
+	DisableKeepAlives         bool          // opens a new network connection for every request
+	KeepAlive                 time.Duration // period of the TCP keep-alive probes, zero uses 15 seconds and negative disables them
+	MaxIdleConnsPerHost       int           // max idle network connections kept per connection, zero uses 2
+	IdleConnTimeout           time.Duration // time after which idle network connections are closed, zero means no limit
+	TLSSessionCacheSize       int           // TLS sessions cached for resumption by the connections of a connector, zero disables it
+	SchemaCacheSize           int           // result schemas cached by statement by the connections of a connector, zero disables it
+	SchemaCacheTTL            time.Duration // time after which a cached result schema is fetched again, zero means no limit
 	CanUseMultipleCatalogs    bool
 	DriverName                string
 	DriverVersion             string
@@ -39,10 +73,15 @@ func (c *Config) ToEndpointURL() string {
 	if c.AccessToken != "" {
 		userInfo = fmt.Sprintf("%s:%s@", "token", url.QueryEscape(c.AccessToken))
 	}
-	endpointUrl := fmt.Sprintf("%s://%s%s:%d%s", c.Protocol, userInfo, c.Host, c.Port, c.HTTPPath)
+	endpointUrl := fmt.Sprintf("%s://%s%s:%d%s%s", c.Protocol, userInfo, c.Host, c.Port, c.PathPrefix, c.HTTPPath)
 	return endpointUrl
 }
 
+// ToWorkspaceURL returns the base url of the workspace REST APIs
+func (c *Config) ToWorkspaceURL() string {
+	return fmt.Sprintf("%s://%s:%d%s", c.Protocol, c.Host, c.Port, c.PathPrefix)
+}
+
 func (c *Config) DeepCopy() *Config {
 	if c == nil {
 		return nil
@@ -52,12 +91,33 @@ func (c *Config) DeepCopy() *Config {
 		UserConfig:    c.UserConfig.DeepCopy(),
 		TLSConfig:     c.TLSConfig.Clone(),
 		Authenticator: c.Authenticator,
+		Dialer:        c.Dialer,
+		Metrics:       c.Metrics,
+		Converters:    copyConverters(c.Converters),
+		Interceptors:  copyInterceptors(c.Interceptors),
+		RequestHooks:  copyRequestHooks(c.RequestHooks),
 
 		RunAsync:                  c.RunAsync,
 		PollInterval:              c.PollInterval,
+		PollMaxInterval:           c.PollMaxInterval,
+		PollBackoffMultiplier:     c.PollBackoffMultiplier,
 		ConnectTimeout:            c.ConnectTimeout,
 		ClientTimeout:             c.ClientTimeout,
 		PingTimeout:               c.PingTimeout,
+		CloseOperationTimeout:     c.CloseOperationTimeout,
+		ExecuteTimeout:            c.ExecuteTimeout,
+		PollTimeout:               c.PollTimeout,
+		FetchTimeout:              c.FetchTimeout,
+		RetryMax:                  c.RetryMax,
+		RetryWaitMin:              c.RetryWaitMin,
+		RetryWaitMax:              c.RetryWaitMax,
+		DisableKeepAlives:         c.DisableKeepAlives,
+		KeepAlive:                 c.KeepAlive,
+		MaxIdleConnsPerHost:       c.MaxIdleConnsPerHost,
+		IdleConnTimeout:           c.IdleConnTimeout,
+		TLSSessionCacheSize:       c.TLSSessionCacheSize,
+		SchemaCacheSize:           c.SchemaCacheSize,
+		SchemaCacheTTL:            c.SchemaCacheTTL,
 		CanUseMultipleCatalogs:    c.CanUseMultipleCatalogs,
 		DriverName:                c.DriverName,
 		DriverVersion:             c.DriverVersion,
@@ -68,6 +128,10 @@ func (c *Config) DeepCopy() *Config {
 	}
 }
 
+// OrgIDHeader is the header of the id of the workspace, which some gateways, e.g.
+// of Azure Private Link, require to route the requests
+const OrgIDHeader = "X-Databricks-Org-Id"
+
 // UserConfig is the set of configurations exposed to users
 type UserConfig struct {
 	Protocol       string
@@ -82,6 +146,82 @@ type UserConfig struct {
 	UserAgentEntry string
 	Location       *time.Location
 	SessionParams  map[string]string
+	// SessionPreset is the name of the registered preset of session parameters set
+	// on the sessions before SessionParams
+	SessionPreset string
+	// QueryTagComment prepends the statement tag from the context as a SQL comment
+	QueryTagComment bool
+	// StatementComment holds the key-value pairs prepended to every statement as a
+	// sqlcommenter-style comment, e.g. the application name
+	StatementComment map[string]string
+	// SessionMaxAge is the max time a session is handed out by the connection pool. Zero means no limit
+	SessionMaxAge time.Duration
+	// SessionIdleTimeout is the time after which the session of a connection left idle in
+	// the connection pool is closed. Zero means sessions are kept open
+	SessionIdleTimeout time.Duration
+	// DisableTimeParsing returns DATE and TIMESTAMP values as the strings sent by the server
+	DisableTimeParsing bool
+	// StrictTimeParsing fails on DATE and TIMESTAMP values that do not match the
+	// default layout, instead of returning them as strings
+	StrictTimeParsing bool
+	// RawStrings returns every value as its string representation, without conversion
+	RawStrings bool
+	// TimestampLayouts and DateLayouts are the time.Parse layouts tried in order for
+	// TIMESTAMP and DATE values. The JDBC compliant layouts are used when empty
+	TimestampLayouts []string
+	DateLayouts      []string
+	// AsyncClose closes the server operation in the background when rows are closed
+	AsyncClose bool
+	// LeakDetection records where rows and statements are created, and reports those
+	// garbage collected without Close
+	LeakDetection bool
+	// PanicOnLeak panics when a leak is detected, instead of logging it
+	PanicOnLeak bool
+	// ImpersonationUser is the user the sessions run their statements as, when the
+	// workspace allows the authenticated principal to impersonate users
+	ImpersonationUser string
+	// InterpolateParams binds query arguments by inlining them as SQL literals
+	InterpolateParams bool
+	// MaxConcurrentFetches limits the result pages fetched at the same time by the
+	// connections of a connector. Zero means no limit
+	MaxConcurrentFetches int
+	// Network is the network dialed: tcp4 for IPv4 only, tcp6 for IPv6 only, or tcp for either
+	Network string
+	// IPVersion is the IP version set with WithIPVersion, kept to reject versions other
+	// than 4 and 6. Zero means either
+	IPVersion int
+	// DefaultLimit is appended as a LIMIT clause to queries that have none. Zero means no limit
+	DefaultLimit int
+	// MaxConcurrentStatements limits the statements run at the same time by the
+	// connections of a connector, the others are queued. Zero means no limit
+	MaxConcurrentStatements int
+	// Insecure allows plain http to hosts other than localhost, e.g. to local
+	// proxies, emulators and capture tools
+	Insecure bool
+	// StatementTimeout is set as the STATEMENT_TIMEOUT of the sessions of SQL
+	// warehouses, which cancel longer statements. Zero keeps the warehouse setting
+	StatementTimeout time.Duration
+	// DisableResultCache sets use_cached_result to false on the sessions of SQL
+	// warehouses, so that statements always run
+	DisableResultCache bool
+	// ResultByteLimit is the max bytes of the results returned with the response to
+	// a statement, the rest being fetched. Zero means the server default
+	ResultByteLimit int64
+	// MaxResultMemory limits the bytes held by the results of a connection. Zero
+	// means no limit
+	MaxResultMemory int64
+	// PathPrefix is the base path the workspace is served under, e.g. by a reverse
+	// proxy, prepended to the http path and to the paths of the workspace REST APIs
+	PathPrefix string
+	// TargetPageBytes adapts the rows fetched per request to the width of the rows,
+	// for pages of about this size. Zero always fetches MaxRows
+	TargetPageBytes int64
+	// TargetPageLatency adapts the rows fetched per request to the latency of the
+	// fetches, for pages fetched in about this time. Zero disables it
+	TargetPageLatency time.Duration
+	// HTTPHeaders are set on every request, e.g. the org id headers required by some
+	// gateways. A Host header sets the host of the requests
+	HTTPHeaders map[string]string
 }
 
 func (ucfg UserConfig) DeepCopy() UserConfig {
@@ -115,7 +255,86 @@ func (ucfg UserConfig) DeepCopy() UserConfig {
 		UserAgentEntry: ucfg.UserAgentEntry,
 		Location:       loccp,
 		SessionParams:  sessionParams,
+		SessionPreset:  ucfg.SessionPreset,
+
+		QueryTagComment:    ucfg.QueryTagComment,
+		StatementComment:   copyStringMap(ucfg.StatementComment),
+		SessionMaxAge:      ucfg.SessionMaxAge,
+		SessionIdleTimeout: ucfg.SessionIdleTimeout,
+
+		DisableTimeParsing: ucfg.DisableTimeParsing,
+		StrictTimeParsing:  ucfg.StrictTimeParsing,
+		RawStrings:         ucfg.RawStrings,
+		TimestampLayouts:   copyStrings(ucfg.TimestampLayouts),
+		DateLayouts:        copyStrings(ucfg.DateLayouts),
+		AsyncClose:         ucfg.AsyncClose,
+		InterpolateParams:  ucfg.InterpolateParams,
+		LeakDetection:      ucfg.LeakDetection,
+		PanicOnLeak:        ucfg.PanicOnLeak,
+		ImpersonationUser:  ucfg.ImpersonationUser,
+
+		MaxConcurrentFetches: ucfg.MaxConcurrentFetches,
+		Network:              ucfg.Network,
+		IPVersion:            ucfg.IPVersion,
+		DefaultLimit:         ucfg.DefaultLimit,
+
+		MaxConcurrentStatements: ucfg.MaxConcurrentStatements,
+		Insecure:                ucfg.Insecure,
+
+		StatementTimeout:   ucfg.StatementTimeout,
+		DisableResultCache: ucfg.DisableResultCache,
+		ResultByteLimit:    ucfg.ResultByteLimit,
+		MaxResultMemory:    ucfg.MaxResultMemory,
+
+		TargetPageBytes:   ucfg.TargetPageBytes,
+		TargetPageLatency: ucfg.TargetPageLatency,
+
+		PathPrefix:  ucfg.PathPrefix,
+		HTTPHeaders: copyStringMap(ucfg.HTTPHeaders),
+	}
+}
+
+func copyConverters(converters map[string]func(value any) (any, error)) map[string]func(value any) (any, error) {
+	if converters == nil {
+		return nil
+	}
+	cp := make(map[string]func(value any) (any, error), len(converters))
+	for k, v := range converters {
+		cp[k] = v
+	}
+	return cp
+}
+
+func copyInterceptors(interceptors []interceptor.Interceptor) []interceptor.Interceptor {
+	if interceptors == nil {
+		return nil
+	}
+	return append([]interceptor.Interceptor{}, interceptors...)
+}
+
+func copyRequestHooks(hooks []func(req *http.Request) error) []func(req *http.Request) error {
+	if hooks == nil {
+		return nil
+	}
+	return append([]func(req *http.Request) error{}, hooks...)
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[string]string, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
 	}
+	return append([]string{}, s...)
 }
 
 func (ucfg UserConfig) WithDefaults() UserConfig {
@@ -125,6 +344,9 @@ func (ucfg UserConfig) WithDefaults() UserConfig {
 	if ucfg.Protocol == "" {
 		ucfg.Protocol = "https"
 	}
+	if ucfg.Port == 0 {
+		ucfg.Port = 443
+	}
 	ucfg.SessionParams = make(map[string]string)
 	return ucfg
 }
@@ -133,12 +355,20 @@ func WithDefaults() *Config {
 	return &Config{
 		UserConfig:                UserConfig{}.WithDefaults(),
 		TLSConfig:                 &tls.Config{MinVersion: tls.VersionTLS12},
-		Authenticator:             "",
+		Authenticator:             nil,
 		RunAsync:                  true,
 		PollInterval:              1 * time.Second,
+		PollMaxInterval:           5 * time.Second,
+		PollBackoffMultiplier:     1.5,
 		ConnectTimeout:            60 * time.Second,
 		ClientTimeout:             900 * time.Second,
 		PingTimeout:               15 * time.Second,
+		CloseOperationTimeout:     15 * time.Second,
+		RetryMax:                  4,
+		RetryWaitMin:              1 * time.Second,
+		RetryWaitMax:              30 * time.Second,
+		IdleConnTimeout:           90 * time.Second,
+		TLSSessionCacheSize:       64,
 		CanUseMultipleCatalogs:    true,
 		DriverName:                "godatabrickssqlconnector", //important. Do not change
 		DriverVersion:             "0.9.0",
@@ -150,6 +380,122 @@ func WithDefaults() *Config {
 
 }
 
+// Validate returns an error listing the settings that are missing or out of range,
+// so that a misconfigured connector fails when it is created instead of on connect.
+// Credentials are not required with plain http, which is only used by local test
+// servers and proxies.
+func (c *Config) Validate() error {
+	var problems []string
+	if c.Host == "" {
+		problems = append(problems, "host is not set")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("port %d is not between 1 and 65535", c.Port))
+	}
+	if c.Protocol != "https" && c.Protocol != "http" {
+		problems = append(problems, fmt.Sprintf("protocol %q is not https or http", c.Protocol))
+	}
+	if c.Protocol == "http" && !c.Insecure && !isLoopback(c.Host) {
+		problems = append(problems, fmt.Sprintf("plain http to host %q requires the insecure flag", c.Host))
+	}
+	if c.HTTPPath != "" && !strings.HasPrefix(c.HTTPPath, "/") {
+		problems = append(problems, fmt.Sprintf("http path %q does not start with /", c.HTTPPath))
+	}
+	if c.PathPrefix != "" && (!strings.HasPrefix(c.PathPrefix, "/") || strings.HasSuffix(c.PathPrefix, "/")) {
+		problems = append(problems, fmt.Sprintf("path prefix %q does not start with / or has a trailing /", c.PathPrefix))
+	}
+	if c.MaxRows <= 0 {
+		problems = append(problems, fmt.Sprintf("max rows %d is not positive", c.MaxRows))
+	}
+	if c.IPVersion != 0 && c.IPVersion != 4 && c.IPVersion != 6 {
+		problems = append(problems, fmt.Sprintf("ip version %d is not 4 or 6", c.IPVersion))
+	}
+	if c.Location == time.Local {
+		problems = append(problems, "time zone is time.Local, use a time zone name")
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"query timeout", c.QueryTimeout},
+		{"connect timeout", c.ConnectTimeout},
+		{"client timeout", c.ClientTimeout},
+		{"ping timeout", c.PingTimeout},
+		{"close operation timeout", c.CloseOperationTimeout},
+		{"execute timeout", c.ExecuteTimeout},
+		{"poll timeout", c.PollTimeout},
+		{"fetch timeout", c.FetchTimeout},
+		{"session max age", c.SessionMaxAge},
+		{"session idle timeout", c.SessionIdleTimeout},
+		{"idle connection timeout", c.IdleConnTimeout},
+		{"schema cache ttl", c.SchemaCacheTTL},
+		{"statement timeout", c.StatementTimeout},
+		{"target page latency", c.TargetPageLatency},
+	} {
+		if d.value < 0 {
+			problems = append(problems, fmt.Sprintf("%s %v is negative", d.name, d.value))
+		}
+	}
+	for _, n := range []struct {
+		name  string
+		value int
+	}{
+		{"max concurrent fetches", c.MaxConcurrentFetches},
+		{"max concurrent statements", c.MaxConcurrentStatements},
+		{"default limit", c.DefaultLimit},
+		{"max retries", c.RetryMax},
+		{"max idle connections per host", c.MaxIdleConnsPerHost},
+		{"tls session cache size", c.TLSSessionCacheSize},
+		{"schema cache size", c.SchemaCacheSize},
+	} {
+		if n.value < 0 {
+			problems = append(problems, fmt.Sprintf("%s %d is negative", n.name, n.value))
+		}
+	}
+	if c.ResultByteLimit < 0 {
+		problems = append(problems, fmt.Sprintf("result byte limit %d is negative", c.ResultByteLimit))
+	}
+	if c.MaxResultMemory < 0 {
+		problems = append(problems, fmt.Sprintf("max result memory %d is negative", c.MaxResultMemory))
+	}
+	if c.TargetPageBytes < 0 {
+		problems = append(problems, fmt.Sprintf("target page bytes %d is negative", c.TargetPageBytes))
+	}
+	if IsClusterPath(c.HTTPPath) {
+		// these are SQL configuration parameters, which clusters reject
+		for _, w := range []struct {
+			name string
+			set  bool
+		}{
+			{"statement timeout", c.StatementTimeout > 0},
+			{"disabling the result cache", c.DisableResultCache},
+		} {
+			if w.set {
+				problems = append(problems, fmt.Sprintf("%s only applies to SQL warehouses, http path %q is a cluster", w.name, c.HTTPPath))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// IsClusterPath returns true if the HTTP path is the one of an all-purpose cluster,
+// e.g. /sql/protocolv1/o/123/0123-456789-abcdef, rather than a SQL warehouse
+func IsClusterPath(path string) bool {
+	return strings.HasPrefix(path, "/sql/protocolv1/")
+}
+
+// isLoopback returns true for localhost and the loopback addresses
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func ParseDSN(dsn string) (UserConfig, error) {
 	fullDSN := dsn
 	if !strings.HasPrefix(dsn, "https://") && !strings.HasPrefix(dsn, "http://") {
@@ -157,7 +503,8 @@ func ParseDSN(dsn string) (UserConfig, error) {
 	}
 	parsedURL, err := url.Parse(fullDSN)
 	if err != nil {
-		return UserConfig{}, errors.Wrap(err, "invalid DSN: invalid format")
+		// the parse error quotes the DSN, which holds the access token
+		return UserConfig{}, errors.Errorf("invalid DSN: invalid format: %s", logger.Redact(err.Error()))
 	}
 	ucfg := UserConfig{}.WithDefaults()
 	ucfg.Protocol = parsedURL.Scheme
@@ -216,9 +563,66 @@ func ParseDSN(dsn string) (UserConfig, error) {
 		ucfg.Schema = params.Get("schema")
 		params.Del("schema")
 	}
+	if params.Has("maxConcurrentFetches") {
+		ucfg.MaxConcurrentFetches, err = strconv.Atoi(params.Get("maxConcurrentFetches"))
+		if err != nil {
+			return UserConfig{}, errors.Wrap(err, "invalid DSN: maxConcurrentFetches param is not an integer")
+		}
+		params.Del("maxConcurrentFetches")
+	}
+	if params.Has("defaultLimit") {
+		ucfg.DefaultLimit, err = strconv.Atoi(params.Get("defaultLimit"))
+		if err != nil {
+			return UserConfig{}, errors.Wrap(err, "invalid DSN: defaultLimit param is not an integer")
+		}
+		params.Del("defaultLimit")
+	}
+	if params.Has("maxConcurrentStatements") {
+		ucfg.MaxConcurrentStatements, err = strconv.Atoi(params.Get("maxConcurrentStatements"))
+		if err != nil {
+			return UserConfig{}, errors.Wrap(err, "invalid DSN: maxConcurrentStatements param is not an integer")
+		}
+		params.Del("maxConcurrentStatements")
+	}
+	if params.Has("network") {
+		ucfg.Network = params.Get("network")
+		if ucfg.Network != "tcp" && ucfg.Network != "tcp4" && ucfg.Network != "tcp6" {
+			return UserConfig{}, errors.Errorf("invalid DSN: network param must be tcp, tcp4 or tcp6, got %q", ucfg.Network)
+		}
+		params.Del("network")
+	}
+	if params.Has("pathPrefix") {
+		ucfg.PathPrefix = params.Get("pathPrefix")
+		params.Del("pathPrefix")
+	}
+	if params.Has("orgId") {
+		ucfg.HTTPHeaders = map[string]string{OrgIDHeader: params.Get("orgId")}
+		params.Del("orgId")
+	}
+	if params.Has("insecure") {
+		ucfg.Insecure, err = strconv.ParseBool(params.Get("insecure"))
+		if err != nil {
+			return UserConfig{}, errors.Wrap(err, "invalid DSN: insecure param is not a boolean")
+		}
+		params.Del("insecure")
+	}
+	if params.Has("interpolateParams") {
+		ucfg.InterpolateParams, err = strconv.ParseBool(params.Get("interpolateParams"))
+		if err != nil {
+			return UserConfig{}, errors.Wrap(err, "invalid DSN: interpolateParams param is not a boolean")
+		}
+		params.Del("interpolateParams")
+	}
+	if params.Has("sessionPreset") {
+		ucfg.SessionPreset = params.Get("sessionPreset")
+		params.Del("sessionPreset")
+	}
 	for k := range params {
 		if strings.ToLower(k) == "timezone" {
-			ucfg.Location, err = time.LoadLocation(params.Get("timezone"))
+			ucfg.Location, err = time.LoadLocation(params.Get(k))
+			if err != nil || ucfg.Location == time.Local {
+				return UserConfig{}, errors.Errorf("invalid DSN: timezone param %q is not a time zone name", params.Get(k))
+			}
 		}
 	}
 	if len(params) > 0 {
@@ -229,5 +633,5 @@ func ParseDSN(dsn string) (UserConfig, error) {
 		ucfg.SessionParams = sessionParams
 	}
 
-	return ucfg, err
+	return ucfg, nil
 }
@@ -43,13 +43,40 @@ func (s WatchStatus) String() string {
 type Done func() bool
 
 type Sentinel struct {
-	StatusFn         func() (doneFn Done, statusResp any, err error)
-	OnCancelFn       func() (onCancelFnResp any, err error)
-	OnDoneFn         func(statusResp any) (onDoneFnResp any, err error)
+	StatusFn   func() (doneFn Done, statusResp any, err error)
+	OnCancelFn func() (onCancelFnResp any, err error)
+	OnDoneFn   func(statusResp any) (onDoneFnResp any, err error)
+	// Multiplier makes the interval between StatusFn calls grow exponentially, up to
+	// MaxInterval. The interval is fixed when Multiplier is 1 or less.
+	Multiplier       float64
+	MaxInterval      time.Duration
 	onCancelFnCalled bool
 }
 
+// nextInterval returns the interval to wait after checking the status every interval.
+// It backs off exponentially, but waits no more than half the time left before the
+// deadline, so the status is still checked as the deadline gets close, and no less
+// than the initial interval.
+func (s Sentinel) nextInterval(interval, minInterval time.Duration, deadline time.Time) time.Duration {
+	if s.Multiplier > 1 {
+		interval = time.Duration(float64(interval) * s.Multiplier)
+		if s.MaxInterval > 0 && interval > s.MaxInterval {
+			interval = s.MaxInterval
+		}
+	}
+	if !deadline.IsZero() {
+		if half := time.Until(deadline) / 2; half < interval {
+			interval = half
+		}
+	}
+	if interval < minInterval {
+		interval = minInterval
+	}
+	return interval
+}
+
 // Wait takes care of checking the status of something on a given interval, up to a timeout.
+// The interval backs off as set by Multiplier and MaxInterval.
 // The StatusFn check will continue until given Done function returns true or statusFn returns an error.
 // Context cancellation is supported and in that case it will return WaitCanceled status.
 func (s Sentinel) Watch(ctx context.Context, interval, timeout time.Duration) (WatchStatus, any, error) {
@@ -63,6 +90,15 @@ func (s Sentinel) Watch(ctx context.Context, interval, timeout time.Duration) (W
 		interval = DEFAULT_INTERVAL
 	}
 
+	// the status is checked no later than the deadline of ctx or the timeout
+	deadline, _ := ctx.Deadline()
+	if timeout != 0 {
+		if timeoutDeadline := time.Now().Add(timeout); deadline.IsZero() || timeoutDeadline.Before(deadline) {
+			deadline = timeoutDeadline
+		}
+	}
+	minInterval := interval
+
 	var timeoutTimerCh <-chan time.Time
 	if timeout != 0 {
 		timeoutTimer := time.NewTimer(timeout)
@@ -100,6 +136,7 @@ func (s Sentinel) Watch(ctx context.Context, interval, timeout time.Duration) (W
 				return WatchErr, statusResp, err
 			}
 			// resetting it here so statusFn is called again after interval time
+			interval = s.nextInterval(interval, minInterval, deadline)
 			_ = intervalTimer.Reset(interval)
 			if done() {
 				intervalTimer.Stop()
@@ -279,4 +279,35 @@ func TestWatch(t *testing.T) {
 		assert.Nil(t, res)
 		assert.ErrorContains(t, err, "failed")
 	})
+	t.Run("it should back off between statusFn calls", func(t *testing.T) {
+		var calls []time.Time
+		var statusFn = func() (Done, any, error) {
+			calls = append(calls, time.Now())
+			return func() bool {
+				return len(calls) == 4
+			}, nil, nil
+		}
+		s := Sentinel{
+			StatusFn:    statusFn,
+			Multiplier:  2,
+			MaxInterval: 80 * time.Millisecond,
+		}
+		start := time.Now()
+		status, _, err := s.Watch(context.Background(), 20*time.Millisecond, 0)
+		assert.Equal(t, WatchSuccess, status)
+		assert.NoError(t, err)
+		// waits of 20, 40, 80 and 80ms
+		assert.GreaterOrEqual(t, calls[3].Sub(start), 220*time.Millisecond)
+	})
+}
+
+func TestNextInterval(t *testing.T) {
+	s := Sentinel{Multiplier: 2, MaxInterval: 10 * time.Second}
+	assert.Equal(t, 2*time.Second, s.nextInterval(time.Second, time.Second, time.Time{}))
+	assert.Equal(t, 10*time.Second, s.nextInterval(8*time.Second, time.Second, time.Time{}))
+	// at most half the time left before the deadline, at least the initial interval
+	assert.InDelta(t, float64(3*time.Second), float64(s.nextInterval(8*time.Second, time.Second, time.Now().Add(6*time.Second))), float64(100*time.Millisecond))
+	assert.Equal(t, time.Second, s.nextInterval(8*time.Second, time.Second, time.Now().Add(time.Second)))
+	// without a multiplier the interval is fixed
+	assert.Equal(t, time.Second, Sentinel{}.nextInterval(time.Second, time.Second, time.Time{}))
 }
@@ -0,0 +1,63 @@
+package dbsql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// JSON implements sql.Scanner for STRING and VARIANT columns holding JSON, which
+// it unmarshals into V during Scan, without scanning into an intermediate string.
+//
+//	var payload dbsql.JSON[Event]
+//	if err := row.Scan(&payload); err != nil {
+//		...
+//	}
+//	fmt.Println(payload.V.Name)
+//
+// JSON also implements driver.Valuer, so it can be bound as a query parameter,
+// which is sent as the JSON text of V.
+type JSON[T any] struct {
+	V T
+	// Valid is false when the value is NULL
+	Valid bool
+}
+
+// Scan implements the sql.Scanner interface. A NULL value resets V to its zero value.
+func (j *JSON[T]) Scan(src any) error {
+	var zero T
+	j.V = zero
+	var text []byte
+	switch v := src.(type) {
+	case nil:
+		j.Valid = false
+		return nil
+	case string:
+		text = []byte(v)
+	case []byte:
+		text = v
+	default:
+		return errors.Errorf("databricks: cannot scan %T into JSON", src)
+	}
+	if err := json.Unmarshal(text, &j.V); err != nil {
+		j.Valid = false
+		return &ConversionError{Value: string(text), Type: fmt.Sprintf("%T", j.V), Reason: err.Error()}
+	}
+	j.Valid = true
+	return nil
+}
+
+// Value implements the driver.Valuer interface, returning V as JSON text, or nil
+// when the value is not valid.
+func (j JSON[T]) Value() (driver.Value, error) {
+	if !j.Valid {
+		return nil, nil
+	}
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,55 @@
+package dbsql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonTestEvent struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+func TestJSON(t *testing.T) {
+	t.Run("unmarshals strings and bytes", func(t *testing.T) {
+		var j JSON[jsonTestEvent]
+		require.NoError(t, j.Scan(`{"name":"click","tags":["a"]}`))
+		assert.True(t, j.Valid)
+		assert.Equal(t, jsonTestEvent{Name: "click", Tags: []string{"a"}}, j.V)
+
+		require.NoError(t, j.Scan([]byte(`{"name":"view"}`)))
+		assert.Equal(t, jsonTestEvent{Name: "view"}, j.V)
+	})
+
+	t.Run("scans NULL", func(t *testing.T) {
+		j := JSON[jsonTestEvent]{V: jsonTestEvent{Name: "x"}, Valid: true}
+		require.NoError(t, j.Scan(nil))
+		assert.False(t, j.Valid)
+		assert.Equal(t, jsonTestEvent{}, j.V)
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		var j JSON[jsonTestEvent]
+		var convErr *ConversionError
+		require.ErrorAs(t, j.Scan(`{"name": 1}`), &convErr)
+		assert.Equal(t, "dbsql.jsonTestEvent", convErr.Type)
+		assert.False(t, j.Valid)
+		assert.Error(t, j.Scan(42))
+	})
+
+	t.Run("binds as JSON text", func(t *testing.T) {
+		v, err := JSON[[]int]{V: []int{1, 2}, Valid: true}.Value()
+		require.NoError(t, err)
+		assert.Equal(t, "[1,2]", v)
+
+		literal, err := formatLiteral(JSON[map[string]int]{V: map[string]int{"a": 1}, Valid: true})
+		require.NoError(t, err)
+		assert.Equal(t, `'{"a":1}'`, literal)
+
+		v, err = JSON[int]{}.Value()
+		require.NoError(t, err)
+		assert.Nil(t, v)
+	})
+}
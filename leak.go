@@ -0,0 +1,37 @@
+package dbsql
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/databricks/databricks-sql-go/logger"
+)
+
+// leakStack returns the stack of the caller when leak detection is enabled, to report
+// where a leaked object was created, "" otherwise
+func leakStack(cfg *config.Config) string {
+	if cfg == nil || !cfg.LeakDetection {
+		return ""
+	}
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// reportLeak logs, or panics with, the leak of an object garbage collected without
+// Close. stack is where it was created, empty when leak detection is disabled.
+func reportLeak(cfg *config.Config, log *logger.DBSQLLogger, msg string, stack string) {
+	if stack != "" {
+		msg += ", created at:\n" + stack
+	}
+	if cfg != nil && cfg.PanicOnLeak {
+		panic(fmt.Sprintf("databricks: %s", msg))
+	}
+	log.Warn().Msgf("databricks: %s", msg)
+}
@@ -0,0 +1,106 @@
+package dbsql
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/databricks/databricks-sql-go/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leakTestOutput collects the log lines written from finalizers
+type leakTestOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *leakTestOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *leakTestOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// prepareLeakedStmt prepares a statement that is dropped without Close
+func prepareLeakedStmt(t *testing.T, c *conn) {
+	_, err := c.PrepareContext(context.Background(), "select 'leaked'")
+	require.NoError(t, err)
+}
+
+func TestLeakDetection(t *testing.T) {
+	out := &leakTestOutput{}
+	logger.SetLogOutput(out)
+	defer logger.SetLogOutput(os.Stderr)
+
+	t.Run("leaked rows are reported with their stack", func(t *testing.T) {
+		recorder := &shutdownRecorder{}
+		testConn := getShutdownTestConn(t, recorder)
+		testConn.cfg.LeakDetection = true
+
+		_, err := testConn.QueryContext(context.Background(), "select 1", []driver.NamedValue{})
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			runtime.GC()
+			return strings.Contains(out.String(), "rows garbage collected without Close")
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Contains(t, out.String(), "TestLeakDetection")
+	})
+
+	t.Run("leaked statements are reported", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.LeakDetection = true
+		testConn := &conn{cfg: cfg}
+
+		prepareLeakedStmt(t, testConn)
+		assert.Eventually(t, func() bool {
+			runtime.GC()
+			return strings.Contains(out.String(), "statement garbage collected without Close: query select 'leaked'")
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Contains(t, out.String(), "prepareLeakedStmt")
+	})
+
+	t.Run("closed statements are not reported", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.LeakDetection = true
+		testConn := &conn{cfg: cfg}
+
+		s, err := testConn.PrepareContext(context.Background(), "select 'closed'")
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+		s = nil
+		for i := 0; i < 3; i++ {
+			runtime.GC()
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.NotContains(t, out.String(), "select 'closed'")
+	})
+
+	t.Run("leaks panic when asked to", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.PanicOnLeak = true
+		assert.PanicsWithValue(t, "databricks: rows garbage collected without Close, created at:\nstack", func() {
+			reportLeak(cfg, logger.WithContext("", "", ""), "rows garbage collected without Close", "stack")
+		})
+	})
+
+	t.Run("stacks are only recorded when enabled", func(t *testing.T) {
+		assert.Empty(t, leakStack(config.WithDefaults()))
+		cfg := config.WithDefaults()
+		cfg.LeakDetection = true
+		assert.Contains(t, leakStack(cfg), "TestLeakDetection")
+	})
+}
@@ -0,0 +1,104 @@
+package dbsql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+type driverQueryContextKey struct{}
+
+// driverQuery returns a context for the queries built by the driver, e.g. the ones of
+// ListTables or RefreshHistory, which read all their rows whatever the default LIMIT
+// of the connector
+func driverQuery(ctx context.Context) context.Context {
+	return context.WithValue(ctx, driverQueryContextKey{}, true)
+}
+
+func isDriverQuery(ctx context.Context) bool {
+	v, _ := ctx.Value(driverQueryContextKey{}).(bool)
+	return v
+}
+
+// limitKeywords start the queries that a default LIMIT is added to. SHOW, DESCRIBE,
+// EXPLAIN and LIST statements do not accept a LIMIT clause.
+var limitKeywords = map[string]bool{
+	"SELECT": true,
+	"WITH":   true,
+	"FROM":   true,
+	"VALUES": true,
+	"TABLE":  true,
+}
+
+// addDefaultLimit appends LIMIT n to query when it is a single query that has no
+// LIMIT clause of its own. Limits of subqueries, i.e. in parentheses, do not count.
+// Queries with an OFFSET clause get the limit before it, as Spark requires. Other
+// statements are returned unchanged.
+func addDefaultLimit(query string, n int) string {
+	if n <= 0 || !isQuery(query) {
+		return query
+	}
+	var first string
+	hasLimit := false
+	depth := 0
+	wordStart := -1
+	// the start of the OFFSET clause, and of an OFFSET word that starts one when it
+	// is followed by a value, unlike e.g. a column named offset
+	offsetStart, offsetWord := -1, -1
+	endWord := func(end int) {
+		if wordStart < 0 {
+			return
+		}
+		word := strings.ToUpper(query[wordStart:end])
+		if first == "" {
+			first = word
+		}
+		if offsetWord >= 0 && word[0] >= '0' && word[0] <= '9' {
+			offsetStart = offsetWord
+		}
+		offsetWord = -1
+		if depth == 0 && word == "LIMIT" {
+			hasLimit = true
+		}
+		if depth == 0 && word == "OFFSET" {
+			offsetWord = wordStart
+		}
+		wordStart = -1
+	}
+	scanSQL(query, func(kind sqlTokenKind, start, end int) bool {
+		c := query[start]
+		if kind == sqlCode && isIdentChar(c) {
+			if wordStart < 0 {
+				wordStart = start
+			}
+			return true
+		}
+		endWord(start)
+		switch kind {
+		case sqlCode:
+			if offsetWord >= 0 && c == '(' {
+				offsetStart = offsetWord
+			}
+			offsetWord = -1
+			switch c {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+		case sqlQuoted:
+			offsetWord = -1
+		}
+		return true
+	})
+	endWord(len(query))
+	if hasLimit || !limitKeywords[first] {
+		return query
+	}
+	limit := "LIMIT " + strconv.Itoa(n)
+	query = trimStatement(query)
+	if offsetStart >= 0 {
+		return query[:offsetStart] + limit + " " + query[offsetStart:]
+	}
+	return query + " " + limit
+}
@@ -0,0 +1,105 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddDefaultLimit(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM t": "SELECT * FROM t LIMIT 100",
+		"select * from t order by a; -- newest first":          "select * from t order by a LIMIT 100",
+		"WITH c AS (SELECT * FROM t LIMIT 5) SELECT * FROM c":  "WITH c AS (SELECT * FROM t LIMIT 5) SELECT * FROM c LIMIT 100",
+		"SELECT * FROM t WHERE a IN (SELECT a FROM u LIMIT 1)": "SELECT * FROM t WHERE a IN (SELECT a FROM u LIMIT 1) LIMIT 100",
+		"SELECT 1 UNION ALL SELECT 2":                          "SELECT 1 UNION ALL SELECT 2 LIMIT 100",
+		"VALUES (1), (2)":                                      "VALUES (1), (2) LIMIT 100",
+		"SELECT 'limit' AS `limit` FROM t":                     "SELECT 'limit' AS `limit` FROM t LIMIT 100",
+		// the limit goes before the offset
+		"SELECT * FROM t ORDER BY a OFFSET 10":            "SELECT * FROM t ORDER BY a LIMIT 100 OFFSET 10",
+		"SELECT * FROM t ORDER BY a\n-- skip\nOFFSET 10;": "SELECT * FROM t ORDER BY a\n-- skip\nLIMIT 100 OFFSET 10",
+		"SELECT * FROM t OFFSET (SELECT count(*) FROM u)": "SELECT * FROM t LIMIT 100 OFFSET (SELECT count(*) FROM u)",
+		"SELECT offset FROM t ORDER BY offset":            "SELECT offset FROM t ORDER BY offset LIMIT 100",
+		"SELECT * FROM (SELECT * FROM t OFFSET 1)":        "SELECT * FROM (SELECT * FROM t OFFSET 1) LIMIT 100",
+		"SELECT * FROM t LIMIT 5 OFFSET 10":               "SELECT * FROM t LIMIT 5 OFFSET 10",
+		// queries with a limit and other statements are left alone
+		"SELECT * FROM t LIMIT 10":            "SELECT * FROM t LIMIT 10",
+		"select * from t limit all":           "select * from t limit all",
+		"(SELECT 1) UNION (SELECT 2) LIMIT 1": "(SELECT 1) UNION (SELECT 2) LIMIT 1",
+		"SHOW TABLES":                         "SHOW TABLES",
+		"DESCRIBE t":                          "DESCRIBE t",
+		"INSERT INTO t SELECT * FROM u":       "INSERT INTO t SELECT * FROM u",
+		"SELECT 1; SELECT 2":                  "SELECT 1; SELECT 2",
+	}
+	for query, expected := range cases {
+		assert.Equal(t, expected, addDefaultLimit(query, 100), query)
+	}
+	assert.Equal(t, "SELECT * FROM t", addDefaultLimit("SELECT * FROM t", 0))
+}
+
+func TestConn_DefaultLimit(t *testing.T) {
+	var statements []string
+	executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+		statements = append(statements, req.Statement)
+		return &cli_service.TExecuteStatementResp{
+			Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			OperationHandle: &cli_service.TOperationHandle{
+				OperationId: &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+			},
+			DirectResults: &cli_service.TSparkDirectResults{
+				OperationStatus: &cli_service.TGetOperationStatusResp{
+					OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+				},
+			},
+		}, nil
+	}
+	cfg := config.WithDefaults()
+	cfg.DefaultLimit = 1000
+	cfg.PollInterval = 10 * time.Millisecond
+	testConn := &conn{
+		session: getTestSession(),
+		client:  &client.TestClient{FnExecuteStatement: executeStatement},
+		cfg:     cfg,
+	}
+
+	_, err := testConn.QueryContext(context.Background(), "SELECT * FROM t", []driver.NamedValue{})
+	require.NoError(t, err)
+	_, err = testConn.QueryContext(WithExplainOnly(context.Background()), "SELECT * FROM t", []driver.NamedValue{})
+	require.NoError(t, err)
+	_, err = testConn.ExecContext(context.Background(), "SELECT * FROM t", []driver.NamedValue{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"SELECT * FROM t LIMIT 1000",
+		"EXPLAIN SELECT * FROM t LIMIT 1000",
+		"SELECT * FROM t",
+	}, statements)
+}
+
+func TestConn_DefaultLimit_DriverQueries(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"update_id", "state", "started_at", "updated_at"}, nil, "", &statements)
+	defer db.Close()
+	c, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, c.Raw(func(driverConn any) error {
+		driverConn.(*conn).cfg.DefaultLimit = 10
+		return nil
+	}))
+	require.NoError(t, c.Close())
+
+	_, err = RefreshHistory(context.Background(), db, "daily_sales", 0)
+	require.NoError(t, err)
+	rows, err := db.QueryContext(context.Background(), "SELECT * FROM t")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	require.Len(t, statements, 2)
+	assert.NotContains(t, statements[0], "LIMIT")
+	assert.Equal(t, "SELECT * FROM t LIMIT 10", statements[1])
+}
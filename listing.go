@@ -0,0 +1,195 @@
+package dbsql
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultListParallelism is the number of namespaces listed at the same time by
+// ListTables and ListColumns when no parallelism is set.
+const DefaultListParallelism = 8
+
+// CatalogTable is a table or view listed by ListTables.
+type CatalogTable struct {
+	Catalog string
+	Schema  string
+	Name    string
+	// Type is MANAGED, EXTERNAL, VIEW, MATERIALIZED_VIEW, STREAMING_TABLE or FOREIGN
+	Type    string
+	Comment string
+}
+
+// CatalogColumn is a column listed by ListColumns.
+type CatalogColumn struct {
+	Catalog string
+	Schema  string
+	Table   string
+	Name    string
+	// Position of the column in the table, starting at 1
+	Position int
+	// Type is the full type text, e.g. DECIMAL(10,2) or ARRAY<INT>
+	Type     string
+	Nullable bool
+	Comment  string
+}
+
+// ListTables returns the tables and views of the namespaces, which are catalogs,
+// e.g. main, or schemas qualified with their catalog, e.g. main.default, for catalog
+// browsers syncing a metastore. The namespaces are listed with a query each, up to
+// parallelism at the same time, DefaultListParallelism when zero. The tables are
+// returned in the order of the namespaces, then by schema and name. Listing a
+// catalog leaves out its information_schema.
+//
+// The tables are read from the information_schema of Unity Catalog, which the
+// hive_metastore catalog does not have: list its schemas with ShowTables. The first
+// namespace that fails to be listed cancels the others and its error is returned.
+func ListTables(ctx context.Context, db Queryer, namespaces []string, parallelism int) ([]CatalogTable, error) {
+	return listNamespaces(ctx, namespaces, parallelism, func(ctx context.Context, ns namespaceFilter) ([]CatalogTable, error) {
+		rows, err := queryNamed(ctx, db, "SELECT table_catalog, table_schema, table_name, table_type, comment FROM "+
+			ns.catalog+".information_schema.tables"+ns.where()+" ORDER BY table_schema, table_name")
+		if err != nil {
+			return nil, err
+		}
+		tables := make([]CatalogTable, 0, len(rows))
+		for _, row := range rows {
+			tables = append(tables, CatalogTable{
+				Catalog: asString(row["table_catalog"]),
+				Schema:  asString(row["table_schema"]),
+				Name:    asString(row["table_name"]),
+				Type:    asString(row["table_type"]),
+				Comment: asString(row["comment"]),
+			})
+		}
+		return tables, nil
+	})
+}
+
+// ListColumns returns the columns of the tables and views of the namespaces, like
+// ListTables. The columns are returned in the order of the namespaces, then by
+// schema, table and position.
+func ListColumns(ctx context.Context, db Queryer, namespaces []string, parallelism int) ([]CatalogColumn, error) {
+	return listNamespaces(ctx, namespaces, parallelism, func(ctx context.Context, ns namespaceFilter) ([]CatalogColumn, error) {
+		rows, err := queryNamed(ctx, db, "SELECT table_catalog, table_schema, table_name, column_name, ordinal_position, "+
+			"full_data_type, is_nullable, comment FROM "+ns.catalog+".information_schema.columns"+ns.where()+
+			" ORDER BY table_schema, table_name, ordinal_position")
+		if err != nil {
+			return nil, err
+		}
+		columns := make([]CatalogColumn, 0, len(rows))
+		for _, row := range rows {
+			position, err := asInt64(row["ordinal_position"])
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, CatalogColumn{
+				Catalog: asString(row["table_catalog"]),
+				Schema:  asString(row["table_schema"]),
+				Table:   asString(row["table_name"]),
+				Name:    asString(row["column_name"]),
+				// the information schema numbers the columns from 0
+				Position: int(position) + 1,
+				Type:     asString(row["full_data_type"]),
+				Nullable: asString(row["is_nullable"]) == "YES",
+				Comment:  asString(row["comment"]),
+			})
+		}
+		return columns, nil
+	})
+}
+
+// namespaceFilter is a catalog, quoted, and the name of one of its schemas, or ""
+// for all of them
+type namespaceFilter struct {
+	catalog string
+	schema  string
+}
+
+// parseNamespace parses a catalog or a schema qualified with its catalog
+func parseNamespace(namespace string) (namespaceFilter, error) {
+	parts, err := quoteNameParts(namespace)
+	if err != nil {
+		return namespaceFilter{}, err
+	}
+	switch len(parts) {
+	case 1:
+		return namespaceFilter{catalog: parts[0]}, nil
+	case 2:
+		schema := strings.ReplaceAll(parts[1][1:len(parts[1])-1], "``", "`")
+		return namespaceFilter{catalog: parts[0], schema: schema}, nil
+	}
+	return namespaceFilter{}, errors.Errorf("%s: %q is not a catalog or a schema", errCatalogInvalidName, namespace)
+}
+
+// where returns the WHERE clause selecting the schemas of the namespace in the
+// information schema
+func (ns namespaceFilter) where() string {
+	if ns.schema == "" {
+		return " WHERE table_schema <> 'information_schema'"
+	}
+	return " WHERE table_schema = " + QuoteLiteral(ns.schema)
+}
+
+// listNamespaces runs list for each namespace, up to parallelism at the same time,
+// and returns their results in the order of the namespaces
+func listNamespaces[T any](ctx context.Context, namespaces []string, parallelism int, list func(context.Context, namespaceFilter) ([]T, error)) ([]T, error) {
+	filters := make([]namespaceFilter, len(namespaces))
+	for i, namespace := range namespaces {
+		filter, err := parseNamespace(namespace)
+		if err != nil {
+			return nil, err
+		}
+		filters[i] = filter
+	}
+	if parallelism <= 0 {
+		parallelism = DefaultListParallelism
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]T, len(namespaces))
+	errs := make([]error, len(namespaces))
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := range filters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-slots }()
+			results[i], errs[i] = list(ctx, filters[i])
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// the error of the listing that failed first, rather than the cancellations it
+	// caused, unless the caller canceled
+	var firstErr error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		err = wrapErrf(err, "failed to list %s", namespaces[i])
+		if firstErr == nil || errors.Is(firstErr, context.Canceled) && !errors.Is(err, context.Canceled) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	var merged []T
+	for _, result := range results {
+		merged = append(merged, result...)
+	}
+	return merged, nil
+}
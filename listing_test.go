@@ -0,0 +1,63 @@
+package dbsql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTables(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"table_catalog", "table_schema", "table_name", "table_type", "comment"}, [][]string{
+		{"main", "default", "events", "MANAGED", "raw events"},
+		{"main", "default", "sessions", "VIEW", ""},
+	}, "", &statements)
+	defer db.Close()
+	// the test connection runs a statement at a time
+	db.SetMaxOpenConns(1)
+
+	tables, err := ListTables(context.Background(), db, []string{"main", "`dev`.`it's`"}, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"SELECT table_catalog, table_schema, table_name, table_type, comment FROM `main`.information_schema.tables " +
+			"WHERE table_schema <> 'information_schema' ORDER BY table_schema, table_name",
+		"SELECT table_catalog, table_schema, table_name, table_type, comment FROM `dev`.information_schema.tables " +
+			`WHERE table_schema = 'it\'s' ORDER BY table_schema, table_name`,
+	}, statements)
+	// the results of each namespace, in the order of the namespaces
+	events := CatalogTable{Catalog: "main", Schema: "default", Name: "events", Type: "MANAGED", Comment: "raw events"}
+	sessions := CatalogTable{Catalog: "main", Schema: "default", Name: "sessions", Type: "VIEW"}
+	assert.Equal(t, []CatalogTable{events, sessions, events, sessions}, tables)
+
+	_, err = ListTables(context.Background(), db, []string{"main.default.events"}, 0)
+	assert.ErrorContains(t, err, `databricks: invalid object name: "main.default.events" is not a catalog or a schema`)
+
+	failing := getStringsTestDB(nil, nil, "42704", &statements)
+	defer failing.Close()
+	_, err = ListTables(context.Background(), failing, []string{"missing"}, 0)
+	assert.Equal(t, "42704", SQLState(err))
+}
+
+func TestListColumns(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"table_catalog", "table_schema", "table_name", "column_name", "ordinal_position",
+		"full_data_type", "is_nullable", "comment"}, [][]string{
+		{"main", "default", "events", "id", "0", "bigint", "NO", "event id"},
+		{"main", "default", "events", "payload", "1", "map<string,string>", "YES", ""},
+	}, "", &statements)
+	defer db.Close()
+
+	columns, err := ListColumns(context.Background(), db, []string{"main.default"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"SELECT table_catalog, table_schema, table_name, column_name, ordinal_position, full_data_type, is_nullable, " +
+			"comment FROM `main`.information_schema.columns WHERE table_schema = 'default' " +
+			"ORDER BY table_schema, table_name, ordinal_position",
+	}, statements)
+	assert.Equal(t, []CatalogColumn{
+		{Catalog: "main", Schema: "default", Table: "events", Name: "id", Position: 1, Type: "bigint", Comment: "event id"},
+		{Catalog: "main", Schema: "default", Table: "events", Name: "payload", Position: 2, Type: "map<string,string>", Nullable: true},
+	}, columns)
+}
@@ -23,14 +23,14 @@ func (l *DBSQLLogger) Duration(msg string, start time.Time) {
 }
 
 var Logger = &DBSQLLogger{
-	zerolog.New(os.Stderr).With().Timestamp().Logger(),
+	zerolog.New(redactingWriter{os.Stderr}).With().Timestamp().Logger(),
 }
 
 // enable pretty printing for interactive terminals and json for production.
 func init() {
 	// for tty terminal enable pretty logs
 	if isatty.IsTerminal(os.Stdout.Fd()) && runtime.GOOS != "windows" {
-		Logger = &DBSQLLogger{Logger.Output(zerolog.ConsoleWriter{Out: os.Stderr})}
+		Logger = &DBSQLLogger{Logger.Output(zerolog.ConsoleWriter{Out: redactingWriter{os.Stderr}})}
 	} else {
 		// UNIX Time is faster and smaller than most timestamps
 		// If you set zerolog.TimeFieldFormat to an empty string,
@@ -62,8 +62,9 @@ func SetLogLevel(l string) error {
 }
 
 // Sets logging output. Default is os.Stderr. If in terminal, pretty logs are enabled.
+// The log lines are redacted according to the redaction policy.
 func SetLogOutput(w io.Writer) {
-	Logger.Logger = Logger.Output(w)
+	Logger.Logger = Logger.Output(redactingWriter{w})
 }
 
 // Sets log to trace. -1
@@ -119,6 +120,11 @@ func WithContext(connectionId string, correlationId string, queryId string) *DBS
 	return &DBSQLLogger{Logger.With().Str("connId", connectionId).Str("corrId", correlationId).Str("queryId", queryId).Logger()}
 }
 
+// WithQueryTag returns a copy of the logger with the statement tag added as a field.
+func (l *DBSQLLogger) WithQueryTag(tag string) *DBSQLLogger {
+	return &DBSQLLogger{l.With().Str("queryTag", tag).Logger()}
+}
+
 // Track is a convenience function to track time spent
 func Track(msg string) (string, time.Time) {
 	return msg, time.Now()
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"io"
+	"regexp"
+	"sync"
+)
+
+// Redacted replaces the sensitive values removed from log output and error messages
+const Redacted = "[REDACTED]"
+
+// RedactionPolicy selects the sensitive values removed from log output and error messages.
+type RedactionPolicy struct {
+	// Secrets redacts access tokens, OAuth secrets and passwords
+	Secrets bool
+	// SQLLiterals redacts the string and numeric literals of the statements the
+	// driver logs, which may hold personal data
+	SQLLiterals bool
+	// Patterns are redacted in addition to the values selected above
+	Patterns []*regexp.Regexp
+}
+
+// DefaultRedactionPolicy redacts secrets but not SQL literals
+var DefaultRedactionPolicy = RedactionPolicy{Secrets: true}
+
+var redactionPolicy = DefaultRedactionPolicy
+var redactionPolicyMu sync.RWMutex
+
+// SetRedactionPolicy sets the sensitive values removed from the log output and the
+// error messages of all connections of the process.
+func SetRedactionPolicy(p RedactionPolicy) {
+	redactionPolicyMu.Lock()
+	defer redactionPolicyMu.Unlock()
+	redactionPolicy = p
+}
+
+// GetRedactionPolicy returns the redaction policy set by SetRedactionPolicy
+func GetRedactionPolicy() RedactionPolicy {
+	redactionPolicyMu.RLock()
+	defer redactionPolicyMu.RUnlock()
+	return redactionPolicy
+}
+
+type secretPattern struct {
+	re *regexp.Regexp
+	// replacement keeps the groups that are not secret
+	replacement string
+}
+
+// secretPatterns match secrets in free text as well as in JSON log lines, so
+// values stop at quotes and backslashes
+var secretPatterns = []secretPattern{
+	// personal access tokens
+	{regexp.MustCompile(`dapi[0-9a-f]{32}(?:-\d+)?`), Redacted},
+	// JSON web tokens, as issued by OAuth
+	{regexp.MustCompile(`eyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`), Redacted},
+	// authorization headers
+	{regexp.MustCompile(`(?i)\b(Bearer|Basic)\s+[A-Za-z0-9\-._~+/]+=*`), "${1} " + Redacted},
+	// passwords of URLs, as in DSNs
+	{regexp.MustCompile(`(://[^:/@\s"\\]+:)[^@/\s"\\]+@`), "${1}" + Redacted + "@"},
+	// key value pairs, as in query strings, headers and JSON
+	{regexp.MustCompile(`(?i)\b((?:access_?token|refresh_?token|id_?token|client_?secret|secret|password|passwd|pwd|token)(?:=|["']?:\s*["']|["']?:\s+))[^\s"'\\&,;]+`), "${1}" + Redacted},
+}
+
+// Redact removes the secrets and the extra patterns of the redaction policy from s
+func Redact(s string) string {
+	return GetRedactionPolicy().redact(s)
+}
+
+func (p RedactionPolicy) redact(s string) string {
+	if p.Secrets {
+		for _, sp := range secretPatterns {
+			s = sp.re.ReplaceAllString(s, sp.replacement)
+		}
+	}
+	for _, re := range p.Patterns {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+// redactingWriter redacts the log lines written to w
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r redactingWriter) Write(p []byte) (int, error) {
+	policy := GetRedactionPolicy()
+	if !policy.Secrets && len(policy.Patterns) == 0 {
+		return r.w.Write(p)
+	}
+	if _, err := io.WriteString(r.w, policy.redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,187 @@
+package dbsql
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/pkg/errors"
+)
+
+// ErrMemoryLimit is matched, with errors.Is, by the errors returned when decoding
+// results would hold more memory than the limit set with WithMaxResultMemory. The
+// rows holding the memory must be closed before more results are read.
+var ErrMemoryLimit = errors.New("databricks: result memory limit exceeded")
+
+// memoryAccount accounts for the bytes held by the results of a connection: the
+// result pages buffered by the rows, with their strings and binary values, and the
+// Arrow batches being written. A nil memoryAccount accounts for nothing.
+type memoryAccount struct {
+	// limit is the max bytes held, zero means no limit
+	limit int64
+	used  atomic.Int64
+}
+
+func newMemoryAccount(limit int64) *memoryAccount {
+	return &memoryAccount{limit: limit}
+}
+
+// reserve accounts for n more bytes, or returns an error matching ErrMemoryLimit
+// if they would exceed the limit
+func (a *memoryAccount) reserve(n int64) error {
+	if a == nil || n <= 0 {
+		return nil
+	}
+	for {
+		used := a.used.Load()
+		if a.limit > 0 && used+n > a.limit {
+			return fmt.Errorf("%w: %d bytes needed, %d of %d bytes in use", ErrMemoryLimit, n, used, a.limit)
+		}
+		if a.used.CompareAndSwap(used, used+n) {
+			return nil
+		}
+	}
+}
+
+// release accounts for n bytes no longer held
+func (a *memoryAccount) release(n int64) {
+	if a == nil || n <= 0 {
+		return
+	}
+	a.used.Add(-n)
+}
+
+// memoryAccounted is implemented by the connections, whose results are accounted for
+type memoryAccounted interface {
+	memoryAccount() *memoryAccount
+}
+
+var _ memoryAccounted = (*conn)(nil)
+
+func (c *conn) memoryAccount() *memoryAccount {
+	return c.memory
+}
+
+// MemoryUsage returns the bytes held by the results read with conn, and the limit set
+// with WithMaxResultMemory, zero if there is none.
+func MemoryUsage(conn *sql.Conn) (used, limit int64, err error) {
+	err = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(memoryAccounted)
+		if !ok {
+			return errors.New(ErrNotImplemented)
+		}
+		if a := c.memoryAccount(); a != nil {
+			used, limit = a.used.Load(), a.limit
+		}
+		return nil
+	})
+	return used, limit, err
+}
+
+// setPage makes resp the current result page, once the memory it holds is accounted
+// for in place of the memory of the previous page. On error the rows have no page.
+func (r *rows) setPage(resp *cli_service.TFetchResultsResp) error {
+	var memory *memoryAccount
+	if r.conn != nil {
+		memory = r.conn.memory
+	}
+	memory.release(r.pageBytes)
+	r.fetchResults, r.pageBytes = nil, 0
+	if resp == nil {
+		return nil
+	}
+	size := rowSetSize(resp.GetResults())
+	if err := memory.reserve(size); err != nil {
+		return err
+	}
+	r.fetchResults, r.pageBytes = resp, size
+	// pages fetched again, e.g. after a rewind, do not tell more about the end of the
+	// results than the furthest one, while an empty last page may have no offset
+	start, n := resp.GetResults().GetStartRowOffset(), getNRows(resp.GetResults())
+	if n == 0 && !resp.GetHasMoreRows() && start <= r.fetchedRows {
+		r.lastFetched = true
+	} else if start+n >= r.fetchedRows {
+		r.fetchedRows, r.lastFetched = start+n, !resp.GetHasMoreRows()
+	}
+	return nil
+}
+
+// dropPage drops the current result page and the column readers referencing its
+// values, so that it can be garbage collected
+func (r *rows) dropPage() {
+	r.releasePage()
+	r.fetchResults, r.readersPage = nil, nil
+	for i := range r.readers {
+		r.readers[i].get = nil
+	}
+}
+
+// releasePage releases the memory of the current result page
+func (r *rows) releasePage() {
+	if r.conn != nil {
+		r.conn.memory.release(r.pageBytes)
+	}
+	r.pageBytes = 0
+}
+
+// rowSetSize estimates the bytes held by a result page
+func rowSetSize(rs *cli_service.TRowSet) int64 {
+	if rs == nil {
+		return 0
+	}
+	var n int64
+	for _, batch := range rs.ArrowBatches {
+		n += int64(len(batch.GetBatch()))
+	}
+	for _, col := range rs.Columns {
+		n += columnSize(col)
+	}
+	return n
+}
+
+// columnSize estimates the bytes held by the values of a column, counting the
+// headers of strings and byte slices along with their content
+func columnSize(col *cli_service.TColumn) int64 {
+	switch {
+	case col == nil:
+		return 0
+	case col.BoolVal != nil:
+		return int64(len(col.BoolVal.Values) + len(col.BoolVal.Nulls))
+	case col.ByteVal != nil:
+		return int64(len(col.ByteVal.Values) + len(col.ByteVal.Nulls))
+	case col.I16Val != nil:
+		return int64(2*len(col.I16Val.Values) + len(col.I16Val.Nulls))
+	case col.I32Val != nil:
+		return int64(4*len(col.I32Val.Values) + len(col.I32Val.Nulls))
+	case col.I64Val != nil:
+		return int64(8*len(col.I64Val.Values) + len(col.I64Val.Nulls))
+	case col.DoubleVal != nil:
+		return int64(8*len(col.DoubleVal.Values) + len(col.DoubleVal.Nulls))
+	case col.StringVal != nil:
+		n := int64(len(col.StringVal.Nulls))
+		for _, s := range col.StringVal.Values {
+			n += 16 + int64(len(s))
+		}
+		return n
+	case col.BinaryVal != nil:
+		n := int64(len(col.BinaryVal.Nulls))
+		for _, b := range col.BinaryVal.Values {
+			n += 24 + int64(len(b))
+		}
+		return n
+	}
+	return 0
+}
+
+// arrowBatchSize returns the bytes held by the buffers of an Arrow batch, which are
+// reused by the next batches up to their capacity
+func arrowBatchSize(batch arrowBatch) int64 {
+	var n int64
+	for _, buffers := range batch.buffers {
+		for _, b := range buffers {
+			n += int64(cap(b))
+		}
+	}
+	return n
+}
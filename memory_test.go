@@ -0,0 +1,155 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryAccount(t *testing.T) {
+	t.Run("reservations are limited", func(t *testing.T) {
+		a := newMemoryAccount(100)
+		require.NoError(t, a.reserve(60))
+		err := a.reserve(50)
+		assert.ErrorIs(t, err, ErrMemoryLimit)
+		assert.EqualError(t, err, "databricks: result memory limit exceeded: 50 bytes needed, 60 of 100 bytes in use")
+		a.release(60)
+		assert.NoError(t, a.reserve(100))
+		assert.Equal(t, int64(100), a.used.Load())
+	})
+
+	t.Run("zero means no limit and nil accounts for nothing", func(t *testing.T) {
+		a := newMemoryAccount(0)
+		assert.NoError(t, a.reserve(1<<40))
+		var none *memoryAccount
+		assert.NoError(t, none.reserve(1<<40))
+		none.release(1 << 40)
+	})
+
+	t.Run("rows account for their current page", func(t *testing.T) {
+		var requests []*cli_service.TFetchResultsReq
+		memory := newMemoryAccount(60)
+		rowSet := &rows{
+			pageSize: 10,
+			client:   getRowsTestCursorClient(20, &requests),
+			conn:     &conn{memory: memory},
+		}
+		dest := make([]driver.Value, 1)
+		for i := 0; i < 20; i++ {
+			require.NoError(t, rowSet.Next(dest))
+			// a page of 10 INT values
+			assert.Equal(t, int64(40), memory.used.Load())
+		}
+		rowSet.releasePage()
+		assert.Equal(t, int64(0), memory.used.Load())
+
+		// the page of another result set is still held
+		require.NoError(t, memory.reserve(30))
+		rowSet = &rows{
+			pageSize: 10,
+			client:   getRowsTestCursorClient(20, &requests),
+			conn:     &conn{memory: memory},
+		}
+		assert.ErrorIs(t, rowSet.Next(dest), ErrMemoryLimit)
+		assert.Equal(t, int64(30), memory.used.Load())
+	})
+
+	t.Run("Arrow batches are accounted for while they are written", func(t *testing.T) {
+		var requests []*cli_service.TFetchResultsReq
+		memory := newMemoryAccount(1000)
+		rowSet := &rows{
+			pageSize: 10,
+			client:   getRowsTestCursorClient(20, &requests),
+			conn:     &conn{memory: memory},
+		}
+		columns, err := rowSet.arrowColumns()
+		require.NoError(t, err)
+		var batches int
+		n, err := rowSet.readArrowBatches(columns, func(batch arrowBatch) error {
+			batches++
+			// the page and the validity and values buffers of the batch
+			assert.GreaterOrEqual(t, arrowBatchSize(batch), int64(2+40))
+			assert.Equal(t, 40+arrowBatchSize(batch), memory.used.Load())
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(20), n)
+		assert.Equal(t, 2, batches)
+		assert.Equal(t, int64(40), memory.used.Load())
+
+		memory = newMemoryAccount(60)
+		rowSet = &rows{
+			pageSize: 10,
+			client:   getRowsTestCursorClient(20, &requests),
+			conn:     &conn{memory: memory},
+		}
+		_, err = rowSet.readArrowBatches(columns, func(batch arrowBatch) error { return nil })
+		assert.ErrorIs(t, err, ErrMemoryLimit)
+	})
+}
+
+func TestMemoryUsage(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"name"}, [][]string{{"abc"}, {"de"}}, "", &statements)
+	defer db.Close()
+	c, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Raw(func(dc any) error {
+		dc.(*conn).memory = newMemoryAccount(1000)
+		return nil
+	}))
+
+	rows, err := c.QueryContext(context.Background(), "SELECT name FROM people")
+	require.NoError(t, err)
+	// the strings and their headers
+	used, limit, err := MemoryUsage(c)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{16 + 3 + 16 + 2, 1000}, []int64{used, limit})
+
+	require.NoError(t, rows.Close())
+	used, _, err = MemoryUsage(c)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), used)
+
+	require.NoError(t, c.Raw(func(dc any) error {
+		dc.(*conn).memory = newMemoryAccount(10)
+		return nil
+	}))
+	_, err = c.QueryContext(context.Background(), "SELECT name FROM people")
+	assert.ErrorIs(t, err, ErrMemoryLimit)
+}
+
+func TestRows_DropsReadPages(t *testing.T) {
+	var requests []*cli_service.TFetchResultsReq
+	testClient := getRowsTestCursorClient(30, &requests)
+	fetchResults := testClient.FnFetchResults
+	var held []bool
+	memory := newMemoryAccount(0)
+	rowSet := &rows{
+		pageSize: 10,
+		client:   testClient,
+		conn:     &conn{memory: memory},
+	}
+	testClient.FnFetchResults = func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+		held = append(held, rowSet.fetchResults != nil || memory.used.Load() != 0)
+		return fetchResults(ctx, req)
+	}
+	dest := make([]driver.Value, 1)
+	for i := 0; i < 30; i++ {
+		require.NoError(t, rowSet.Next(dest))
+		assert.Equal(t, int32(i), dest[0])
+	}
+	// the pages read are not held while the next ones are fetched
+	assert.Equal(t, []bool{false, false, false}, held)
+
+	// pages are kept when fetching backwards
+	require.NoError(t, rowSet.SeekRow(5))
+	require.NoError(t, rowSet.Next(dest))
+	assert.Equal(t, int32(5), dest[0])
+	assert.Equal(t, []bool{false, false, false, true}, held)
+}
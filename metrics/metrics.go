@@ -0,0 +1,87 @@
+// Package metrics defines how the driver reports measurements of its work.
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// Collector receives the measurements of the driver. Its methods are called
+// synchronously from the goroutine that uses the connection, so they should
+// return quickly, e.g. by only updating counters and histograms.
+type Collector interface {
+	// PageFetched is called for every result page fetched from the server,
+	// once its rows were read, i.e. when the next page is fetched or the rows
+	// are closed.
+	PageFetched(ctx context.Context, page PageFetch)
+}
+
+// PageFetch is the time spent in each phase of fetching and reading a result
+// page. Wait being large compared to the other phases points at the server,
+// while Transfer points at the network and Decompress, Deserialize and Decode
+// at the client.
+type PageFetch struct {
+	// QueryID is the id of the query the page belongs to
+	QueryID string
+	// Rows is the number of rows of the page
+	Rows int64
+	// PageSize is the max rows requested for the page, which changes between the
+	// pages of a result set with an adaptive page size
+	PageSize int64
+	// Bytes is the size of the response as received, before decompression
+	Bytes int64
+	// Wait is the time from sending the request until the response headers
+	// arrived, i.e. the time the server took to produce the page
+	Wait time.Duration
+	// Transfer is the time spent receiving the response body
+	Transfer time.Duration
+	// Decompress is the time spent decompressing the response body. It is zero
+	// when the response was not compressed.
+	Decompress time.Duration
+	// Deserialize is the time spent decoding the thrift response into columns
+	Deserialize time.Duration
+	// Decode is the time spent converting the column values of the rows that
+	// were read into Go values
+	Decode time.Duration
+}
+
+// StatementQueueCollector is implemented by collectors that also receive the time
+// statements waited for one of the concurrent executions allowed by the connector.
+// It is only used when the number of concurrent statements is limited.
+type StatementQueueCollector interface {
+	// StatementQueued is called for every statement before it is sent to the
+	// server, once it may run, or when its context was done while it waited.
+	StatementQueued(ctx context.Context, queue StatementQueue)
+}
+
+// StatementQueue is the wait of a statement for one of the concurrent executions
+// allowed by the connector. Statements that did not have to wait report zero.
+type StatementQueue struct {
+	// Wait is the time the statement waited
+	Wait time.Duration
+	// Queued is the number of statements that were already waiting when the
+	// statement was queued
+	Queued int64
+}
+
+// ThrottleCollector is implemented by collectors that also receive the requests
+// rate limited by the gateway, with 429 Too Many Requests or 503 Service
+// Unavailable.
+type ThrottleCollector interface {
+	// Throttled is called for every throttled response, before the request is
+	// retried or the retries are given up.
+	Throttled(ctx context.Context, throttle Throttle)
+}
+
+// Throttle is a response that rate limited a request.
+type Throttle struct {
+	// StatusCode is the http status of the response
+	StatusCode int
+	// Attempt is the number of times the request was sent, starting at 1
+	Attempt int
+	// RetryAfter is the wait asked by the Retry-After header, zero if none
+	RetryAfter time.Duration
+	// Wait is the time waited before the request is sent again, zero when the
+	// retries are given up
+	Wait time.Duration
+}
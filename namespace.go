@@ -0,0 +1,145 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Namespace returns the current catalog and schema of the session of conn, e.g. for
+// tools that resolve unqualified table names client-side. The driver tracks them
+// from the namespace the session was opened with and the USE and SET CATALOG
+// statements run on the connection, without a round trip. They are empty when the
+// server defaults apply, until read with RefreshNamespace.
+func Namespace(conn *sql.Conn) (catalog, schema string, err error) {
+	err = conn.Raw(func(driverConn any) error {
+		s, ok := driverConn.(Session)
+		if !ok {
+			return errors.New(ErrNotImplemented)
+		}
+		catalog, schema = s.Namespace()
+		return nil
+	})
+	return catalog, schema, err
+}
+
+// RefreshNamespace reads the current catalog and schema from the session of conn,
+// for sessions opened with the server defaults or changed by statements the driver
+// does not parse, e.g. in SQL scripting blocks, and tracks them from then on.
+func RefreshNamespace(ctx context.Context, conn *sql.Conn) (catalog, schema string, err error) {
+	err = conn.QueryRowContext(driverQuery(ctx), "SELECT current_catalog(), current_schema()").Scan(&catalog, &schema)
+	if err != nil {
+		return "", "", err
+	}
+	err = conn.Raw(func(driverConn any) error {
+		if s, ok := driverConn.(namespaceTracker); ok {
+			s.trackNamespace(catalog, schema)
+		}
+		return nil
+	})
+	return catalog, schema, err
+}
+
+// namespaceTracker is implemented by the connections, which track the current
+// namespace of their session
+type namespaceTracker interface {
+	trackNamespace(catalog, schema string)
+}
+
+var _ namespaceTracker = (*conn)(nil)
+
+func (c *conn) trackNamespace(catalog, schema string) {
+	c.catalog, c.schema = catalog, schema
+}
+
+// setNamespace updates the current namespace of the connection after statement ran
+// successfully, when it is a USE or SET CATALOG statement.
+func (c *conn) setNamespace(statement string) {
+	catalog, schema, ok := parseUseStatement(statement)
+	if !ok {
+		return
+	}
+	if catalog != "" {
+		c.catalog = catalog
+	}
+	c.schema = schema
+}
+
+// parseUseStatement returns the catalog and the schema a USE or SET CATALOG
+// statement switches to. The catalog is empty when the statement only switches the
+// schema. Setting the catalog resets the schema to default, as on the server.
+func parseUseStatement(statement string) (catalog, schema string, ok bool) {
+	tokens := sqlTokens(statement)
+	for len(tokens) > 0 && tokens[len(tokens)-1] == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) < 2 {
+		return "", "", false
+	}
+	keyword := strings.ToUpper(tokens[0])
+	if keyword != "USE" && keyword != "SET" {
+		return "", "", false
+	}
+	first, kind := tokens[1], ""
+	if len(tokens) > 2 {
+		kind = strings.ToUpper(first)
+		tokens = tokens[2:]
+	} else {
+		tokens = tokens[1:]
+	}
+	switch {
+	case kind == "CATALOG" && len(tokens) == 1:
+		return unquoteIdentifier(tokens[0]), "default", true
+	case keyword == "SET":
+		return "", "", false
+	case (kind == "SCHEMA" || kind == "DATABASE") && len(tokens) == 1,
+		kind == "" && len(tokens) == 1:
+		return "", unquoteIdentifier(tokens[0]), true
+	case (kind == "SCHEMA" || kind == "DATABASE") && len(tokens) == 3 && tokens[1] == ".":
+		return unquoteIdentifier(tokens[0]), unquoteIdentifier(tokens[2]), true
+	case kind != "" && len(tokens) == 2 && tokens[0] == ".":
+		// USE catalog.schema
+		return unquoteIdentifier(first), unquoteIdentifier(tokens[1]), true
+	}
+	return "", "", false
+}
+
+// sqlTokens splits query into words, quoted pieces and single characters of code,
+// leaving out whitespace and comments.
+func sqlTokens(query string) []string {
+	var tokens []string
+	wordStart := -1
+	scanSQL(query, func(kind sqlTokenKind, start, end int) bool {
+		if kind == sqlCode && isIdentChar(query[start]) {
+			if wordStart < 0 {
+				wordStart = start
+			}
+			return true
+		}
+		if wordStart >= 0 {
+			tokens = append(tokens, query[wordStart:start])
+			wordStart = -1
+		}
+		if kind == sqlCode || kind == sqlQuoted {
+			tokens = append(tokens, query[start:end])
+		}
+		return true
+	})
+	if wordStart >= 0 {
+		tokens = append(tokens, query[wordStart:])
+	}
+	return tokens
+}
+
+// unquoteIdentifier removes the quotes around an identifier or a string literal
+func unquoteIdentifier(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	if q := s[0]; (q == '`' || q == '\'' || q == '"') && s[len(s)-1] == q {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,96 @@
+package dbsql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/interceptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUseStatement(t *testing.T) {
+	cases := []struct {
+		statement string
+		catalog   string
+		schema    string
+		ok        bool
+	}{
+		{statement: "USE CATALOG tenant_a", catalog: "tenant_a", schema: "default", ok: true},
+		{statement: "set catalog `tenant-b`;", catalog: "tenant-b", schema: "default", ok: true},
+		{statement: "USE CATALOG 'tenant_c'", catalog: "tenant_c", schema: "default", ok: true},
+		{statement: "USE SCHEMA sales", schema: "sales", ok: true},
+		{statement: "use database sales", schema: "sales", ok: true},
+		{statement: "/* app */ USE sales -- switch", schema: "sales", ok: true},
+		{statement: "USE SCHEMA tenant_a.sales", catalog: "tenant_a", schema: "sales", ok: true},
+		{statement: "USE Tenant_A . `sales`", catalog: "Tenant_A", schema: "sales", ok: true},
+		{statement: "SET spark.sql.ansi.enabled = true"},
+		{statement: "SET TIME ZONE 'UTC'"},
+		{statement: "SELECT * FROM sales"},
+		{statement: "USE"},
+		{statement: "USE SCHEMA a.b.c"},
+	}
+	for _, c := range cases {
+		catalog, schema, ok := parseUseStatement(c.statement)
+		assert.Equal(t, c.ok, ok, c.statement)
+		assert.Equal(t, c.catalog, catalog, c.statement)
+		assert.Equal(t, c.schema, schema, c.statement)
+	}
+}
+
+func TestConn_Namespace(t *testing.T) {
+	t.Run("rewrite hooks get the current namespace", func(t *testing.T) {
+		var statements []string
+		var namespaces []string
+		db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+		require.NoError(t, c.Raw(func(dc any) error {
+			dc.(*conn).catalog = "main"
+			dc.(*conn).cfg.Interceptors = []interceptor.Interceptor{
+				interceptor.RewriteFunc(func(ctx context.Context, query interceptor.Query) (string, error) {
+					namespaces = append(namespaces, query.Catalog+"."+query.Schema)
+					if query.Catalog == "tenant_a" {
+						return query.Statement + " WHERE tenant = 'a'", nil
+					}
+					return query.Statement, nil
+				}),
+			}
+			return nil
+		}))
+
+		for _, statement := range []string{"USE SCHEMA sales", "USE CATALOG tenant_a", "SELECT * FROM orders"} {
+			_, err = c.ExecContext(context.Background(), statement)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"main.", "main.sales", "tenant_a.default"}, namespaces)
+		assert.Equal(t, "SELECT * FROM orders WHERE tenant = 'a'", statements[2])
+		catalog, schema, err := Namespace(c)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tenant_a", "default"}, []string{catalog, schema})
+	})
+
+	t.Run("the namespace is read from the session", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"catalog", "schema"}, [][]string{{"hive_metastore", "sales"}}, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+
+		catalog, schema, err := Namespace(c)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", ""}, []string{catalog, schema})
+
+		catalog, schema, err = RefreshNamespace(context.Background(), c)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hive_metastore", "sales"}, []string{catalog, schema})
+		assert.Equal(t, []string{"SELECT current_catalog(), current_schema()"}, statements)
+
+		catalog, schema, err = Namespace(c)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hive_metastore", "sales"}, []string{catalog, schema})
+	})
+}
@@ -0,0 +1,177 @@
+package dbsql
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNullValue is matched, with errors.Is, by the errors returned when a NULL value
+// is scanned into a destination wrapped with NotNull.
+var ErrNullValue = errors.New("databricks: NULL value scanned into a NOT NULL destination")
+
+// NotNull returns a sql.Scanner that scans a value into dest, and fails with an error
+// matching ErrNullValue on NULL. database/sql already fails to scan NULL into a
+// destination that is not a pointer or a sql.Null type, but with an error that cannot
+// be told apart from other conversion errors.
+//
+// The values are converted as by database/sql: numbers into any numeric type they fit
+// in, strings into numbers, booleans and []byte, and values into strings. Destinations
+// that implement sql.Scanner, e.g. Decimal or UUID, scan the value themselves.
+func NotNull[T any](dest *T) sql.Scanner {
+	return &nullScanner[T]{dest: dest, notNull: true}
+}
+
+// NullAsZero returns a sql.Scanner that scans a value into dest, and NULL as the
+// zero value of T, for destinations that cannot hold NULL, such as int or string:
+//
+//	var name string
+//	var amount float64
+//	err := rows.Scan(dbsql.NullAsZero(&name), dbsql.NullAs(&amount, math.NaN()))
+//
+// The values are converted as with NotNull.
+func NullAsZero[T any](dest *T) sql.Scanner {
+	return &nullScanner[T]{dest: dest}
+}
+
+// NullAs returns a sql.Scanner that scans a value into dest, and NULL as the
+// sentinel value, e.g. -1 for a count. The values are converted as with NotNull.
+func NullAs[T any](dest *T, value T) sql.Scanner {
+	return &nullScanner[T]{dest: dest, null: value}
+}
+
+type nullScanner[T any] struct {
+	dest *T
+	// the value NULL is scanned as, unless notNull
+	null    T
+	notNull bool
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *nullScanner[T]) Scan(src any) error {
+	if src == nil {
+		if s.notNull {
+			return ErrNullValue
+		}
+		*s.dest = s.null
+		return nil
+	}
+	return scanValue(reflect.ValueOf(s.dest).Elem(), src)
+}
+
+// scanValue assigns the non-NULL value src to dest, converting it to the type of dest
+func scanValue(dest reflect.Value, src any) error {
+	if scanner, ok := dest.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+	if dest.Kind() == reflect.Pointer {
+		p := reflect.New(dest.Type().Elem())
+		if err := scanValue(p.Elem(), src); err != nil {
+			return err
+		}
+		dest.Set(p)
+		return nil
+	}
+	if b, ok := src.([]byte); ok {
+		// the bytes are only valid until the next row
+		src = append([]byte(nil), b...)
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dest.Type()) {
+		dest.Set(sv)
+		return nil
+	}
+	outOfRange := func() error {
+		return &ConversionError{Value: asString(src), Type: dest.Type().String(), Reason: "out of range"}
+	}
+	str, isText := src.(string)
+	if b, ok := src.([]byte); ok {
+		str, isText = string(b), true
+	}
+	switch dest.Kind() {
+	case reflect.String:
+		dest.SetString(asString(src))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case isInt(sv):
+			if dest.OverflowInt(sv.Int()) {
+				return outOfRange()
+			}
+			dest.SetInt(sv.Int())
+			return nil
+		case isText:
+			i, err := strconv.ParseInt(str, 10, dest.Type().Bits())
+			if err != nil {
+				return &ConversionError{Value: str, Type: dest.Type().String(), Reason: err.Error()}
+			}
+			dest.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch {
+		case isInt(sv):
+			if sv.Int() < 0 || dest.OverflowUint(uint64(sv.Int())) {
+				return outOfRange()
+			}
+			dest.SetUint(uint64(sv.Int()))
+			return nil
+		case isText:
+			u, err := strconv.ParseUint(str, 10, dest.Type().Bits())
+			if err != nil {
+				return &ConversionError{Value: str, Type: dest.Type().String(), Reason: err.Error()}
+			}
+			dest.SetUint(u)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch {
+		case isInt(sv):
+			dest.SetFloat(float64(sv.Int()))
+			return nil
+		case sv.Kind() == reflect.Float32 || sv.Kind() == reflect.Float64:
+			if dest.OverflowFloat(sv.Float()) {
+				return outOfRange()
+			}
+			dest.SetFloat(sv.Float())
+			return nil
+		case isText:
+			f, err := strconv.ParseFloat(str, dest.Type().Bits())
+			if err != nil {
+				return &ConversionError{Value: str, Type: dest.Type().String(), Reason: err.Error()}
+			}
+			dest.SetFloat(f)
+			return nil
+		}
+	case reflect.Bool:
+		if isText {
+			b, err := strconv.ParseBool(str)
+			if err != nil {
+				return &ConversionError{Value: str, Type: dest.Type().String(), Reason: err.Error()}
+			}
+			dest.SetBool(b)
+			return nil
+		}
+	case reflect.Slice:
+		if dest.Type().Elem().Kind() == reflect.Uint8 && isText {
+			dest.SetBytes([]byte(str))
+			return nil
+		}
+	}
+	if sv.Type().ConvertibleTo(dest.Type()) && sv.Kind() == dest.Kind() {
+		// named types, e.g. a time.Time based type
+		dest.Set(sv.Convert(dest.Type()))
+		return nil
+	}
+	return errors.Errorf("databricks: cannot scan %T into %s", src, dest.Type())
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package dbsql
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullScanners(t *testing.T) {
+	t.Run("NULL maps to the zero value, a sentinel or an error", func(t *testing.T) {
+		name, count := "x", 5
+		require.NoError(t, NullAsZero(&name).Scan(nil))
+		require.NoError(t, NullAs(&count, -1).Scan(nil))
+		assert.Equal(t, "", name)
+		assert.Equal(t, -1, count)
+
+		var amount float64
+		err := NotNull(&amount).Scan(nil)
+		assert.ErrorIs(t, err, ErrNullValue)
+
+		p := new(int)
+		require.NoError(t, NullAsZero(&p).Scan(nil))
+		assert.Nil(t, p)
+	})
+
+	t.Run("values are converted to the destination", func(t *testing.T) {
+		var i int
+		var u uint8
+		var f float64
+		var s string
+		var b bool
+		var bs []byte
+		var p *int64
+		var ts time.Time
+		var d Decimal
+		var ns sql.NullString
+		type status string
+		var st status
+
+		now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		for _, tc := range []struct {
+			scanner sql.Scanner
+			src     any
+		}{
+			{NullAsZero(&i), int32(42)},
+			{NullAsZero(&u), int64(200)},
+			{NullAsZero(&f), "10.25"},
+			{NullAsZero(&s), int64(7)},
+			{NullAsZero(&b), "true"},
+			{NullAsZero(&bs), "abc"},
+			{NullAsZero(&p), int16(3)},
+			{NullAsZero(&ts), now},
+			{NullAsZero(&d), "12.50"},
+			{NullAsZero(&ns), "x"},
+			{NullAsZero(&st), "done"},
+		} {
+			require.NoError(t, tc.scanner.Scan(tc.src), "%T", tc.src)
+		}
+		assert.Equal(t, 42, i)
+		assert.Equal(t, uint8(200), u)
+		assert.Equal(t, 10.25, f)
+		assert.Equal(t, "7", s)
+		assert.True(t, b)
+		assert.Equal(t, []byte("abc"), bs)
+		require.NotNil(t, p)
+		assert.Equal(t, int64(3), *p)
+		assert.Equal(t, now, ts)
+		assert.Equal(t, "12.50", d.String())
+		assert.Equal(t, sql.NullString{String: "x", Valid: true}, ns)
+		assert.Equal(t, status("done"), st)
+	})
+
+	t.Run("values that do not fit fail", func(t *testing.T) {
+		var i8 int8
+		var conversionErr *ConversionError
+		assert.True(t, errors.As(NullAsZero(&i8).Scan(int32(300)), &conversionErr))
+		var u uint
+		assert.True(t, errors.As(NullAsZero(&u).Scan(int64(-1)), &conversionErr))
+		var i int
+		assert.True(t, errors.As(NullAsZero(&i).Scan("1.5"), &conversionErr))
+		var f float32
+		require.NoError(t, NullAs(&f, float32(math.NaN())).Scan(nil))
+		assert.True(t, math.IsNaN(float64(f)))
+		assert.EqualError(t, NullAsZero(&f).Scan(1e300), "databricks: cannot convert 1e+300 to float32: out of range")
+		assert.True(t, math.IsNaN(float64(f)))
+		require.NoError(t, NullAsZero(&f).Scan(math.Inf(-1)))
+		assert.True(t, math.IsInf(float64(f), -1))
+		var ts time.Time
+		assert.EqualError(t, NullAsZero(&ts).Scan(true), "databricks: cannot scan bool into time.Time")
+	})
+
+	t.Run("scanned bytes are copied", func(t *testing.T) {
+		src := []byte("abc")
+		var bs []byte
+		require.NoError(t, NullAsZero(&bs).Scan(src))
+		src[0] = 'x'
+		assert.Equal(t, []byte("abc"), bs)
+	})
+}
+
+func TestNullScanners_Rows(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"name", "amount"}, [][]string{{"a", "1.5"}}, "", &statements)
+	defer db.Close()
+
+	var name string
+	var amount float64
+	require.NoError(t, db.QueryRow("SELECT name, amount FROM t").Scan(NotNull(&name), NullAs(&amount, -1)))
+	assert.Equal(t, "a", name)
+	assert.Equal(t, 1.5, amount)
+}
@@ -0,0 +1,86 @@
+package dbsql
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+)
+
+// watchOrphan cancels and closes the server operation of the rows in the background
+// once ctx is done, or once the rows are garbage collected, in case they are never
+// closed. database/sql closes its rows once the query context is done, but the
+// operations of rows used through sql.Conn.Raw, or of rows that are dropped without
+// Close under a context that is never done, would otherwise stay open on the server
+// until the session is closed.
+//
+// The goroutine watching ctx holds the rows until ctx is done, so rows leaked under a
+// context that is never done are only found by the finalizer when ctx has no Done
+// channel, e.g. context.Background().
+func (r *rows) watchOrphan(ctx context.Context) {
+	r.done = make(chan struct{})
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				if !r.opClosed.Load() {
+					r.logger().Debug().Msgf("databricks: query context done before the rows were closed, closing the operation")
+					r.closeOrphan()
+				}
+			case <-r.done:
+			}
+		}()
+	}
+	runtime.SetFinalizer(r, func(r *rows) {
+		if r.opClosed.Load() {
+			return
+		}
+		// nothing else holds the rows, so their page can be released from here
+		r.releasePage()
+		r.closeOrphan()
+		reportLeak(r.config, r.logger(), "rows garbage collected without Close, closing the operation: query "+loggableQuery(r.query.Statement), r.stack)
+	})
+}
+
+// stopWatching stops watching the rows for being orphaned, once they are closed
+func (r *rows) stopWatching() {
+	if r.done == nil {
+		return
+	}
+	r.doneOnce.Do(func() {
+		close(r.done)
+		runtime.SetFinalizer(r, nil)
+	})
+}
+
+// closeOrphan cancels and closes the operation with the close client of the
+// connection, since the connection may be running another statement
+func (r *rows) closeOrphan() {
+	if r.conn == nil || r.conn.newCloseClient == nil {
+		return
+	}
+	r.conn.closeOperationAsync(func(client cli_service.TCLIService) error {
+		if r.opClosed.Load() {
+			return nil
+		}
+		ctx, cancel := r.closeContext()
+		defer cancel()
+		// canceling a finished operation fails, which is fine since it is closed next
+		if _, err := client.CancelOperation(ctx, &cli_service.TCancelOperationReq{OperationHandle: r.opHandle}); err != nil {
+			r.logger().Debug().Msgf("databricks: failed to cancel operation: %v", err)
+		}
+		return r.closeOperation(client)
+	}, r.logger())
+}
+
+// closeContext returns the context of the requests closing the operation, bounded by
+// the close timeout. It does not derive from the query context since that is usually
+// done by the time the rows are closed.
+func (r *rows) closeContext() (context.Context, context.CancelFunc) {
+	ctx := driverctx.NewContextWithCorrelationId(driverctx.NewContextWithConnId(context.Background(), r.connId), r.correlationId)
+	if r.config != nil && r.config.CloseOperationTimeout > 0 {
+		return context.WithTimeout(ctx, r.config.CloseOperationTimeout)
+	}
+	return ctx, func() {}
+}
@@ -0,0 +1,61 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRows_orphaned(t *testing.T) {
+	closed := func(recorder *shutdownRecorder) []string {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		return append([]string{}, recorder.closed...)
+	}
+
+	t.Run("operations are closed once their context is done", func(t *testing.T) {
+		recorder := &shutdownRecorder{}
+		testConn := getShutdownTestConn(t, recorder)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		r, err := testConn.QueryContext(ctx, "select 1", []driver.NamedValue{})
+		require.NoError(t, err)
+		cancel()
+		assert.Eventually(t, func() bool { return len(closed(recorder)) == 1 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{"00000001-0000-0000-0000-000000000000"}, recorder.canceled)
+
+		// the operation is not closed again
+		require.NoError(t, r.Close())
+		assert.Len(t, closed(recorder), 1)
+	})
+
+	t.Run("closed rows are left alone", func(t *testing.T) {
+		recorder := &shutdownRecorder{}
+		testConn := getShutdownTestConn(t, recorder)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		r, err := testConn.QueryContext(ctx, "select 1", []driver.NamedValue{})
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		cancel()
+		time.Sleep(50 * time.Millisecond)
+		assert.Empty(t, closed(recorder))
+	})
+
+	t.Run("operations of rows garbage collected without Close are closed", func(t *testing.T) {
+		recorder := &shutdownRecorder{}
+		testConn := getShutdownTestConn(t, recorder)
+
+		_, err := testConn.QueryContext(context.Background(), "select 1", []driver.NamedValue{})
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			runtime.GC()
+			return len(closed(recorder)) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
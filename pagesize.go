@@ -0,0 +1,58 @@
+package dbsql
+
+import (
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+)
+
+// the bounds of the rows fetched per request with an adaptive page size
+const (
+	minAdaptivePageRows = 100
+	maxAdaptivePageRows = 1000000
+	// maxAdaptivePageGrowth is how many times larger than the previous page the next
+	// one can be, so that a few narrow rows at the start of the results do not lead
+	// to a giant page
+	maxAdaptivePageGrowth = 4
+)
+
+// adaptPageSize sets the rows of the next fetches from the width of the rows and the
+// latency of a page fetched with maxRows, when an adaptive page size is set
+func (r *rows) adaptPageSize(maxRows int64, resp *cli_service.TFetchResultsResp, latency time.Duration) {
+	if r.config == nil || (r.config.TargetPageBytes <= 0 && r.config.TargetPageLatency <= 0) {
+		return
+	}
+	results := resp.GetResults()
+	n := getNRows(results)
+	if n <= 0 {
+		return
+	}
+	size := int64(maxAdaptivePageRows)
+	if target := r.config.TargetPageBytes; target > 0 {
+		width := rowSetSize(results) / n
+		if width < 1 {
+			width = 1
+		}
+		size = target / width
+	}
+	// only full pages tell the time the server takes per row, the last one may be
+	// mostly fixed costs
+	if target := r.config.TargetPageLatency; target > 0 && n >= maxRows && latency > 0 {
+		if byLatency := int64(float64(n) * float64(target) / float64(latency)); byLatency < size {
+			size = byLatency
+		}
+	}
+	if limit := maxAdaptivePageGrowth * r.pageSize; size > limit {
+		size = limit
+	}
+	if size < minAdaptivePageRows {
+		size = minAdaptivePageRows
+	}
+	if size > maxAdaptivePageRows {
+		size = maxAdaptivePageRows
+	}
+	if size != r.pageSize {
+		r.logger().Debug().Msgf("page size adapted from %d to %d rows", r.pageSize, size)
+		r.pageSize = size
+	}
+}
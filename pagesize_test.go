@@ -0,0 +1,69 @@
+package dbsql
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptivePageSize(t *testing.T) {
+	int32Page := func(n int) *cli_service.TFetchResultsResp {
+		return &cli_service.TFetchResultsResp{Results: &cli_service.TRowSet{
+			Columns: []*cli_service.TColumn{{I32Val: &cli_service.TI32Column{Values: make([]int32, n)}}},
+		}}
+	}
+
+	t.Run("pages grow to the target bytes", func(t *testing.T) {
+		var requests []*cli_service.TFetchResultsReq
+		cfg := config.WithDefaults()
+		// 800 rows of a single int column
+		cfg.TargetPageBytes = 3200
+		r := &rows{pageSize: 100, client: getRowsTestCursorClient(2000, &requests), config: cfg}
+		dest := make([]driver.Value, 1)
+		var n int
+		for r.Next(dest) == nil {
+			n++
+		}
+		assert.Equal(t, 2000, n)
+		var sizes []int64
+		for _, req := range requests {
+			sizes = append(sizes, req.MaxRows)
+		}
+		assert.Equal(t, []int64{100, 400, 800, 800}, sizes)
+	})
+
+	t.Run("full pages slower than the target latency shrink", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.TargetPageLatency = 100 * time.Millisecond
+		r := &rows{pageSize: 1000, config: cfg}
+		r.adaptPageSize(1000, int32Page(1000), 400*time.Millisecond)
+		assert.Equal(t, int64(250), r.pageSize)
+
+		// the last page is not full, so its latency is ignored
+		r.adaptPageSize(250, int32Page(10), time.Second)
+		assert.Equal(t, int64(1000), r.pageSize)
+	})
+
+	t.Run("page sizes are bounded", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.TargetPageBytes = 1
+		r := &rows{pageSize: 1000, config: cfg}
+		r.adaptPageSize(1000, int32Page(1000), time.Millisecond)
+		assert.Equal(t, int64(minAdaptivePageRows), r.pageSize)
+
+		cfg.TargetPageBytes = 1 << 40
+		r = &rows{pageSize: 500000, config: cfg}
+		r.adaptPageSize(500000, int32Page(10), time.Millisecond)
+		assert.Equal(t, int64(maxAdaptivePageRows), r.pageSize)
+	})
+
+	t.Run("page sizes are fixed without targets", func(t *testing.T) {
+		r := &rows{pageSize: 1000, config: config.WithDefaults()}
+		r.adaptPageSize(1000, int32Page(1000), time.Hour)
+		assert.Equal(t, int64(1000), r.pageSize)
+	})
+}
@@ -0,0 +1,327 @@
+package dbsql
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+var errParamsCount = "databricks: query has %d placeholders but %d arguments"
+var errParamsNamed = "databricks: named parameters are not supported"
+var errParamsUnsupportedType = "databricks: unsupported parameter type %T"
+var errParamsEmptyInList = "databricks: empty slice bound to an IN list"
+var errParamsInvalidString = "databricks: string parameter is not valid UTF-8"
+
+var _ driver.NamedValueChecker = (*conn)(nil)
+
+// CheckNamedValue lets slices and maps through to the driver, which binds them
+// as ARRAY and MAP literals, as well as Decimal values, the slices of InListArray
+// and InListValues, and unsigned integers, which the default conversion rejects
+// above math.MaxInt64. All other values get the default conversion.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case Decimal, *Decimal, inListArray, inListValues:
+		return nil
+	}
+	switch reflect.ValueOf(nv.Value).Kind() {
+	case reflect.Uint, reflect.Uint64:
+		return nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if _, ok := nv.Value.([]byte); ok {
+			return driver.ErrSkip
+		}
+		if _, ok := nv.Value.(driver.Valuer); ok {
+			return driver.ErrSkip
+		}
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// interpolateParams replaces the ? placeholders of query with the arguments
+// formatted as SQL literals. Placeholders in string literals, quoted identifiers
+// and comments are left alone.
+//
+// Slices become ARRAY literals, except when the placeholder is the only item of an
+// IN list, as in WHERE id IN (?), where the slice is expanded into the list.
+// Maps become MAP literals with the keys in sorted order.
+func interpolateParams(query string, args []driver.NamedValue) (string, error) {
+	return parseParams(query).bind(args)
+}
+
+// paramQuery is a query with the positions of its placeholders, so that a
+// prepared statement scans its query once and binds it many times.
+type paramQuery struct {
+	query        string
+	placeholders []placeholder
+}
+
+type placeholder struct {
+	// offset of the ? in the query
+	pos int
+	// the placeholder is the only item of an IN list
+	inList bool
+}
+
+// parseParams finds the placeholders of query
+func parseParams(query string) *paramQuery {
+	// offsets of the code characters, to look around placeholders
+	var code []int
+	scanSQL(query, func(kind sqlTokenKind, start, end int) bool {
+		if kind == sqlCode {
+			code = append(code, start)
+		}
+		return true
+	})
+
+	q := &paramQuery{query: query}
+	for i, pos := range code {
+		if query[pos] == '?' {
+			q.placeholders = append(q.placeholders, placeholder{pos: pos, inList: isInListItem(query, code, i)})
+		}
+	}
+	return q
+}
+
+// bind returns the query with the placeholders replaced by args
+func (q *paramQuery) bind(args []driver.NamedValue) (string, error) {
+	for _, arg := range args {
+		if arg.Name != "" {
+			return "", errors.New(errParamsNamed)
+		}
+	}
+	if len(q.placeholders) != len(args) {
+		return "", errors.Errorf(errParamsCount, len(q.placeholders), len(args))
+	}
+
+	var b strings.Builder
+	var last int
+	for i, p := range q.placeholders {
+		var literal string
+		var err error
+		if p.inList {
+			literal, err = formatInList(args[i].Value)
+		} else {
+			literal, err = formatLiteral(args[i].Value)
+		}
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(q.query[last:p.pos])
+		b.WriteString(literal)
+		last = p.pos + 1
+	}
+	b.WriteString(q.query[last:])
+	return b.String(), nil
+}
+
+// isInListItem returns true if the placeholder at code[i] is the only item of
+// an IN list, i.e. it is preceded by IN ( and followed by ).
+func isInListItem(query string, code []int, i int) bool {
+	if i < 3 || i+1 >= len(code) || query[code[i-1]] != '(' || query[code[i+1]] != ')' {
+		return false
+	}
+	n, i1 := code[i-3], code[i-2]
+	if i1 != n+1 || !strings.EqualFold(query[n:i1+1], "in") {
+		return false
+	}
+	// IN must be a whole word
+	return i < 4 || code[i-4] != n-1 || !isIdentChar(query[n-1])
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// formatInList formats the items of a slice as an IN list. Other values are
+// formatted as a single literal.
+func formatInList(v any) (string, error) {
+	switch v.(type) {
+	case inListArray, inListValues:
+		return formatInListSubquery(v)
+	}
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || isBytes(v) {
+		return formatLiteral(v)
+	}
+	if rv.Len() == 0 {
+		return "", errors.New(errParamsEmptyInList)
+	}
+	return formatItems(rv)
+}
+
+// formatLiteral formats v as a Databricks SQL literal.
+func formatLiteral(v any) (string, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return "NULL", nil
+		}
+		value, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		v = value
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return formatString(v)
+	case Decimal:
+		if !v.Valid {
+			return "NULL", nil
+		}
+		return signed(v.s + "BD"), nil
+	case []byte:
+		if v == nil {
+			return "NULL", nil
+		}
+		return "X'" + hex.EncodeToString(v) + "'", nil
+	case inListArray:
+		return formatLiteral(v.values)
+	case inListValues:
+		return formatLiteral(v.values)
+	case time.Time:
+		return "TIMESTAMP " + quoteStringLiteral(v.Format("2006-01-02T15:04:05.999999Z07:00")), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return formatLiteral(rv.Elem().Interface())
+	case reflect.Bool:
+		return formatLiteral(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return signed(strconv.FormatInt(rv.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return formatFloat(rv.Float(), rv.Type().Bits()), nil
+	case reflect.String:
+		return formatString(rv.String())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return "NULL", nil
+		}
+		items, err := formatItems(rv)
+		if err != nil {
+			return "", err
+		}
+		return "array(" + items + ")", nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return formatMap(rv)
+	}
+	return "", errors.Errorf(errParamsUnsupportedType, v)
+}
+
+// formatString quotes s as a string literal. Strings that are not valid UTF-8
+// are rejected rather than mangled by the server.
+func formatString(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", errors.New(errParamsInvalidString)
+	}
+	return quoteStringLiteral(s), nil
+}
+
+func formatFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "double('NaN')"
+	case math.IsInf(f, 1):
+		return "double('Infinity')"
+	case math.IsInf(f, -1):
+		return "double('-Infinity')"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, bits)
+	if bits == 32 {
+		return "CAST(" + s + " AS FLOAT)"
+	}
+	// without an exponent the literal would be a DECIMAL
+	if !strings.ContainsAny(s, "eE") {
+		s += "D"
+	}
+	return signed(s)
+}
+
+// signed wraps a negative numeric literal in parentheses, so that its sign can not
+// merge with the SQL before the placeholder, e.g. 1-? would become the comment 1--1
+func signed(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// formatItems formats the items of a slice or array separated by commas
+func formatItems(rv reflect.Value) (string, error) {
+	items := make([]string, rv.Len())
+	for i := range items {
+		item, err := formatLiteral(rv.Index(i).Interface())
+		if err != nil {
+			return "", err
+		}
+		items[i] = item
+	}
+	return strings.Join(items, ", "), nil
+}
+
+// formatMap formats a map as a MAP literal with its entries sorted by key
+func formatMap(rv reflect.Value) (string, error) {
+	entries := make([][2]string, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := formatLiteral(iter.Key().Interface())
+		if err != nil {
+			return "", err
+		}
+		value, err := formatLiteral(iter.Value().Interface())
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, [2]string{key, value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i][0] < entries[j][0] })
+	parts := make([]string, 0, 2*len(entries))
+	for _, e := range entries {
+		parts = append(parts, e[0], e[1])
+	}
+	return fmt.Sprintf("map(%s)", strings.Join(parts, ", ")), nil
+}
+
+func isBytes(v any) bool {
+	_, ok := v.([]byte)
+	return ok
+}
+
+// bindParams returns query with args inlined when parameter interpolation is
+// enabled. Without it, any argument is an error.
+func (c *conn) bindParams(query string, args []driver.NamedValue) (string, error) {
+	if len(args) == 0 {
+		return query, nil
+	}
+	if !c.cfg.InterpolateParams {
+		return "", errors.New(ErrParametersNotSupported)
+	}
+	return interpolateParams(query, args)
+}
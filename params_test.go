@@ -0,0 +1,156 @@
+package dbsql
+
+import (
+	"database/sql/driver"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func namedValues(values ...any) []driver.NamedValue {
+	args := make([]driver.NamedValue, len(values))
+	for i, v := range values {
+		args[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return args
+}
+
+type testValuer struct{ v string }
+
+func (v testValuer) Value() (driver.Value, error) { return v.v, nil }
+
+func TestFormatLiteral(t *testing.T) {
+	ts := time.Date(2023, 1, 2, 3, 4, 5, 6000, time.UTC)
+	var nilPtr *int
+	cases := []struct {
+		value    any
+		expected string
+	}{
+		{nil, "NULL"},
+		{true, "TRUE"},
+		{int64(-3), "(-3)"},
+		{-1.5, "(-1.5D)"},
+		{-1e100, "(-1e+100)"},
+		{float32(-0.5), "CAST(-0.5 AS FLOAT)"},
+		{decimal("-2.5"), "(-2.5BD)"},
+		{uint8(7), "7"},
+		{1.5, "1.5D"},
+		{1e100, "1e+100"},
+		{float32(0.5), "CAST(0.5 AS FLOAT)"},
+		{math.NaN(), "double('NaN')"},
+		{math.Inf(-1), "double('-Infinity')"},
+		{"it's \\", `'it\'s \\'`},
+		{[]byte{0xca, 0xfe}, "X'cafe'"},
+		{ts, "TIMESTAMP '2023-01-02T03:04:05.000006Z'"},
+		{testValuer{"v"}, "'v'"},
+		{nilPtr, "NULL"},
+		{[]int{1, 2}, "array(1, 2)"},
+		{[]string{}, "array()"},
+		{[][]string{{"a"}, {"b", "c"}}, "array(array('a'), array('b', 'c'))"},
+		{map[string]int{"b": 2, "a": 1}, "map('a', 1, 'b', 2)"},
+		{map[string][]int{"a": {1}}, "map('a', array(1))"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{decimal("12345678901234567890.5"), "12345678901234567890.5BD"},
+		{&Decimal{}, "NULL"},
+	}
+	for _, c := range cases {
+		literal, err := formatLiteral(c.value)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, literal)
+	}
+
+	_, err := formatLiteral(struct{}{})
+	assert.EqualError(t, err, "databricks: unsupported parameter type struct {}")
+	_, err = formatLiteral(complex(1, 2))
+	assert.EqualError(t, err, "databricks: unsupported parameter type complex128")
+	_, err = formatLiteral("\xff")
+	assert.EqualError(t, err, errParamsInvalidString)
+	_, err = formatLiteral([]string{"\xff"})
+	assert.EqualError(t, err, errParamsInvalidString)
+}
+
+func decimal(s string) Decimal {
+	var d Decimal
+	if err := d.Scan(s); err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestInterpolateParams(t *testing.T) {
+	t.Run("placeholders are replaced in code only", func(t *testing.T) {
+		query, err := interpolateParams("select ?, '?', `?` -- ?\n, ?", namedValues(1, "a"))
+		require.NoError(t, err)
+		assert.Equal(t, "select 1, '?', `?` -- ?\n, 'a'", query)
+
+		query, err = interpolateParams(`select r'\', ?, /* /* ? */ ? */ ?`, namedValues(1, 2))
+		require.NoError(t, err)
+		assert.Equal(t, `select r'\', 1, /* /* ? */ ? */ 2`, query)
+	})
+
+	t.Run("slices are expanded in IN lists", func(t *testing.T) {
+		query, err := interpolateParams("select * from t where id in ( ? ) and tags = ?", namedValues([]int{1, 2}, []string{"x"}))
+		require.NoError(t, err)
+		assert.Equal(t, "select * from t where id in ( 1, 2 ) and tags = array('x')", query)
+
+		query, err = interpolateParams("select * from t where id IN(?)", namedValues(3))
+		require.NoError(t, err)
+		assert.Equal(t, "select * from t where id IN(3)", query)
+
+		query, err = interpolateParams("select join(?)", namedValues([]int{1}))
+		require.NoError(t, err)
+		assert.Equal(t, "select join(array(1))", query)
+
+		_, err = interpolateParams("select * from t where id in (?)", namedValues([]int{}))
+		assert.EqualError(t, err, errParamsEmptyInList)
+	})
+
+	t.Run("negative numbers do not start comments", func(t *testing.T) {
+		query, err := interpolateParams("select * from t where a = 1-? and b = ?", namedValues(-1, "\nor 1=1 --"))
+		require.NoError(t, err)
+		assert.Equal(t, "select * from t where a = 1-(-1) and b = '\nor 1=1 --'", query)
+
+		query, err = interpolateParams("select 1-?, 2-?, 3-?, ?", namedValues(-1.5, decimal("-2"), []int{-3}, "--"))
+		require.NoError(t, err)
+		assert.Equal(t, "select 1-(-1.5D), 2-(-2BD), 3-array((-3)), '--'", query)
+	})
+
+	t.Run("argument count must match", func(t *testing.T) {
+		_, err := interpolateParams("select ?, ?", namedValues(1))
+		assert.EqualError(t, err, "databricks: query has 2 placeholders but 1 arguments")
+		_, err = interpolateParams("select ?", namedValues(1, 2))
+		assert.EqualError(t, err, "databricks: query has 1 placeholders but 2 arguments")
+	})
+
+	t.Run("named arguments are rejected", func(t *testing.T) {
+		_, err := interpolateParams("select ?", []driver.NamedValue{{Name: "a", Ordinal: 1, Value: 1}})
+		assert.EqualError(t, err, errParamsNamed)
+	})
+}
+
+func TestConn_CheckNamedValue(t *testing.T) {
+	c := &conn{}
+	assert.NoError(t, c.CheckNamedValue(&driver.NamedValue{Value: []int{1}}))
+	assert.NoError(t, c.CheckNamedValue(&driver.NamedValue{Value: map[string]int{}}))
+	assert.ErrorIs(t, c.CheckNamedValue(&driver.NamedValue{Value: []byte{1}}), driver.ErrSkip)
+	assert.ErrorIs(t, c.CheckNamedValue(&driver.NamedValue{Value: 1}), driver.ErrSkip)
+	assert.NoError(t, c.CheckNamedValue(&driver.NamedValue{Value: uint64(math.MaxUint64)}))
+	assert.NoError(t, c.CheckNamedValue(&driver.NamedValue{Value: Decimal{}}))
+	// UUID is a [16]byte Valuer, which the default conversion binds as its string form
+	assert.ErrorIs(t, c.CheckNamedValue(&driver.NamedValue{Value: UUID{}}), driver.ErrSkip)
+}
+
+func TestConn_BindParams(t *testing.T) {
+	c := &conn{cfg: config.WithDefaults()}
+	_, err := c.bindParams("select ?", namedValues([]int{1}))
+	assert.EqualError(t, err, ErrParametersNotSupported)
+
+	c.cfg.InterpolateParams = true
+	query, err := c.bindParams("select ?", namedValues([]int{1}))
+	require.NoError(t, err)
+	assert.Equal(t, "select array(1)", query)
+}
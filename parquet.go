@@ -0,0 +1,88 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"io"
+
+	"github.com/apache/arrow/go/v11/parquet"
+	"github.com/apache/arrow/go/v11/parquet/file"
+	"github.com/apache/arrow/go/v11/parquet/pqarrow"
+)
+
+// parquetRowGroupLength is the number of rows of the row groups of WriteParquet,
+// which are buffered until they are full
+const parquetRowGroupLength = 1 << 20
+
+// WriteParquet runs the query on conn and writes its results to w as a Parquet file,
+// for extracts that keep the types of the columns, including decimals and timestamps.
+// It returns the number of rows written.
+//
+// The result pages are decoded to Arrow record batches, as with WriteArrowIPC, which
+// are written to row groups as they are fetched, so the results are not held in
+// memory at once. See Rows.WriteParquet for the types of the Parquet columns.
+func WriteParquet(ctx context.Context, conn *sql.Conn, w io.Writer, query string, args ...any) (int64, error) {
+	var n int64
+	err := queryRows(ctx, conn, query, args, func(r Rows) (err error) {
+		n, err = r.WriteParquet(w)
+		return err
+	})
+	return n, err
+}
+
+// WriteParquet writes the rows that were not read yet to w as a Parquet file, with
+// row groups of up to a million rows. The pages are not compressed. w is not closed.
+//
+// The columns are optional and are converted from the Arrow types of the stream
+// written by WriteArrowIPC: TINYINT and SMALLINT columns are written as annotated
+// INT32 columns, DATE columns as INT32 dates, TIMESTAMP columns as INT64
+// microseconds since the epoch, adjusted to UTC, and DECIMAL columns as
+// FIXED_LEN_BYTE_ARRAY decimals. STRING and the other text columns are written as
+// UTF8 annotated BYTE_ARRAY columns.
+func (r *rows) WriteParquet(w io.Writer) (int64, error) {
+	columns, err := r.arrowColumns()
+	if err != nil {
+		return 0, err
+	}
+	createdBy := "databricks-sql-go"
+	if r.config != nil && r.config.DriverVersion != "" {
+		createdBy += " version " + r.config.DriverVersion
+	}
+	props := parquet.NewWriterProperties(
+		parquet.WithCreatedBy(createdBy),
+		parquet.WithMaxRowGroupLength(parquetRowGroupLength),
+	)
+	schema := arrowSchema(columns, r.location)
+	// the file writer closes its writer when it is a Closer, which is up to the caller
+	sink := struct{ io.Writer }{w}
+	// the Arrow file writer cannot be closed before a record is written to it, so
+	// it is created with the first one
+	var writer *pqarrow.FileWriter
+	n, err := r.readArrowBatches(columns, func(batch arrowBatch) (err error) {
+		if batch.rows == 0 {
+			return nil
+		}
+		if writer == nil {
+			writer, err = pqarrow.NewFileWriter(schema, sink, props, pqarrow.DefaultWriterProps())
+			if err != nil {
+				return err
+			}
+		}
+		record := arrowRecord(schema, batch)
+		defer record.Release()
+		// the values are encoded to the pages of the row group, so the buffers of
+		// the batch can be reused by the next one
+		return writer.WriteBuffered(record)
+	})
+	if err != nil {
+		return n, err
+	}
+	if writer != nil {
+		return n, writer.Close()
+	}
+	fileSchema, err := pqarrow.ToParquet(schema, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return n, err
+	}
+	return n, file.NewParquetWriter(sink, fileSchema.Root(), file.WithWriterProps(props)).Close()
+}
@@ -0,0 +1,121 @@
+package dbsql
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/memory"
+	"github.com/apache/arrow/go/v11/parquet/file"
+	"github.com/apache/arrow/go/v11/parquet/pqarrow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readParquet reads a Parquet file with the Arrow library and returns the file and
+// its rows as an Arrow table, which the caller releases
+func readParquet(t *testing.T, b []byte) (*file.Reader, arrow.Table) {
+	pf, err := file.NewParquetReader(bytes.NewReader(b))
+	require.NoError(t, err)
+	reader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	table, err := reader.ReadTable(context.Background())
+	require.NoError(t, err)
+	return pf, table
+}
+
+// closeRecorder is a writer that records whether it was closed
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *closeRecorder) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestRowsWriteParquet(t *testing.T) {
+	t.Run("result pages are buffered into row groups", func(t *testing.T) {
+		r := getArrowTestRows(t)
+		var buf bytes.Buffer
+		n, err := r.WriteParquet(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+		assert.Equal(t, int64(3), r.NextRowNumber())
+
+		pf, table := readParquet(t, buf.Bytes())
+		defer pf.Close()
+		defer table.Release()
+		assert.Equal(t, 1, pf.NumRowGroups())
+		assert.Equal(t, "databricks-sql-go version 0.9.0", pf.MetaData().GetCreatedBy())
+		assert.Equal(t, int64(3), table.NumRows())
+		var types []string
+		for _, field := range table.Schema().Fields() {
+			assert.True(t, field.Nullable)
+			types = append(types, field.Name+" "+field.Type.String())
+		}
+		assert.Equal(t, []string{"id int64", "name utf8", "ok bool", "price decimal(10, 2)", "day date32", "at timestamp[us, tz=UTC]", "score float64"}, types)
+		for i, want := range arrowTestValues {
+			assert.Equal(t, want, arrowValues(t, table.Column(i).Data().Chunks()...), table.Schema().Field(i).Name)
+		}
+	})
+
+	t.Run("statements without a result set have no columns or row groups", func(t *testing.T) {
+		r := getArrowTestRows(t)
+		r.noResultSet = true
+		var buf bytes.Buffer
+		n, err := r.WriteParquet(&buf)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		pf, table := readParquet(t, buf.Bytes())
+		defer pf.Close()
+		defer table.Release()
+		assert.Zero(t, pf.NumRowGroups())
+		assert.Zero(t, table.NumCols())
+	})
+
+	t.Run("empty results have columns but no row groups", func(t *testing.T) {
+		r := getArrowTestRows(t)
+		dest := make([]driver.Value, 7)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, r.Next(dest))
+		}
+		var buf bytes.Buffer
+		n, err := r.WriteParquet(&buf)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		pf, table := readParquet(t, buf.Bytes())
+		defer pf.Close()
+		defer table.Release()
+		assert.Zero(t, pf.NumRowGroups())
+		assert.Equal(t, int64(7), table.NumCols())
+		assert.Equal(t, "price decimal(10, 2)", table.Schema().Field(3).Name+" "+table.Schema().Field(3).Type.String())
+	})
+
+	t.Run("the writer is not closed", func(t *testing.T) {
+		r := getArrowTestRows(t)
+		w := &closeRecorder{}
+		_, err := r.WriteParquet(w)
+		require.NoError(t, err)
+		assert.False(t, w.closed)
+		assert.Equal(t, "PAR1", w.String()[w.Len()-4:])
+	})
+}
+
+func TestWriteParquet(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"name"}, [][]string{{"a"}, {"b"}}, "", &statements)
+	defer db.Close()
+	c, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer c.Close()
+
+	var buf bytes.Buffer
+	n, err := WriteParquet(context.Background(), c, &buf, "SELECT name FROM t")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, "PAR1", buf.String()[:4])
+}
@@ -0,0 +1,64 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// WarmPool opens n connections of db concurrently and pings each of them, which
+// opens their sessions and wakes up the warehouse, so that the first requests after
+// a deploy do not wait for it. The connections are then returned to the pool.
+//
+// The pool only keeps as many idle connections as allowed by db.SetMaxIdleConns,
+// which defaults to 2, so set it to at least n for the connections to be kept. n is
+// capped at the limit set with db.SetMaxOpenConns, since the connections are all
+// held at the same time. WarmPool returns the error of the first connection that
+// failed, after all connections were tried. It does nothing when n is not positive.
+func WarmPool(ctx context.Context, db *sql.DB, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if max := db.Stats().MaxOpenConnections; max > 0 && n > max {
+		n = max
+	}
+	conns := make([]*sql.Conn, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			conns[i] = conn
+			errs[i] = conn.PingContext(ctx)
+		}(i)
+	}
+	// hold all connections until every one is open, otherwise the pool would
+	// hand the same idle connection to several goroutines
+	wg.Wait()
+
+	var firstErr error
+	failed := 0
+	for i, conn := range conns {
+		if conn != nil {
+			_ = conn.Close()
+		}
+		if errs[i] != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+		}
+	}
+	if firstErr != nil {
+		return errors.Wrapf(firstErr, "databricks: failed to warm up %d of %d connections", failed, n)
+	}
+	return nil
+}
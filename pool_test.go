@@ -0,0 +1,113 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warmPoolConnector opens a new test connection on each Connect, whose statements
+// fail when fail returns true
+type warmPoolConnector struct {
+	mu       sync.Mutex
+	opened   int
+	executed int32
+	fail     func(n int) bool
+}
+
+func (c *warmPoolConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	c.opened++
+	n := c.opened
+	c.mu.Unlock()
+
+	cfg := config.WithDefaults()
+	cfg.PollInterval = 10 * time.Millisecond
+	return &conn{
+		session: getTestSession(),
+		client: &client.TestClient{
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				atomic.AddInt32(&c.executed, 1)
+				if c.fail != nil && c.fail(n) {
+					return nil, errors.New("warehouse unavailable")
+				}
+				return &cli_service.TExecuteStatementResp{
+					Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+					OperationHandle: &cli_service.TOperationHandle{
+						OperationId: &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+					},
+					DirectResults: &cli_service.TSparkDirectResults{
+						OperationStatus: &cli_service.TGetOperationStatusResp{
+							OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+						},
+					},
+				}, nil
+			},
+		},
+		cfg: cfg,
+	}, nil
+}
+
+func (c *warmPoolConnector) Driver() driver.Driver {
+	return &databricksDriver{}
+}
+
+func TestWarmPool(t *testing.T) {
+	t.Run("opens and pings n connections", func(t *testing.T) {
+		connector := &warmPoolConnector{}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+		db.SetMaxIdleConns(4)
+
+		require.NoError(t, WarmPool(context.Background(), db, 4))
+		assert.Equal(t, 4, connector.opened)
+		assert.Equal(t, int32(4), connector.executed)
+		assert.Equal(t, 4, db.Stats().Idle)
+	})
+
+	t.Run("opens at most the max open connections", func(t *testing.T) {
+		connector := &warmPoolConnector{}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+		db.SetMaxIdleConns(4)
+		db.SetMaxOpenConns(2)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, WarmPool(ctx, db, 3))
+		assert.Equal(t, 2, connector.opened)
+		assert.Equal(t, 2, db.Stats().Idle)
+	})
+
+	t.Run("does nothing for no connections", func(t *testing.T) {
+		connector := &warmPoolConnector{}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		require.NoError(t, WarmPool(context.Background(), db, 0))
+		require.NoError(t, WarmPool(context.Background(), db, -1))
+		assert.Zero(t, connector.opened)
+	})
+
+	t.Run("reports failed connections", func(t *testing.T) {
+		connector := &warmPoolConnector{fail: func(n int) bool { return n%2 == 0 }}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+		db.SetMaxIdleConns(4)
+
+		err := WarmPool(context.Background(), db, 4)
+		assert.EqualError(t, err, "databricks: failed to warm up 2 of 4 connections: "+driver.ErrBadConn.Error())
+		assert.Equal(t, 2, db.Stats().Idle)
+	})
+}
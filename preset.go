@@ -0,0 +1,108 @@
+package dbsql
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+)
+
+var errSessionPresetUnknown = "databricks: unknown session preset %q"
+var errSessionPresetDuplicate = "databricks: a session preset is already registered as %q"
+var errSessionPresetInvalid = "databricks: session preset %q: %s"
+
+// Built-in session presets, to pass to WithSessionPreset.
+const (
+	// SessionPresetStrictANSI enables the ANSI SQL mode, which fails on invalid
+	// casts and arithmetic overflows instead of returning NULL, and fails on
+	// datetime patterns that the legacy parser would have accepted.
+	SessionPresetStrictANSI = "strict-ansi"
+	// SessionPresetLegacy disables the ANSI SQL mode and parses datetime values
+	// like Spark 2, for workloads written against the legacy semantics.
+	SessionPresetLegacy = "legacy"
+)
+
+var sessionPresetsMu sync.RWMutex
+
+var sessionPresets = map[string]map[string]string{
+	SessionPresetStrictANSI: {"ansi_mode": "true", "legacy_time_parser_policy": "EXCEPTION"},
+	SessionPresetLegacy:     {"ansi_mode": "false", "legacy_time_parser_policy": "LEGACY"},
+}
+
+// RegisterSessionPreset registers a named set of session parameters, so that the
+// services of a platform apply the same SQL semantics by name with WithSessionPreset
+// or the sessionPreset DSN parameter:
+//
+//	err := dbsql.RegisterSessionPreset("reporting", map[string]string{
+//		"ansi_mode":                 "true",
+//		"legacy_time_parser_policy": "CORRECTED",
+//	})
+//	connector, err := dbsql.NewConnector(..., dbsql.WithSessionPreset("reporting"))
+//
+// It returns an error if a preset is already registered with the name, including
+// the built-in ones. The time zone cannot be part of a preset, set it with
+// WithTimeZone.
+func RegisterSessionPreset(name string, params map[string]string) error {
+	if name == "" {
+		return errors.Errorf(errSessionPresetInvalid, name, "the name is empty")
+	}
+	copied := make(map[string]string, len(params))
+	for k, v := range params {
+		if strings.ToLower(k) == "timezone" {
+			return errors.Errorf(errSessionPresetInvalid, name, "set the time zone with WithTimeZone")
+		}
+		copied[k] = v
+	}
+	sessionPresetsMu.Lock()
+	defer sessionPresetsMu.Unlock()
+	if _, ok := sessionPresets[name]; ok {
+		return errors.Errorf(errSessionPresetDuplicate, name)
+	}
+	sessionPresets[name] = copied
+	return nil
+}
+
+// SessionPresetParams returns a copy of the session parameters of a preset, and
+// false if no preset is registered with the name.
+func SessionPresetParams(name string) (map[string]string, bool) {
+	params, ok := lookupSessionPreset(name)
+	if !ok {
+		return nil, false
+	}
+	copied := make(map[string]string, len(params))
+	for k, v := range params {
+		copied[k] = v
+	}
+	return copied, true
+}
+
+func lookupSessionPreset(name string) (map[string]string, bool) {
+	sessionPresetsMu.RLock()
+	defer sessionPresetsMu.RUnlock()
+	params, ok := sessionPresets[name]
+	return params, ok
+}
+
+// sessionParams returns the parameters set on the sessions of cfg: those of its
+// session preset, overridden by its session params
+func sessionParams(cfg *config.Config) map[string]string {
+	preset, _ := lookupSessionPreset(cfg.SessionPreset)
+	if len(preset) == 0 {
+		return cfg.SessionParams
+	}
+	overridden := make(map[string]bool, len(cfg.SessionParams))
+	for k := range cfg.SessionParams {
+		overridden[strings.ToLower(k)] = true
+	}
+	params := make(map[string]string, len(preset)+len(cfg.SessionParams))
+	for k, v := range preset {
+		if !overridden[strings.ToLower(k)] {
+			params[k] = v
+		}
+	}
+	for k, v := range cfg.SessionParams {
+		params[k] = v
+	}
+	return params
+}
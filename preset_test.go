@@ -0,0 +1,83 @@
+package dbsql
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterSessionPreset(t *testing.T) {
+	t.Run("presets are registered once", func(t *testing.T) {
+		params := map[string]string{"ansi_mode": "true"}
+		require.NoError(t, RegisterSessionPreset("test-preset", params))
+		params["ansi_mode"] = "false"
+
+		registered, ok := SessionPresetParams("test-preset")
+		assert.True(t, ok)
+		assert.Equal(t, map[string]string{"ansi_mode": "true"}, registered)
+
+		assert.EqualError(t, RegisterSessionPreset("test-preset", nil), `databricks: a session preset is already registered as "test-preset"`)
+		assert.EqualError(t, RegisterSessionPreset(SessionPresetStrictANSI, nil), `databricks: a session preset is already registered as "strict-ansi"`)
+	})
+
+	t.Run("invalid presets are rejected", func(t *testing.T) {
+		assert.EqualError(t, RegisterSessionPreset("", nil), `databricks: session preset "": the name is empty`)
+		assert.EqualError(t, RegisterSessionPreset("zoned", map[string]string{"TimeZone": "UTC"}), `databricks: session preset "zoned": set the time zone with WithTimeZone`)
+		_, ok := SessionPresetParams("zoned")
+		assert.False(t, ok)
+	})
+
+	t.Run("unknown presets fail validation", func(t *testing.T) {
+		_, err := NewConnector(WithServerHostname("localhost"), WithSessionPreset("unknown"))
+		assert.EqualError(t, err, `databricks: unknown session preset "unknown"`)
+	})
+}
+
+func TestSessionParams(t *testing.T) {
+	cfg := config.WithDefaults()
+	cfg.SessionParams = map[string]string{"a": "1"}
+	assert.Equal(t, map[string]string{"a": "1"}, sessionParams(cfg))
+
+	cfg.SessionPreset = SessionPresetStrictANSI
+	cfg.SessionParams = map[string]string{"ANSI_MODE": "false"}
+	assert.Equal(t, map[string]string{"ANSI_MODE": "false", "legacy_time_parser_policy": "EXCEPTION"}, sessionParams(cfg))
+}
+
+func TestConnector_ConnectSetsSessionPreset(t *testing.T) {
+	var openSessionResp cli_service.TOpenSessionResp
+	var executeStatementResp cli_service.TExecuteStatementResp
+	loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+	loadTestData(t, "ExecuteStatement1.json", &executeStatementResp)
+	var statements []string
+	ts := initThriftTestServer(&client.TestClient{
+		FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+			return &openSessionResp, nil
+		},
+		FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+			statements = append(statements, req.Statement)
+			return &executeStatementResp, nil
+		},
+	})
+	defer ts.Close()
+	r, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(r.Port())
+	require.NoError(t, err)
+
+	testConnector, err := NewConnector(
+		WithServerHostname("localhost"),
+		WithPort(port),
+		WithSessionPreset(SessionPresetLegacy),
+	)
+	require.NoError(t, err)
+	_, err = testConnector.Connect(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"SET `ansi_mode` = `false`;", "SET `legacy_time_parser_policy` = `LEGACY`;"}, statements)
+}
@@ -0,0 +1,33 @@
+package dbsql
+
+import "strings"
+
+// QuoteIdentifier quotes each part of an object name with backticks, doubling the
+// backticks they hold, and joins them with dots, for names used in dynamically built
+// statements:
+//
+//	dbsql.QuoteIdentifier("main", "sales", "order items") // `main`.`sales`.`order items`
+//
+// The parts are taken as they are: a dot in a part is part of the identifier.
+func QuoteIdentifier(parts ...string) string {
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+	}
+	return strings.Join(quoted, ".")
+}
+
+// QuoteLiteral returns s as a single quoted string literal, escaping the quotes and
+// backslashes it holds, e.g. for the COMMENT clause of a CREATE TABLE statement. Use
+// query parameters for the values of other types.
+func QuoteLiteral(s string) string {
+	return quoteStringLiteral(s)
+}
+
+// SanitizeComment returns s with the sequences that open or close a block comment
+// broken up, so that it can be embedded in a /* ... */ comment without ending it
+// early or nesting another one.
+func SanitizeComment(s string) string {
+	s = strings.ReplaceAll(s, "*/", "* /")
+	return strings.ReplaceAll(s, "/*", "/ *")
+}
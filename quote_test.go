@@ -0,0 +1,31 @@
+package dbsql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "`t`", QuoteIdentifier("t"))
+	assert.Equal(t, "`main`.`sales`.`order items`", QuoteIdentifier("main", "sales", "order items"))
+	assert.Equal(t, "`a``b`.`c.d`", QuoteIdentifier("a`b", "c.d"))
+	assert.Equal(t, "", QuoteIdentifier())
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	assert.Equal(t, `'it\'s'`, QuoteLiteral("it's"))
+	assert.Equal(t, `'a\\\'b'`, QuoteLiteral(`a\'b`))
+	assert.Equal(t, `'"x"'`, QuoteLiteral(`"x"`))
+}
+
+func TestSanitizeComment(t *testing.T) {
+	for _, s := range []string{"done */ DROP TABLE t; /*", "*/*", "/*/", "**//**"} {
+		sanitized := SanitizeComment(s)
+		assert.NotContains(t, sanitized, "*/")
+		assert.NotContains(t, sanitized, "/*")
+		assert.Equal(t, []string{"x"}, sqlTokens("/* "+sanitized+" */ x"), s)
+	}
+	assert.Equal(t, "done * / DROP TABLE t; / *", SanitizeComment("done */ DROP TABLE t; /*"))
+	assert.Equal(t, "a -- b", SanitizeComment("a -- b"))
+}
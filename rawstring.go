@@ -0,0 +1,88 @@
+package dbsql
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+)
+
+// rawStrings returns true when the values of the rows are returned as strings, as
+// set by the query context or else by the connector
+func (r *rows) rawStrings() bool {
+	if r.ctx != nil {
+		if enabled, ok := driverctx.RawStringsFromContext(r.ctx); ok {
+			return enabled
+		}
+	}
+	return r.config != nil && r.config.RawStrings
+}
+
+// rawStringValue returns the value of a row as a string, or nil for NULL. Strings
+// are returned as sent by the server, the other values are formatted as the server
+// casts them to strings.
+func rawStringValue(tColumn *cli_service.TColumn, tColumnDesc *cli_service.TColumnDesc, rowNum int64) any {
+	return rawString(rawValue(tColumn, rowNum), getDBTypeID(tColumnDesc) == cli_service.TTypeId_FLOAT_TYPE)
+}
+
+// rawString returns a value sent by the server as a string, formatting float32
+// values, sent as doubles, as FLOAT values
+func rawString(val any, float32 bool) any {
+	switch v := val.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int8:
+		return strconv.FormatInt(int64(v), 10)
+	case int16:
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		bits := 64
+		if float32 {
+			bits = 32
+		}
+		return formatJavaFloat(v, bits)
+	}
+	return nil
+}
+
+// formatJavaFloat formats f as Java's Double.toString and Float.toString do, which
+// the server uses to cast DOUBLE and FLOAT values to strings: in decimal notation
+// with at least one fractional digit between 10^-3 and 10^7, and in scientific
+// notation otherwise, e.g. 1.5E10.
+func formatJavaFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0 && math.Signbit(f):
+		return "-0.0"
+	case f == 0:
+		return "0.0"
+	}
+	if abs := math.Abs(f); abs >= 1e-3 && abs < 1e7 {
+		s := strconv.FormatFloat(f, 'f', -1, bits)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	}
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'E', -1, bits), "E")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	exp, _ := strconv.Atoi(exponent)
+	return mantissa + "E" + strconv.Itoa(exp)
+}
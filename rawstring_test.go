@@ -0,0 +1,99 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"math"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatJavaFloat(t *testing.T) {
+	for _, tc := range []struct {
+		f    float64
+		bits int
+		want string
+	}{
+		{1, 64, "1.0"},
+		{-2.5, 64, "-2.5"},
+		{0.001, 64, "0.001"},
+		{0.0001, 64, "1.0E-4"},
+		{1234567.5, 64, "1234567.5"},
+		{1e7, 64, "1.0E7"},
+		{1.5e300, 64, "1.5E300"},
+		{float64(float32(1.1)), 32, "1.1"},
+		{float64(float32(3e10)), 32, "3.0E10"},
+		{0, 64, "0.0"},
+		{math.Copysign(0, -1), 64, "-0.0"},
+		{math.NaN(), 64, "NaN"},
+		{math.Inf(1), 64, "Infinity"},
+		{math.Inf(-1), 64, "-Infinity"},
+	} {
+		assert.Equal(t, tc.want, formatJavaFloat(tc.f, tc.bits), tc.want)
+	}
+}
+
+func TestRows_RawStrings(t *testing.T) {
+	typeDesc := func(typeId cli_service.TTypeId) *cli_service.TTypeDesc {
+		return &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: typeId}}}}
+	}
+	getRows := func(ctx context.Context, cfg *config.Config) *rows {
+		return &rows{
+			client: &client.TestClient{},
+			ctx:    ctx,
+			config: cfg,
+			fetchResults: &cli_service.TFetchResultsResp{Results: &cli_service.TRowSet{Columns: []*cli_service.TColumn{
+				{I32Val: &cli_service.TI32Column{Values: []int32{42, 0}, Nulls: []byte{2}}},
+				{DoubleVal: &cli_service.TDoubleColumn{Values: []float64{1e10, 0.5}}},
+				{DoubleVal: &cli_service.TDoubleColumn{Values: []float64{float64(float32(0.1)), 2}}},
+				{BoolVal: &cli_service.TBoolColumn{Values: []bool{true, false}}},
+				{StringVal: &cli_service.TStringColumn{Values: []string{"2024-01-02 03:04:05.1", "1999-12-31 00:00:00"}}},
+				{StringVal: &cli_service.TStringColumn{Values: []string{"10.50", "-1.00"}}},
+				{BinaryVal: &cli_service.TBinaryColumn{Values: [][]byte{[]byte("ab"), nil}, Nulls: []byte{2}}},
+			}}},
+			fetchResultsMetadata: &cli_service.TGetResultSetMetadataResp{Schema: &cli_service.TTableSchema{Columns: []*cli_service.TColumnDesc{
+				{ColumnName: "i", TypeDesc: typeDesc(cli_service.TTypeId_INT_TYPE)},
+				{ColumnName: "d", TypeDesc: typeDesc(cli_service.TTypeId_DOUBLE_TYPE)},
+				{ColumnName: "f", TypeDesc: typeDesc(cli_service.TTypeId_FLOAT_TYPE)},
+				{ColumnName: "b", TypeDesc: typeDesc(cli_service.TTypeId_BOOLEAN_TYPE)},
+				{ColumnName: "ts", TypeDesc: typeDesc(cli_service.TTypeId_TIMESTAMP_TYPE)},
+				{ColumnName: "dec", TypeDesc: typeDesc(cli_service.TTypeId_DECIMAL_TYPE)},
+				{ColumnName: "bin", TypeDesc: typeDesc(cli_service.TTypeId_BINARY_TYPE)},
+			}}},
+		}
+	}
+
+	t.Run("every value is a string", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.RawStrings = true
+		r := getRows(context.Background(), cfg)
+		assert.Equal(t, scanTypeString, r.ColumnTypeScanType(0))
+		assert.Equal(t, scanTypeString, r.ColumnTypeScanType(4))
+
+		dest := make([]driver.Value, 7)
+		require.NoError(t, r.Next(dest))
+		assert.Equal(t, []driver.Value{"42", "1.0E10", "0.1", "true", "2024-01-02 03:04:05.1", "10.50", "ab"}, dest)
+		require.NoError(t, r.Next(dest))
+		assert.Equal(t, []driver.Value{nil, "0.5", "2.0", "false", "1999-12-31 00:00:00", "-1.00", nil}, dest)
+	})
+
+	t.Run("the query context overrides the connector", func(t *testing.T) {
+		r := getRows(driverctx.NewContextWithRawStrings(context.Background(), true), config.WithDefaults())
+		dest := make([]driver.Value, 7)
+		require.NoError(t, r.Next(dest))
+		assert.Equal(t, "42", dest[0])
+
+		cfg := config.WithDefaults()
+		cfg.RawStrings = true
+		r = getRows(driverctx.NewContextWithRawStrings(context.Background(), false), cfg)
+		require.NoError(t, r.Next(dest))
+		assert.Equal(t, int32(42), dest[0])
+		assert.Equal(t, scanTypeDateTime, r.ColumnTypeScanType(4))
+	})
+}
@@ -0,0 +1,87 @@
+package dbsql
+
+import (
+	"context"
+	"strings"
+)
+
+type readOnlyContextKey struct{}
+
+// readOnlyKeywords are the statements that can run with a read-only context
+var readOnlyKeywords = map[string]bool{
+	"SELECT":   true,
+	"WITH":     true,
+	"FROM":     true,
+	"VALUES":   true,
+	"TABLE":    true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"EXPLAIN":  true,
+	"LIST":     true,
+}
+
+// writeKeywords start the statements that common table expressions and FROM
+// first queries can be followed by, e.g. WITH t AS (...) INSERT INTO ...
+var writeKeywords = map[string]bool{
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+	"MERGE":  true,
+}
+
+// WithReadOnly returns a context that only lets queries run: statements run with it
+// that are not SELECT, WITH, FROM, VALUES, TABLE, SHOW, DESCRIBE, EXPLAIN or LIST
+// statements fail with ErrReadOnly before they are sent to the server. Queries that
+// start with WITH or FROM are also rejected when they contain INSERT, UPDATE, DELETE
+// or MERGE outside of string literals, quoted identifiers and comments.
+//
+// The check is done by the driver, since the protocol has no read-only mode. It
+// guards services that should only read against running DML or DDL by mistake,
+// but it is not a replacement for access control on the server.
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyContextKey{}, true)
+}
+
+func readOnlyFromContext(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyContextKey{}).(bool)
+	return readOnly
+}
+
+// isQuery returns true when query is a single statement that only reads data.
+func isQuery(query string) bool {
+	var words []string
+	var word strings.Builder
+	terminated, multiple := false, false
+	scanSQL(query, func(kind sqlTokenKind, start, end int) bool {
+		c := query[start]
+		if kind == sqlCode && isIdentChar(c) {
+			word.WriteByte(c)
+		} else if word.Len() > 0 {
+			words = append(words, strings.ToUpper(word.String()))
+			word.Reset()
+		}
+		switch {
+		case kind == sqlCode && c == ';':
+			terminated = true
+		case terminated && (kind == sqlCode || kind == sqlQuoted):
+			multiple = true
+			return false
+		}
+		return true
+	})
+	if word.Len() > 0 {
+		words = append(words, strings.ToUpper(word.String()))
+	}
+	if multiple || len(words) == 0 || !readOnlyKeywords[words[0]] {
+		return false
+	}
+	if words[0] == "WITH" || words[0] == "FROM" {
+		for _, w := range words[1:] {
+			if writeKeywords[w] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package dbsql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsQuery(t *testing.T) {
+	queries := []string{
+		"SELECT 1",
+		"  -- report\n select * from t;",
+		"(SELECT 1) UNION (SELECT 2)",
+		"WITH t AS (SELECT 1) SELECT * FROM t",
+		"with t as (select 'insert into x' as s) select `delete` from t",
+		"FROM t SELECT a",
+		"VALUES (1), (2)",
+		"TABLE t",
+		"SHOW TABLES IN s",
+		"DESCRIBE TABLE EXTENDED t",
+		"desc t",
+		"EXPLAIN DELETE FROM t",
+		"LIST '/Volumes/c/s/v'",
+		"select 1;; -- done",
+	}
+	for _, q := range queries {
+		assert.True(t, isQuery(q), q)
+	}
+
+	statements := []string{
+		"",
+		"-- nothing",
+		"INSERT INTO t VALUES (1)",
+		"update t set a = 1",
+		"DELETE FROM t",
+		"MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN DELETE",
+		"CREATE TABLE t (a INT)",
+		"DROP TABLE t",
+		"TRUNCATE TABLE t",
+		"SET spark.sql.ansi.enabled = false",
+		"USE CATALOG c",
+		"OPTIMIZE t",
+		"WITH t AS (SELECT 1) INSERT INTO x SELECT * FROM t",
+		"FROM t INSERT INTO x SELECT a",
+		"SELECT 1; DROP TABLE t",
+		"SELECT 1; 'x'",
+	}
+	for _, q := range statements {
+		assert.False(t, isQuery(q), q)
+	}
+}
+
+func TestWithReadOnly(t *testing.T) {
+	var statements []string
+	db := getExplainTestDB([]string{"a"}, &statements)
+	defer db.Close()
+	ctx := WithReadOnly(context.Background())
+
+	_, err := db.ExecContext(ctx, "DROP TABLE t")
+	assert.EqualError(t, err, ErrReadOnly)
+	_, err = db.QueryContext(ctx, "INSERT INTO t VALUES (?)", "a")
+	assert.EqualError(t, err, ErrReadOnly)
+	assert.Empty(t, statements)
+
+	var s string
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT ?", "a; DROP TABLE t").Scan(&s))
+	assert.Equal(t, []string{"SELECT 'a; DROP TABLE t'"}, statements)
+
+	_, err = db.ExecContext(context.Background(), "DROP TABLE t")
+	require.NoError(t, err)
+}
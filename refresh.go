@@ -0,0 +1,182 @@
+package dbsql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultRefreshPollInterval is the time between two checks of WaitForRefresh when no
+// interval is set.
+const DefaultRefreshPollInterval = 10 * time.Second
+
+var errRefreshNoUpdate = "databricks: %s has no refresh"
+var errRefreshNotDone = "databricks: refresh %s of %s %s"
+
+// RefreshState is the state of a refresh of a streaming table or materialized view.
+// The states other than RefreshCompleted, RefreshFailed and RefreshCanceled, e.g.
+// QUEUED, INITIALIZING or RUNNING, are those of a refresh in progress.
+type RefreshState string
+
+const (
+	RefreshCompleted RefreshState = "COMPLETED"
+	RefreshFailed    RefreshState = "FAILED"
+	RefreshCanceled  RefreshState = "CANCELED"
+)
+
+// Done returns true once the refresh completed, failed or was canceled.
+func (s RefreshState) Done() bool {
+	return s == RefreshCompleted || s == RefreshFailed || s == RefreshCanceled
+}
+
+// RefreshInfo is the refresh information of a streaming table or materialized view,
+// as reported by DESCRIBE TABLE EXTENDED.
+type RefreshInfo struct {
+	// LastRefreshed is the time of the data of the last successful refresh
+	LastRefreshed time.Time
+	// LastRefreshType is e.g. FULL or INCREMENTAL
+	LastRefreshType string
+	// Status is the state of the latest refresh
+	Status RefreshState
+	// URL is the page of the latest refresh in the workspace
+	URL      string
+	Schedule string
+	// Details are all the rows of the refresh information by name
+	Details map[string]string
+}
+
+// RefreshUpdate is a refresh of a streaming table or materialized view, as recorded
+// in its event log.
+type RefreshUpdate struct {
+	UpdateID  string
+	State     RefreshState
+	StartedAt time.Time
+	// UpdatedAt is the time of the last change of state
+	UpdatedAt time.Time
+}
+
+// RefreshStatus returns the refresh information of the streaming table or
+// materialized view. name may be qualified with the schema and catalog, e.g.
+// main.default.daily_sales.
+func RefreshStatus(ctx context.Context, db Queryer, name string) (*RefreshInfo, error) {
+	lines, err := describeExtended(ctx, db, name)
+	if err != nil {
+		return nil, err
+	}
+	info := &RefreshInfo{Details: map[string]string{}}
+	section := ""
+	for _, line := range lines {
+		if strings.HasPrefix(line[0], "#") {
+			section = strings.TrimSpace(strings.TrimPrefix(line[0], "#"))
+			continue
+		}
+		if section == "Refresh Information" && line[0] != "" {
+			info.Details[line[0]] = line[1]
+		}
+	}
+	if v := info.Details["Last Refreshed"]; v != "" {
+		if info.LastRefreshed, err = asTime(strings.TrimSuffix(v, " UTC")); err != nil {
+			return nil, wrapErrf(err, "failed to read the refresh information of %s", name)
+		}
+	}
+	info.LastRefreshType = info.Details["Last Refresh Type"]
+	info.Status = refreshState(info.Details["Latest Refresh Status"])
+	info.URL = info.Details["Latest Refresh"]
+	info.Schedule = info.Details["Refresh Schedule"]
+	return info, nil
+}
+
+// RefreshHistory returns the latest refreshes of the streaming table or materialized
+// view, up to limit, all of them when zero, the latest first. The refreshes are read
+// from the event log of the table, which needs to be its owner.
+func RefreshHistory(ctx context.Context, db Queryer, name string, limit int) ([]RefreshUpdate, error) {
+	quoted, err := quoteName(name)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT origin.update_id AS update_id, max_by(details:update_progress.state, timestamp) AS state, " +
+		"min(timestamp) AS started_at, max(timestamp) AS updated_at FROM event_log(TABLE(" + quoted + ")) " +
+		"WHERE event_type = 'update_progress' GROUP BY origin.update_id ORDER BY started_at DESC"
+	if limit > 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := queryNamed(ctx, db, query)
+	if err != nil {
+		return nil, wrapErrf(err, "failed to read the refresh history of %s", name)
+	}
+	updates := make([]RefreshUpdate, 0, len(rows))
+	for _, row := range rows {
+		update := RefreshUpdate{
+			UpdateID: asString(row["update_id"]),
+			State:    refreshState(asString(row["state"])),
+		}
+		if update.StartedAt, err = asTime(row["started_at"]); err != nil {
+			return nil, wrapErrf(err, "failed to read the refresh history of %s", name)
+		}
+		if update.UpdatedAt, err = asTime(row["updated_at"]); err != nil {
+			return nil, wrapErrf(err, "failed to read the refresh history of %s", name)
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// WaitForRefresh waits for the latest refresh of the streaming table or materialized
+// view started at or after since to be done, checking its event log every interval,
+// DefaultRefreshPollInterval when zero, and returns it. Right after a REFRESH ...
+// ASYNC, the latest refresh of the event log can still be the previous one, so since
+// is the time the refresh was requested, a little earlier to allow for the clock of
+// the server, and the refreshes started before are waited out. A zero since waits for
+// the latest refresh, whenever it started. A refresh that failed or was canceled is
+// returned with an error. ctx bounds the wait.
+//
+//	since := time.Now().Add(-time.Minute)
+//	if _, err := db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW daily_sales ASYNC"); err != nil {
+//		...
+//	}
+//	update, err := dbsql.WaitForRefresh(ctx, db, "daily_sales", since, 0)
+func WaitForRefresh(ctx context.Context, db Queryer, name string, since time.Time, interval time.Duration) (*RefreshUpdate, error) {
+	if interval <= 0 {
+		interval = DefaultRefreshPollInterval
+	}
+	for {
+		updates, err := RefreshHistory(ctx, db, name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(updates) == 0 && since.IsZero() {
+			return nil, errors.Errorf(errRefreshNoUpdate, name)
+		}
+		// the refresh may not be in the event log yet
+		if len(updates) > 0 && !updates[0].StartedAt.Before(since) && updates[0].State.Done() {
+			update := updates[0]
+			if update.State != RefreshCompleted {
+				return &update, errors.Errorf(errRefreshNotDone, update.UpdateID, name, strings.ToLower(string(update.State)))
+			}
+			return &update, nil
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// refreshState normalizes the states of DESCRIBE TABLE EXTENDED, e.g. Succeeded, to
+// those of the event log
+func refreshState(s string) RefreshState {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch s {
+	case "SUCCEEDED":
+		return RefreshCompleted
+	case "CANCELLED":
+		return RefreshCanceled
+	}
+	return RefreshState(s)
+}
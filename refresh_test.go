@@ -0,0 +1,114 @@
+package dbsql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshStatus(t *testing.T) {
+	var statements []string
+	db := getCatalogTestDB([][3]string{
+		{"day", "date", ""},
+		{"total", "bigint", ""},
+		{"", "", ""},
+		{"# Detailed Table Information", "", ""},
+		{"Type", "MATERIALIZED_VIEW", ""},
+		{"", "", ""},
+		{"# Refresh Information", "", ""},
+		{"Last Refreshed", "2024-05-01 10:00:00", ""},
+		{"Last Refresh Type", "INCREMENTAL", ""},
+		{"Latest Refresh Status", "Succeeded", ""},
+		{"Latest Refresh", "https://example.cloud.databricks.com/#joblist/pipelines/p/updates/u", ""},
+		{"Refresh Schedule", "EVERY 1 HOURS", ""},
+	}, "", &statements)
+	defer db.Close()
+
+	info, err := RefreshStatus(context.Background(), db, "main.default.daily_sales")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DESCRIBE TABLE EXTENDED `main`.`default`.`daily_sales`"}, statements)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), info.LastRefreshed)
+	assert.Equal(t, "INCREMENTAL", info.LastRefreshType)
+	assert.Equal(t, RefreshCompleted, info.Status)
+	assert.Equal(t, "https://example.cloud.databricks.com/#joblist/pipelines/p/updates/u", info.URL)
+	assert.Equal(t, "EVERY 1 HOURS", info.Schedule)
+	assert.NotContains(t, info.Details, "Type")
+}
+
+func TestRefreshHistory(t *testing.T) {
+	columns := []string{"update_id", "state", "started_at", "updated_at"}
+
+	t.Run("refreshes are read from the event log", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB(columns, [][]string{
+			{"u2", "RUNNING", "2024-05-02 10:00:00", "2024-05-02 10:01:00"},
+			{"u1", "COMPLETED", "2024-05-01 10:00:00", "2024-05-01 10:05:00"},
+		}, "", &statements)
+		defer db.Close()
+
+		updates, err := RefreshHistory(context.Background(), db, "daily_sales", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []RefreshUpdate{
+			{UpdateID: "u2", State: "RUNNING", StartedAt: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, 5, 2, 10, 1, 0, 0, time.UTC)},
+			{UpdateID: "u1", State: RefreshCompleted, StartedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC)},
+		}, updates)
+		assert.Equal(t, []string{
+			"SELECT origin.update_id AS update_id, max_by(details:update_progress.state, timestamp) AS state, " +
+				"min(timestamp) AS started_at, max(timestamp) AS updated_at FROM event_log(TABLE(`daily_sales`)) " +
+				"WHERE event_type = 'update_progress' GROUP BY origin.update_id ORDER BY started_at DESC LIMIT 2",
+		}, statements)
+		assert.False(t, updates[0].State.Done())
+		assert.True(t, updates[1].State.Done())
+	})
+
+	t.Run("waiting returns the refresh once done", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB(columns, [][]string{{"u1", "COMPLETED", "2024-05-01 10:00:00", "2024-05-01 10:05:00"}}, "", &statements)
+		defer db.Close()
+
+		update, err := WaitForRefresh(context.Background(), db, "daily_sales", time.Time{}, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, "u1", update.UpdateID)
+	})
+
+	t.Run("waiting fails with the refresh", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB(columns, [][]string{{"u1", "FAILED", "2024-05-01 10:00:00", "2024-05-01 10:05:00"}}, "", &statements)
+		defer db.Close()
+
+		update, err := WaitForRefresh(context.Background(), db, "daily_sales", time.Time{}, time.Millisecond)
+		assert.EqualError(t, err, "databricks: refresh u1 of daily_sales failed")
+		assert.Equal(t, RefreshFailed, update.State)
+	})
+
+	t.Run("waiting skips the refreshes started before since", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB(columns, [][]string{{"u1", "COMPLETED", "2024-05-01 10:00:00", "2024-05-01 10:05:00"}}, "", &statements)
+		defer db.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := WaitForRefresh(ctx, db, "daily_sales", time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), 10*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Greater(t, len(statements), 1)
+
+		update, err := WaitForRefresh(context.Background(), db, "daily_sales", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, "u1", update.UpdateID)
+	})
+
+	t.Run("waiting stops with the context", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB(columns, [][]string{{"u1", "RUNNING", "2024-05-01 10:00:00", "2024-05-01 10:05:00"}}, "", &statements)
+		defer db.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := WaitForRefresh(ctx, db, "daily_sales", time.Time{}, 10*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Greater(t, len(statements), 1)
+	})
+}
@@ -1,6 +1,10 @@
 package dbsql
 
-import "database/sql/driver"
+import (
+	"database/sql/driver"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+)
 
 type result struct {
 	AffectedRows int64
@@ -9,6 +13,28 @@ type result struct {
 
 var _ driver.Result = (*result)(nil)
 
+// newResult returns the result of a statement. The number of rows it modified is
+// the one of the operation status, or else the num_affected_rows column of the
+// direct results, which DML statements such as UPDATE, DELETE and MERGE return
+// instead on some warehouses.
+func newResult(exStmtResp *cli_service.TExecuteStatementResp, opStatus *cli_service.TGetOperationStatusResp) *result {
+	if opStatus != nil && opStatus.NumModifiedRows != nil {
+		return &result{AffectedRows: *opStatus.NumModifiedRows}
+	}
+	direct := exStmtResp.DirectResults
+	if direct == nil || direct.ResultSetMetadata == nil || direct.ResultSetMetadata.Schema == nil ||
+		direct.ResultSet == nil || direct.ResultSet.Results == nil {
+		return &result{}
+	}
+	columns := direct.ResultSet.Results.Columns
+	for i, desc := range direct.ResultSetMetadata.Schema.Columns {
+		if desc.ColumnName == "num_affected_rows" && i < len(columns) && columns[i].I64Val != nil && len(columns[i].I64Val.Values) > 0 {
+			return &result{AffectedRows: columns[i].I64Val.Values[0]}
+		}
+	}
+	return &result{}
+}
+
 func (res *result) LastInsertId() (int64, error) {
 	return res.InsertId, nil
 }
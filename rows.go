@@ -4,30 +4,75 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"io"
 	"math"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/interceptor"
 	"github.com/databricks/databricks-sql-go/internal/cli_service"
 	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
 	"github.com/databricks/databricks-sql-go/logger"
+	"github.com/databricks/databricks-sql-go/metrics"
 	"github.com/pkg/errors"
 )
 
 type rows struct {
-	client               cli_service.TCLIService
-	connId               string
-	correlationId        string
-	opHandle             *cli_service.TOperationHandle
-	pageSize             int64
-	location             *time.Location
-	fetchResults         *cli_service.TFetchResultsResp
+	client        cli_service.TCLIService
+	connId        string
+	correlationId string
+	opHandle      *cli_service.TOperationHandle
+	pageSize      int64
+	location      *time.Location
+	config        *config.Config
+	// the query context, server requests made while iterating are canceled with it
+	ctx context.Context
+	// the connection that ran the query, told about session errors while fetching
+	conn         *conn
+	fetchResults *cli_service.TFetchResultsResp
+	// the bytes of fetchResults accounted for by the memory account of the connection
+	pageBytes            int64
 	fetchResultsMetadata *cli_service.TGetResultSetMetadataResp
-	nextRowIndex         int64
-	nextRowNumber        int64
+	// the column readers of readersPage with the schema of readersMetadata, and
+	// whether the values are returned as raw strings
+	readers         []columnReader
+	readersPage     *cli_service.TFetchResultsResp
+	readersMetadata *cli_service.TGetResultSetMetadataResp
+	readersRaw      bool
+	nextRowIndex    int64
+	nextRowNumber   int64
+	// the end of the furthest page fetched, and whether the server reported it to be
+	// the last page of the results
+	fetchedRows int64
+	lastFetched bool
+	// the timings of the current page, when page metrics are collected or logged
+	pageTimings *metrics.PageFetch
+	// set once the result set was invalidated, returned by all later fetches
+	invalidated error
+	// set when the statement has no result set, e.g. DDL and SET statements. The
+	// rows are empty and have no columns
+	noResultSet bool
+	// the query of the rows, passed to the interceptors
+	query interceptor.Query
+	// caches the metadata of the results under schemaKey, may be nil
+	schemaCache *schemaCache
+	schemaKey   string
+	// set while the metadata taken from the schema cache was not checked against a
+	// result page
+	schemaFromCache bool
+	// closed once the rows are closed, stops watching them for being orphaned
+	done     chan struct{}
+	doneOnce sync.Once
+	// set once the server operation is closed
+	opClosed atomic.Bool
+	// where the rows were created, when leak detection is enabled
+	stack string
 }
 
 var _ driver.Rows = (*rows)(nil)
@@ -35,11 +80,15 @@ var _ driver.RowsColumnTypeScanType = (*rows)(nil)
 var _ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
 var _ driver.RowsColumnTypeNullable = (*rows)(nil)
 var _ driver.RowsColumnTypeLength = (*rows)(nil)
+var _ driver.RowsColumnTypePrecisionScale = (*rows)(nil)
+var _ Rows = (*rows)(nil)
 
 var errRowsFetchPriorToStart = "unable to fetch row page prior to start of results"
 var errRowsNoSchemaAvailable = "no schema in result set metadata response"
 var errRowsNoClient = "instance of Rows missing client"
 var errRowsNilRows = "nil Rows instance"
+var errRowsNegativeOffset = "row offset must not be negative"
+var errRowsUnexpectedPage = "fetched result page does not lead to the requested row"
 
 // Columns returns the names of the columns. The number of
 // columns of the result is inferred from the length of the
@@ -47,7 +96,7 @@ var errRowsNilRows = "nil Rows instance"
 // string should be returned for that entry.
 func (r *rows) Columns() []string {
 	err := isValidRows(r)
-	if err != nil {
+	if err != nil || r.noResultSet {
 		return []string{}
 	}
 
@@ -71,20 +120,44 @@ func (r *rows) Columns() []string {
 }
 
 // Close closes the rows iterator.
+// With async close enabled the server operation is closed in the background
+// and Close returns right away.
 func (r *rows) Close() error {
 	err := isValidRows(r)
 	if err != nil {
 		return err
 	}
 
+	r.stopWatching()
+	r.reportPageTimings()
+	r.releasePage()
+
+	if r.config != nil && r.config.AsyncClose && r.conn != nil {
+		r.conn.closeOperationAsync(r.closeOperation, r.logger())
+		return nil
+	}
+
+	return r.closeOperation(r.client)
+}
+
+// closeOperation closes the server operation within the close timeout, unless it was
+// closed already, e.g. once orphaned.
+func (r *rows) closeOperation(client cli_service.TCLIService) error {
+	if r.opClosed.Swap(true) {
+		return nil
+	}
+	ctx, cancel := r.closeContext()
+	defer cancel()
+
 	req := cli_service.TCloseOperationReq{
 		OperationHandle: r.opHandle,
 	}
-	ctx := driverctx.NewContextWithCorrelationId(driverctx.NewContextWithConnId(context.Background(), r.connId), r.correlationId)
-
-	_, err1 := r.client.CloseOperation(ctx, &req)
-	if err1 != nil {
-		return err1
+	_, err := client.CloseOperation(ctx, &req)
+	if r.conn != nil {
+		r.conn.ops.remove(r.opHandle)
+	}
+	if err != nil {
+		return err
 	}
 	return nil
 }
@@ -103,30 +176,47 @@ func (r *rows) Next(dest []driver.Value) error {
 	if err != nil {
 		return err
 	}
+	if r.noResultSet {
+		return io.EOF
+	}
 
 	// if the next row is not in the current result page
 	// fetch the containing page
 	if !r.isNextRowInPage() {
 		err := r.fetchResultPage()
 		if err != nil {
-			return err
+			return r.wrapErr(err)
 		}
 	}
 
 	// need the column info to retrieve/convert values
 	metadata, err := r.getResultMetadata()
 	if err != nil {
-		return err
+		return r.wrapErr(err)
 	}
 
-	// populate the destinatino slice
-	for i := range dest {
-		val, err := value(r.fetchResults.Results.Columns[i], metadata.Schema.Columns[i], r.nextRowIndex, r.location)
+	readers, rawStrings, err := r.columnReaders(metadata)
+	if err != nil {
+		return r.wrapErr(err)
+	}
 
-		if err != nil {
-			return err
-		}
+	if r.pageTimings != nil {
+		defer func(start time.Time) { r.pageTimings.Decode += time.Since(start) }(time.Now())
+	}
 
+	// populate the destination slice
+	for i := range dest {
+		reader := &readers[i]
+		val := reader.get(r.nextRowIndex)
+		switch {
+		case rawStrings:
+			val = rawString(val, reader.float32)
+		case val != nil && reader.convert != nil:
+			val, err = reader.convert(val)
+			if err != nil {
+				return r.wrapErr(err)
+			}
+		}
 		dest[i] = val
 	}
 
@@ -150,7 +240,14 @@ func (r *rows) ColumnTypeScanType(index int) reflect.Type {
 		return nil
 	}
 
+	if r.rawStrings() {
+		return scanTypeString
+	}
 	scanType := getScanType(column)
+	// DATE and TIMESTAMP values are returned as strings when time parsing is disabled
+	if scanType == scanTypeDateTime && r.config != nil && r.config.DisableTimeParsing {
+		scanType = scanTypeString
+	}
 	return scanType
 }
 
@@ -203,6 +300,46 @@ func (r *rows) ColumnTypeLength(index int) (length int64, ok bool) {
 	}
 }
 
+// ColumnTypePrecisionScale returns the precision and scale for DECIMAL columns.
+func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	columnInfo, err := r.getColumnMetadataByIndex(index)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if getDBTypeID(columnInfo) != cli_service.TTypeId_DECIMAL_TYPE {
+		return 0, 0, false
+	}
+
+	desc := newColumnDescriptor(columnInfo)
+	return desc.Precision, desc.Scale, true
+}
+
+// ColumnDescriptors returns the full type information for every column
+func (r *rows) ColumnDescriptors() ([]ColumnDescriptor, error) {
+	err := isValidRows(r)
+	if err != nil {
+		return nil, err
+	}
+
+	resultMetadata, err := r.getResultMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	if !resultMetadata.IsSetSchema() {
+		return nil, errors.New(errRowsNoSchemaAvailable)
+	}
+
+	tColumns := resultMetadata.Schema.GetColumns()
+	descriptors := make([]ColumnDescriptor, len(tColumns))
+	for i := range tColumns {
+		descriptors[i] = newColumnDescriptor(tColumns[i])
+	}
+
+	return descriptors, nil
+}
+
 var (
 	scanTypeNull     = reflect.TypeOf(nil)
 	scanTypeBoolean  = reflect.TypeOf(true)
@@ -216,14 +353,19 @@ var (
 	scanTypeDateTime = reflect.TypeOf(time.Time{})
 	scanTypeRawBytes = reflect.TypeOf(sql.RawBytes{})
 	scanTypeUnknown  = reflect.TypeOf(new(interface{}))
+	scanTypeJSON     = reflect.TypeOf(json.RawMessage{})
+	scanTypeGeometry = reflect.TypeOf(Geometry{})
 )
 
 func getScanType(column *cli_service.TColumnDesc) reflect.Type {
+	if isVariant(column) {
+		return scanTypeJSON
+	}
+	if getGeospatialTypeName(column) != "" {
+		return scanTypeGeometry
+	}
 
-	// TODO: handle non-primitive types
-	entry := column.TypeDesc.Types[0].PrimitiveEntry
-
-	switch entry.Type {
+	switch getDBTypeID(column) {
 	case cli_service.TTypeId_BOOLEAN_TYPE:
 		return scanTypeBoolean
 	case cli_service.TTypeId_TINYINT_TYPE:
@@ -261,17 +403,39 @@ func getScanType(column *cli_service.TColumnDesc) reflect.Type {
 }
 
 func getDBTypeName(column *cli_service.TColumnDesc) string {
-	// TODO: handle non-primitive types
-	entry := column.TypeDesc.Types[0].PrimitiveEntry
-	dbtype := strings.TrimSuffix(entry.Type.String(), "_TYPE")
+	if isVariant(column) {
+		return variantTypeName
+	}
+	if name := getGeospatialTypeName(column); name != "" {
+		return name
+	}
+	dbtype := strings.TrimSuffix(getDBTypeID(column).String(), "_TYPE")
 
 	return dbtype
 }
 
+// getDBTypeID returns the type id of the column. Complex types described
+// with nested type entries are reported by their top level type.
 func getDBTypeID(column *cli_service.TColumnDesc) cli_service.TTypeId {
-	// TODO: handle non-primitive types
-	entry := column.TypeDesc.Types[0].PrimitiveEntry
-	return entry.Type
+	if column == nil || column.TypeDesc == nil || len(column.TypeDesc.Types) == 0 || column.TypeDesc.Types[0] == nil {
+		return cli_service.TTypeId_USER_DEFINED_TYPE
+	}
+
+	entry := column.TypeDesc.Types[0]
+	switch {
+	case entry.PrimitiveEntry != nil:
+		return entry.PrimitiveEntry.Type
+	case entry.ArrayEntry != nil:
+		return cli_service.TTypeId_ARRAY_TYPE
+	case entry.MapEntry != nil:
+		return cli_service.TTypeId_MAP_TYPE
+	case entry.StructEntry != nil:
+		return cli_service.TTypeId_STRUCT_TYPE
+	case entry.UnionEntry != nil:
+		return cli_service.TTypeId_UNION_TYPE
+	default:
+		return cli_service.TTypeId_USER_DEFINED_TYPE
+	}
 }
 
 // isValidRows checks that the row instance is not nil
@@ -315,6 +479,14 @@ func (r *rows) getColumnMetadataByIndex(index int) (*cli_service.TColumnDesc, er
 // isNextRowInPage returns a boolean flag indicating whether
 // the next result set row is in the current result set page
 func (r *rows) isNextRowInPage() bool {
+	if r == nil {
+		return false
+	}
+	return r.isRowInPage(r.nextRowNumber)
+}
+
+// isRowInPage returns true if the result set row is in the current result set page
+func (r *rows) isRowInPage(rowNumber int64) bool {
 	if r == nil || r.fetchResults == nil {
 		return false
 	}
@@ -325,7 +497,7 @@ func (r *rows) isNextRowInPage() bool {
 	}
 
 	startRowOffset := r.getPageStartRowNum()
-	return r.nextRowNumber >= startRowOffset && r.nextRowNumber < (startRowOffset+nRowsInPage)
+	return rowNumber >= startRowOffset && rowNumber < (startRowOffset+nRowsInPage)
 }
 
 func (r *rows) getResultMetadata() (*cli_service.TGetResultSetMetadataResp, error) {
@@ -338,15 +510,16 @@ func (r *rows) getResultMetadata() (*cli_service.TGetResultSetMetadataResp, erro
 		req := cli_service.TGetResultSetMetadataReq{
 			OperationHandle: r.opHandle,
 		}
-		ctx := driverctx.NewContextWithCorrelationId(driverctx.NewContextWithConnId(context.Background(), r.connId), r.correlationId)
+		ctx := r.requestContext()
 
 		resp, err := r.client.GetResultSetMetadata(ctx, &req)
 		if err != nil {
+			r.checkBroken(err)
 			return nil, err
 		}
 
 		r.fetchResultsMetadata = resp
-
+		r.schemaCache.put(r.schemaKey, resp)
 	}
 
 	return r.fetchResultsMetadata, nil
@@ -357,12 +530,7 @@ func (r *rows) fetchResultPage() error {
 	if err != nil {
 		return err
 	}
-	var log *logger.DBSQLLogger
-	if r.opHandle != nil {
-		log = logger.WithContext(r.connId, r.correlationId, client.SprintGuid(r.opHandle.OperationId.GUID))
-	} else {
-		log = logger.WithContext(r.connId, r.correlationId, "")
-	}
+	log := r.logger()
 
 	for !r.isNextRowInPage() {
 
@@ -370,9 +538,20 @@ func (r *rows) fetchResultPage() error {
 		// TFetchOrientation_FETCH_PRIOR and TFetchOrientation_FETCH_NEXT
 		var direction cli_service.TFetchOrientation = r.getPageFetchDirection()
 		if direction == cli_service.TFetchOrientation_FETCH_PRIOR {
-			if r.getPageStartRowNum() == 0 {
+			if r.nextRowNumber < 0 || r.getPageStartRowNum() == 0 {
 				return errors.New(errRowsFetchPriorToStart)
 			}
+			// a row more than a page back is fetched directly with an absolute fetch,
+			// instead of fetching every page in between
+			if r.fetchResults != nil && r.nextRowNumber < r.getPageStartRowNum()-getNRows(r.fetchResults.Results) {
+				if err := r.fetchResultPageAt(cli_service.TFetchOrientation_FETCH_ABSOLUTE, r.nextRowNumber); err != nil {
+					return err
+				}
+				if !r.isNextRowInPage() {
+					return errors.New(errRowsUnexpectedPage)
+				}
+				break
+			}
 		} else if direction == cli_service.TFetchOrientation_FETCH_NEXT {
 			if r.fetchResults != nil && !r.fetchResults.GetHasMoreRows() {
 				return io.EOF
@@ -381,19 +560,40 @@ func (r *rows) fetchResultPage() error {
 			return errors.Errorf("unhandled fetch result orientation: %s", direction)
 		}
 
+		prevStart := r.getPageStartRowNum()
+		hadPage := r.fetchResults != nil && getNRows(r.fetchResults.Results) > 0
+		if direction == cli_service.TFetchOrientation_FETCH_NEXT {
+			// the rows of the page were read, so it can be collected while the next
+			// one is decoded, instead of both being held at the peak
+			r.dropPage()
+		}
+
 		req := cli_service.TFetchResultsReq{
 			OperationHandle: r.opHandle,
 			MaxRows:         r.pageSize,
 			Orientation:     direction,
 		}
-		ctx := driverctx.NewContextWithCorrelationId(driverctx.NewContextWithConnId(context.Background(), r.connId), r.correlationId)
+		ctx := r.requestContext()
 		log.Debug().Msgf("fetching next batch of %d rows", r.pageSize)
-		fetchResult, err := r.client.FetchResults(ctx, &req)
+		fetchResult, err := r.fetch(ctx, &req)
 		if err != nil {
+			r.checkBroken(err)
+			return err
+		}
+
+		if err := r.setPage(fetchResult); err != nil {
 			return err
 		}
 
-		r.fetchResults = fetchResult
+		// stop when the page can't lead to the next row, i.e. it skipped past the
+		// row or did not move in the fetch direction, instead of fetching forever
+		if !r.isNextRowInPage() && getNRows(fetchResult.GetResults()) > 0 {
+			start := r.getPageStartRowNum()
+			if direction == cli_service.TFetchOrientation_FETCH_NEXT && (start > r.nextRowNumber || hadPage && start <= prevStart) ||
+				direction == cli_service.TFetchOrientation_FETCH_PRIOR && hadPage && start >= prevStart {
+				return errors.New(errRowsUnexpectedPage)
+			}
+		}
 	}
 
 	// don't assume the next row is the first row in the page
@@ -402,6 +602,315 @@ func (r *rows) fetchResultPage() error {
 	return nil
 }
 
+// SeekRow positions the rows so that the next call to Next returns the row at offset.
+// When the row is not in the current page the page starting at offset is fetched
+// with an absolute fetch. io.EOF is returned if offset is past the end of the results.
+// The position is left unchanged when an error is returned.
+func (r *rows) SeekRow(offset int64) error {
+	err := isValidRows(r)
+	if err != nil {
+		return err
+	}
+
+	if offset < 0 {
+		return errors.New(errRowsNegativeOffset)
+	}
+
+	if !r.isRowInPage(offset) {
+		fetchResult, err := r.fetchPageAt(cli_service.TFetchOrientation_FETCH_ABSOLUTE, offset)
+		if err != nil {
+			return r.wrapErr(err)
+		}
+		// the current page is kept unless the fetched one holds the row
+		rs := fetchResult.GetResults()
+		start, n := rs.GetStartRowOffset(), getNRows(rs)
+		if offset < start || offset >= start+n {
+			return io.EOF
+		}
+		if err := r.setPage(fetchResult); err != nil {
+			return r.wrapErr(err)
+		}
+	}
+
+	r.nextRowNumber = offset
+	r.nextRowIndex = r.nextRowNumber - r.getPageStartRowNum()
+	return nil
+}
+
+// Rewind positions the rows at the first row of the result set. Unless the first row
+// is in the current page the first page is fetched again with a FETCH_FIRST fetch.
+func (r *rows) Rewind() error {
+	err := isValidRows(r)
+	if err != nil {
+		return err
+	}
+
+	if !r.isRowInPage(0) {
+		err := r.fetchResultPageAt(cli_service.TFetchOrientation_FETCH_FIRST, 0)
+		if err != nil {
+			return r.wrapErr(err)
+		}
+	}
+
+	r.nextRowNumber = 0
+	r.nextRowIndex = 0
+	return nil
+}
+
+// NextRowNumber returns the offset of the row returned by the next call to Next.
+func (r *rows) NextRowNumber() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.nextRowNumber
+}
+
+// HasMoreRows reports whether rows remain to be read, in the current page or pending
+// on the server.
+func (r *rows) HasMoreRows() bool {
+	if r == nil || r.noResultSet {
+		return false
+	}
+	return !r.lastFetched || r.nextRowNumber < r.fetchedRows
+}
+
+// TotalRows returns the number of rows of the results and true once their last page
+// was fetched, or else the number of rows fetched so far and false.
+func (r *rows) TotalRows() (int64, bool) {
+	if r == nil || r.noResultSet {
+		return 0, true
+	}
+	return r.fetchedRows, r.lastFetched
+}
+
+// fetchResultPageAt fetches the result page starting at offset using the given
+// orientation and makes it the current page.
+func (r *rows) fetchResultPageAt(direction cli_service.TFetchOrientation, offset int64) error {
+	fetchResult, err := r.fetchPageAt(direction, offset)
+	if err != nil {
+		return err
+	}
+
+	return r.setPage(fetchResult)
+}
+
+// fetchPageAt fetches the result page starting at offset using the given orientation,
+// without making it the current page.
+func (r *rows) fetchPageAt(direction cli_service.TFetchOrientation, offset int64) (*cli_service.TFetchResultsResp, error) {
+	req := cli_service.TFetchResultsReq{
+		OperationHandle: r.opHandle,
+		MaxRows:         r.pageSize,
+		Orientation:     direction,
+		StartRowOffset:  &offset,
+	}
+	ctx := r.requestContext()
+	r.logger().Debug().Msgf("fetching batch of %d rows at offset %d", r.pageSize, offset)
+	fetchResult, err := r.fetch(ctx, &req)
+	if err != nil {
+		r.checkBroken(err)
+		return nil, err
+	}
+
+	return fetchResult, nil
+}
+
+// fetch fetches a result page and checks that it still belongs to the result set
+// being read. Once the operation handle is invalidated or a page does not match the
+// schema of the results, all fetches fail with ErrResultSetInvalidated, instead of
+// decoding the columns of the page with the wrong types.
+func (r *rows) fetch(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+	if r.invalidated != nil {
+		return nil, r.invalidated
+	}
+	done := statementTimerFromContext(r.ctx).fetch()
+	start := time.Now()
+	resp, err := r.fetchPage(ctx, req)
+	done(err == nil)
+	if err == nil && r.config != nil {
+		page := interceptor.Page{Rows: getNRows(resp.GetResults()), Duration: time.Since(start)}
+		for _, i := range r.config.Interceptors {
+			i.OnFetchPage(r.requestContext(), r.query, page)
+		}
+	}
+	if err != nil {
+		if isResultExpired(err) {
+			return nil, r.expire(err)
+		}
+		return nil, err
+	}
+	if reason := r.checkPageSchema(resp); reason != "" {
+		return nil, r.invalidate(reason, nil)
+	}
+	r.adaptPageSize(req.MaxRows, resp, time.Since(start))
+	return resp, nil
+}
+
+// invalidate marks the result set as invalidated and returns the error telling why
+func (r *rows) invalidate(reason string, err error) error {
+	r.invalidated = &resultSetInvalidatedError{queryId: r.queryId(), reason: reason, err: err}
+	r.logger().Err(r.invalidated).Msg("databricks: result set invalidated")
+	return r.invalidated
+}
+
+// expire marks the result set as invalidated because the server no longer holds the
+// results, and returns the error matching ErrResultExpired
+func (r *rows) expire(err error) error {
+	r.invalidated = &resultSetInvalidatedError{queryId: r.queryId(), reason: "the results expired on the server", expired: true, err: err}
+	r.logger().Err(r.invalidated).Msg("databricks: result set expired")
+	return r.invalidated
+}
+
+// checkPageSchema returns why the metadata returned with the page does not match the
+// schema of the results, or "" if it does. The schema is taken from the page when it
+// was not known yet, e.g. for rows of an operation that was not run by them.
+func (r *rows) checkPageSchema(resp *cli_service.TFetchResultsResp) string {
+	if r.schemaFromCache {
+		if reason := r.checkCachedSchema(resp); reason != "" {
+			return reason
+		}
+	}
+	if resp.IsSetResultSetMetadata() && resp.ResultSetMetadata.IsSetSchema() {
+		if r.fetchResultsMetadata == nil || !r.fetchResultsMetadata.IsSetSchema() {
+			r.fetchResultsMetadata = resp.ResultSetMetadata
+		} else if !sameSchema(r.fetchResultsMetadata.Schema, resp.ResultSetMetadata.Schema) {
+			return "the schema of the results changed"
+		}
+	}
+	return ""
+}
+
+// sameSchema returns true if the schemas have the same column names and types,
+// including their qualifiers, e.g. the precision and scale of decimals
+func sameSchema(a, b *cli_service.TTableSchema) bool {
+	if len(a.Columns) != len(b.Columns) {
+		return false
+	}
+	for i := range a.Columns {
+		if a.Columns[i].ColumnName != b.Columns[i].ColumnName || !a.Columns[i].TypeDesc.Equals(b.Columns[i].TypeDesc) {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchPage fetches a result page once the fetch limits of the connector and of the
+// process allow it
+func (r *rows) fetchPage(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+	var sem fetchSemaphore
+	if r.conn != nil {
+		sem = r.conn.fetchSem
+	}
+	if err := sem.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer sem.release()
+	global := getGlobalFetchSemaphore()
+	if err := global.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer global.release()
+	// the time waiting for a fetch slot does not count
+	if r.config != nil && r.config.FetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.FetchTimeout)
+		defer cancel()
+	}
+
+	r.reportPageTimings()
+	var collector metrics.Collector
+	if r.config != nil {
+		collector = r.config.Metrics
+	}
+	if collector == nil && !r.logger().Debug().Enabled() {
+		return r.client.FetchResults(ctx, req)
+	}
+	var timings client.Timings
+	start := time.Now()
+	resp, err := r.client.FetchResults(client.NewContextWithTimings(ctx, &timings), req)
+	if err != nil {
+		return resp, err
+	}
+	// the response body is read while it is deserialized, so what is left of the
+	// request time once the network and decompression are accounted for is spent
+	// deserializing
+	deserialize := time.Since(start) - timings.Wait - timings.Transfer - timings.Decompress
+	if deserialize < 0 {
+		deserialize = 0
+	}
+	r.pageTimings = &metrics.PageFetch{
+		QueryID:     r.queryId(),
+		Rows:        getNRows(resp.GetResults()),
+		PageSize:    req.MaxRows,
+		Bytes:       timings.Bytes,
+		Wait:        timings.Wait,
+		Transfer:    timings.Transfer,
+		Decompress:  timings.Decompress,
+		Deserialize: deserialize,
+	}
+	return resp, nil
+}
+
+// reportPageTimings passes the timings of the current page, which include the time
+// spent decoding the rows read from it, to the metrics collector and the debug log
+func (r *rows) reportPageTimings() {
+	page := r.pageTimings
+	if page == nil {
+		return
+	}
+	r.pageTimings = nil
+	r.logger().Debug().Msgf("databricks: fetched page of %d rows, %d bytes: wait %v, transfer %v, decompress %v, deserialize %v, decode %v",
+		page.Rows, page.Bytes, page.Wait, page.Transfer, page.Decompress, page.Deserialize, page.Decode)
+	if r.config != nil && r.config.Metrics != nil {
+		r.config.Metrics.PageFetched(r.requestContext(), *page)
+	}
+}
+
+// requestContext returns the context for server requests made while iterating
+func (r *rows) requestContext() context.Context {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return driverctx.NewContextWithCorrelationId(driverctx.NewContextWithConnId(ctx, r.connId), r.correlationId)
+}
+
+// logger returns a logger with the connection, correlation and query ids and the
+// statement tag of the query context
+func (r *rows) logger() *logger.DBSQLLogger {
+	return statementLogger(r.requestContext(), r.connId, r.queryId())
+}
+
+// queryId returns the id of the operation of the rows, or "" if there is none
+func (r *rows) queryId() string {
+	if r.opHandle != nil && r.opHandle.OperationId != nil {
+		return client.SprintGuid(r.opHandle.OperationId.GUID)
+	}
+	return ""
+}
+
+// checkBroken flags the connection as broken when err is a session error
+func (r *rows) checkBroken(err error) {
+	if r.conn != nil {
+		r.conn.checkBroken(err)
+	}
+}
+
+// wrapErr adds the statement tag of the query context and the timings of the
+// statement to errors returned while iterating. io.EOF is returned as is since
+// database/sql compares it directly.
+func (r *rows) wrapErr(err error) error {
+	if err == io.EOF {
+		return err
+	}
+	err = statementTimerFromContext(r.ctx).wrapErr(wrapQueryTag(r.requestContext(), err))
+	if r.config != nil {
+		for _, i := range r.config.Interceptors {
+			i.OnError(r.requestContext(), r.query, err)
+		}
+	}
+	return err
+}
+
 // getPageFetchDirection returns the cli_service.TFetchOrientation
 // necessary to fetch a result page containing the next row number.
 // Note: if the next row number is in the current page TFetchOrientation_FETCH_NEXT
@@ -435,43 +944,83 @@ const (
 	DateFormat      = "2006-01-02"
 )
 
-func value(tColumn *cli_service.TColumn, tColumnDesc *cli_service.TColumnDesc, rowNum int64, location *time.Location) (val interface{}, err error) {
-	if location == nil {
-		location = time.UTC
+func value(tColumn *cli_service.TColumn, tColumnDesc *cli_service.TColumnDesc, rowNum int64, location *time.Location, cfg *config.Config) (val interface{}, err error) {
+	val = rawValue(tColumn, rowNum)
+	if convert := newValueConverter(getDBTypeName(tColumnDesc), location, cfg); val != nil && convert != nil {
+		return convert(val)
 	}
+	return val, nil
+}
 
-	entry := tColumnDesc.TypeDesc.Types[0].PrimitiveEntry
-	dbtype := strings.TrimSuffix(entry.Type.String(), "_TYPE")
-	if tVal := tColumn.GetStringVal(); tVal != nil && !isNull(tVal.Nulls, rowNum) {
-		val = tVal.Values[rowNum]
+// rawValue returns the value of a row as sent by the server, or nil for NULL
+func rawValue(tColumn *cli_service.TColumn, rowNum int64) any {
+	if tVal := tColumn.GetStringVal(); tVal != nil && hasValue(tVal.Nulls, len(tVal.Values), rowNum) {
+		return tVal.Values[rowNum]
+	} else if tVal := tColumn.GetByteVal(); tVal != nil && hasValue(tVal.Nulls, len(tVal.Values), rowNum) {
+		return tVal.Values[rowNum]
+	} else if tVal := tColumn.GetI16Val(); tVal != nil && hasValue(tVal.Nulls, len(tVal.Values), rowNum) {
+		return tVal.Values[rowNum]
+	} else if tVal := tColumn.GetI32Val(); tVal != nil && hasValue(tVal.Nulls, len(tVal.Values), rowNum) {
+		return tVal.Values[rowNum]
+	} else if tVal := tColumn.GetI64Val(); tVal != nil && hasValue(tVal.Nulls, len(tVal.Values), rowNum) {
+		return tVal.Values[rowNum]
+	} else if tVal := tColumn.GetBoolVal(); tVal != nil && hasValue(tVal.Nulls, len(tVal.Values), rowNum) {
+		return tVal.Values[rowNum]
+	} else if tVal := tColumn.GetDoubleVal(); tVal != nil && hasValue(tVal.Nulls, len(tVal.Values), rowNum) {
+		return tVal.Values[rowNum]
+	} else if tVal := tColumn.GetBinaryVal(); tVal != nil && hasValue(tVal.Nulls, len(tVal.Values), rowNum) {
+		return tVal.Values[rowNum]
+	}
+	return nil
+}
+
+// hasValue returns true when the row of a column of n values is set and not NULL.
+// Rows past the values of the column, e.g. of a column only holding NULLs, are NULL.
+func hasValue(nulls []byte, n int, rowNum int64) bool {
+	return rowNum < int64(n) && !isNull(nulls, rowNum)
+}
+
+// parseTimeValue parses a DATE or TIMESTAMP value. A value that does not match the
+// default layout is returned as a string, or is a *ConversionError with strict time
+// parsing, while a value that matches none of the layouts configured with
+// WithTimestampLayouts or WithDateLayouts is an error.
+func parseTimeValue(s string, dbtype string, location *time.Location, cfg *config.Config) (any, error) {
+	var layouts []string
+	defaultLayout := DateFormat
+	if dbtype == "TIMESTAMP" {
+		defaultLayout = TimestampFormat
+	}
+	if cfg != nil {
 		if dbtype == "TIMESTAMP" {
-			t, err := time.ParseInLocation(TimestampFormat, val.(string), location)
-			if err == nil {
-				val = t
-			}
-		} else if dbtype == "DATE" {
-			t, err := time.ParseInLocation(DateFormat, val.(string), location)
-			if err == nil {
-				val = t
-			}
+			layouts = cfg.TimestampLayouts
+		} else {
+			layouts = cfg.DateLayouts
 		}
-	} else if tVal := tColumn.GetByteVal(); tVal != nil && !isNull(tVal.Nulls, rowNum) {
-		val = tVal.Values[rowNum]
-	} else if tVal := tColumn.GetI16Val(); tVal != nil && !isNull(tVal.Nulls, rowNum) {
-		val = tVal.Values[rowNum]
-	} else if tVal := tColumn.GetI32Val(); tVal != nil && !isNull(tVal.Nulls, rowNum) {
-		val = tVal.Values[rowNum]
-	} else if tVal := tColumn.GetI64Val(); tVal != nil && !isNull(tVal.Nulls, rowNum) {
-		val = tVal.Values[rowNum]
-	} else if tVal := tColumn.GetBoolVal(); tVal != nil && !isNull(tVal.Nulls, rowNum) {
-		val = tVal.Values[rowNum]
-	} else if tVal := tColumn.GetDoubleVal(); tVal != nil && !isNull(tVal.Nulls, rowNum) {
-		val = tVal.Values[rowNum]
-	} else if tVal := tColumn.GetBinaryVal(); tVal != nil && !isNull(tVal.Nulls, rowNum) {
-		val = tVal.Values[rowNum]
 	}
+	if t, ok := parseTime(s, layouts, defaultLayout, location); ok {
+		return t, nil
+	}
+	if len(layouts) > 0 {
+		return nil, errors.Errorf("databricks: %s value %q matches none of the configured layouts", dbtype, s)
+	}
+	if cfg != nil && cfg.StrictTimeParsing {
+		return nil, &ConversionError{Value: s, Type: "time.Time", Reason: "does not match the " + dbtype + " layout " + defaultLayout}
+	}
+	return s, nil
+}
 
-	return val, err
+// parseTime parses s with the first matching layout, or with the default
+// layout when no layouts are given
+func parseTime(s string, layouts []string, defaultLayout string, location *time.Location) (time.Time, bool) {
+	if len(layouts) == 0 {
+		layouts = []string{defaultLayout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, location); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
 }
 
 func isNull(nulls []byte, position int64) bool {
@@ -483,35 +1032,57 @@ func isNull(nulls []byte, position int64) bool {
 	return false
 }
 
+// getNRows returns the number of rows of a page. The row counts sent by the server
+// with arrow batches and result links are used when set, then the row based rows.
+// Column based pages have no row count, so the longest column is used: a page is
+// not counted as empty because its first columns only hold NULLs.
 func getNRows(rs *cli_service.TRowSet) int64 {
 	if rs == nil {
 		return 0
 	}
-	for _, col := range rs.Columns {
-		if col.BoolVal != nil {
-			return int64(len(col.BoolVal.Values))
-		}
-		if col.ByteVal != nil {
-			return int64(len(col.ByteVal.Values))
-		}
-		if col.I16Val != nil {
-			return int64(len(col.I16Val.Values))
-		}
-		if col.I32Val != nil {
-			return int64(len(col.I32Val.Values))
-		}
-		if col.I64Val != nil {
-			return int64(len(col.I64Val.Values))
-		}
-		if col.StringVal != nil {
-			return int64(len(col.StringVal.Values))
+	if len(rs.ArrowBatches) > 0 || len(rs.ResultLinks) > 0 {
+		var n int64
+		for _, batch := range rs.ArrowBatches {
+			n += batch.GetRowCount()
 		}
-		if col.DoubleVal != nil {
-			return int64(len(col.DoubleVal.Values))
+		for _, link := range rs.ResultLinks {
+			n += link.GetRowCount()
 		}
-		if col.BinaryVal != nil {
-			return int64(len(col.BinaryVal.Values))
+		return n
+	}
+	if len(rs.Rows) > 0 {
+		return int64(len(rs.Rows))
+	}
+	var n int64
+	for _, col := range rs.Columns {
+		if c := getNColumnValues(col); c > n {
+			n = c
 		}
 	}
+	return n
+}
+
+// getNColumnValues returns the number of values of a column, 0 if it has none
+func getNColumnValues(col *cli_service.TColumn) int64 {
+	switch {
+	case col == nil:
+		return 0
+	case col.BoolVal != nil:
+		return int64(len(col.BoolVal.Values))
+	case col.ByteVal != nil:
+		return int64(len(col.ByteVal.Values))
+	case col.I16Val != nil:
+		return int64(len(col.I16Val.Values))
+	case col.I32Val != nil:
+		return int64(len(col.I32Val.Values))
+	case col.I64Val != nil:
+		return int64(len(col.I64Val.Values))
+	case col.StringVal != nil:
+		return int64(len(col.StringVal.Values))
+	case col.DoubleVal != nil:
+		return int64(len(col.DoubleVal.Values))
+	case col.BinaryVal != nil:
+		return int64(len(col.BinaryVal.Values))
+	}
 	return 0
 }
@@ -10,11 +10,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/databricks/databricks-sql-go/driverctx"
 	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/databricks/databricks-sql-go/metrics"
 
 	"github.com/databricks/databricks-sql-go/internal/cli_service"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRowsNextRowInPage(t *testing.T) {
@@ -214,6 +218,366 @@ func TestRowsFetchResultPageErrors(t *testing.T) {
 	assert.EqualError(t, err, io.EOF.Error(), "row number past end of result set should return EOF")
 }
 
+func TestRowsClose(t *testing.T) {
+	t.Parallel()
+	t.Run("closes the operation synchronously by default", func(t *testing.T) {
+		var closeCount int
+		rowSet := &rows{
+			config: config.WithDefaults(),
+			client: &client.TestClient{
+				FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+					closeCount++
+					_, ok := ctx.Deadline()
+					assert.True(t, ok, "close should be bounded by a timeout")
+					return &cli_service.TCloseOperationResp{}, nil
+				},
+			},
+		}
+		assert.NoError(t, rowSet.Close())
+		assert.Equal(t, 1, closeCount)
+	})
+	t.Run("returns close errors", func(t *testing.T) {
+		rowSet := &rows{
+			client: &client.TestClient{
+				FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+					return nil, errors.New("close failed")
+				},
+			},
+		}
+		assert.EqualError(t, rowSet.Close(), "close failed")
+	})
+	t.Run("close ignores a canceled query context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		rowSet := &rows{
+			ctx: ctx,
+			client: &client.TestClient{
+				FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+					return &cli_service.TCloseOperationResp{}, ctx.Err()
+				},
+			},
+		}
+		assert.NoError(t, rowSet.Close())
+	})
+}
+
+func TestRowsRequestContext(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rowSet := &rows{
+		ctx: ctx,
+		client: &client.TestClient{
+			FnGetResultSetMetadata: func(ctx context.Context, req *cli_service.TGetResultSetMetadataReq) (*cli_service.TGetResultSetMetadataResp, error) {
+				return nil, ctx.Err()
+			},
+		},
+	}
+	_, err := rowSet.getResultMetadata()
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRowsQueryTag(t *testing.T) {
+	t.Parallel()
+	ctx := driverctx.NewContextWithQueryTag(context.Background(), "nightly-report:step3")
+	rowSet := &rows{
+		ctx: ctx,
+		client: &client.TestClient{
+			FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+				return nil, errors.New("fetch failed")
+			},
+		},
+	}
+	err := rowSet.Next(make([]driver.Value, 1))
+	assert.EqualError(t, err, "statement nightly-report:step3: fetch failed")
+
+	noMoreRows := false
+	rowSet.fetchResults = &cli_service.TFetchResultsResp{HasMoreRows: &noMoreRows, Results: &cli_service.TRowSet{}}
+	err = rowSet.Next(make([]driver.Value, 1))
+	assert.Equal(t, io.EOF, err, "io.EOF must not be wrapped")
+}
+
+func TestRowsSeekRow(t *testing.T) {
+	t.Parallel()
+
+	const nRows = 100
+	var requests []*cli_service.TFetchResultsReq
+	rowSet := &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(nRows, &requests),
+	}
+	dest := make([]driver.Value, 1)
+
+	assert.Equal(t, int64(0), rowSet.NextRowNumber())
+	err := rowSet.SeekRow(42)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), rowSet.NextRowNumber())
+	assert.Len(t, requests, 1)
+	assert.Equal(t, cli_service.TFetchOrientation_FETCH_ABSOLUTE, requests[0].Orientation)
+	assert.Equal(t, int64(42), *requests[0].StartRowOffset)
+	assert.NoError(t, rowSet.Next(dest))
+	assert.Equal(t, int32(42), dest[0])
+
+	// seeking within the current page does not fetch
+	err = rowSet.SeekRow(50)
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+	assert.NoError(t, rowSet.Next(dest))
+	assert.Equal(t, int32(50), dest[0])
+
+	// iteration continues with the following pages
+	for i := 51; i < 63; i++ {
+		assert.NoError(t, rowSet.Next(dest))
+		assert.Equal(t, int32(i), dest[0])
+	}
+	assert.Len(t, requests, 3)
+	assert.Equal(t, cli_service.TFetchOrientation_FETCH_NEXT, requests[1].Orientation)
+
+	err = rowSet.SeekRow(-1)
+	assert.EqualError(t, err, errRowsNegativeOffset)
+
+	// the position is unchanged when seeking past the end
+	err = rowSet.SeekRow(nRows)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, int64(63), rowSet.nextRowNumber)
+	nRequests := len(requests)
+	assert.NoError(t, rowSet.Next(dest))
+	assert.Equal(t, int32(63), dest[0])
+	assert.Len(t, requests, nRequests, "the current page must be kept")
+}
+
+func TestRowsFetchFarBackwards(t *testing.T) {
+	t.Parallel()
+
+	var requests []*cli_service.TFetchResultsReq
+	rowSet := &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(100, &requests),
+	}
+	dest := make([]driver.Value, 1)
+	for i := 0; i < 95; i++ {
+		require.NoError(t, rowSet.Next(dest))
+	}
+	require.Len(t, requests, 10)
+
+	// the previous page is fetched with FETCH_PRIOR
+	rowSet.nextRowNumber = 85
+	require.NoError(t, rowSet.Next(dest))
+	assert.Equal(t, int32(85), dest[0])
+	require.Len(t, requests, 11)
+	assert.Equal(t, cli_service.TFetchOrientation_FETCH_PRIOR, requests[10].Orientation)
+
+	// rows further back take a single absolute fetch instead of one per page
+	rowSet.nextRowNumber = 3
+	require.NoError(t, rowSet.Next(dest))
+	assert.Equal(t, int32(3), dest[0])
+	require.Len(t, requests, 12)
+	assert.Equal(t, cli_service.TFetchOrientation_FETCH_ABSOLUTE, requests[11].Orientation)
+	assert.Equal(t, int64(3), *requests[11].StartRowOffset)
+
+	// iteration goes on forward from there
+	require.NoError(t, rowSet.Next(dest))
+	assert.Equal(t, int32(4), dest[0])
+	assert.Len(t, requests, 12)
+}
+
+func TestRowsNextRowNumber(t *testing.T) {
+	t.Parallel()
+
+	var requests []*cli_service.TFetchResultsReq
+	rowSet := &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(25, &requests),
+	}
+	dest := make([]driver.Value, 1)
+	for i := 0; i < 13; i++ {
+		assert.NoError(t, rowSet.Next(dest))
+	}
+	// a checkpoint taken after 13 rows resumes at the 14th row on other rows
+	checkpoint := rowSet.NextRowNumber()
+	assert.Equal(t, int64(13), checkpoint)
+
+	resumed := &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(25, &requests),
+	}
+	assert.NoError(t, resumed.SeekRow(checkpoint))
+	assert.NoError(t, resumed.Next(dest))
+	assert.Equal(t, int32(13), dest[0])
+	assert.Equal(t, int64(14), resumed.NextRowNumber())
+
+	assert.NoError(t, resumed.Rewind())
+	assert.Equal(t, int64(0), resumed.NextRowNumber())
+}
+
+func TestRowsTotalRows(t *testing.T) {
+	t.Parallel()
+
+	var requests []*cli_service.TFetchResultsReq
+	rowSet := &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(25, &requests),
+	}
+	total, exact := rowSet.TotalRows()
+	assert.Equal(t, int64(0), total)
+	assert.False(t, exact)
+	assert.True(t, rowSet.HasMoreRows())
+
+	dest := make([]driver.Value, 1)
+	for i := 0; i < 12; i++ {
+		assert.NoError(t, rowSet.Next(dest))
+	}
+	// the server reported more rows after the second page
+	total, exact = rowSet.TotalRows()
+	assert.Equal(t, int64(20), total)
+	assert.False(t, exact)
+	assert.True(t, rowSet.HasMoreRows())
+
+	for i := 12; i < 25; i++ {
+		assert.NoError(t, rowSet.Next(dest))
+	}
+	total, exact = rowSet.TotalRows()
+	assert.Equal(t, int64(25), total)
+	assert.True(t, exact)
+	assert.False(t, rowSet.HasMoreRows())
+	assert.Equal(t, io.EOF, rowSet.Next(dest))
+
+	// the first page fetched again does not hide the end of the results
+	assert.NoError(t, rowSet.Rewind())
+	total, exact = rowSet.TotalRows()
+	assert.Equal(t, int64(25), total)
+	assert.True(t, exact)
+	assert.True(t, rowSet.HasMoreRows())
+
+	// an empty last page ends the results without an offset
+	hasMoreRows := false
+	rowSet = &rows{fetchedRows: 30}
+	assert.NoError(t, rowSet.setPage(&cli_service.TFetchResultsResp{HasMoreRows: &hasMoreRows, Results: &cli_service.TRowSet{}}))
+	total, exact = rowSet.TotalRows()
+	assert.Equal(t, int64(30), total)
+	assert.True(t, exact)
+
+	rowSet = &rows{noResultSet: true}
+	total, exact = rowSet.TotalRows()
+	assert.Equal(t, int64(0), total)
+	assert.True(t, exact)
+	assert.False(t, rowSet.HasMoreRows())
+}
+
+func TestRowsRewind(t *testing.T) {
+	t.Parallel()
+
+	var requests []*cli_service.TFetchResultsReq
+	rowSet := &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(25, &requests),
+	}
+	dest := make([]driver.Value, 1)
+	var count int
+	for rowSet.Next(dest) == nil {
+		count++
+	}
+	assert.Equal(t, 25, count)
+	assert.Len(t, requests, 3)
+
+	err := rowSet.Rewind()
+	assert.NoError(t, err)
+	assert.Len(t, requests, 4)
+	assert.Equal(t, cli_service.TFetchOrientation_FETCH_FIRST, requests[3].Orientation)
+
+	count = 0
+	for rowSet.Next(dest) == nil {
+		assert.Equal(t, int32(count), dest[0])
+		count++
+	}
+	assert.Equal(t, 25, count)
+
+	// rewinding within the first page does not fetch
+	rowSet = &rows{
+		pageSize: 10,
+		client:   getRowsTestCursorClient(5, &requests),
+	}
+	requests = nil
+	assert.NoError(t, rowSet.Next(dest))
+	assert.NoError(t, rowSet.Next(dest))
+	assert.NoError(t, rowSet.Rewind())
+	assert.NoError(t, rowSet.Next(dest))
+	assert.Equal(t, int32(0), dest[0])
+	assert.Len(t, requests, 1)
+}
+
+func TestRowsFetchResultPageUnexpectedPage(t *testing.T) {
+	t.Parallel()
+
+	// a server that always returns the first page
+	fetchResults := func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+		hasMoreRows := true
+		return &cli_service.TFetchResultsResp{
+			HasMoreRows: &hasMoreRows,
+			Results: &cli_service.TRowSet{
+				Columns: []*cli_service.TColumn{{I32Val: &cli_service.TI32Column{Values: []int32{0, 1}}}},
+			},
+		}, nil
+	}
+	rowSet := &rows{
+		pageSize:      2,
+		nextRowNumber: 2,
+		client:        &client.TestClient{FnFetchResults: fetchResults},
+	}
+	err := rowSet.fetchResultPage()
+	assert.EqualError(t, err, errRowsUnexpectedPage)
+}
+
+// getRowsTestCursorClient returns a client serving a single INT column with the values
+// 0 to nRows-1. Like the server it keeps a cursor that FETCH_NEXT continues from.
+func getRowsTestCursorClient(nRows int64, requests *[]*cli_service.TFetchResultsReq) *client.TestClient {
+	var cursor, pageStart int64
+	fetchResults := func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+		*requests = append(*requests, req)
+		start := cursor
+		switch req.Orientation {
+		case cli_service.TFetchOrientation_FETCH_ABSOLUTE:
+			start = *req.StartRowOffset
+		case cli_service.TFetchOrientation_FETCH_FIRST:
+			start = 0
+		case cli_service.TFetchOrientation_FETCH_PRIOR:
+			start = pageStart - req.MaxRows
+			if start < 0 {
+				start = 0
+			}
+		}
+		var values []int32
+		for i := start; i < start+req.MaxRows && i < nRows; i++ {
+			values = append(values, int32(i))
+		}
+		pageStart, cursor = start, start+int64(len(values))
+		hasMoreRows := cursor < nRows
+		return &cli_service.TFetchResultsResp{
+			Status:      &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			HasMoreRows: &hasMoreRows,
+			Results: &cli_service.TRowSet{
+				StartRowOffset: start,
+				Columns:        []*cli_service.TColumn{{I32Val: &cli_service.TI32Column{Values: values}}},
+			},
+		}, nil
+	}
+	getMetadata := func(ctx context.Context, req *cli_service.TGetResultSetMetadataReq) (*cli_service.TGetResultSetMetadataResp, error) {
+		return &cli_service.TGetResultSetMetadataResp{
+			Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			Schema: &cli_service.TTableSchema{Columns: []*cli_service.TColumnDesc{{
+				ColumnName: "id",
+				TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{{
+					PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_INT_TYPE},
+				}}},
+			}}},
+		}, nil
+	}
+	return &client.TestClient{
+		FnFetchResults:         fetchResults,
+		FnGetResultSetMetadata: getMetadata,
+	}
+}
+
 func TestGetResultMetadataNoDirectResults(t *testing.T) {
 	t.Parallel()
 	var getMetadataCount, fetchResultsCount int
@@ -330,27 +694,37 @@ func TestRowsFetchResultPageNoDirectResults(t *testing.T) {
 		errMessage:        &errMsg,
 	}.validatePaging(t, rowSet, err, fetchResultsCount, getMetadataCount)
 
-	// next row number is before start of results, should fetch all result pages
-	// going forward and then return EOF
+	// next row number is before start of results, should return an error
+	// without fetching
 	rowSet.nextRowNumber = -1
 	err = rowSet.fetchResultPage()
 	errMsg = errRowsFetchPriorToStart
 	rowTestPagingResult{
 		getMetadataCount:  0,
-		fetchResultsCount: 7,
+		fetchResultsCount: 5,
 		nextRowIndex:      int64(2),
 		nextRowNumber:     int64(-1),
-		offset:            i64Zero,
+		offset:            int64(10),
 		errMessage:        &errMsg,
 	}.validatePaging(t, rowSet, err, fetchResultsCount, getMetadataCount)
 
+	// jump back to the first page, two pages back, with a single absolute fetch
+	rowSet.nextRowNumber = 1
+	err = rowSet.fetchResultPage()
+	rowTestPagingResult{
+		getMetadataCount:  0,
+		fetchResultsCount: 6,
+		nextRowIndex:      int64(1),
+		nextRowNumber:     int64(1),
+		offset:            i64Zero,
+	}.validatePaging(t, rowSet, err, fetchResultsCount, getMetadataCount)
+
 	// jump back to last page
 	rowSet.nextRowNumber = 12
 	err = rowSet.fetchResultPage()
-	errMsg = "unable to fetch row page prior to start of results"
 	rowTestPagingResult{
 		getMetadataCount:  0,
-		fetchResultsCount: 9,
+		fetchResultsCount: 8,
 		nextRowIndex:      int64(2),
 		nextRowNumber:     int64(12),
 		offset:            int64(10),
@@ -430,27 +804,37 @@ func TestRowsFetchResultPageWithDirectResults(t *testing.T) {
 		errMessage:        &errMsg,
 	}.validatePaging(t, rowSet, err, fetchResultsCount, getMetadataCount)
 
-	// next row number is before start of results, should fetch all result pages
-	// going forward and then return EOF
+	// next row number is before start of results, should return an error
+	// without fetching
 	rowSet.nextRowNumber = -1
 	err = rowSet.fetchResultPage()
 	errMsg = errRowsFetchPriorToStart
 	rowTestPagingResult{
 		getMetadataCount:  0,
-		fetchResultsCount: 7,
+		fetchResultsCount: 5,
 		nextRowIndex:      int64(2),
 		nextRowNumber:     int64(-1),
-		offset:            i64Zero,
+		offset:            int64(10),
 		errMessage:        &errMsg,
 	}.validatePaging(t, rowSet, err, fetchResultsCount, getMetadataCount)
 
+	// jump back to the first page, two pages back, with a single absolute fetch
+	rowSet.nextRowNumber = 1
+	err = rowSet.fetchResultPage()
+	rowTestPagingResult{
+		getMetadataCount:  0,
+		fetchResultsCount: 6,
+		nextRowIndex:      int64(1),
+		nextRowNumber:     int64(1),
+		offset:            i64Zero,
+	}.validatePaging(t, rowSet, err, fetchResultsCount, getMetadataCount)
+
 	// jump back to last page
 	rowSet.nextRowNumber = 12
 	err = rowSet.fetchResultPage()
-	errMsg = "unable to fetch row page prior to start of results"
 	rowTestPagingResult{
 		getMetadataCount:  0,
-		fetchResultsCount: 9,
+		fetchResultsCount: 8,
 		nextRowIndex:      int64(2),
 		nextRowNumber:     int64(12),
 		offset:            int64(10),
@@ -671,6 +1055,108 @@ func TestGetScanType(t *testing.T) {
 	assert.Equal(t, expectedScanTypes, scanTypes)
 }
 
+func TestGetDBTypeID(t *testing.T) {
+	entries := []*cli_service.TTypeEntry{
+		{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_INT_TYPE}},
+		{ArrayEntry: &cli_service.TArrayTypeEntry{ObjectTypePtr: 0}},
+		{MapEntry: &cli_service.TMapTypeEntry{KeyTypePtr: 0, ValueTypePtr: 0}},
+		{StructEntry: &cli_service.TStructTypeEntry{NameToTypePtr: map[string]cli_service.TTypeEntryPtr{"a": 0}}},
+		{UnionEntry: &cli_service.TUnionTypeEntry{NameToTypePtr: map[string]cli_service.TTypeEntryPtr{"a": 0}}},
+		{UserDefinedTypeEntry: &cli_service.TUserDefinedTypeEntry{TypeClassName: "foo"}},
+	}
+	expected := []string{"INT", "ARRAY", "MAP", "STRUCT", "UNION", "USER_DEFINED"}
+	for i := range entries {
+		col := &cli_service.TColumnDesc{TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{entries[i], entries[0]}}}
+		assert.Equal(t, expected[i], getDBTypeName(col))
+	}
+
+	assert.Equal(t, cli_service.TTypeId_USER_DEFINED_TYPE, getDBTypeID(&cli_service.TColumnDesc{}))
+	assert.Equal(t, scanTypeRawBytes, getScanType(&cli_service.TColumnDesc{TypeDesc: &cli_service.TTypeDesc{Types: entries[1:2]}}))
+}
+
+func TestValueTimeParsing(t *testing.T) {
+	tsDesc := &cli_service.TColumnDesc{TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{
+		{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_TIMESTAMP_TYPE}},
+	}}}
+	dateDesc := &cli_service.TColumnDesc{TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{
+		{PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_DATE_TYPE}},
+	}}}
+	col := func(v string) *cli_service.TColumn {
+		return &cli_service.TColumn{StringVal: &cli_service.TStringColumn{Values: []string{v}}}
+	}
+
+	t.Run("default layouts", func(t *testing.T) {
+		val, err := value(col("2021-07-01 05:43:28"), tsDesc, 0, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2021, 7, 1, 5, 43, 28, 0, time.UTC), val)
+
+		val, err = value(col("2021-07-01"), dateDesc, 0, nil, config.WithDefaults())
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC), val)
+	})
+
+	t.Run("parsing disabled", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.DisableTimeParsing = true
+		val, err := value(col("2021-07-01 05:43:28"), tsDesc, 0, nil, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "2021-07-01 05:43:28", val)
+	})
+
+	t.Run("alternate layouts", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		cfg.TimestampLayouts = []string{TimestampFormat, time.RFC3339}
+		cfg.DateLayouts = []string{"01/02/2006"}
+		val, err := value(col("2021-07-01T05:43:28Z"), tsDesc, 0, nil, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2021, 7, 1, 5, 43, 28, 0, time.UTC), val)
+
+		val, err = value(col("07/01/2021"), dateDesc, 0, nil, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC), val)
+
+		_, err = value(col("2021-07-01"), dateDesc, 0, nil, cfg)
+		assert.EqualError(t, err, `databricks: DATE value "2021-07-01" matches none of the configured layouts`)
+	})
+
+	t.Run("values not matching the default layout are strings", func(t *testing.T) {
+		val, err := value(col("not a timestamp"), tsDesc, 0, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "not a timestamp", val)
+	})
+
+	t.Run("strict parsing fails on values not matching the default layout", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		WithTimeParsingMode(TimeParsingStrict)(cfg)
+		_, err := value(col("not a timestamp"), tsDesc, 0, nil, cfg)
+		var convErr *ConversionError
+		assert.ErrorAs(t, err, &convErr)
+		assert.EqualError(t, err, "databricks: cannot convert not a timestamp to time.Time: does not match the TIMESTAMP layout "+TimestampFormat)
+
+		val, err := value(col("2021-07-01"), dateDesc, 0, nil, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC), val)
+
+		WithTimeParsingMode(TimeParsingLenient)(cfg)
+		val, err = value(col("not a date"), dateDesc, 0, nil, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "not a date", val)
+	})
+
+	t.Run("scan type follows time parsing", func(t *testing.T) {
+		cfg := config.WithDefaults()
+		rowSet := &rows{
+			client:               &client.TestClient{},
+			config:               cfg,
+			fetchResultsMetadata: &cli_service.TGetResultSetMetadataResp{Schema: &cli_service.TTableSchema{Columns: []*cli_service.TColumnDesc{tsDesc, dateDesc}}},
+		}
+		assert.Equal(t, scanTypeDateTime, rowSet.ColumnTypeScanType(0))
+		cfg.DisableTimeParsing = true
+		assert.Equal(t, scanTypeString, rowSet.ColumnTypeScanType(0))
+		assert.Equal(t, scanTypeString, rowSet.ColumnTypeScanType(1))
+	})
+}
+
 func TestLengthTRowSet(t *testing.T) {
 	rowSet := &cli_service.TRowSet{}
 	assert.Equal(t, int64(0), getNRows(rowSet))
@@ -708,6 +1194,59 @@ func TestLengthTRowSet(t *testing.T) {
 	bin := make([][]byte, 23)
 	rowSet.Columns[0] = &cli_service.TColumn{BinaryVal: &cli_service.TBinaryColumn{Values: bin}}
 	assert.Equal(t, int64(len(bin)), getNRows(rowSet))
+
+	// columns only holding NULLs don't hide the rows of the others
+	rowSet.Columns = []*cli_service.TColumn{
+		{},
+		{StringVal: &cli_service.TStringColumn{Nulls: []byte{0xff}}},
+		{I32Val: &cli_service.TI32Column{Values: make([]int32, 3)}},
+	}
+	assert.Equal(t, int64(3), getNRows(rowSet))
+	val, err := value(rowSet.Columns[1], &cli_service.TColumnDesc{}, 2, nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+
+	// the row counts sent by the server are used when set
+	rowSet.ArrowBatches = []*cli_service.TSparkArrowBatch{{RowCount: 4}, {RowCount: 5}}
+	assert.Equal(t, int64(9), getNRows(rowSet))
+	rowSet.ArrowBatches = nil
+	rowSet.Rows = make([]*cli_service.TRow, 2)
+	assert.Equal(t, int64(2), getNRows(rowSet))
+}
+
+func TestRowsNoResultSet(t *testing.T) {
+	testClient := &client.TestClient{
+		FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+			return &cli_service.TExecuteStatementResp{
+				Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+				OperationHandle: &cli_service.TOperationHandle{
+					OperationId:  &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+					HasResultSet: false,
+				},
+				DirectResults: &cli_service.TSparkDirectResults{
+					OperationStatus: &cli_service.TGetOperationStatusResp{
+						OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+					},
+				},
+			}, nil
+		},
+		FnGetResultSetMetadata: func(ctx context.Context, req *cli_service.TGetResultSetMetadataReq) (*cli_service.TGetResultSetMetadataResp, error) {
+			t.Error("result set metadata requested for a statement without results")
+			return nil, errors.New("no result set")
+		},
+		FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+			t.Error("results fetched for a statement without results")
+			return nil, errors.New("no result set")
+		},
+	}
+	testConn := &conn{session: getTestSession(), client: testClient, cfg: config.WithDefaults()}
+
+	for _, query := range []string{"CREATE TABLE t (a INT)", "SET spark.sql.ansi.enabled = true"} {
+		r, err := testConn.QueryContext(context.Background(), query, nil)
+		require.NoError(t, err, query)
+		assert.Empty(t, r.Columns())
+		assert.Equal(t, io.EOF, r.Next(nil))
+	}
 }
 
 func TestColumnTypeNullable(t *testing.T) {
@@ -799,6 +1338,47 @@ func TestColumnTypeDatabaseTypeName(t *testing.T) {
 	assert.Equal(t, expectedScanTypes, scanTypes)
 }
 
+func TestColumnTypePrecisionScale(t *testing.T) {
+	var getMetadataCount, fetchResultsCount int
+
+	rowSet := &rows{}
+	client := getRowsTestSimpleClient(&getMetadataCount, &fetchResultsCount)
+	rowSet.client = client
+
+	colNames := rowSet.Columns()
+	for i := range colNames {
+		_, _, ok := rowSet.ColumnTypePrecisionScale(i)
+
+		cm, _ := rowSet.getColumnMetadataByIndex(i)
+		assert.Equal(t, getDBTypeID(cm) == cli_service.TTypeId_DECIMAL_TYPE, ok)
+	}
+
+	_, _, ok := rowSet.ColumnTypePrecisionScale(len(colNames))
+	assert.False(t, ok)
+}
+
+func TestColumnDescriptors(t *testing.T) {
+	var getMetadataCount, fetchResultsCount int
+
+	rowSet := &rows{}
+	client := getRowsTestSimpleClient(&getMetadataCount, &fetchResultsCount)
+	rowSet.client = client
+
+	descriptors, err := rowSet.ColumnDescriptors()
+	assert.Nil(t, err)
+
+	colNames := rowSet.Columns()
+	assert.Equal(t, len(colNames), len(descriptors))
+	for i := range colNames {
+		assert.Equal(t, colNames[i], descriptors[i].Name)
+		assert.Equal(t, rowSet.ColumnTypeDatabaseTypeName(i), descriptors[i].DatabaseTypeName)
+	}
+
+	var nilRows *rows
+	_, err = nilRows.ColumnDescriptors()
+	assert.EqualError(t, err, errRowsNilRows)
+}
+
 type rowTestPagingResult struct {
 	getMetadataCount  int
 	fetchResultsCount int
@@ -1199,6 +1779,16 @@ func getRowsTestSimpleClient(getMetadataCount, fetchResultsCount *int) cli_servi
 				return nil, errors.New("can't fetch prior to start of result set")
 			}
 			pageIndex--
+		} else if req.Orientation == cli_service.TFetchOrientation_FETCH_ABSOLUTE {
+			pageIndex = -1
+			for i, page := range pages {
+				if start := page.Results.StartRowOffset; *req.StartRowOffset >= start && *req.StartRowOffset < start+5 {
+					pageIndex = i
+				}
+			}
+			if pageIndex < 0 {
+				return nil, errors.New("can't fetch outside of result set")
+			}
 		} else {
 			return nil, errors.New("invalid fetch results orientation")
 		}
@@ -1213,3 +1803,154 @@ func getRowsTestSimpleClient(getMetadataCount, fetchResultsCount *int) cli_servi
 
 	return client
 }
+
+type pageMetricsRecorder []metrics.PageFetch
+
+func (r *pageMetricsRecorder) PageFetched(ctx context.Context, page metrics.PageFetch) {
+	*r = append(*r, page)
+}
+
+func TestRowsPageMetrics(t *testing.T) {
+	var requests []*cli_service.TFetchResultsReq
+	testClient := getRowsTestCursorClient(5, &requests)
+	testClient.FnCloseOperation = func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+		return &cli_service.TCloseOperationResp{Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS}}, nil
+	}
+	var recorder pageMetricsRecorder
+	cfg := config.WithDefaults()
+	cfg.Metrics = &recorder
+	rowSet := &rows{
+		pageSize: 2,
+		client:   testClient,
+		config:   cfg,
+		opHandle: &cli_service.TOperationHandle{OperationId: &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}}},
+	}
+
+	dest := make([]driver.Value, 1)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, rowSet.Next(dest))
+	}
+	// the first page is reported once the second one is fetched
+	require.Len(t, recorder, 1)
+	assert.Equal(t, int64(2), recorder[0].Rows)
+	assert.Equal(t, "01020304-0506-0708-090a-0b0c0d0e0f10", recorder[0].QueryID)
+	assert.Greater(t, recorder[0].Decode, time.Duration(0))
+
+	require.NoError(t, rowSet.Close())
+	require.Len(t, recorder, 2)
+	assert.Equal(t, int64(2), recorder[1].Rows)
+}
+
+func TestRowsResultSetInvalidated(t *testing.T) {
+	intSchema := func(names ...string) *cli_service.TGetResultSetMetadataResp {
+		columns := make([]*cli_service.TColumnDesc, len(names))
+		for i, name := range names {
+			columns[i] = &cli_service.TColumnDesc{
+				ColumnName: name,
+				TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{{
+					PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_INT_TYPE},
+				}}},
+			}
+		}
+		return &cli_service.TGetResultSetMetadataResp{Schema: &cli_service.TTableSchema{Columns: columns}}
+	}
+	page := func(start int64, metadata *cli_service.TGetResultSetMetadataResp, columns ...[]int32) *cli_service.TFetchResultsResp {
+		hasMoreRows := true
+		resp := &cli_service.TFetchResultsResp{
+			Status:            &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			HasMoreRows:       &hasMoreRows,
+			Results:           &cli_service.TRowSet{StartRowOffset: start},
+			ResultSetMetadata: metadata,
+		}
+		for _, values := range columns {
+			resp.Results.Columns = append(resp.Results.Columns, &cli_service.TColumn{I32Val: &cli_service.TI32Column{Values: values}})
+		}
+		return resp
+	}
+	// getRows returns rows holding the first page of an id column, with the second
+	// page returned by fetchResults
+	getRows := func(fetchResults func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error), fetches *int) *rows {
+		return &rows{
+			pageSize: 1,
+			client: &client.TestClient{FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+				*fetches++
+				return fetchResults(ctx, req)
+			}},
+			fetchResults:         page(0, nil, []int32{1}),
+			fetchResultsMetadata: intSchema("id"),
+		}
+	}
+
+	t.Run("invalid operation handle", func(t *testing.T) {
+		var fetches int
+		rowSet := getRows(func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+			return nil, client.ErrInvalidHandle
+		}, &fetches)
+
+		dest := make([]driver.Value, 1)
+		require.NoError(t, rowSet.Next(dest))
+		err := rowSet.Next(dest)
+		assert.ErrorIs(t, err, ErrResultSetInvalidated)
+		assert.ErrorIs(t, err, ErrResultExpired)
+		assert.ErrorIs(t, err, client.ErrInvalidHandle)
+
+		// the result set is not fetched again
+		assert.ErrorIs(t, rowSet.Next(dest), ErrResultSetInvalidated)
+		assert.Equal(t, 1, fetches)
+	})
+
+	t.Run("schema changed between pages", func(t *testing.T) {
+		var fetches int
+		rowSet := getRows(func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+			return page(1, intSchema("name"), []int32{2}), nil
+		}, &fetches)
+
+		dest := make([]driver.Value, 1)
+		require.NoError(t, rowSet.Next(dest))
+		err := rowSet.Next(dest)
+		assert.ErrorIs(t, err, ErrResultSetInvalidated)
+		assert.NotErrorIs(t, err, ErrResultExpired)
+		assert.ErrorContains(t, err, "the schema of the results changed")
+	})
+
+	t.Run("page columns do not match the schema", func(t *testing.T) {
+		var fetches int
+		rowSet := getRows(func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+			return page(1, nil, []int32{2}, []int32{3}), nil
+		}, &fetches)
+
+		dest := make([]driver.Value, 1)
+		require.NoError(t, rowSet.Next(dest))
+		err := rowSet.Next(dest)
+		assert.ErrorIs(t, err, ErrResultSetInvalidated)
+		assert.ErrorContains(t, err, "page has 2 columns, the results have 1")
+		assert.ErrorIs(t, rowSet.Next(dest), ErrResultSetInvalidated)
+		assert.Equal(t, 1, fetches)
+	})
+
+	t.Run("same schema", func(t *testing.T) {
+		var fetches int
+		rowSet := getRows(func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+			return page(1, intSchema("id"), []int32{2}), nil
+		}, &fetches)
+
+		dest := make([]driver.Value, 1)
+		require.NoError(t, rowSet.Next(dest))
+		require.NoError(t, rowSet.Next(dest))
+		assert.Equal(t, int32(2), dest[0])
+	})
+
+	t.Run("schema taken from the page when not known", func(t *testing.T) {
+		rowSet := &rows{
+			pageSize: 1,
+			client: &client.TestClient{FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+				return page(0, intSchema("id"), []int32{1}), nil
+			}},
+		}
+
+		dest := make([]driver.Value, 1)
+		require.NoError(t, rowSet.Next(dest))
+		assert.Equal(t, int32(1), dest[0])
+		assert.Equal(t, []string{"id"}, rowSet.Columns())
+	})
+}
@@ -0,0 +1,67 @@
+package dbsql
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CellReader implements sql.Scanner and exposes the contents of a STRING, VARCHAR,
+// CHAR or BINARY cell as an io.Reader, for APIs that consume readers such as io.Copy
+// or an HTTP request body.
+//
+// The Thrift result format does not allow reading a cell incrementally, so the cell
+// value is already held in memory by the current result page. CellReader reads from
+// that value directly. This avoids the copy database/sql makes when scanning a BINARY
+// cell into a *[]byte and the []byte conversion of a STRING value, but it does not
+// lower the memory needed to hold the result page itself.
+// database/sql only guarantees a scanned value until the next call to Rows.Next, so
+// the reader must be consumed before then.
+//
+//	var cell dbsql.CellReader
+//	for rows.Next() {
+//		if err := rows.Scan(&cell); err != nil {
+//			...
+//		}
+//		if cell.Valid {
+//			_, err = io.Copy(w, &cell)
+//		}
+//	}
+type CellReader struct {
+	r    io.Reader
+	size int64
+	// Valid is false when the cell is NULL
+	Valid bool
+}
+
+var _ io.Reader = (*CellReader)(nil)
+
+// Scan implements the sql.Scanner interface.
+func (c *CellReader) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		c.r, c.size, c.Valid = nil, 0, false
+	case string:
+		c.r, c.size, c.Valid = strings.NewReader(v), int64(len(v)), true
+	case []byte:
+		c.r, c.size, c.Valid = bytes.NewReader(v), int64(len(v)), true
+	default:
+		return errors.Errorf("databricks: cannot scan %T into CellReader", src)
+	}
+	return nil
+}
+
+// Read implements io.Reader. Reading a NULL cell returns io.EOF.
+func (c *CellReader) Read(p []byte) (int, error) {
+	if c.r == nil {
+		return 0, io.EOF
+	}
+	return c.r.Read(p)
+}
+
+// Size returns the size in bytes of the cell contents.
+func (c *CellReader) Size() int64 {
+	return c.size
+}
@@ -0,0 +1,54 @@
+package dbsql
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCellReader(t *testing.T) {
+	t.Run("reads string cells", func(t *testing.T) {
+		var cell CellReader
+		err := cell.Scan("hello world")
+		assert.NoError(t, err)
+		assert.True(t, cell.Valid)
+		assert.Equal(t, int64(11), cell.Size())
+		b, err := io.ReadAll(&cell)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello world", string(b))
+	})
+	t.Run("reads binary cells", func(t *testing.T) {
+		var cell CellReader
+		err := cell.Scan([]byte{1, 2, 3})
+		assert.NoError(t, err)
+		b, err := io.ReadAll(&cell)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1, 2, 3}, b)
+	})
+	t.Run("binary cells are not copied", func(t *testing.T) {
+		src := []byte{1, 2, 3}
+		var cell CellReader
+		err := cell.Scan(src)
+		assert.NoError(t, err)
+		src[0] = 9
+		b, err := io.ReadAll(&cell)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{9, 2, 3}, b)
+	})
+	t.Run("null cells are empty and invalid", func(t *testing.T) {
+		cell := CellReader{}
+		_ = cell.Scan("previous")
+		err := cell.Scan(nil)
+		assert.NoError(t, err)
+		assert.False(t, cell.Valid)
+		n, err := cell.Read(make([]byte, 1))
+		assert.Equal(t, 0, n)
+		assert.Equal(t, io.EOF, err)
+	})
+	t.Run("other types are rejected", func(t *testing.T) {
+		var cell CellReader
+		err := cell.Scan(int64(1))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,65 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+type schemaOnlyContextKey struct{}
+
+// WithSchemaOnly returns a context that makes the queries run with it return their
+// columns but no rows: they are wrapped in SELECT * FROM (...) LIMIT 0, so the server
+// plans them without reading data. Statements that are not SELECT, WITH, FROM, VALUES
+// or TABLE queries fail with ErrSchemaOnly before they are sent to the server.
+//
+// Use QuerySchema to get the column types, or Rows.ColumnDescriptors for the full
+// type information of nested types.
+func WithSchemaOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, schemaOnlyContextKey{}, true)
+}
+
+func schemaOnlyFromContext(ctx context.Context) bool {
+	schemaOnly, _ := ctx.Value(schemaOnlyContextKey{}).(bool)
+	return schemaOnly
+}
+
+// QuerySchema returns the columns of the result of the query without running it,
+// e.g. for query builders and validation layers to derive the output types. It fails
+// with ErrSchemaOnly for statements that are not queries.
+func QuerySchema(ctx context.Context, db Queryer, query string, args ...any) ([]*sql.ColumnType, error) {
+	rows, err := db.QueryContext(WithSchemaOnly(ctx), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	return columns, rows.Close()
+}
+
+// schemaOnlyQuery wraps query so that it returns no rows. It returns false when query
+// is not a single query that can be used as a subquery.
+func schemaOnlyQuery(query string) (string, bool) {
+	if !isQuery(query) {
+		return "", false
+	}
+	var first strings.Builder
+	scanSQL(query, func(kind sqlTokenKind, start, end int) bool {
+		if kind != sqlCode {
+			return first.Len() == 0
+		}
+		c := query[start]
+		if isIdentChar(c) {
+			first.WriteByte(c)
+			return true
+		}
+		return first.Len() == 0
+	})
+	if !limitKeywords[strings.ToUpper(first.String())] {
+		return "", false
+	}
+	return "SELECT * FROM (" + trimStatement(query) + ") LIMIT 0", true
+}
@@ -0,0 +1,54 @@
+package dbsql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaOnlyQuery(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM t":                           "SELECT * FROM (SELECT * FROM t) LIMIT 0",
+		"select a from t order by a; -- newest":     "SELECT * FROM (select a from t order by a) LIMIT 0",
+		"SELECT a FROM t -- all of them":            "SELECT * FROM (SELECT a FROM t) LIMIT 0",
+		"/* report */ WITH c AS (SELECT 1) TABLE c": "SELECT * FROM (/* report */ WITH c AS (SELECT 1) TABLE c) LIMIT 0",
+		"VALUES (1, 'a')":                           "SELECT * FROM (VALUES (1, 'a')) LIMIT 0",
+	}
+	for query, expected := range cases {
+		actual, ok := schemaOnlyQuery(query)
+		assert.True(t, ok, query)
+		assert.Equal(t, expected, actual, query)
+	}
+	for _, query := range []string{"SHOW TABLES", "DESCRIBE t", "INSERT INTO t VALUES (1)", "SELECT 1; DROP TABLE t", ""} {
+		_, ok := schemaOnlyQuery(query)
+		assert.False(t, ok, query)
+	}
+}
+
+func TestQuerySchema(t *testing.T) {
+	t.Run("returns the columns of the query", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id", "name"}, nil, "", &statements)
+		defer db.Close()
+
+		columns, err := QuerySchema(context.Background(), db, "SELECT id, name FROM users;")
+		require.NoError(t, err)
+		require.Len(t, columns, 2)
+		assert.Equal(t, "id", columns[0].Name())
+		assert.Equal(t, "STRING", columns[0].DatabaseTypeName())
+		assert.Equal(t, "name", columns[1].Name())
+		assert.Equal(t, []string{"SELECT * FROM (SELECT id, name FROM users) LIMIT 0"}, statements)
+	})
+
+	t.Run("statements that are not queries are not sent", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+		defer db.Close()
+
+		_, err := QuerySchema(context.Background(), db, "DELETE FROM users")
+		assert.ErrorContains(t, err, ErrSchemaOnly)
+		assert.Empty(t, statements)
+	})
+}
@@ -0,0 +1,189 @@
+package dbsql
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+)
+
+// schemaCache holds the result set metadata of the most recently run statements,
+// so that running a statement again does not need a GetResultSetMetadata request
+// when the server does not return the metadata with the results. A nil schemaCache
+// caches nothing.
+type schemaCache struct {
+	size int
+	ttl  time.Duration
+
+	mu sync.Mutex
+	// the entries by key, and in the order of use, most recent first
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type schemaCacheEntry struct {
+	key      string
+	metadata *cli_service.TGetResultSetMetadataResp
+	added    time.Time
+}
+
+func newSchemaCache(size int, ttl time.Duration) *schemaCache {
+	if size <= 0 {
+		return nil
+	}
+	return &schemaCache{size: size, ttl: ttl, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// schemaCacheKey returns the key of the results of the statement, which depend on
+// the namespace unqualified names resolve in
+func schemaCacheKey(catalog, schema, statement string) string {
+	h := sha256.New()
+	for _, s := range []string{catalog, schema, statement} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the metadata cached for the key, nil when there is none or it
+// expired
+func (sc *schemaCache) get(key string) *cli_service.TGetResultSetMetadataResp {
+	if sc == nil {
+		return nil
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	e, ok := sc.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := e.Value.(*schemaCacheEntry)
+	if sc.ttl > 0 && time.Since(entry.added) > sc.ttl {
+		sc.order.Remove(e)
+		delete(sc.entries, key)
+		return nil
+	}
+	sc.order.MoveToFront(e)
+	return entry.metadata
+}
+
+// put caches the metadata for the key, evicting the least recently used entry
+// beyond the size of the cache. Metadata without a schema is not cached.
+func (sc *schemaCache) put(key string, metadata *cli_service.TGetResultSetMetadataResp) {
+	if sc == nil || metadata == nil || !metadata.IsSetSchema() {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if e, ok := sc.entries[key]; ok {
+		sc.order.Remove(e)
+	}
+	sc.entries[key] = sc.order.PushFront(&schemaCacheEntry{key: key, metadata: metadata, added: time.Now()})
+	for sc.order.Len() > sc.size {
+		oldest := sc.order.Back()
+		sc.order.Remove(oldest)
+		delete(sc.entries, oldest.Value.(*schemaCacheEntry).key)
+	}
+}
+
+// remove drops the metadata cached for the key, e.g. once it turned out stale
+func (sc *schemaCache) remove(key string) {
+	if sc == nil {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if e, ok := sc.entries[key]; ok {
+		sc.order.Remove(e)
+		delete(sc.entries, key)
+	}
+}
+
+// useSchemaCache caches the metadata of the results under key, or takes it from the
+// cache when the server did not return it with the results
+func (r *rows) useSchemaCache(sc *schemaCache, key string) {
+	r.schemaCache, r.schemaKey = sc, key
+	if r.fetchResultsMetadata != nil {
+		sc.put(key, r.fetchResultsMetadata)
+		return
+	}
+	cached := sc.get(key)
+	if cached == nil {
+		return
+	}
+	if r.fetchResults != nil && !pageMatchesSchema(cached.Schema, r.fetchResults.GetResults().GetColumns()) {
+		sc.remove(key)
+		return
+	}
+	r.fetchResultsMetadata = cached
+	// the first page is checked when it is fetched
+	r.schemaFromCache = r.fetchResults == nil
+}
+
+// checkCachedSchema checks the metadata taken from the schema cache against the first
+// page fetched, and returns why the results cannot be read with it, if so. The
+// columns of the rows may already be known from the cached metadata, so metadata
+// returned with the page with other column names or types, or page columns that do
+// not hold the values of the cached types, evict it and invalidate the result set.
+// The metadata returned with the page is cached in its place.
+func (r *rows) checkCachedSchema(resp *cli_service.TFetchResultsResp) string {
+	r.schemaFromCache = false
+	cached := r.fetchResultsMetadata.GetSchema()
+	if resp.IsSetResultSetMetadata() && resp.ResultSetMetadata.IsSetSchema() {
+		if !sameSchema(cached, resp.ResultSetMetadata.Schema) {
+			r.schemaCache.put(r.schemaKey, resp.ResultSetMetadata)
+			return "the cached schema of the results is stale"
+		}
+		return ""
+	}
+	if !pageMatchesSchema(cached, resp.GetResults().GetColumns()) {
+		r.schemaCache.remove(r.schemaKey)
+		return "the cached schema of the results is stale"
+	}
+	return ""
+}
+
+// pageMatchesSchema returns true if the columns of a page, when there are any, are as
+// many as the columns of the schema and hold the values of their types
+func pageMatchesSchema(schema *cli_service.TTableSchema, columns []*cli_service.TColumn) bool {
+	if len(columns) == 0 {
+		return true
+	}
+	if len(columns) != len(schema.GetColumns()) {
+		return false
+	}
+	for i, column := range columns {
+		if !columnHoldsType(column, schema.Columns[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// columnHoldsType returns true if the column holds the values of the type of desc.
+// Values of types other than booleans, numbers and binaries are held as strings,
+// except for those of NULL and unknown types, which are not checked.
+func columnHoldsType(column *cli_service.TColumn, desc *cli_service.TColumnDesc) bool {
+	switch getDBTypeID(desc) {
+	case cli_service.TTypeId_BOOLEAN_TYPE:
+		return column.IsSetBoolVal()
+	case cli_service.TTypeId_TINYINT_TYPE:
+		return column.IsSetByteVal()
+	case cli_service.TTypeId_SMALLINT_TYPE:
+		return column.IsSetI16Val()
+	case cli_service.TTypeId_INT_TYPE:
+		return column.IsSetI32Val()
+	case cli_service.TTypeId_BIGINT_TYPE:
+		return column.IsSetI64Val()
+	case cli_service.TTypeId_FLOAT_TYPE, cli_service.TTypeId_DOUBLE_TYPE:
+		return column.IsSetDoubleVal()
+	case cli_service.TTypeId_BINARY_TYPE:
+		return column.IsSetBinaryVal()
+	case cli_service.TTypeId_NULL_TYPE, cli_service.TTypeId_USER_DEFINED_TYPE:
+		return true
+	}
+	return column.IsSetStringVal()
+}
@@ -0,0 +1,222 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCache(t *testing.T) {
+	metadata := func(name string) *cli_service.TGetResultSetMetadataResp {
+		return &cli_service.TGetResultSetMetadataResp{Schema: &cli_service.TTableSchema{
+			Columns: []*cli_service.TColumnDesc{{ColumnName: name}},
+		}}
+	}
+
+	t.Run("least recently used entries are evicted", func(t *testing.T) {
+		sc := newSchemaCache(2, 0)
+		a, b, c := metadata("a"), metadata("b"), metadata("c")
+		sc.put("a", a)
+		sc.put("b", b)
+		assert.Same(t, a, sc.get("a"))
+		sc.put("c", c)
+		assert.Same(t, a, sc.get("a"))
+		assert.Nil(t, sc.get("b"))
+		assert.Same(t, c, sc.get("c"))
+
+		sc.remove("c")
+		assert.Nil(t, sc.get("c"))
+		sc.put("d", &cli_service.TGetResultSetMetadataResp{})
+		assert.Nil(t, sc.get("d"))
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		sc := newSchemaCache(2, 10*time.Millisecond)
+		sc.put("a", metadata("a"))
+		assert.NotNil(t, sc.get("a"))
+		time.Sleep(20 * time.Millisecond)
+		assert.Nil(t, sc.get("a"))
+	})
+
+	t.Run("a nil cache caches nothing", func(t *testing.T) {
+		sc := newSchemaCache(0, time.Minute)
+		assert.Nil(t, sc)
+		sc.put("a", metadata("a"))
+		assert.Nil(t, sc.get("a"))
+		sc.remove("a")
+	})
+
+	t.Run("keys depend on the namespace", func(t *testing.T) {
+		assert.Equal(t, schemaCacheKey("main", "sales", "SELECT 1"), schemaCacheKey("main", "sales", "SELECT 1"))
+		assert.NotEqual(t, schemaCacheKey("main", "sales", "SELECT 1"), schemaCacheKey("main", "hr", "SELECT 1"))
+		assert.NotEqual(t, schemaCacheKey("a", "b.c", "SELECT 1"), schemaCacheKey("a.b", "c", "SELECT 1"))
+	})
+}
+
+func TestConn_SchemaCache(t *testing.T) {
+	var fetches []*cli_service.TFetchResultsReq
+	testClient := getRowsTestCursorClient(3, &fetches)
+	var metadataRequests int
+	getMetadata := testClient.FnGetResultSetMetadata
+	testClient.FnGetResultSetMetadata = func(ctx context.Context, req *cli_service.TGetResultSetMetadataReq) (*cli_service.TGetResultSetMetadataResp, error) {
+		metadataRequests++
+		return getMetadata(ctx, req)
+	}
+	testClient.FnExecuteStatement = func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+		return &cli_service.TExecuteStatementResp{
+			Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			OperationHandle: &cli_service.TOperationHandle{
+				OperationId:  &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+				HasResultSet: true,
+			},
+			// the results are fetched, without the metadata
+			DirectResults: &cli_service.TSparkDirectResults{
+				OperationStatus: &cli_service.TGetOperationStatusResp{
+					OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+				},
+			},
+		}, nil
+	}
+	testClient.FnCloseOperation = func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+		return &cli_service.TCloseOperationResp{}, nil
+	}
+	cfg := config.WithDefaults()
+	cfg.PollInterval = 10 * time.Millisecond
+	testConn := &conn{
+		session:     getTestSession(),
+		client:      testClient,
+		cfg:         cfg,
+		schemaCache: newSchemaCache(8, time.Minute),
+	}
+	db := sql.OpenDB(&testConnConnector{testConn})
+	defer db.Close()
+
+	query := func(statement string) []int32 {
+		rows, err := db.Query(statement)
+		require.NoError(t, err)
+		defer rows.Close()
+		columns, err := rows.Columns()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id"}, columns)
+		var ids []int32
+		for rows.Next() {
+			var id int32
+			require.NoError(t, rows.Scan(&id))
+			ids = append(ids, id)
+		}
+		require.NoError(t, rows.Err())
+		return ids
+	}
+
+	assert.Equal(t, []int32{0, 1, 2}, query("SELECT id FROM t"))
+	assert.Equal(t, 1, metadataRequests)
+	assert.Equal(t, []int32{0, 1, 2}, query("SELECT id FROM t"))
+	assert.Equal(t, 1, metadataRequests)
+	query("SELECT id FROM u")
+	assert.Equal(t, 2, metadataRequests)
+
+	t.Run("a stale schema is evicted", func(t *testing.T) {
+		// the cached schema has two columns, the pages one
+		key := schemaCacheKey("", "", "SELECT id FROM v")
+		testConn.schemaCache.put(key, getMetadataWithColumns("id", "name"))
+		rows, err := db.Query("SELECT id FROM v")
+		require.NoError(t, err)
+		assert.False(t, rows.Next())
+		assert.ErrorIs(t, rows.Err(), ErrResultSetInvalidated)
+		rows.Close()
+		assert.Nil(t, testConn.schemaCache.get(key))
+
+		assert.Equal(t, []int32{0, 1, 2}, query("SELECT id FROM v"))
+		assert.Equal(t, 3, metadataRequests)
+	})
+
+	t.Run("a schema with other column types is evicted", func(t *testing.T) {
+		// the cached column is a string, the page holds ints
+		key := schemaCacheKey("", "", "SELECT id FROM x")
+		metadata := getMetadataWithColumns("id")
+		metadata.Schema.Columns[0].TypeDesc.Types[0].PrimitiveEntry.Type = cli_service.TTypeId_STRING_TYPE
+		testConn.schemaCache.put(key, metadata)
+		rows, err := db.Query("SELECT id FROM x")
+		require.NoError(t, err)
+		assert.False(t, rows.Next())
+		assert.ErrorIs(t, rows.Err(), ErrResultSetInvalidated)
+		rows.Close()
+		assert.Nil(t, testConn.schemaCache.get(key))
+	})
+
+	t.Run("a schema with other column names is replaced by the one of the page", func(t *testing.T) {
+		key := schemaCacheKey("", "", "SELECT id FROM w")
+		testConn.schemaCache.put(key, getMetadataWithColumns("old_id"))
+		var pages []*cli_service.TFetchResultsReq
+		r := &rows{client: getRowsTestCursorClient(1, &pages), pageSize: 10}
+		r.useSchemaCache(testConn.schemaCache, key)
+		require.True(t, r.schemaFromCache)
+		// the columns of the rows are known from the cached schema
+		assert.Equal(t, []string{"old_id"}, r.Columns())
+
+		page := &cli_service.TFetchResultsResp{
+			ResultSetMetadata: getMetadataWithColumns("id"),
+			Results:           &cli_service.TRowSet{Columns: []*cli_service.TColumn{{I32Val: &cli_service.TI32Column{Values: []int32{1}}}}},
+		}
+		assert.Equal(t, "the cached schema of the results is stale", r.checkPageSchema(page))
+		assert.Same(t, page.ResultSetMetadata, testConn.schemaCache.get(key))
+	})
+
+	t.Run("a schema with other type qualifiers is replaced by the one of the page", func(t *testing.T) {
+		key := schemaCacheKey("", "", "SELECT price FROM p")
+		decimal := func(precision int32) *cli_service.TGetResultSetMetadataResp {
+			metadata := getMetadataWithColumns("price")
+			entry := metadata.Schema.Columns[0].TypeDesc.Types[0].PrimitiveEntry
+			entry.Type = cli_service.TTypeId_DECIMAL_TYPE
+			entry.TypeQualifiers = &cli_service.TTypeQualifiers{Qualifiers: map[string]*cli_service.TTypeQualifierValue{
+				cli_service.PRECISION: {I32Value: &precision},
+			}}
+			return metadata
+		}
+		testConn.schemaCache.put(key, decimal(10))
+		r := &rows{pageSize: 10}
+		r.useSchemaCache(testConn.schemaCache, key)
+
+		page := &cli_service.TFetchResultsResp{
+			ResultSetMetadata: decimal(12),
+			Results:           &cli_service.TRowSet{Columns: []*cli_service.TColumn{{StringVal: &cli_service.TStringColumn{Values: []string{"1.5"}}}}},
+		}
+		assert.Equal(t, "the cached schema of the results is stale", r.checkPageSchema(page))
+		assert.Same(t, page.ResultSetMetadata, testConn.schemaCache.get(key))
+	})
+
+	t.Run("a schema matching the page is kept", func(t *testing.T) {
+		key := schemaCacheKey("", "", "SELECT id FROM y")
+		testConn.schemaCache.put(key, getMetadataWithColumns("id"))
+		var pages []*cli_service.TFetchResultsReq
+		r := &rows{client: getRowsTestCursorClient(1, &pages), pageSize: 10}
+		r.useSchemaCache(testConn.schemaCache, key)
+
+		page := &cli_service.TFetchResultsResp{
+			Results: &cli_service.TRowSet{Columns: []*cli_service.TColumn{{I32Val: &cli_service.TI32Column{Values: []int32{1}}}}},
+		}
+		assert.Equal(t, "", r.checkPageSchema(page))
+		assert.Equal(t, []string{"id"}, r.Columns())
+		assert.NotNil(t, testConn.schemaCache.get(key))
+	})
+}
+
+// getMetadataWithColumns returns metadata with INT columns of the names
+func getMetadataWithColumns(names ...string) *cli_service.TGetResultSetMetadataResp {
+	var columns []*cli_service.TColumnDesc
+	for _, name := range names {
+		columns = append(columns, &cli_service.TColumnDesc{
+			ColumnName: name,
+			TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{{
+				PrimitiveEntry: &cli_service.TPrimitiveTypeEntry{Type: cli_service.TTypeId_INT_TYPE},
+			}}},
+		})
+	}
+	return &cli_service.TGetResultSetMetadataResp{Schema: &cli_service.TTableSchema{Columns: columns}}
+}
@@ -0,0 +1,123 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SplitStatements splits a SQL script into its statements at the semicolons found
+// outside of string literals, quoted identifiers and comments. The statements are
+// trimmed of surrounding whitespace, comments and semicolons, and the empty ones are
+// dropped. Compound BEGIN ... END blocks are split at their inner semicolons too.
+func SplitStatements(script string) []string {
+	var statements []string
+	for _, s := range splitScript(script) {
+		statements = append(statements, s.text)
+	}
+	return statements
+}
+
+// scriptStatement is a statement of a script with the line it starts on
+type scriptStatement struct {
+	text string
+	line int
+}
+
+func splitScript(script string) []scriptStatement {
+	var statements []scriptStatement
+	// start of the current statement, at its first piece of code or quoted text
+	start := -1
+	add := func(end int) {
+		if start >= 0 {
+			text := trimStatement(script[start:end])
+			if text != "" {
+				statements = append(statements, scriptStatement{text, strings.Count(script[:start], "\n") + 1})
+			}
+		}
+		start = -1
+	}
+	scanSQL(script, func(kind sqlTokenKind, from, to int) bool {
+		switch {
+		case kind == sqlCode && script[from] == ';':
+			add(from)
+		case start < 0 && (kind == sqlCode || kind == sqlQuoted):
+			start = from
+		}
+		return true
+	})
+	add(len(script))
+	return statements
+}
+
+// ScriptResult is the outcome of a statement run by RunScript.
+type ScriptResult struct {
+	// Statement is the text of the statement
+	Statement string
+	// Line is the line of the script the statement starts on, from 1
+	Line int
+	// RowsAffected is the number of rows written by the statement, when it succeeded
+	RowsAffected int64
+	// Err is the error of the statement, nil when it succeeded
+	Err error
+}
+
+// ScriptError is returned by RunScript when statements of the script failed. Its
+// message is the one of the first failure, and it unwraps to its error.
+type ScriptError struct {
+	// Failed are the results of the statements that failed, in script order
+	Failed []ScriptResult
+}
+
+func (e *ScriptError) Error() string {
+	first := e.Failed[0]
+	msg := fmt.Sprintf("databricks: statement at line %d of the script failed: %v", first.Line, first.Err)
+	if len(e.Failed) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Failed)-1)
+	}
+	return msg
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Failed[0].Err
+}
+
+// RunScript splits the script with SplitStatements and runs its statements one after
+// the other on conn, so that they share its session, e.g. for migrations or setup
+// scripts. It returns a result for each statement that was run.
+//
+// The script stops at the first statement that fails, unless continueOnError is set,
+// in which case the remaining statements still run. Either way, the failures are
+// returned as a *ScriptError:
+//
+//	results, err := dbsql.RunScript(ctx, conn, script, false)
+//	var scriptErr *dbsql.ScriptError
+//	if errors.As(err, &scriptErr) {
+//		log.Printf("%q failed: %v", scriptErr.Failed[0].Statement, scriptErr.Failed[0].Err)
+//	}
+//
+// A canceled context stops the script whatever continueOnError is.
+func RunScript(ctx context.Context, conn *sql.Conn, script string, continueOnError bool) ([]ScriptResult, error) {
+	var results []ScriptResult
+	var failed []ScriptResult
+	for _, s := range splitScript(script) {
+		result := ScriptResult{Statement: s.text, Line: s.line}
+		res, err := conn.ExecContext(ctx, s.text)
+		if err == nil {
+			result.RowsAffected, err = res.RowsAffected()
+		}
+		result.Err = err
+		results = append(results, result)
+		if err != nil {
+			failed = append(failed, result)
+			if !continueOnError || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return results, &ScriptError{Failed: failed}
+	}
+	return results, nil
+}
@@ -0,0 +1,98 @@
+package dbsql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- setup
+CREATE TABLE t (s STRING); -- the table
+INSERT INTO t VALUES ('a;b'), ("c;d");;
+/* a; comment */
+SELECT ` + "`x;y`" + ` FROM t
+;
+-- trailing`
+	assert.Equal(t, []string{
+		"CREATE TABLE t (s STRING)",
+		`INSERT INTO t VALUES ('a;b'), ("c;d")`,
+		"SELECT `x;y` FROM t",
+	}, SplitStatements(script))
+
+	assert.Equal(t, []string{`SELECT r'\'`, "SELECT 2"},
+		SplitStatements("SELECT r'\\'; /* a /* nested; */ comment; */ SELECT 2"))
+
+	assert.Empty(t, SplitStatements(" ; -- nothing\n"))
+	assert.Equal(t, []scriptStatement{{"SELECT 1", 2}, {"SELECT 2", 2}}, splitScript("\nSELECT 1; SELECT 2"))
+}
+
+func TestRunScript(t *testing.T) {
+	script := "CREATE TABLE t (id INT);\nINSERT INTO boom VALUES (1);\nSELECT 1;\n\nDROP TABLE boom"
+
+	runScript := func(t *testing.T, continueOnError bool) ([]ScriptResult, []string, error) {
+		var statements []string
+		db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+		err = c.Raw(func(dc any) error {
+			testClient := dc.(*conn).client.(*client.TestClient)
+			executeStatement := testClient.FnExecuteStatement
+			testClient.FnExecuteStatement = func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				if !strings.Contains(req.Statement, "boom") {
+					return executeStatement(ctx, req)
+				}
+				statements = append(statements, req.Statement)
+				resp := &cli_service.TExecuteStatementResp{
+					Status: &cli_service.TStatus{
+						StatusCode:   cli_service.TStatusCode_ERROR_STATUS,
+						ErrorMessage: strPtr("table not found"),
+					},
+				}
+				return resp, client.CheckStatus(resp)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		results, err := RunScript(context.Background(), c, script, continueOnError)
+		return results, statements, err
+	}
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		results, statements, err := runScript(t, false)
+		assert.Equal(t, []string{"CREATE TABLE t (id INT)", "INSERT INTO boom VALUES (1)"}, statements)
+		require.Len(t, results, 2)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, 1, results[0].Line)
+		assert.Error(t, results[1].Err)
+		assert.Equal(t, 2, results[1].Line)
+
+		var scriptErr *ScriptError
+		require.True(t, errors.As(err, &scriptErr))
+		assert.Len(t, scriptErr.Failed, 1)
+		assert.ErrorIs(t, err, results[1].Err)
+		assert.True(t, strings.HasPrefix(err.Error(), "databricks: statement at line 2 of the script failed: "), err.Error())
+	})
+
+	t.Run("continues after failures", func(t *testing.T) {
+		results, statements, err := runScript(t, true)
+		assert.Len(t, statements, 4)
+		require.Len(t, results, 4)
+		assert.NoError(t, results[2].Err)
+		assert.Equal(t, "DROP TABLE boom", results[3].Statement)
+		assert.Equal(t, 5, results[3].Line)
+
+		var scriptErr *ScriptError
+		require.True(t, errors.As(err, &scriptErr))
+		assert.Equal(t, []ScriptResult{results[1], results[3]}, scriptErr.Failed)
+		assert.True(t, strings.HasSuffix(err.Error(), " (and 1 more)"), err.Error())
+	})
+}
@@ -0,0 +1,65 @@
+package dbsql
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+)
+
+var errSecretResolve = "databricks: failed to resolve secret %q"
+
+// secretAuthenticator authenticates requests with a token resolved from a secret
+// manager, resolved again by Connect
+type secretAuthenticator struct {
+	resolver auth.SecretResolver
+	name     string
+	mu       sync.Mutex
+	token    string
+}
+
+var _ auth.Authenticator = (*secretAuthenticator)(nil)
+
+// resolve fetches the current value of the secret
+func (a *secretAuthenticator) resolve(ctx context.Context) (string, error) {
+	token, err := a.resolver.Resolve(ctx, a.name)
+	token = strings.TrimSpace(token)
+	if err == nil && token == "" {
+		err = errors.New("the secret is empty")
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, errSecretResolve, a.name)
+	}
+	a.mu.Lock()
+	a.token = token
+	a.mu.Unlock()
+	return token, nil
+}
+
+func (a *secretAuthenticator) Authenticate(r *http.Request) error {
+	a.mu.Lock()
+	token := a.token
+	a.mu.Unlock()
+	if token == "" {
+		// e.g. for the workspace REST APIs used before any connection is opened
+		var err error
+		if token, err = a.resolve(r.Context()); err != nil {
+			return err
+		}
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// resolveSecrets resolves the secrets of cfg again, for a new connection
+func resolveSecrets(ctx context.Context, cfg *config.Config) error {
+	if a, ok := cfg.Authenticator.(*secretAuthenticator); ok {
+		_, err := a.resolve(ctx)
+		return err
+	}
+	return nil
+}
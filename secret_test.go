@@ -0,0 +1,82 @@
+package dbsql
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/auth"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSecretResolver(t *testing.T) {
+	t.Run("secrets are resolved on every connect", func(t *testing.T) {
+		var openSessionResp cli_service.TOpenSessionResp
+		loadTestData(t, "OpenSessionSuccess.json", &openSessionResp)
+		ts := initThriftTestServer(&client.TestClient{
+			FnOpenSession: func(ctx context.Context, req *cli_service.TOpenSessionReq) (*cli_service.TOpenSessionResp, error) {
+				return &openSessionResp, nil
+			},
+		})
+		defer ts.Close()
+		r, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(r.Port())
+		require.NoError(t, err)
+
+		var names []string
+		resolver := auth.SecretResolverFunc(func(ctx context.Context, name string) (string, error) {
+			names = append(names, name)
+			return "token-" + strconv.Itoa(len(names)) + "\n", nil
+		})
+		testConnector, err := NewConnector(
+			WithServerHostname("localhost"),
+			WithPort(port),
+			WithSecretResolver(resolver, "databricks-token"),
+		)
+		require.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			_, err = testConnector.Connect(context.Background())
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"databricks-token", "databricks-token"}, names)
+
+		req, err := http.NewRequest(http.MethodGet, "https://localhost", nil)
+		require.NoError(t, err)
+		require.NoError(t, testConnector.(*connector).cfg.Authenticator.Authenticate(req))
+		assert.Equal(t, "Bearer token-2", req.Header.Get("Authorization"))
+	})
+
+	t.Run("requests before connecting resolve the secret", func(t *testing.T) {
+		a := &secretAuthenticator{name: "databricks-token", resolver: auth.SecretResolverFunc(func(ctx context.Context, name string) (string, error) {
+			return "token", nil
+		})}
+		req, err := http.NewRequest(http.MethodGet, "https://localhost", nil)
+		require.NoError(t, err)
+		require.NoError(t, a.Authenticate(req))
+		assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	})
+
+	t.Run("connecting fails when the secret cannot be resolved", func(t *testing.T) {
+		for _, resolved := range []struct {
+			value string
+			err   error
+		}{{"", errors.New("permission denied")}, {" ", nil}} {
+			testConnector, err := NewConnector(
+				WithServerHostname("localhost"),
+				WithSecretResolver(auth.SecretResolverFunc(func(ctx context.Context, name string) (string, error) {
+					return resolved.value, resolved.err
+				}), "databricks-token"),
+			)
+			require.NoError(t, err)
+			_, err = testConnector.Connect(context.Background())
+			assert.ErrorContains(t, err, `databricks: failed to resolve secret "databricks-token"`)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// Session is implemented by the connections of this driver. Each connection holds a
+// server session for its whole life: temporary views, session variables, SET
+// parameters and USE statements apply to the statements run later on the same
+// connection, and Exec returns once the statement finished, so the queries that
+// follow it on the connection read its writes. Use sql.Conn.Raw to get it:
+//
+//	err := conn.Raw(func(driverConn any) error {
+//		id := driverConn.(dbsql.Session).SessionID()
+//		...
+//	})
+//
+// database/sql runs each statement of an *sql.DB on any connection of its pool, so
+// statements that rely on the same session must run on one *sql.Conn, e.g. with
+// RunInSession. Once its session is lost, a connection fails with driver.ErrBadConn,
+// and the *sql.Conn with sql.ErrConnDone after that, instead of running the next
+// statements on a new session.
+type Session interface {
+	// SessionID returns the id of the server session of the connection
+	SessionID() string
+	// Namespace returns the current catalog and schema of the session, empty when
+	// the server defaults apply
+	Namespace() (catalog, schema string)
+}
+
+var _ Session = (*conn)(nil)
+
+func (c *conn) SessionID() string {
+	return c.id
+}
+
+func (c *conn) Namespace() (catalog, schema string) {
+	return c.catalog, c.schema
+}
+
+// SessionID returns the id of the server session of conn, e.g. to find its
+// statements in the query history.
+func SessionID(conn *sql.Conn) (string, error) {
+	var id string
+	err := conn.Raw(func(driverConn any) error {
+		s, ok := driverConn.(Session)
+		if !ok {
+			return errors.New(ErrNotImplemented)
+		}
+		id = s.SessionID()
+		return nil
+	})
+	return id, err
+}
+
+// RunInSession calls fn with a connection of db held for the duration of fn, so that
+// its statements all run on the same server session, e.g. to create a temporary view
+// and query it, or to query the rows written by a previous statement. The connection
+// is returned to the pool once fn returns, after dropping the temporary views created
+// with CreateTempView. The other session objects fn created are kept.
+//
+//	err := dbsql.RunInSession(ctx, db, func(ctx context.Context, conn *sql.Conn) error {
+//		if _, err := conn.ExecContext(ctx, "CREATE TEMPORARY VIEW recent AS SELECT ..."); err != nil {
+//			return err
+//		}
+//		rows, err := conn.QueryContext(ctx, "SELECT * FROM recent")
+//		...
+//	})
+func RunInSession(ctx context.Context, db *sql.DB, fn func(ctx context.Context, conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = fn(ctx, conn)
+	if dropErr := DropTempViews(ctx, conn); err == nil {
+		err = dropErr
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunInSession(t *testing.T) {
+	t.Run("statements run on the same session", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id"}, [][]string{{"1"}}, "", &statements)
+		defer db.Close()
+
+		var sessions []string
+		err := RunInSession(context.Background(), db, func(ctx context.Context, c *sql.Conn) error {
+			require.NoError(t, c.Raw(func(driverConn any) error {
+				driverConn.(*conn).id = "01f0-session"
+				return nil
+			}))
+			if _, err := c.ExecContext(ctx, "CREATE TEMPORARY VIEW v AS SELECT 1 AS id"); err != nil {
+				return err
+			}
+			id, err := SessionID(c)
+			require.NoError(t, err)
+			sessions = append(sessions, id)
+			var n string
+			if err := c.QueryRowContext(ctx, "SELECT id FROM v").Scan(&n); err != nil {
+				return err
+			}
+			require.NoError(t, c.Raw(func(driverConn any) error {
+				sessions = append(sessions, driverConn.(Session).SessionID())
+				return nil
+			}))
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"01f0-session", "01f0-session"}, sessions)
+		assert.Equal(t, []string{"CREATE TEMPORARY VIEW v AS SELECT 1 AS id", "SELECT id FROM v"}, statements)
+		assert.Zero(t, db.Stats().InUse)
+	})
+
+	t.Run("the error of fn is returned and the connection released", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+		defer db.Close()
+
+		failed := errors.New("failed")
+		err := RunInSession(context.Background(), db, func(ctx context.Context, conn *sql.Conn) error {
+			return failed
+		})
+		assert.Equal(t, failed, err)
+		assert.Zero(t, db.Stats().InUse)
+	})
+}
+
+func TestConn_BrokenSession(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+	defer db.Close()
+	c, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Raw(func(dc any) error {
+		dc.(*conn).broken = true
+		return nil
+	}))
+
+	_, err = c.ExecContext(context.Background(), "INSERT INTO t VALUES (1)")
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	// database/sql closes the connection, later statements fail as well
+	_, err = c.QueryContext(context.Background(), "SELECT * FROM t")
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.Empty(t, statements)
+}
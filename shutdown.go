@@ -0,0 +1,161 @@
+package dbsql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/logger"
+)
+
+// Shutdowner is implemented by the connectors and connections of this driver, to
+// end their server side work before the process exits, e.g. on SIGTERM. Shutdown
+// cancels the running queries, closes the operations whose results were not read
+// yet and closes the sessions, so that nothing is left running on the warehouse.
+// It can be called while queries are running on other goroutines; they fail once
+// canceled. Connections that were shut down are discarded by the pool.
+//
+// Use the connector returned by NewConnector, or sql.Conn.Raw for a connection:
+//
+//	db := sql.OpenDB(connector)
+//	...
+//	err := connector.(dbsql.Shutdowner).Shutdown(ctx)
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+var _ Shutdowner = (*conn)(nil)
+var _ Shutdowner = (*connector)(nil)
+
+// operations tracks the server operations of a connection that may still be running
+// or hold results
+type operations struct {
+	mu      sync.Mutex
+	handles map[string]*cli_service.TOperationHandle
+}
+
+func (o *operations) add(handle *cli_service.TOperationHandle) {
+	if handle == nil || handle.OperationId == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.handles == nil {
+		o.handles = map[string]*cli_service.TOperationHandle{}
+	}
+	o.handles[string(handle.OperationId.GUID)] = handle
+}
+
+func (o *operations) remove(handle *cli_service.TOperationHandle) {
+	if handle == nil || handle.OperationId == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.handles, string(handle.OperationId.GUID))
+}
+
+// take removes and returns all the tracked operations
+func (o *operations) take() []*cli_service.TOperationHandle {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	handles := make([]*cli_service.TOperationHandle, 0, len(o.handles))
+	for _, handle := range o.handles {
+		handles = append(handles, handle)
+	}
+	o.handles = nil
+	return handles
+}
+
+// Shutdown cancels and closes the operations of the connection and closes its session.
+// The requests are sent with the close client of the connection, since its own client
+// may be in use by a running query.
+func (c *conn) Shutdown(ctx context.Context) error {
+	log := logger.WithContext(c.id, driverctx.CorrelationIdFromContext(ctx), "")
+	ctx = driverctx.NewContextWithConnId(ctx, c.id)
+
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	if c.closeClient == nil {
+		closeClient, err := c.newCloseClient()
+		if err != nil {
+			return wrapErr(err, "failed to create client to shut down connection")
+		}
+		c.closeClient = closeClient
+	}
+	c.shutdown.Store(true)
+
+	var firstErr error
+	for _, handle := range c.ops.take() {
+		queryId := client.SprintGuid(handle.OperationId.GUID)
+		// canceling a finished operation fails, which is fine since it is closed next
+		if _, err := c.closeClient.CancelOperation(ctx, &cli_service.TCancelOperationReq{OperationHandle: handle}); err != nil {
+			log.Debug().Msgf("databricks: failed to cancel operation %s: %v", queryId, err)
+		}
+		if _, err := c.closeClient.CloseOperation(ctx, &cli_service.TCloseOperationReq{OperationHandle: handle}); err != nil {
+			log.Err(err).Msgf("databricks: failed to close operation %s", queryId)
+			if firstErr == nil {
+				firstErr = wrapErrf(err, "failed to close operation %s", queryId)
+			}
+		}
+	}
+	if _, err := c.closeClient.CloseSession(ctx, &cli_service.TCloseSessionReq{SessionHandle: c.session.SessionHandle}); err != nil {
+		log.Err(err).Msg("databricks: failed to close session")
+		if firstErr == nil {
+			firstErr = wrapErr(err, "failed to close session")
+		}
+	}
+
+	c.closed = true
+	closeIdleConnections(c.closeClient)
+	c.closeClient = nil
+	return firstErr
+}
+
+// Shutdown shuts down all the open connections of the connector concurrently and
+// returns the first error.
+func (c *connector) Shutdown(ctx context.Context) error {
+	c.connsMu.Lock()
+	conns := make([]*conn, 0, len(c.conns))
+	for dc := range c.conns {
+		conns = append(conns, dc)
+	}
+	c.connsMu.Unlock()
+
+	errs := make([]error, len(conns))
+	var wg sync.WaitGroup
+	for i, dc := range conns {
+		wg.Add(1)
+		go func(i int, dc *conn) {
+			defer wg.Done()
+			errs[i] = dc.Shutdown(ctx)
+		}(i, dc)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackConn adds dc to the open connections of the connector until it is closed
+func (c *connector) trackConn(dc *conn) {
+	c.connsMu.Lock()
+	defer c.connsMu.Unlock()
+	if c.conns == nil {
+		c.conns = map[*conn]struct{}{}
+	}
+	c.conns[dc] = struct{}{}
+	dc.release = func() {
+		c.connsMu.Lock()
+		defer c.connsMu.Unlock()
+		delete(c.conns, dc)
+	}
+}
@@ -0,0 +1,135 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shutdownRecorder records the requests sent by the close clients of shutdown tests
+type shutdownRecorder struct {
+	mu       sync.Mutex
+	canceled []string
+	closed   []string
+	sessions int
+}
+
+func (r *shutdownRecorder) client() *client.TestClient {
+	return &client.TestClient{
+		FnCancelOperation: func(ctx context.Context, req *cli_service.TCancelOperationReq) (*cli_service.TCancelOperationResp, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.canceled = append(r.canceled, client.SprintGuid(req.OperationHandle.OperationId.GUID))
+			return &cli_service.TCancelOperationResp{}, nil
+		},
+		FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.closed = append(r.closed, client.SprintGuid(req.OperationHandle.OperationId.GUID))
+			return &cli_service.TCloseOperationResp{}, nil
+		},
+		FnCloseSession: func(ctx context.Context, req *cli_service.TCloseSessionReq) (*cli_service.TCloseSessionResp, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.sessions++
+			return &cli_service.TCloseSessionResp{}, nil
+		},
+	}
+}
+
+// getShutdownTestConn returns a connection whose statements finish right away,
+// with operation ids 00000001-..., 00000002-... in the order they were run
+func getShutdownTestConn(t *testing.T, recorder *shutdownRecorder) *conn {
+	var n byte
+	executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+		n++
+		return &cli_service.TExecuteStatementResp{
+			Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+			OperationHandle: &cli_service.TOperationHandle{
+				OperationId: &cli_service.THandleIdentifier{GUID: []byte{0, 0, 0, n, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			},
+			DirectResults: &cli_service.TSparkDirectResults{
+				OperationStatus: &cli_service.TGetOperationStatusResp{
+					OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+				},
+			},
+		}, nil
+	}
+	cfg := config.WithDefaults()
+	cfg.PollInterval = 10 * time.Millisecond
+	return &conn{
+		session: getTestSession(),
+		client: &client.TestClient{
+			FnExecuteStatement: executeStatement,
+			FnCloseSession: func(ctx context.Context, req *cli_service.TCloseSessionReq) (*cli_service.TCloseSessionResp, error) {
+				t.Error("session closed again after shutdown")
+				return &cli_service.TCloseSessionResp{}, nil
+			},
+			FnCloseOperation: func(ctx context.Context, req *cli_service.TCloseOperationReq) (*cli_service.TCloseOperationResp, error) {
+				return &cli_service.TCloseOperationResp{}, nil
+			},
+		},
+		cfg: cfg,
+		newCloseClient: func() (cli_service.TCLIService, error) {
+			return recorder.client(), nil
+		},
+	}
+}
+
+func TestConn_Shutdown(t *testing.T) {
+	recorder := &shutdownRecorder{}
+	testConn := getShutdownTestConn(t, recorder)
+
+	// the results of the first query are read and closed, the statement is done
+	// and the results of the last query are left open
+	r, err := testConn.QueryContext(context.Background(), "select 1", []driver.NamedValue{})
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	_, err = testConn.ExecContext(context.Background(), "insert into t values (1)", []driver.NamedValue{})
+	require.NoError(t, err)
+	_, err = testConn.QueryContext(context.Background(), "select 2", []driver.NamedValue{})
+	require.NoError(t, err)
+
+	require.NoError(t, testConn.Shutdown(context.Background()))
+	assert.Equal(t, []string{"00000003-0000-0000-0000-000000000000"}, recorder.canceled)
+	assert.Equal(t, []string{"00000003-0000-0000-0000-000000000000"}, recorder.closed)
+	assert.Equal(t, 1, recorder.sessions)
+	assert.False(t, testConn.IsValid())
+
+	// shutting down again and closing do not send anything
+	require.NoError(t, testConn.Shutdown(context.Background()))
+	require.NoError(t, testConn.Close())
+	assert.Equal(t, 1, recorder.sessions)
+}
+
+func TestConnector_Shutdown(t *testing.T) {
+	recorder := &shutdownRecorder{}
+	testConnector := &connector{cfg: config.WithDefaults()}
+	conns := []*conn{getShutdownTestConn(t, recorder), getShutdownTestConn(t, recorder), getShutdownTestConn(t, recorder)}
+	for _, c := range conns {
+		testConnector.trackConn(c)
+	}
+	// closed connections are no longer shut down
+	conns[2].client.(*client.TestClient).FnCloseSession = func(ctx context.Context, req *cli_service.TCloseSessionReq) (*cli_service.TCloseSessionResp, error) {
+		return &cli_service.TCloseSessionResp{}, nil
+	}
+	require.NoError(t, conns[2].Close())
+
+	require.NoError(t, testConnector.Shutdown(context.Background()))
+	assert.Equal(t, 2, recorder.sessions)
+	assert.False(t, conns[0].IsValid())
+	assert.False(t, conns[1].IsValid())
+
+	// the pool then closes the connections that were shut down
+	require.NoError(t, conns[0].Close())
+	require.NoError(t, conns[1].Close())
+	assert.Empty(t, testConnector.conns)
+}
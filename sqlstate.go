@@ -0,0 +1,102 @@
+package dbsql
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/pkg/errors"
+)
+
+// SQLSTATE values of the errors applications most often handle. Compare them with
+// the SQLState of an *ExecutionError, or use SQLState(err).
+const (
+	ErrCodeSyntaxError         = "42601"
+	ErrCodePermissionDenied    = "42501"
+	ErrCodeTableNotFound       = "42P01"
+	ErrCodeTableAlreadyExists  = "42P07"
+	ErrCodeSchemaNotFound      = "42704"
+	ErrCodeSchemaAlreadyExists = "42P06"
+	ErrCodeCatalogNotFound     = "42P08"
+	ErrCodeColumnNotFound      = "42703"
+	ErrCodeFunctionNotFound    = "42883"
+	ErrCodeDivisionByZero      = "22012"
+	ErrCodeInvalidInput        = "22018"
+	ErrCodeNumericOutOfRange   = "22003"
+	ErrCodeQueryCanceled       = "57014"
+)
+
+// errorClassSQLStates maps Databricks error classes to their SQLSTATE, for servers
+// that report the error class in the message but not the SQLSTATE.
+var errorClassSQLStates = map[string]string{
+	"PARSE_SYNTAX_ERROR":           ErrCodeSyntaxError,
+	"INSUFFICIENT_PERMISSIONS":     ErrCodePermissionDenied,
+	"PERMISSION_DENIED":            ErrCodePermissionDenied,
+	"TABLE_OR_VIEW_NOT_FOUND":      ErrCodeTableNotFound,
+	"TABLE_OR_VIEW_ALREADY_EXISTS": ErrCodeTableAlreadyExists,
+	"SCHEMA_NOT_FOUND":             ErrCodeSchemaNotFound,
+	"SCHEMA_ALREADY_EXISTS":        ErrCodeSchemaAlreadyExists,
+	"CATALOG_NOT_FOUND":            ErrCodeCatalogNotFound,
+	"UNRESOLVED_COLUMN":            ErrCodeColumnNotFound,
+	"UNRESOLVED_ROUTINE":           ErrCodeFunctionNotFound,
+	"DIVIDE_BY_ZERO":               ErrCodeDivisionByZero,
+	"CAST_INVALID_INPUT":           ErrCodeInvalidInput,
+	"ARITHMETIC_OVERFLOW":          ErrCodeNumericOutOfRange,
+	"NUMERIC_VALUE_OUT_OF_RANGE":   ErrCodeNumericOutOfRange,
+}
+
+// hiveErrorSQLStates maps the messages of Hive errors, which have no error class,
+// to their SQLSTATE.
+var hiveErrorSQLStates = []struct {
+	message  string
+	sqlState string
+}{
+	{"Table or view not found", ErrCodeTableNotFound},
+	{"mismatched input", ErrCodeSyntaxError},
+	{"does not have privilege", ErrCodePermissionDenied},
+	{"Permission denied", ErrCodePermissionDenied},
+}
+
+// errorClassPattern matches the error class at the start of Databricks error
+// messages, as in [UNRESOLVED_COLUMN.WITH_SUGGESTION], without the subclass
+var errorClassPattern = regexp.MustCompile(`\[([A-Z][A-Z0-9_]*)(?:\.[A-Z0-9_]+)*\]`)
+
+// newExecutionError returns the error of a failed statement. The SQLSTATE reported
+// by the server is kept, otherwise it is looked up by error class or message.
+func newExecutionError(message, sqlState string) *ExecutionError {
+	e := &ExecutionError{Message: message, SQLState: sqlState}
+	if m := errorClassPattern.FindStringSubmatch(message); m != nil {
+		e.ErrorClass = m[1]
+	}
+	if e.SQLState == "" {
+		e.SQLState = errorClassSQLStates[e.ErrorClass]
+	}
+	if e.SQLState == "" {
+		for _, h := range hiveErrorSQLStates {
+			if strings.Contains(message, h.message) {
+				e.SQLState = h.sqlState
+				break
+			}
+		}
+	}
+	return e
+}
+
+// operationError returns the error of an operation that ended in a bad state
+func operationError(opStatus *cli_service.TGetOperationStatusResp) error {
+	e := newExecutionError(opStatus.GetDisplayMessage(), opStatus.GetSqlState())
+	if e.SQLState == "" && opStatus.GetOperationState() == cli_service.TOperationState_CANCELED_STATE {
+		e.SQLState = ErrCodeQueryCanceled
+	}
+	return errors.WithStack(e)
+}
+
+// SQLState returns the SQLSTATE of the statement that failed with err, or "" if
+// err is not an *ExecutionError or the SQLSTATE is unknown.
+func SQLState(err error) string {
+	var execErr *ExecutionError
+	if errors.As(err, &execErr) {
+		return execErr.SQLState
+	}
+	return ""
+}
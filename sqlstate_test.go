@@ -0,0 +1,109 @@
+package dbsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExecutionError(t *testing.T) {
+	t.Run("keeps the SQLSTATE reported by the server", func(t *testing.T) {
+		err := newExecutionError("[TABLE_OR_VIEW_NOT_FOUND] The table or view `t` cannot be found.", "42S02")
+		assert.Equal(t, "TABLE_OR_VIEW_NOT_FOUND", err.ErrorClass)
+		assert.Equal(t, "42S02", err.SQLState)
+	})
+
+	t.Run("maps error classes to SQLSTATE", func(t *testing.T) {
+		cases := map[string]string{
+			"[TABLE_OR_VIEW_NOT_FOUND] The table or view `t` cannot be found.":                 ErrCodeTableNotFound,
+			"org.apache.spark.sql.AnalysisException: [INSUFFICIENT_PERMISSIONS] User does not": ErrCodePermissionDenied,
+			"[UNRESOLVED_COLUMN.WITH_SUGGESTION] A column `x` cannot be resolved.":             ErrCodeColumnNotFound,
+			"[PARSE_SYNTAX_ERROR] Syntax error at or near 'selec'.":                            ErrCodeSyntaxError,
+			"[DIVIDE_BY_ZERO] Division by zero.":                                               ErrCodeDivisionByZero,
+			"[SOME_NEW_ERROR] Unknown error class.":                                            "",
+		}
+		for message, sqlState := range cases {
+			assert.Equal(t, sqlState, newExecutionError(message, "").SQLState, message)
+		}
+		assert.Equal(t, "UNRESOLVED_COLUMN", newExecutionError("[UNRESOLVED_COLUMN.WITH_SUGGESTION] x", "").ErrorClass)
+	})
+
+	t.Run("maps Hive messages to SQLSTATE", func(t *testing.T) {
+		err := newExecutionError("Error running query: org.apache.spark.sql.AnalysisException: Table or view not found: t", "")
+		assert.Equal(t, "", err.ErrorClass)
+		assert.Equal(t, ErrCodeTableNotFound, err.SQLState)
+	})
+
+	t.Run("canceled operations have the query canceled SQLSTATE", func(t *testing.T) {
+		err := operationError(&cli_service.TGetOperationStatusResp{
+			OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_CANCELED_STATE),
+		})
+		assert.Equal(t, ErrCodeQueryCanceled, SQLState(err))
+	})
+
+	t.Run("SQLState returns empty for other errors", func(t *testing.T) {
+		assert.Equal(t, "", SQLState(errors.New("other")))
+		assert.Equal(t, "", SQLState(nil))
+	})
+}
+
+func TestConn_ExecutionError(t *testing.T) {
+	t.Run("failed operations return an ExecutionError", func(t *testing.T) {
+		executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+			return &cli_service.TExecuteStatementResp{
+				Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+				OperationHandle: &cli_service.TOperationHandle{
+					OperationId: &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+				},
+				DirectResults: &cli_service.TSparkDirectResults{
+					OperationStatus: &cli_service.TGetOperationStatusResp{
+						OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_ERROR_STATE),
+						DisplayMessage: strPtr("[TABLE_OR_VIEW_NOT_FOUND] The table or view `t` cannot be found."),
+					},
+				},
+			}, nil
+		}
+		cfg := config.WithDefaults()
+		cfg.PollInterval = 10 * time.Millisecond
+		testConn := &conn{
+			session: getTestSession(),
+			client:  &client.TestClient{FnExecuteStatement: executeStatement},
+			cfg:     cfg,
+		}
+		_, err := testConn.ExecContext(context.Background(), "delete from t", nil)
+		var execErr *ExecutionError
+		require.ErrorAs(t, err, &execErr)
+		assert.Equal(t, "TABLE_OR_VIEW_NOT_FOUND", execErr.ErrorClass)
+		assert.Equal(t, ErrCodeTableNotFound, execErr.SQLState)
+		assert.Equal(t, "[TABLE_OR_VIEW_NOT_FOUND] The table or view `t` cannot be found.", err.Error())
+	})
+
+	t.Run("failed requests return an ExecutionError", func(t *testing.T) {
+		executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+			resp := &cli_service.TExecuteStatementResp{
+				Status: &cli_service.TStatus{
+					StatusCode:   cli_service.TStatusCode_ERROR_STATUS,
+					SqlState:     strPtr(ErrCodePermissionDenied),
+					ErrorMessage: strPtr("[INSUFFICIENT_PERMISSIONS] User does not have USE SCHEMA on s."),
+				},
+			}
+			return resp, client.CheckStatus(resp)
+		}
+		cfg := config.WithDefaults()
+		cfg.PollInterval = 10 * time.Millisecond
+		testConn := &conn{
+			session: getTestSession(),
+			client:  &client.TestClient{FnExecuteStatement: executeStatement},
+			cfg:     cfg,
+		}
+		_, err := testConn.QueryContext(context.Background(), "select * from s.t", nil)
+		assert.Equal(t, ErrCodePermissionDenied, SQLState(err))
+	})
+}
@@ -0,0 +1,136 @@
+package dbsql
+
+import "strings"
+
+// sqlTokenKind is the kind of a lexical piece of SQL text returned by scanSQL
+type sqlTokenKind int
+
+const (
+	// a single character of SQL code outside quotes and comments
+	sqlCode sqlTokenKind = iota
+	// a quoted string literal or identifier, including the quotes
+	sqlQuoted
+	// a line or block comment
+	sqlComment
+	// a single whitespace character
+	sqlSpace
+)
+
+// scanSQL calls fn with the kind and the start and end offsets of each lexical
+// piece of query, stopping early when fn returns false. It only knows enough SQL
+// to tell code apart from string literals, quoted identifiers and comments, so
+// that placeholders or statement terminators are only looked for in code.
+//
+// Block comments nest, as in Spark SQL, and raw string literals, r'...' or R"...",
+// have no escapes.
+func scanSQL(query string, fn func(kind sqlTokenKind, start, end int) bool) {
+	for i := 0; i < len(query); {
+		start := i
+		kind := sqlCode
+		switch c := query[i]; {
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			kind = sqlComment
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			kind = sqlComment
+			depth := 1
+			for i += 2; i < len(query) && depth > 0; i++ {
+				if query[i] == '/' && i+1 < len(query) && query[i+1] == '*' {
+					depth++
+					i++
+				} else if query[i] == '*' && i+1 < len(query) && query[i+1] == '/' {
+					depth--
+					i++
+				}
+			}
+		case (c == 'r' || c == 'R') && i+1 < len(query) && (query[i+1] == '\'' || query[i+1] == '"') &&
+			(i == 0 || !isIdentChar(query[i-1])):
+			kind = sqlQuoted
+			quote := query[i+1]
+			end := strings.IndexByte(query[i+2:], quote)
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+		case c == '\'' || c == '"' || c == '`':
+			kind = sqlQuoted
+			for i++; i < len(query) && query[i] != c; i++ {
+				// backticks quote identifiers and have no escapes
+				if query[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+			i++
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			kind = sqlSpace
+			i++
+		default:
+			i++
+		}
+		if i > len(query) {
+			i = len(query)
+		}
+		if !fn(kind, start, i) {
+			return
+		}
+	}
+}
+
+// quoteStringLiteral returns s as a single quoted SQL string literal
+func quoteStringLiteral(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// trimStatement removes trailing whitespace, comments and semicolons from a
+// single SQL statement so it can be embedded in another statement.
+func trimStatement(query string) string {
+	query = query[:statementEnd(query)]
+	for strings.HasSuffix(query, ";") {
+		query = query[:statementEnd(query[:len(query)-1])]
+	}
+	return query
+}
+
+// statementEnd returns the offset following the last piece of query that is
+// code or quoted text, i.e. not whitespace or a comment.
+func statementEnd(query string) int {
+	end := 0
+	scanSQL(query, func(kind sqlTokenKind, start, stop int) bool {
+		if kind == sqlCode || kind == sqlQuoted {
+			end = stop
+		}
+		return true
+	})
+	return end
+}
+
+// redactLiterals replaces the string and numeric literals of query with ?, so that
+// the statement can be logged without the data it holds. Quoted identifiers are kept.
+func redactLiterals(query string) string {
+	var b strings.Builder
+	inNumber := false
+	scanSQL(query, func(kind sqlTokenKind, start, end int) bool {
+		c := query[start]
+		switch {
+		case kind == sqlQuoted && c != '`':
+			b.WriteByte('?')
+			inNumber = false
+			return true
+		case kind == sqlCode && inNumber && (isIdentChar(c) || c == '.'):
+			return true
+		case kind == sqlCode && c >= '0' && c <= '9' && (start == 0 || !isIdentChar(query[start-1])):
+			b.WriteByte('?')
+			inNumber = true
+			return true
+		}
+		inNumber = false
+		b.WriteString(query[start:end])
+		return true
+	})
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package dbsql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanSQL(t *testing.T) {
+	query := "select 'a?', `b?` -- c?\n/* d? */ ?"
+	var kinds []sqlTokenKind
+	var pieces []string
+	scanSQL(query, func(kind sqlTokenKind, start, end int) bool {
+		if kind != sqlCode && kind != sqlSpace {
+			kinds = append(kinds, kind)
+			pieces = append(pieces, query[start:end])
+		}
+		return true
+	})
+	assert.Equal(t, []sqlTokenKind{sqlQuoted, sqlQuoted, sqlComment, sqlComment}, kinds)
+	assert.Equal(t, []string{"'a?'", "`b?`", "-- c?", "/* d? */"}, pieces)
+
+	t.Run("block comments nest", func(t *testing.T) {
+		query := "select /* a /* b? */ c? */ ?, /* unterminated /* */ ?"
+		var pieces []string
+		scanSQL(query, func(kind sqlTokenKind, start, end int) bool {
+			if kind == sqlComment {
+				pieces = append(pieces, query[start:end])
+			}
+			return true
+		})
+		assert.Equal(t, []string{"/* a /* b? */ c? */", "/* unterminated /* */ ?"}, pieces)
+	})
+
+	t.Run("raw string literals have no escapes", func(t *testing.T) {
+		query := `select r'C:\dir\', R"\", "?", order'x', ?`
+		var pieces []string
+		scanSQL(query, func(kind sqlTokenKind, start, end int) bool {
+			if kind == sqlQuoted {
+				pieces = append(pieces, query[start:end])
+			}
+			return true
+		})
+		assert.Equal(t, []string{`r'C:\dir\'`, `R"\"`, `"?"`, `'x'`}, pieces)
+	})
+}
+
+func TestTrimStatement(t *testing.T) {
+	cases := map[string]string{
+		"select 1":                         "select 1",
+		"select 1;  ":                      "select 1",
+		"select 1; -- comment":             "select 1",
+		"select 1 /* a */ ; /* b */":       "select 1",
+		"select `a--b`, \"c;\" from t;;":   "select `a--b`, \"c;\" from t",
+		"select 'it\\'s -- not a comment'": "select 'it\\'s -- not a comment'",
+		"select 1 -- a\n + 2 -- b":         "select 1 -- a\n + 2",
+		"select 1 /* a /* ; */ b */ ;":     "select 1",
+		"select r'\\' ; -- end":            "select r'\\'",
+	}
+	for query, expected := range cases {
+		assert.Equal(t, expected, trimStatement(query), query)
+	}
+}
+
+func TestRedactLiterals(t *testing.T) {
+	cases := map[string]string{
+		"select * from t where name = 'bob' and id = 42":   "select * from t where name = ? and id = ?",
+		"select `col1`, t2.x from t2 where y > 1.5e3":      "select `col1`, t2.x from t2 where y > ?",
+		"insert into t values (\"a\", 10L, -3.5BD, x'ff')": "insert into t values (?, ?, -?, x?)",
+		"select 'it\\'s' -- comment 'kept'":                "select ? -- comment 'kept'",
+		"select r'C:\\dir\\', 1 /* /* 'kept' */ 2 */":      "select ?, ? /* /* 'kept' */ 2 */",
+	}
+	for query, expected := range cases {
+		assert.Equal(t, expected, redactLiterals(query), query)
+	}
+}
@@ -0,0 +1,174 @@
+// Package sshtunnel opens the network connections of the databricks driver through
+// an SSH bastion host, like a local port forward, for workspaces only reachable from
+// peered networks:
+//
+//	tunnel, err := sshtunnel.New(sshtunnel.Config{
+//		Addr:            "bastion.example.com:22",
+//		User:            "deploy",
+//		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+//		HostKeyCallback: hostKeyCallback,
+//	})
+//	defer tunnel.Close()
+//	connector, err := dbsql.NewConnector(
+//		dbsql.WithServerHostname(host),
+//		dbsql.WithHTTPPath(httpPath),
+//		dbsql.WithAccessToken(token),
+//		dbsql.WithDialer(tunnel.DialContext),
+//	)
+//
+// It is a separate module, so that the driver itself does not depend on the SSH
+// implementation.
+package sshtunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultTimeout is the max time to connect to the bastion host when none is set
+const DefaultTimeout = 30 * time.Second
+
+// ErrClosed is returned by the dials of a closed tunnel.
+var ErrClosed = errors.New("databricks: ssh tunnel is closed")
+
+// Config is the configuration of a tunnel.
+type Config struct {
+	// Addr is the address of the bastion host, with port 22 when it has none
+	Addr string
+	// User is the user logged in on the bastion host
+	User string
+	// Auth are the methods tried to authenticate, e.g. ssh.PublicKeys
+	Auth []ssh.AuthMethod
+	// HostKeyCallback verifies the key of the bastion host, e.g. a callback
+	// returned by knownhosts.New. Mandatory
+	HostKeyCallback ssh.HostKeyCallback
+	// Timeout is the max time to connect to the bastion host, DefaultTimeout when zero
+	Timeout time.Duration
+}
+
+// Tunnel opens network connections from the bastion host. It connects to the
+// bastion host on the first dial, and again after the connection to it is lost.
+type Tunnel struct {
+	config Config
+
+	mu     sync.Mutex
+	client *ssh.Client
+	closed bool
+}
+
+// New returns a tunnel through the bastion host of the config, which is not
+// connected to until the first dial.
+func New(config Config) (*Tunnel, error) {
+	if config.Addr == "" || config.User == "" {
+		return nil, errors.New("databricks: ssh tunnel address and user must be set")
+	}
+	if config.HostKeyCallback == nil {
+		return nil, errors.New("databricks: ssh tunnel host key callback must be set")
+	}
+	if _, _, err := net.SplitHostPort(config.Addr); err != nil {
+		config.Addr = net.JoinHostPort(config.Addr, "22")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	return &Tunnel{config: config}, nil
+}
+
+// DialContext opens a connection to addr from the bastion host, which resolves the
+// host name of addr. Pass it to dbsql.WithDialer. The connections are TCP whatever
+// the network.
+func (t *Tunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type dialed struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan dialed, 1)
+	go func() {
+		// tcp4 and tcp6 would resolve the address locally, while the bastion host may
+		// be the only one to resolve private names
+		conn, err := client.Dial("tcp", addr)
+		done <- dialed{conn, err}
+	}()
+	select {
+	case d := <-done:
+		if d.err != nil {
+			return nil, fmt.Errorf("databricks: ssh tunnel failed to dial %s: %w", addr, d.err)
+		}
+		return d.conn, nil
+	case <-ctx.Done():
+		go func() {
+			if d := <-done; d.conn != nil {
+				d.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// connect returns the client connected to the bastion host, connecting it if needed
+func (t *Tunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, ErrClosed
+	}
+	if t.client != nil {
+		return t.client, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.config.Timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", t.config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("databricks: ssh tunnel failed to connect to %s: %w", t.config.Addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, t.config.Addr, &ssh.ClientConfig{
+		User:            t.config.User,
+		Auth:            t.config.Auth,
+		HostKeyCallback: t.config.HostKeyCallback,
+		Timeout:         t.config.Timeout,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("databricks: ssh tunnel failed to connect to %s: %w", t.config.Addr, err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	client := ssh.NewClient(sshConn, chans, reqs)
+	t.client = client
+	go func() {
+		// the next dial connects again once the bastion host drops the connection
+		_ = client.Wait()
+		t.mu.Lock()
+		if t.client == client {
+			t.client = nil
+		}
+		t.mu.Unlock()
+	}()
+	return client, nil
+}
+
+// Close closes the connection to the bastion host, and the connections opened
+// through it. The dials that follow fail with ErrClosed.
+func (t *Tunnel) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.client == nil {
+		return nil
+	}
+	err := t.client.Close()
+	t.client = nil
+	return err
+}
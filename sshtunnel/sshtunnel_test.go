@@ -0,0 +1,223 @@
+package sshtunnel
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// bastion is an SSH server forwarding direct-tcpip channels, as used by local port
+// forwards
+type bastion struct {
+	addr    string
+	hostKey ssh.PublicKey
+	mu      sync.Mutex
+	conns   []*ssh.ServerConn
+	targets []string
+}
+
+func newBastion(t *testing.T) *bastion {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "deploy" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(signer)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	b := &bastion{addr: l.Addr().String(), hostKey: signer.PublicKey()}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn, config)
+		}
+	}()
+	return b
+}
+
+func (b *bastion) serve(conn net.Conn, config *ssh.ServerConfig) {
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	b.mu.Lock()
+	b.conns = append(b.conns, sshConn)
+	b.mu.Unlock()
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		var target struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		addr := net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port)))
+		b.mu.Lock()
+		b.targets = append(b.targets, addr)
+		b.mu.Unlock()
+		targetConn, err := net.Dial("tcp", addr)
+		if err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			targetConn.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			_, _ = io.Copy(channel, targetConn)
+			channel.Close()
+		}()
+		go func() {
+			_, _ = io.Copy(targetConn, channel)
+			targetConn.Close()
+		}()
+	}
+}
+
+// dropConnections closes the connections of the clients, as a restarted bastion would
+func (b *bastion) dropConnections() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, c := range b.conns {
+		c.Close()
+	}
+}
+
+// newEchoServer returns the address of a server echoing what it reads
+func newEchoServer(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func echo(t *testing.T, conn net.Conn, msg string) {
+	_, err := conn.Write([]byte(msg))
+	require.NoError(t, err)
+	buf := make([]byte, len(msg))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, msg, string(buf))
+}
+
+func TestTunnel(t *testing.T) {
+	b := newBastion(t)
+	target := newEchoServer(t)
+	config := Config{
+		Addr:            b.addr,
+		User:            "deploy",
+		Auth:            []ssh.AuthMethod{ssh.Password("secret")},
+		HostKeyCallback: ssh.FixedHostKey(b.hostKey),
+	}
+
+	t.Run("connections are opened from the bastion host", func(t *testing.T) {
+		tunnel, err := New(config)
+		require.NoError(t, err)
+		defer tunnel.Close()
+
+		for i := 0; i < 2; i++ {
+			conn, err := tunnel.DialContext(context.Background(), "tcp4", target)
+			require.NoError(t, err)
+			echo(t, conn, "select 1")
+			conn.Close()
+		}
+		b.mu.Lock()
+		assert.Len(t, b.conns, 1)
+		assert.Equal(t, []string{target, target}, b.targets)
+		b.mu.Unlock()
+	})
+
+	t.Run("the tunnel reconnects once the bastion drops the connection", func(t *testing.T) {
+		tunnel, err := New(config)
+		require.NoError(t, err)
+		defer tunnel.Close()
+		conn, err := tunnel.DialContext(context.Background(), "tcp", target)
+		require.NoError(t, err)
+		conn.Close()
+
+		b.dropConnections()
+		assert.Eventually(t, func() bool {
+			conn, err := tunnel.DialContext(context.Background(), "tcp", target)
+			if err != nil {
+				return false
+			}
+			defer conn.Close()
+			echo(t, conn, "select 2")
+			return true
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("unknown host keys are rejected", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		otherSigner, err := ssh.NewSignerFromKey(otherKey)
+		require.NoError(t, err)
+		wrongKey := config
+		wrongKey.HostKeyCallback = ssh.FixedHostKey(otherSigner.PublicKey())
+		tunnel, err := New(wrongKey)
+		require.NoError(t, err)
+		_, err = tunnel.DialContext(context.Background(), "tcp", target)
+		assert.ErrorContains(t, err, "databricks: ssh tunnel failed to connect to "+b.addr)
+	})
+
+	t.Run("closed tunnels cannot dial", func(t *testing.T) {
+		tunnel, err := New(config)
+		require.NoError(t, err)
+		require.NoError(t, tunnel.Close())
+		_, err = tunnel.DialContext(context.Background(), "tcp", target)
+		assert.ErrorIs(t, err, ErrClosed)
+	})
+
+	t.Run("invalid configs are rejected", func(t *testing.T) {
+		_, err := New(Config{Addr: b.addr, User: "deploy"})
+		assert.EqualError(t, err, "databricks: ssh tunnel host key callback must be set")
+		_, err = New(Config{User: "deploy", HostKeyCallback: ssh.FixedHostKey(b.hostKey)})
+		assert.EqualError(t, err, "databricks: ssh tunnel address and user must be set")
+
+		tunnel, err := New(Config{Addr: "bastion.example.com", User: "deploy", HostKeyCallback: ssh.FixedHostKey(b.hostKey)})
+		require.NoError(t, err)
+		assert.Equal(t, "bastion.example.com:22", tunnel.config.Addr)
+		assert.Equal(t, DefaultTimeout, tunnel.config.Timeout)
+	})
+}
@@ -4,21 +4,49 @@ import (
 	"context"
 	"database/sql/driver"
 	"errors"
+	"runtime"
+
+	"github.com/databricks/databricks-sql-go/logger"
 )
 
 type stmt struct {
 	conn  *conn
 	query string
+	// the placeholders of query, set when parameter interpolation is enabled
+	params *paramQuery
+	// where the statement was prepared, when leak detection is enabled
+	stack string
 }
 
 // Close closes the statement.
 func (s *stmt) Close() error {
-	// no-op
+	if s.stack != "" {
+		s.stack = ""
+		runtime.SetFinalizer(s, nil)
+	}
 	return nil
 }
 
+// watchLeak reports the statement if it is garbage collected without Close, when
+// leak detection is enabled. database/sql holds the statements it prepares until they
+// are closed, and closes them with their connection, so this finds the statements
+// prepared on the driver connection, e.g. through sql.Conn.Raw.
+func (s *stmt) watchLeak() {
+	if s.stack = leakStack(s.conn.cfg); s.stack == "" {
+		return
+	}
+	runtime.SetFinalizer(s, func(s *stmt) {
+		reportLeak(s.conn.cfg, logger.WithContext(s.conn.id, "", ""), "statement garbage collected without Close: query "+loggableQuery(s.query), s.stack)
+	})
+}
+
+// NumInput returns the number of placeholders when parameter interpolation is
+// enabled, so database/sql checks the number of arguments, and -1 otherwise.
 func (s *stmt) NumInput() int {
-	return -1
+	if s.params == nil {
+		return -1
+	}
+	return len(s.params.placeholders)
 }
 
 // Deprecated: Use StmtExecContext instead.
@@ -37,7 +65,14 @@ func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
 // ExecContext honors the context timeout and return when it is canceled.
 // Statement ExecContext is the same as connection ExecContext
 func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
-	return s.conn.ExecContext(ctx, s.query, args)
+	if s.params == nil {
+		return s.conn.ExecContext(ctx, s.query, args)
+	}
+	query, err := s.params.bind(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.conn.ExecContext(ctx, query, nil)
 }
 
 // QueryContext executes a query that may return rows, such as a
@@ -46,7 +81,16 @@ func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (drive
 // QueryContext honors the context timeout and return when it is canceled.
 // Statement QueryContext is the same as connection QueryContext
 func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
-	return s.conn.QueryContext(ctx, s.query, args)
+	if s.params == nil {
+		return s.conn.QueryContext(ctx, s.query, args)
+	}
+	query, err := s.params.bind(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.conn.QueryContext(ctx, query, nil)
 }
 
 var _ driver.Stmt = (*stmt)(nil)
+var _ driver.StmtExecContext = (*stmt)(nil)
+var _ driver.StmtQueryContext = (*stmt)(nil)
@@ -164,3 +164,62 @@ func TestStmt_QueryContext(t *testing.T) {
 		assert.Equal(t, testQuery, savedQueryString)
 	})
 }
+
+func TestStmt_Reuse(t *testing.T) {
+	t.Run("prepared statement binds its placeholders on every execution", func(t *testing.T) {
+		var statements []string
+		executeStatement := func(ctx context.Context, req *cli_service.TExecuteStatementReq) (r *cli_service.TExecuteStatementResp, err error) {
+			statements = append(statements, req.Statement)
+			return &cli_service.TExecuteStatementResp{
+				Status: &cli_service.TStatus{
+					StatusCode: cli_service.TStatusCode_SUCCESS_STATUS,
+				},
+				OperationHandle: &cli_service.TOperationHandle{
+					OperationId: &cli_service.THandleIdentifier{
+						GUID:   []byte{1, 2, 3, 4, 2, 23, 4, 2, 3, 2, 3, 4, 4, 223, 34, 54},
+						Secret: []byte("b"),
+					},
+				},
+			}, nil
+		}
+		getOperationStatus := func(ctx context.Context, req *cli_service.TGetOperationStatusReq) (r *cli_service.TGetOperationStatusResp, err error) {
+			return &cli_service.TGetOperationStatusResp{
+				OperationState:  cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+				NumModifiedRows: thrift.Int64Ptr(1),
+			}, nil
+		}
+		cfg := config.WithDefaults()
+		cfg.InterpolateParams = true
+		testConn := &conn{
+			session: getTestSession(),
+			client: &client.TestClient{
+				FnExecuteStatement:   executeStatement,
+				FnGetOperationStatus: getOperationStatus,
+			},
+			cfg: cfg,
+		}
+		s, err := testConn.PrepareContext(context.Background(), "insert into t select ?, '?' where ? in (?)")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, s.NumInput())
+
+		execer := s.(driver.StmtExecContext)
+		_, err = execer.ExecContext(context.Background(), namedValues(1, "a", []int{1, 2}))
+		assert.NoError(t, err)
+		_, err = execer.ExecContext(context.Background(), namedValues(2, nil, []string{"b"}))
+		assert.NoError(t, err)
+		_, err = execer.ExecContext(context.Background(), namedValues(3))
+		assert.EqualError(t, err, "databricks: query has 3 placeholders but 1 arguments")
+
+		assert.Equal(t, []string{
+			"insert into t select 1, '?' where 'a' in (1, 2)",
+			"insert into t select 2, '?' where NULL in ('b')",
+		}, statements)
+	})
+
+	t.Run("without parameter interpolation the number of inputs is unknown", func(t *testing.T) {
+		testConn := &conn{cfg: config.WithDefaults()}
+		s, err := testConn.Prepare("select ?")
+		assert.NoError(t, err)
+		assert.Equal(t, -1, s.NumInput())
+	})
+}
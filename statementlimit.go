@@ -0,0 +1,76 @@
+package dbsql
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/metrics"
+)
+
+// statementLimiter queues the statements of the connections of a connector beyond
+// its limit of concurrent executions. A nil statementLimiter does not limit them.
+type statementLimiter struct {
+	slots chan struct{}
+	// the number of statements waiting for a slot
+	queued atomic.Int64
+}
+
+func newStatementLimiter(n int) *statementLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return &statementLimiter{slots: make(chan struct{}, n)}
+}
+
+// acquire waits for a free slot, or until ctx is done. It returns how long the
+// statement waited and how many statements were waiting when it was queued.
+func (l *statementLimiter) acquire(ctx context.Context) (metrics.StatementQueue, error) {
+	if l == nil {
+		return metrics.StatementQueue{}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return metrics.StatementQueue{}, nil
+	default:
+	}
+
+	queue := metrics.StatementQueue{Queued: l.queued.Add(1) - 1}
+	defer l.queued.Add(-1)
+	start := time.Now()
+	select {
+	case l.slots <- struct{}{}:
+		queue.Wait = time.Since(start)
+		return queue, nil
+	case <-ctx.Done():
+		queue.Wait = time.Since(start)
+		return queue, ctx.Err()
+	}
+}
+
+func (l *statementLimiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
+
+// acquireStatement waits until the statement may run within the concurrency limit of
+// the connector and reports the wait to the metrics collector and the debug log.
+// The returned func releases the slot once the statement is done.
+func (c *conn) acquireStatement(ctx context.Context) (func(), error) {
+	if c.stmtLimiter == nil {
+		return func() {}, nil
+	}
+	queue, err := c.stmtLimiter.acquire(ctx)
+	statementTimerFromContext(ctx).queued(queue.Wait)
+	if queue.Wait > 0 {
+		statementLogger(ctx, c.id, "").Debug().Msgf("databricks: statement queued for %v behind %d statements", queue.Wait, queue.Queued)
+	}
+	if collector, ok := c.cfg.Metrics.(metrics.StatementQueueCollector); ok {
+		collector.StatementQueued(ctx, queue)
+	}
+	if err != nil {
+		return nil, wrapErr(err, "canceled while queued")
+	}
+	return c.stmtLimiter.release, nil
+}
@@ -0,0 +1,135 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/databricks/databricks-sql-go/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statementQueueRecorder struct {
+	mu     sync.Mutex
+	queues []metrics.StatementQueue
+}
+
+func (r *statementQueueRecorder) PageFetched(ctx context.Context, page metrics.PageFetch) {}
+
+func (r *statementQueueRecorder) StatementQueued(ctx context.Context, queue metrics.StatementQueue) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queues = append(r.queues, queue)
+}
+
+func TestStatementLimiter(t *testing.T) {
+	t.Run("nil limiter does not limit", func(t *testing.T) {
+		var limiter *statementLimiter
+		assert.Nil(t, newStatementLimiter(0))
+		queue, err := limiter.acquire(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, queue)
+		limiter.release()
+	})
+
+	t.Run("acquire waits for a slot", func(t *testing.T) {
+		limiter := newStatementLimiter(1)
+		queue, err := limiter.acquire(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, queue)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		queue, err = limiter.acquire(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.GreaterOrEqual(t, queue.Wait, 10*time.Millisecond)
+		assert.Equal(t, int64(0), queue.Queued)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			limiter.release()
+		}()
+		queue, err = limiter.acquire(context.Background())
+		assert.NoError(t, err)
+		assert.Greater(t, queue.Wait, time.Duration(0))
+		assert.Equal(t, int64(0), limiter.queued.Load())
+	})
+
+	t.Run("connections of a connector share its limit", func(t *testing.T) {
+		c, err := NewConnector(WithServerHostname("localhost"), WithMaxConcurrentStatements(3))
+		require.NoError(t, err)
+		limiter := c.(*connector).getStatementLimiter()
+		assert.Equal(t, 3, cap(limiter.slots))
+		assert.Same(t, limiter, c.(*connector).getStatementLimiter())
+	})
+}
+
+func TestConn_MaxConcurrentStatements(t *testing.T) {
+	limiter := newStatementLimiter(1)
+	recorder := &statementQueueRecorder{}
+	running := make(chan struct{})
+	finish := make(chan struct{})
+	getConn := func(block bool) *conn {
+		cfg := config.WithDefaults()
+		cfg.PollInterval = 10 * time.Millisecond
+		cfg.Metrics = recorder
+		return &conn{
+			session: getTestSession(),
+			client: &client.TestClient{
+				FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+					if block {
+						close(running)
+						<-finish
+					}
+					return &cli_service.TExecuteStatementResp{
+						Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+						OperationHandle: &cli_service.TOperationHandle{
+							OperationId: &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}},
+						},
+						DirectResults: &cli_service.TSparkDirectResults{
+							OperationStatus: &cli_service.TGetOperationStatusResp{
+								OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+							},
+						},
+					}, nil
+				},
+			},
+			cfg:         cfg,
+			stmtLimiter: limiter,
+		}
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := getConn(true).ExecContext(context.Background(), "insert into t values (1)", []driver.NamedValue{})
+		done <- err
+	}()
+	<-running
+
+	// the second statement is canceled while it waits for the first one
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := getConn(false).ExecContext(ctx, "insert into t values (2)", []driver.NamedValue{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the third one runs once the first one finishes
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(finish)
+	}()
+	_, err = getConn(false).ExecContext(context.Background(), "insert into t values (3)", []driver.NamedValue{})
+	assert.NoError(t, err)
+	assert.NoError(t, <-done)
+
+	require.Len(t, recorder.queues, 3)
+	assert.Zero(t, recorder.queues[0])
+	assert.GreaterOrEqual(t, recorder.queues[1].Wait, 10*time.Millisecond)
+	assert.Greater(t, recorder.queues[2].Wait, time.Duration(0))
+	assert.Len(t, limiter.slots, 0)
+}
@@ -0,0 +1,61 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// QueryChan runs the query and sends its rows, mapped to T by mapper, on the returned
+// channel, for pipeline-style consumers. The result pages are fetched as the rows are
+// received: the query goroutine waits for the consumer, so a slow consumer does not
+// hold more than the current page in memory.
+//
+// The rows channel is closed once the rows are all sent or the query fails. The error
+// channel then receives the error of the query, of mapper or of ctx, if any, and is
+// closed. Cancel ctx to stop consuming early, otherwise the query goroutine waits
+// until its next row is received.
+//
+//	rows, errs := dbsql.QueryChan(ctx, db, "SELECT id, name FROM users", func(rows *sql.Rows) (User, error) {
+//		var u User
+//		err := rows.Scan(&u.ID, &u.Name)
+//		return u, err
+//	})
+//	for u := range rows {
+//		...
+//	}
+//	if err := <-errs; err != nil {
+//		...
+//	}
+func QueryChan[T any](ctx context.Context, db Queryer, query string, mapper func(*sql.Rows) (T, error), args ...any) (<-chan T, <-chan error) {
+	out := make(chan T)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		err := queryChan(ctx, db, query, mapper, args, out)
+		close(out)
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return out, errs
+}
+
+func queryChan[T any](ctx context.Context, db Queryer, query string, mapper func(*sql.Rows) (T, error), args []any, out chan<- T) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		v, err := mapper(rows)
+		if err != nil {
+			return err
+		}
+		select {
+		case out <- v:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return rows.Err()
+}
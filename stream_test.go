@@ -0,0 +1,92 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamTestUser struct {
+	ID   string
+	Name string
+}
+
+func scanStreamTestUser(rows *sql.Rows) (streamTestUser, error) {
+	var u streamTestUser
+	err := rows.Scan(&u.ID, &u.Name)
+	return u, err
+}
+
+func TestQueryChan(t *testing.T) {
+	lines := [][]string{{"1", "ada"}, {"2", "grace"}, {"3", "edsger"}}
+
+	t.Run("sends the mapped rows and no error", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id", "name"}, lines, "", &statements)
+		defer db.Close()
+
+		rows, errs := QueryChan(context.Background(), db, "SELECT id, name FROM users", scanStreamTestUser)
+		var users []streamTestUser
+		for u := range rows {
+			users = append(users, u)
+		}
+		assert.NoError(t, <-errs)
+		assert.Equal(t, []streamTestUser{{"1", "ada"}, {"2", "grace"}, {"3", "edsger"}}, users)
+		assert.Equal(t, []string{"SELECT id, name FROM users"}, statements)
+	})
+
+	t.Run("sends the error of the query", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id", "name"}, nil, "42P01", &statements)
+		defer db.Close()
+
+		rows, errs := QueryChan(context.Background(), db, "SELECT id, name FROM users", scanStreamTestUser)
+		_, ok := <-rows
+		assert.False(t, ok)
+		err := <-errs
+		require.Error(t, err)
+		assert.Equal(t, "42P01", SQLState(err))
+		_, ok = <-errs
+		assert.False(t, ok)
+	})
+
+	t.Run("stops at the error of the mapper", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id", "name"}, lines, "", &statements)
+		defer db.Close()
+
+		mapErr := errors.New("bad user")
+		rows, errs := QueryChan(context.Background(), db, "SELECT id, name FROM users", func(rows *sql.Rows) (streamTestUser, error) {
+			u, err := scanStreamTestUser(rows)
+			if u.ID == "2" {
+				return u, mapErr
+			}
+			return u, err
+		})
+		var users []streamTestUser
+		for u := range rows {
+			users = append(users, u)
+		}
+		assert.Equal(t, mapErr, <-errs)
+		assert.Equal(t, []streamTestUser{{"1", "ada"}}, users)
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id", "name"}, lines, "", &statements)
+		defer db.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		rows, errs := QueryChan(ctx, db, "SELECT id, name FROM users", scanStreamTestUser)
+		assert.Equal(t, streamTestUser{"1", "ada"}, <-rows)
+		// the query goroutine waits to send the next row until it sees the cancellation
+		cancel()
+		assert.ErrorIs(t, <-errs, context.Canceled)
+		_, ok := <-rows
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,143 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errTempViewNoRows = "databricks: a temporary view needs at least one row"
+var errTempViewRowLength = "databricks: row %d has %d values, the view has %d columns"
+
+// CreateTempView creates, or replaces, a temporary view of the session of conn holding
+// the rows, e.g. to join local data with tables in a query. The values are formatted
+// as SQL literals, as with parameter interpolation, and their types inferred by the
+// server, so the view suits small data sets: the rows are sent within the statement.
+//
+// The view only exists in the session of conn and is dropped with it when the
+// connection is closed. Views created within RunInSession are dropped when it returns,
+// otherwise use DropTempViews before returning the connection to the pool.
+//
+//	err := dbsql.CreateTempView(ctx, conn, "wanted", []string{"id", "label"}, [][]any{{1, "a"}, {2, "b"}})
+//	...
+//	rows, err := conn.QueryContext(ctx, "SELECT e.* FROM events e JOIN wanted w ON e.id = w.id")
+func CreateTempView(ctx context.Context, conn *sql.Conn, name string, columns []string, rows [][]any) error {
+	view, err := quoteTempViewName(name)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return errors.New(errTempViewNoRows)
+	}
+	var b strings.Builder
+	b.WriteString("CREATE OR REPLACE TEMPORARY VIEW ")
+	b.WriteString(view)
+	b.WriteString(" (")
+	for i, column := range columns {
+		quoted, err := quoteTempViewName(column)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(quoted)
+	}
+	b.WriteString(") AS VALUES ")
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return errors.Errorf(errTempViewRowLength, i, len(row), len(columns))
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j, v := range row {
+			literal, err := formatLiteral(v)
+			if err != nil {
+				return err
+			}
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(literal)
+		}
+		b.WriteByte(')')
+	}
+	if _, err := conn.ExecContext(ctx, b.String()); err != nil {
+		return wrapErrf(err, "failed to create temporary view %s", name)
+	}
+	return conn.Raw(func(driverConn any) error {
+		if c, ok := driverConn.(tempViewTracker); ok {
+			c.addTempView(view)
+		}
+		return nil
+	})
+}
+
+// DropTempViews drops the temporary views created with CreateTempView on conn, so that
+// they are not visible to the next users of the connection once it is returned to
+// the pool.
+func DropTempViews(ctx context.Context, conn *sql.Conn) error {
+	var views []string
+	err := conn.Raw(func(driverConn any) error {
+		if c, ok := driverConn.(tempViewTracker); ok {
+			views = c.takeTempViews()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, view := range views {
+		if _, err := conn.ExecContext(ctx, "DROP VIEW IF EXISTS "+view); err != nil && firstErr == nil {
+			firstErr = wrapErrf(err, "failed to drop temporary view %s", view)
+		}
+	}
+	return firstErr
+}
+
+// tempViewTracker is implemented by the connections, which keep track of the
+// temporary views created with CreateTempView
+type tempViewTracker interface {
+	addTempView(view string)
+	// takeTempViews returns the views and forgets them
+	takeTempViews() []string
+}
+
+var _ tempViewTracker = (*conn)(nil)
+
+func (c *conn) addTempView(view string) {
+	if c.tempViews == nil {
+		c.tempViews = map[string]struct{}{}
+	}
+	c.tempViews[view] = struct{}{}
+}
+
+func (c *conn) takeTempViews() []string {
+	views := make([]string, 0, len(c.tempViews))
+	for view := range c.tempViews {
+		views = append(views, view)
+	}
+	// drop in a stable order
+	sort.Strings(views)
+	c.tempViews = nil
+	return views
+}
+
+// quoteTempViewName quotes the name of a temporary view or of a column, which cannot
+// be qualified
+func quoteTempViewName(name string) (string, error) {
+	quoted, err := quoteName(name)
+	if err != nil {
+		return "", err
+	}
+	if len(sqlTokens(quoted)) != 1 {
+		return "", errors.Errorf("%s: %q", errCatalogInvalidName, name)
+	}
+	return quoted, nil
+}
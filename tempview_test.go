@@ -0,0 +1,71 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTempView(t *testing.T) {
+	t.Run("rows are created as VALUES and dropped", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+
+		day := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		err = CreateTempView(context.Background(), c, "wanted", []string{"id", "label", "seen at"}, [][]any{
+			{1, "a", day},
+			{2, "o'b", nil},
+		})
+		require.NoError(t, err)
+		require.NoError(t, CreateTempView(context.Background(), c, "`other`", []string{"x"}, [][]any{{true}}))
+		require.NoError(t, CreateTempView(context.Background(), c, "wanted", []string{"x"}, [][]any{{1.5}}))
+		require.NoError(t, DropTempViews(context.Background(), c))
+		require.NoError(t, DropTempViews(context.Background(), c))
+
+		assert.Equal(t, []string{
+			"CREATE OR REPLACE TEMPORARY VIEW `wanted` (`id`, `label`, `seen at`) AS VALUES (1, 'a', TIMESTAMP '2024-01-02T03:04:05Z'), (2, 'o\\'b', NULL)",
+			"CREATE OR REPLACE TEMPORARY VIEW `other` (`x`) AS VALUES (TRUE)",
+			"CREATE OR REPLACE TEMPORARY VIEW `wanted` (`x`) AS VALUES (1.5D)",
+			"DROP VIEW IF EXISTS `other`",
+			"DROP VIEW IF EXISTS `wanted`",
+		}, statements)
+	})
+
+	t.Run("invalid views are rejected", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+
+		ctx := context.Background()
+		assert.EqualError(t, CreateTempView(ctx, c, "main.default.v", []string{"x"}, [][]any{{1}}), `databricks: invalid object name: "main.default.v"`)
+		assert.EqualError(t, CreateTempView(ctx, c, "v", []string{"x"}, nil), errTempViewNoRows)
+		assert.EqualError(t, CreateTempView(ctx, c, "v", []string{"x", "y"}, [][]any{{1, 2}, {3}}), "databricks: row 1 has 1 values, the view has 2 columns")
+		assert.Empty(t, statements)
+	})
+
+	t.Run("views created in a session are dropped when it ends", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB([]string{"id"}, nil, "", &statements)
+		defer db.Close()
+
+		err := RunInSession(context.Background(), db, func(ctx context.Context, c *sql.Conn) error {
+			return CreateTempView(ctx, c, "ids", []string{"id"}, [][]any{{1}, {2}})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"CREATE OR REPLACE TEMPORARY VIEW `ids` (`id`) AS VALUES (1), (2)",
+			"DROP VIEW IF EXISTS `ids`",
+		}, statements)
+	})
+}
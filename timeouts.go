@@ -0,0 +1,25 @@
+package dbsql
+
+import "time"
+
+// RPCTimeouts are the max times of the requests made to the server, by type, since
+// opening a session, submitting a statement, waiting for it and fetching its results
+// take very different times. A zero value keeps the default. Set them with
+// WithRPCTimeouts.
+type RPCTimeouts struct {
+	// OpenSession is the max time to open a session. Default is 60 seconds
+	OpenSession time.Duration
+	// ExecuteStatement is the max time of the request submitting a statement, which
+	// returns once the statement runs or with the results of short statements.
+	// Default is the query timeout set with WithTimeout
+	ExecuteStatement time.Duration
+	// Polling is the max time polling the status of a running statement, in total.
+	// The statement is canceled once it is exceeded. Default is no limit
+	Polling time.Duration
+	// FetchResults is the max time of each request fetching a result page, not
+	// counting the wait for the limits of WithMaxConcurrentFetches. Default is no limit
+	FetchResults time.Duration
+	// CloseOperation is the max time to close the operation of a statement once its
+	// rows are closed. Default is 15 seconds
+	CloseOperation time.Duration
+}
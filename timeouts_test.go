@@ -0,0 +1,60 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPCTimeouts(t *testing.T) {
+	t.Run("polling stops and cancels the statement after the poll timeout", func(t *testing.T) {
+		var canceled atomic.Bool
+		testClient := &client.TestClient{
+			FnGetOperationStatus: func(ctx context.Context, req *cli_service.TGetOperationStatusReq) (*cli_service.TGetOperationStatusResp, error) {
+				return &cli_service.TGetOperationStatusResp{
+					OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_RUNNING_STATE),
+				}, nil
+			},
+			FnCancelOperation: func(ctx context.Context, req *cli_service.TCancelOperationReq) (*cli_service.TCancelOperationResp, error) {
+				canceled.Store(true)
+				return &cli_service.TCancelOperationResp{}, nil
+			},
+		}
+		cfg := config.WithDefaults()
+		cfg.PollInterval = 5 * time.Millisecond
+		cfg.PollTimeout = 50 * time.Millisecond
+		testConn := &conn{session: getTestSession(), client: testClient, cfg: cfg}
+
+		start := time.Now()
+		_, err := testConn.pollOperation(context.Background(), &cli_service.TOperationHandle{
+			OperationId: &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+		})
+		assert.ErrorContains(t, err, "sentinel timed out")
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Eventually(t, func() bool { return canceled.Load() }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("each fetch is bounded by the fetch timeout", func(t *testing.T) {
+		testClient := &client.TestClient{
+			FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}
+		cfg := config.WithDefaults()
+		cfg.FetchTimeout = 20 * time.Millisecond
+		r := &rows{pageSize: 10, client: testClient, config: cfg}
+		err := r.Next(make([]driver.Value, 1))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
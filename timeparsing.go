@@ -0,0 +1,16 @@
+package dbsql
+
+// TimeParsingMode tells what Next returns for DATE and TIMESTAMP values that do not
+// match the default layout, DateFormat or TimestampFormat, when no layouts are set
+// with WithDateLayouts or WithTimestampLayouts. Set it with WithTimeParsingMode.
+type TimeParsingMode int
+
+const (
+	// TimeParsingLenient returns the values that do not match as the strings sent by
+	// the server, while the others are returned as time.Time. Scan them into a
+	// destination accepting both, e.g. any. It is the default.
+	TimeParsingLenient TimeParsingMode = iota
+	// TimeParsingStrict fails with a *ConversionError on the values that do not
+	// match, so that the values of a column are always time.Time.
+	TimeParsingStrict
+)
@@ -0,0 +1,197 @@
+package dbsql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StatementTimings tells where the time of a statement went, from the time it was
+// queued to the last result page fetched.
+type StatementTimings struct {
+	// Connect is the time it took to open the session of the connection
+	Connect time.Duration
+	// Queued is the time waiting for the concurrency limit of WithMaxConcurrentStatements
+	Queued time.Duration
+	// Execute is the time of the request running the statement
+	Execute time.Duration
+	// Poll is the time of the requests polling the statement status, PollCycles their number
+	Poll       time.Duration
+	PollCycles int
+	// Fetch is the time of the requests fetching result pages, Fetches the number of
+	// pages fetched
+	Fetch   time.Duration
+	Fetches int
+	// Elapsed is the time since the statement started
+	Elapsed time.Duration
+}
+
+func (t StatementTimings) String() string {
+	return fmt.Sprintf("connect %v, queued %v, execute %v, poll %v (%d cycles), fetch %v (%d pages), elapsed %v",
+		t.Connect, t.Queued, t.Execute, t.Poll, t.PollCycles, t.Fetch, t.Fetches, t.Elapsed)
+}
+
+// TimingError is returned when a statement fails or times out, or when fetching its
+// rows fails. It tells where the time of the statement went, without enabling debug
+// logs in advance. Its message is the one of the error it wraps. Get it with errors.As:
+//
+//	var timingErr *dbsql.TimingError
+//	if errors.As(err, &timingErr) {
+//		log.Printf("%v: %v", err, timingErr.Timings)
+//	}
+type TimingError struct {
+	Timings StatementTimings
+	err     error
+}
+
+func (e *TimingError) Error() string {
+	return e.err.Error()
+}
+
+func (e *TimingError) Unwrap() error {
+	return e.err
+}
+
+// Cause returns the cause of the wrapped error, as errors.Cause does
+func (e *TimingError) Cause() error {
+	return e.err
+}
+
+// StackTrace returns the stack trace of the wrapped error, if any
+func (e *TimingError) StackTrace() errors.StackTrace {
+	if st, ok := e.err.(stackTracer); ok {
+		return st.StackTrace()
+	}
+	return nil
+}
+
+// Format formats the wrapped error, so that %+v still prints its stack trace
+func (e *TimingError) Format(s fmt.State, verb rune) {
+	if f, ok := e.err.(fmt.Formatter); ok {
+		f.Format(s, verb)
+		return
+	}
+	_, _ = io.WriteString(s, e.err.Error())
+}
+
+// statementTimer records the timings of a statement. The requests it times may run
+// in other goroutines, e.g. an execute request still in flight when it times out.
+type statementTimer struct {
+	start   time.Time
+	mu      sync.Mutex
+	timings StatementTimings
+	// the start of the timed requests still in flight, by the duration they add to
+	inflight map[*time.Duration]time.Time
+}
+
+type statementTimerKey struct{}
+
+// startStatementTimer returns a context carrying the timer of a statement run on c
+func (c *conn) startStatementTimer(ctx context.Context) (context.Context, *statementTimer) {
+	t := &statementTimer{start: time.Now(), inflight: map[*time.Duration]time.Time{}}
+	t.timings.Connect = c.connectTime
+	return context.WithValue(ctx, statementTimerKey{}, t), t
+}
+
+// statementTimerFromContext returns the timer of the statement, or nil if there is none
+func statementTimerFromContext(ctx context.Context) *statementTimer {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(statementTimerKey{}).(*statementTimer)
+	return t
+}
+
+// queued records the time the statement waited for the concurrency limit
+func (t *statementTimer) queued(d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings.Queued += d
+}
+
+// time starts timing a request adding to d, and counting in n if it is not nil.
+// The returned func ends it.
+func (t *statementTimer) time(d *time.Duration, n *int) func() {
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	t.mu.Lock()
+	t.inflight[d] = start
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.inflight, d)
+		*d += time.Since(start)
+		if n != nil {
+			*n++
+		}
+	}
+}
+
+func (t *statementTimer) execute() func() {
+	if t == nil {
+		return func() {}
+	}
+	return t.time(&t.timings.Execute, nil)
+}
+
+func (t *statementTimer) poll() func() {
+	if t == nil {
+		return func() {}
+	}
+	return t.time(&t.timings.Poll, &t.timings.PollCycles)
+}
+
+// fetch times the fetch of a result page, which only counts once it is fetched
+func (t *statementTimer) fetch() func(fetched bool) {
+	if t == nil {
+		return func(bool) {}
+	}
+	done := t.time(&t.timings.Fetch, nil)
+	return func(fetched bool) {
+		done()
+		if fetched {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.Fetches++
+		}
+	}
+}
+
+// snapshot returns the timings so far, the requests in flight count up to now
+func (t *statementTimer) snapshot() StatementTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timings := t.timings
+	now := time.Now()
+	for d, start := range t.inflight {
+		switch d {
+		case &t.timings.Execute:
+			timings.Execute += now.Sub(start)
+		case &t.timings.Poll:
+			timings.Poll += now.Sub(start)
+		case &t.timings.Fetch:
+			timings.Fetch += now.Sub(start)
+		}
+	}
+	timings.Elapsed = now.Sub(t.start)
+	return timings
+}
+
+// wrapErr attaches the timings so far to err. io.EOF is returned as is since
+// database/sql compares it directly.
+func (t *statementTimer) wrapErr(err error) error {
+	if t == nil || err == nil || err == io.EOF {
+		return err
+	}
+	return &TimingError{Timings: t.snapshot(), err: err}
+}
@@ -0,0 +1,117 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementTimings(t *testing.T) {
+	opHandle := &cli_service.TOperationHandle{
+		OperationId: &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4, 2, 23, 4, 2, 3, 1, 2, 3, 4, 4, 223, 34}, Secret: []byte("b")},
+	}
+
+	t.Run("failed statements carry the timings of the execute and poll requests", func(t *testing.T) {
+		var polls int
+		testClient := &client.TestClient{
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				time.Sleep(5 * time.Millisecond)
+				return &cli_service.TExecuteStatementResp{
+					Status:          &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+					OperationHandle: opHandle,
+				}, nil
+			},
+			FnGetOperationStatus: func(ctx context.Context, req *cli_service.TGetOperationStatusReq) (*cli_service.TGetOperationStatusResp, error) {
+				polls++
+				state := cli_service.TOperationState_RUNNING_STATE
+				if polls == 3 {
+					state = cli_service.TOperationState_ERROR_STATE
+				}
+				return &cli_service.TGetOperationStatusResp{
+					OperationState: cli_service.TOperationStatePtr(state),
+					DisplayMessage: strPtr("query failed"),
+				}, nil
+			},
+		}
+		cfg := config.WithDefaults()
+		cfg.PollInterval = 10 * time.Millisecond
+		testConn := &conn{session: getTestSession(), client: testClient, cfg: cfg, connectTime: time.Second}
+
+		_, err := testConn.ExecContext(context.Background(), "insert into t values (1)", []driver.NamedValue{})
+		var timingErr *TimingError
+		require.True(t, errors.As(err, &timingErr))
+		assert.Contains(t, timingErr.Error(), "query failed")
+		var execErr *ExecutionError
+		assert.True(t, errors.As(err, &execErr))
+		timings := timingErr.Timings
+		assert.Equal(t, time.Second, timings.Connect)
+		assert.GreaterOrEqual(t, timings.Execute, 5*time.Millisecond)
+		assert.Equal(t, 3, timings.PollCycles)
+		assert.Equal(t, 0, timings.Fetches)
+		assert.GreaterOrEqual(t, timings.Elapsed, timings.Execute+timings.Poll)
+		// the message is unchanged, the stack trace is still printed
+		assert.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+		assert.Contains(t, fmt.Sprintf("%+v", err), "timing_test.go")
+	})
+
+	t.Run("requests in flight count up to the time the error is returned", func(t *testing.T) {
+		// the request runs on its own goroutine, which is left behind at the deadline
+		inFlight := make(chan time.Duration, 1)
+		testClient := &client.TestClient{
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				started := time.Now()
+				deadline, ok := ctx.Deadline()
+				if ok {
+					inFlight <- deadline.Sub(started)
+				}
+				close(inFlight)
+				time.Sleep(200 * time.Millisecond)
+				return &cli_service.TExecuteStatementResp{}, nil
+			},
+		}
+		cfg := config.WithDefaults()
+		cfg.PollInterval = 10 * time.Millisecond
+		testConn := &conn{session: getTestSession(), client: testClient, cfg: cfg}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := testConn.QueryContext(ctx, "select 1", []driver.NamedValue{})
+		var timingErr *TimingError
+		require.True(t, errors.As(err, &timingErr))
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		// the request never returned, it counts up to the deadline
+		untilDeadline, ok := <-inFlight
+		require.True(t, ok, "the request has no deadline")
+		assert.GreaterOrEqual(t, timingErr.Timings.Execute, untilDeadline)
+		assert.LessOrEqual(t, timingErr.Timings.Execute, timingErr.Timings.Elapsed)
+	})
+
+	t.Run("errors fetching rows carry the fetches completed", func(t *testing.T) {
+		var fetches int
+		testClient := &client.TestClient{
+			FnFetchResults: func(ctx context.Context, req *cli_service.TFetchResultsReq) (*cli_service.TFetchResultsResp, error) {
+				fetches++
+				return nil, errors.New("fetch failed")
+			},
+		}
+		testConn := &conn{cfg: config.WithDefaults()}
+		ctx, timer := testConn.startStatementTimer(context.Background())
+		timer.fetch()(true)
+		r := &rows{client: testClient, opHandle: opHandle, ctx: ctx, config: testConn.cfg}
+
+		err := r.Next(make([]driver.Value, 1))
+		var timingErr *TimingError
+		require.True(t, errors.As(err, &timingErr))
+		assert.Equal(t, 1, fetches)
+		assert.Equal(t, 1, timingErr.Timings.Fetches)
+	})
+}
@@ -0,0 +1,129 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errUpsertNoKeys = "databricks: an upsert needs at least one key column"
+var errUpsertUnknownKey = "databricks: key %q is not one of the columns"
+
+// UpsertResult is the outcome of the MERGE of Upsert and UpsertFiles.
+type UpsertResult struct {
+	// Affected is the number of rows of the table inserted or updated
+	Affected int64
+	// Updated is the number of rows of the table that matched a row on the keys
+	Updated  int64
+	Inserted int64
+}
+
+// Upsert merges the rows into the columns of table: the rows of the table that match
+// a row on the key columns get its values, the other rows are inserted. The rows are
+// first inserted into a staging table created with CREATE TABLE ... LIKE next to the
+// table, in chunks within the limits of opts as with InsertRows, then merged with a
+// single MERGE statement, so that the table gets either all the rows or none of them.
+// The staging table is dropped once the rows are merged or the upsert failed.
+//
+// The rows should not hold the same keys twice, which makes the MERGE fail.
+//
+//	res, err := dbsql.Upsert(ctx, conn, "main.default.users", []string{"id", "name"}, []string{"id"}, rows, dbsql.InsertOptions{})
+//	...
+//	log.Printf("%d updated, %d inserted", res.Updated, res.Inserted)
+func Upsert(ctx context.Context, conn *sql.Conn, table string, columns, keys []string, rows [][]any, opts InsertOptions) (UpsertResult, error) {
+	var res UpsertResult
+	target, err := quoteName(table)
+	if err != nil {
+		return res, err
+	}
+	quotedColumns, quotedKeys, err := quoteUpsertColumns(columns, keys)
+	if err != nil {
+		return res, err
+	}
+	chunks, err := insertChunks(quotedColumns, rows, opts)
+	if err != nil {
+		return res, err
+	}
+	if len(chunks) == 0 {
+		return res, nil
+	}
+	_, err = stageRows(ctx, conn, table, target, chunks, len(rows), opts.Progress, func(stage string) (err error) {
+		res, err = queryMergeResult(ctx, conn, mergeStatement(target, stage, quotedColumns, quotedKeys))
+		return err
+	})
+	return res, err
+}
+
+// UpsertFiles merges the rows of the files at path into the columns of table, like
+// Upsert, e.g. for data uploaded to a volume or with DBFS.WriteFile, which is read
+// by the warehouse with read_files, without a staging table. The files hold the
+// columns by name: CSV files have a header.
+//
+//	res, err := dbsql.UpsertFiles(ctx, db, "main.default.users", []string{"id", "name"}, []string{"id"},
+//		"/Volumes/main/default/uploads/users", dbsql.VolumeFormatParquet)
+func UpsertFiles(ctx context.Context, db Queryer, table string, columns, keys []string, path string, format VolumeFormat) (UpsertResult, error) {
+	target, err := quoteName(table)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+	quotedColumns, quotedKeys, err := quoteUpsertColumns(columns, keys)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+	var options string
+	switch format {
+	case VolumeFormatParquet, VolumeFormatJSON:
+	case VolumeFormatCSV:
+		options = ", header => true"
+	default:
+		return UpsertResult{}, errors.Errorf("%s: %s", errVolumeInvalidFormat, format)
+	}
+	source := "(SELECT " + strings.Join(quotedColumns, ", ") + " FROM read_files(" + quoteStringLiteral(path) +
+		", format => " + quoteStringLiteral(strings.ToLower(string(format))) + options + "))"
+	res, err := queryMergeResult(ctx, db, mergeStatement(target, source, quotedColumns, quotedKeys))
+	return res, wrapErrf(err, "failed to merge %s into %s", path, table)
+}
+
+// queryMergeResult runs the MERGE statement and reads the numbers of rows it reports
+func queryMergeResult(ctx context.Context, db Queryer, merge string) (UpsertResult, error) {
+	var res UpsertResult
+	rows, err := queryNamed(ctx, db, merge)
+	if err != nil || len(rows) == 0 {
+		return res, err
+	}
+	if res.Affected, err = asInt64(rows[0]["num_affected_rows"]); err != nil {
+		return res, err
+	}
+	if res.Updated, err = asInt64(rows[0]["num_updated_rows"]); err != nil {
+		return res, err
+	}
+	res.Inserted, err = asInt64(rows[0]["num_inserted_rows"])
+	return res, err
+}
+
+// quoteUpsertColumns quotes the columns and the keys, which must be columns
+func quoteUpsertColumns(columns, keys []string) ([]string, []string, error) {
+	quotedColumns, err := quoteInsertColumns(columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil, errors.New(errUpsertNoKeys)
+	}
+	quotedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		if quotedKeys[i], err = quoteTempViewName(key); err != nil {
+			return nil, nil, err
+		}
+		found := false
+		for _, column := range quotedColumns {
+			found = found || column == quotedKeys[i]
+		}
+		if !found {
+			return nil, nil, errors.Errorf(errUpsertUnknownKey, key)
+		}
+	}
+	return quotedColumns, quotedKeys, nil
+}
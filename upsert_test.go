@@ -0,0 +1,86 @@
+package dbsql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsert(t *testing.T) {
+	defer func(f func(string) (string, error)) { stagingTableName = f }(stagingTableName)
+	stagingTableName = func(table string) (string, error) {
+		return "`main`.`default`.`users_dbsql_stage_1`", nil
+	}
+	mergeResult := []string{"num_affected_rows", "num_updated_rows", "num_deleted_rows", "num_inserted_rows"}
+
+	t.Run("staged rows are merged on the keys", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB(mergeResult, [][]string{{"3", "1", "0", "2"}}, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+
+		res, err := Upsert(context.Background(), c, "main.default.users", []string{"id", "region", "name"}, []string{"id", "`region`"},
+			[][]any{{1, "eu", "a"}, {2, "eu", "b"}, {3, "us", "c"}}, InsertOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, UpsertResult{Affected: 3, Updated: 1, Inserted: 2}, res)
+		assert.Equal(t, []string{
+			"CREATE TABLE `main`.`default`.`users_dbsql_stage_1` LIKE `main`.`default`.`users`",
+			"INSERT INTO `main`.`default`.`users_dbsql_stage_1` (`id`, `region`, `name`) VALUES (1, 'eu', 'a'), (2, 'eu', 'b'), (3, 'us', 'c')",
+			"MERGE INTO `main`.`default`.`users` AS t USING `main`.`default`.`users_dbsql_stage_1` AS s " +
+				"ON t.`id` = s.`id` AND t.`region` = s.`region` " +
+				"WHEN MATCHED THEN UPDATE SET t.`name` = s.`name` " +
+				"WHEN NOT MATCHED THEN INSERT (`id`, `region`, `name`) VALUES (s.`id`, s.`region`, s.`name`)",
+			"DROP TABLE IF EXISTS `main`.`default`.`users_dbsql_stage_1`",
+		}, statements)
+	})
+
+	t.Run("failed merges drop the staging table", func(t *testing.T) {
+		var statements []string
+		db, c := getInsertTestConn(t, "MERGE", &statements)
+		defer db.Close()
+		defer c.Close()
+
+		_, err := Upsert(context.Background(), c, "main.default.users", []string{"id", "name"}, []string{"id"}, [][]any{{1, "a"}}, InsertOptions{})
+		assert.Error(t, err)
+		assert.Equal(t, "DROP TABLE IF EXISTS `main`.`default`.`users_dbsql_stage_1`", statements[len(statements)-1])
+	})
+
+	t.Run("invalid keys are rejected", func(t *testing.T) {
+		var statements []string
+		db := getStringsTestDB(mergeResult, nil, "", &statements)
+		defer db.Close()
+		c, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer c.Close()
+
+		ctx := context.Background()
+		_, err = Upsert(ctx, c, "users", []string{"id"}, nil, [][]any{{1}}, InsertOptions{})
+		assert.EqualError(t, err, errUpsertNoKeys)
+		_, err = Upsert(ctx, c, "users", []string{"id"}, []string{"name"}, [][]any{{1}}, InsertOptions{})
+		assert.EqualError(t, err, `databricks: key "name" is not one of the columns`)
+		assert.Empty(t, statements)
+	})
+}
+
+func TestUpsertFiles(t *testing.T) {
+	var statements []string
+	db := getStringsTestDB([]string{"num_affected_rows", "num_updated_rows", "num_inserted_rows"}, [][]string{{"2", "2", "0"}}, "", &statements)
+	defer db.Close()
+
+	res, err := UpsertFiles(context.Background(), db, "users", []string{"id", "name"}, []string{"id"}, "/Volumes/main/default/uploads/users", VolumeFormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, UpsertResult{Affected: 2, Updated: 2}, res)
+	assert.Equal(t, []string{
+		"MERGE INTO `users` AS t USING (SELECT `id`, `name` FROM read_files('/Volumes/main/default/uploads/users', format => 'csv', header => true)) AS s " +
+			"ON t.`id` = s.`id` WHEN MATCHED THEN UPDATE SET t.`name` = s.`name` " +
+			"WHEN NOT MATCHED THEN INSERT (`id`, `name`) VALUES (s.`id`, s.`name`)",
+	}, statements)
+
+	_, err = UpsertFiles(context.Background(), db, "users", []string{"id"}, []string{"id"}, "/tmp/x", VolumeFormat("AVRO"))
+	assert.True(t, strings.HasPrefix(err.Error(), errVolumeInvalidFormat), err.Error())
+}
@@ -0,0 +1,147 @@
+package dbsql
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"sync"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+)
+
+// DefaultUserPoolsSize is the number of connection pools kept by UserPools when no
+// size is set
+const DefaultUserPoolsSize = 100
+
+// UserPools keeps a connection pool per end user access token, whose sessions are
+// all opened on behalf of that user, for web services running queries with the OAuth
+// tokens of their users. Unlike passing the tokens on the contexts of a shared pool,
+// which discards the idle sessions of the other users, the sessions of a user are
+// reused for their next requests.
+//
+// The pools of the least recently used tokens are closed beyond the size, e.g. once
+// the tokens of users are refreshed, so the size must exceed the number of users
+// served at the same time.
+type UserPools struct {
+	connector driver.Connector
+	size      int
+	configure func(db *sql.DB)
+	// returns the context the sessions of the pool of a user are opened with
+	userContext func(ctx context.Context, user string) context.Context
+
+	mu sync.Mutex
+	// the pools by token hash, and in the order of use, most recent first
+	pools  map[string]*list.Element
+	order  *list.List
+	closed bool
+}
+
+type userPool struct {
+	key string
+	db  *sql.DB
+}
+
+// NewUserPools returns the pools of the users of connector, up to size pools, or
+// DefaultUserPoolsSize when zero. configure, if not nil, is called with every new
+// pool, e.g. to set its limits of connections.
+//
+//	pools := dbsql.NewUserPools(connector, 0, func(db *sql.DB) { db.SetMaxIdleConns(2) })
+//	defer pools.Close()
+//	rows, err := pools.DB(token).QueryContext(ctx, "SELECT * FROM sales")
+func NewUserPools(connector driver.Connector, size int, configure func(db *sql.DB)) *UserPools {
+	if size <= 0 {
+		size = DefaultUserPoolsSize
+	}
+	return &UserPools{
+		connector:   connector,
+		size:        size,
+		configure:   configure,
+		userContext: driverctx.NewContextWithAccessToken,
+		pools:       map[string]*list.Element{},
+		order:       list.New(),
+	}
+}
+
+// NewImpersonationPools returns pools whose sessions run as the users passed to DB,
+// with the credentials of connector, which the workspace must allow to impersonate
+// users, so that the audit logs attribute the statements to the end users of a
+// service rather than to its principal. size and configure are the ones of
+// NewUserPools.
+//
+//	pools := dbsql.NewImpersonationPools(connector, 0, nil)
+//	defer pools.Close()
+//	rows, err := pools.DB("alice@example.com").QueryContext(ctx, "SELECT * FROM sales")
+func NewImpersonationPools(connector driver.Connector, size int, configure func(db *sql.DB)) *UserPools {
+	p := NewUserPools(connector, size, configure)
+	p.userContext = driverctx.NewContextWithImpersonationUser
+	return p
+}
+
+// DB returns the connection pool of the user of token, or of the user itself for the
+// pools of NewImpersonationPools, opened on the first call. The pool must not be
+// closed by the caller.
+func (p *UserPools) DB(token string) *sql.DB {
+	h := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(h[:])
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.pools[key]; ok {
+		p.order.MoveToFront(e)
+		return e.Value.(*userPool).db
+	}
+	db := sql.OpenDB(&userConnector{Connector: p.connector, user: token, userContext: p.userContext})
+	if p.configure != nil {
+		p.configure(db)
+	}
+	if p.closed {
+		// the pool fails with sql: database is closed, like the pools of a closed DB
+		_ = db.Close()
+		return db
+	}
+	p.pools[key] = p.order.PushFront(&userPool{key: key, db: db})
+	for p.order.Len() > p.size {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		evicted := oldest.Value.(*userPool)
+		delete(p.pools, evicted.key)
+		_ = evicted.db.Close()
+	}
+	return db
+}
+
+// Close closes the pools of all users.
+func (p *UserPools) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	var firstErr error
+	for e := p.order.Front(); e != nil; e = e.Next() {
+		if err := e.Value.(*userPool).db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	p.pools = map[string]*list.Element{}
+	p.order.Init()
+	return firstErr
+}
+
+// userConnector opens the sessions of its connections on behalf of user, the access
+// token or the impersonated user set by userContext
+type userConnector struct {
+	driver.Connector
+	user        string
+	userContext func(ctx context.Context, user string) context.Context
+}
+
+func (c *userConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.Connector.Connect(c.userContext(ctx, c.user))
+	if conn, ok := dc.(*conn); ok {
+		// the statements of the pool are run on behalf of the user without it on
+		// their contexts
+		conn.userPool = true
+	}
+	return dc, err
+}
@@ -0,0 +1,129 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/databricks/databricks-sql-go/driverctx"
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/databricks/databricks-sql-go/internal/client"
+	"github.com/databricks/databricks-sql-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userPoolsTestConnector opens connections on behalf of the user of the token or as
+// the impersonated user of the context, like the connector does, and records the
+// tokens, the users and the closed sessions
+type userPoolsTestConnector struct {
+	mu     sync.Mutex
+	tokens []string
+	users  []string
+	closed int
+}
+
+func (c *userPoolsTestConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	token := driverctx.AccessTokenFromContext(ctx)
+	user := driverctx.ImpersonationUserFromContext(ctx)
+	cfg := config.WithDefaults()
+	cfg.PollInterval = 10 * time.Millisecond
+	c.mu.Lock()
+	c.tokens = append(c.tokens, token)
+	c.users = append(c.users, user)
+	c.mu.Unlock()
+	return &conn{
+		session:           getTestSession(),
+		accessToken:       token,
+		impersonationUser: user,
+		client: &client.TestClient{
+			FnExecuteStatement: func(ctx context.Context, req *cli_service.TExecuteStatementReq) (*cli_service.TExecuteStatementResp, error) {
+				return &cli_service.TExecuteStatementResp{
+					Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS},
+					OperationHandle: &cli_service.TOperationHandle{
+						OperationId: &cli_service.THandleIdentifier{GUID: []byte{1, 2, 3, 4}, Secret: []byte("b")},
+					},
+					DirectResults: &cli_service.TSparkDirectResults{
+						OperationStatus: &cli_service.TGetOperationStatusResp{
+							OperationState: cli_service.TOperationStatePtr(cli_service.TOperationState_FINISHED_STATE),
+						},
+					},
+				}, nil
+			},
+			FnCloseSession: func(ctx context.Context, req *cli_service.TCloseSessionReq) (*cli_service.TCloseSessionResp, error) {
+				c.mu.Lock()
+				c.closed++
+				c.mu.Unlock()
+				return &cli_service.TCloseSessionResp{Status: &cli_service.TStatus{StatusCode: cli_service.TStatusCode_SUCCESS_STATUS}}, nil
+			},
+		},
+		cfg: cfg,
+	}, nil
+}
+
+func (c *userPoolsTestConnector) Driver() driver.Driver {
+	return &databricksDriver{}
+}
+
+func TestUserPools(t *testing.T) {
+	t.Run("the sessions of a user are reused", func(t *testing.T) {
+		connector := &userPoolsTestConnector{}
+		pools := NewUserPools(connector, 2, nil)
+		defer pools.Close()
+
+		for i := 0; i < 2; i++ {
+			for _, token := range []string{"alice", "bob"} {
+				_, err := pools.DB(token).ExecContext(context.Background(), "UPDATE t SET a = 1")
+				require.NoError(t, err)
+			}
+		}
+		assert.Equal(t, []string{"alice", "bob"}, connector.tokens)
+		assert.Equal(t, 0, connector.closed)
+		assert.Same(t, pools.DB("alice"), pools.DB("alice"))
+
+		// the token of the context must match the user of the pool
+		ctx := driverctx.NewContextWithAccessToken(context.Background(), "bob")
+		_, err := pools.DB("alice").ExecContext(ctx, "UPDATE t SET a = 1")
+		assert.Error(t, err)
+	})
+
+	t.Run("the pools of the least recently used tokens are closed", func(t *testing.T) {
+		connector := &userPoolsTestConnector{}
+		pools := NewUserPools(connector, 2, func(db *sql.DB) { db.SetMaxIdleConns(1) })
+
+		alice := pools.DB("alice")
+		for _, token := range []string{"alice", "bob", "alice", "carol"} {
+			_, err := pools.DB(token).ExecContext(context.Background(), "UPDATE t SET a = 1")
+			require.NoError(t, err)
+		}
+		// bob was used least recently
+		assert.Equal(t, []string{"alice", "bob", "carol"}, connector.tokens)
+		assert.Equal(t, 1, connector.closed)
+		assert.Same(t, alice, pools.DB("alice"))
+
+		require.NoError(t, pools.Close())
+		assert.Equal(t, 3, connector.closed)
+		_, err := pools.DB("alice").ExecContext(context.Background(), "UPDATE t SET a = 1")
+		assert.EqualError(t, err, "sql: database is closed")
+	})
+
+	t.Run("the sessions of impersonation pools run as their user", func(t *testing.T) {
+		connector := &userPoolsTestConnector{}
+		pools := NewImpersonationPools(connector, 2, nil)
+		defer pools.Close()
+
+		for i := 0; i < 2; i++ {
+			for _, user := range []string{"alice@example.com", "bob@example.com"} {
+				_, err := pools.DB(user).ExecContext(context.Background(), "UPDATE t SET a = 1")
+				require.NoError(t, err)
+			}
+		}
+		assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, connector.users)
+		assert.Equal(t, []string{"", ""}, connector.tokens)
+		assert.Equal(t, 0, connector.closed)
+
+	})
+}
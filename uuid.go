@@ -0,0 +1,135 @@
+package dbsql
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// UUID implements sql.Scanner for STRING columns holding UUIDs, such as the values
+// returned by the uuid() function, and BINARY columns holding the 16 raw bytes.
+//
+// UUID has the same layout as the UUID types of common libraries, so it converts
+// to them directly, e.g. uuid.UUID(id) for github.com/google/uuid. UUID also
+// implements driver.Valuer, so it can be bound as a query parameter, which is
+// sent in the canonical string form. Scan NULLable columns into a NullUUID.
+//
+//	var id dbsql.UUID
+//	if err := row.Scan(&id); err != nil {
+//		...
+//	}
+//	fmt.Println(id)
+type UUID [16]byte
+
+// ParseUUID parses the canonical form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx of a
+// UUID, as well as the same form without hyphens, wrapped in braces or prefixed
+// with urn:uuid:. Hexadecimal digits may be in either case.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	text := s
+	if len(text) == 45 && strings.EqualFold(text[:9], "urn:uuid:") {
+		text = text[9:]
+	} else if len(text) == 38 && text[0] == '{' && text[37] == '}' {
+		text = text[1:37]
+	}
+	if len(text) == 36 {
+		if text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+			return u, &ConversionError{Value: s, Type: "UUID", Reason: "not a UUID"}
+		}
+		text = text[:8] + text[9:13] + text[14:18] + text[19:23] + text[24:]
+	}
+	if len(text) != 32 {
+		return u, &ConversionError{Value: s, Type: "UUID", Reason: "not a UUID"}
+	}
+	if _, err := hex.Decode(u[:], []byte(text)); err != nil {
+		return u, &ConversionError{Value: s, Type: "UUID", Reason: "not a UUID"}
+	}
+	return u, nil
+}
+
+// Scan implements the sql.Scanner interface. Strings are parsed with ParseUUID and
+// byte slices are either 16 raw bytes or UUID text. NULL values are rejected.
+func (u *UUID) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		return &ConversionError{Value: "NULL", Type: "UUID", Reason: "use NullUUID for NULL values"}
+	case string:
+		parsed, err := ParseUUID(v)
+		if err != nil {
+			return err
+		}
+		*u = parsed
+	case []byte:
+		if len(v) == len(u) {
+			copy(u[:], v)
+			return nil
+		}
+		parsed, err := ParseUUID(string(v))
+		if err != nil {
+			return err
+		}
+		*u = parsed
+	default:
+		return errors.Errorf("databricks: cannot scan %T into UUID", src)
+	}
+	return nil
+}
+
+// String returns the canonical lower case form of the UUID.
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// Value implements the driver.Valuer interface, returning the canonical form.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// NullUUID is a UUID that may be NULL.
+type NullUUID struct {
+	UUID UUID
+	// Valid is false when the value is NULL
+	Valid bool
+}
+
+// Scan implements the sql.Scanner interface.
+func (n *NullUUID) Scan(src any) error {
+	if src == nil {
+		n.UUID, n.Valid = UUID{}, false
+		return nil
+	}
+	if err := n.UUID.Scan(src); err != nil {
+		n.Valid = false
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// String returns the canonical form of the UUID, or NULL.
+func (n NullUUID) String() string {
+	if !n.Valid {
+		return "NULL"
+	}
+	return n.UUID.String()
+}
+
+// Value implements the driver.Valuer interface, returning nil when the value is NULL.
+func (n NullUUID) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.UUID.Value()
+}
@@ -0,0 +1,81 @@
+package dbsql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUUID(t *testing.T) {
+	const canonical = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+	t.Run("parses UUID text", func(t *testing.T) {
+		for _, s := range []string{
+			canonical,
+			"6BA7B810-9DAD-11D1-80B4-00C04FD430C8",
+			"6ba7b8109dad11d180b400c04fd430c8",
+			"{6ba7b810-9dad-11d1-80b4-00c04fd430c8}",
+			"urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		} {
+			u, err := ParseUUID(s)
+			require.NoError(t, err, s)
+			assert.Equal(t, canonical, u.String(), s)
+		}
+	})
+
+	t.Run("scans strings and bytes", func(t *testing.T) {
+		var u UUID
+		require.NoError(t, u.Scan(canonical))
+		assert.Equal(t, UUID{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}, u)
+
+		var raw UUID
+		require.NoError(t, raw.Scan(u[:]))
+		assert.Equal(t, u, raw)
+
+		var text UUID
+		require.NoError(t, text.Scan([]byte(canonical)))
+		assert.Equal(t, u, text)
+
+		// UUID converts to [16]byte based UUID types
+		assert.Equal(t, [16]byte(u), [16]byte(text))
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		var u UUID
+		var convErr *ConversionError
+		for _, s := range []string{"", "6ba7b810", "6ba7b810-9dad-11d1-80b4_00c04fd430c8", "zba7b810-9dad-11d1-80b4-00c04fd430c8", "{6ba7b810-9dad-11d1-80b4-00c04fd430c8"} {
+			assert.ErrorAs(t, u.Scan(s), &convErr, s)
+		}
+		assert.ErrorAs(t, u.Scan(nil), &convErr)
+		assert.Error(t, u.Scan(42))
+	})
+
+	t.Run("scans NULL into NullUUID", func(t *testing.T) {
+		n := NullUUID{UUID: UUID{1}, Valid: true}
+		require.NoError(t, n.Scan(nil))
+		assert.False(t, n.Valid)
+		assert.Equal(t, UUID{}, n.UUID)
+		assert.Equal(t, "NULL", n.String())
+
+		require.NoError(t, n.Scan(canonical))
+		assert.True(t, n.Valid)
+		assert.Equal(t, canonical, n.String())
+	})
+
+	t.Run("binds as a string", func(t *testing.T) {
+		u, err := ParseUUID(canonical)
+		require.NoError(t, err)
+		literal, err := formatLiteral(u)
+		require.NoError(t, err)
+		assert.Equal(t, "'"+canonical+"'", literal)
+
+		literal, err = formatLiteral(NullUUID{})
+		require.NoError(t, err)
+		assert.Equal(t, "NULL", literal)
+
+		literal, err = formatLiteral(&u)
+		require.NoError(t, err)
+		assert.Equal(t, "'"+canonical+"'", literal)
+	})
+}
@@ -0,0 +1,132 @@
+package dbsql
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/pkg/errors"
+)
+
+// variantTypeName is the class name of VARIANT columns, which the protocol
+// describes as user defined types
+const variantTypeName = "VARIANT"
+
+// Variant implements sql.Scanner for VARIANT columns, which hold semi-structured
+// data sent by the server as JSON text. The JSON is only parsed when it is read
+// with Unmarshal, Field or Index, so scanning many rows stays cheap.
+//
+//	var v dbsql.Variant
+//	if err := row.Scan(&v); err != nil {
+//		...
+//	}
+//	city, err := v.Field("address")
+//	var name string
+//	err = city.Unmarshal(&name)
+//
+// VARIANT columns can also be scanned into a json.RawMessage, a string or any
+// other type that accepts JSON text.
+type Variant struct {
+	raw json.RawMessage
+	// Valid is false when the value is NULL
+	Valid bool
+}
+
+// Scan implements the sql.Scanner interface. The value must be valid JSON.
+func (v *Variant) Scan(src any) error {
+	var raw []byte
+	switch s := src.(type) {
+	case nil:
+		v.raw, v.Valid = nil, false
+		return nil
+	case string:
+		raw = []byte(s)
+	case []byte:
+		// the driver may reuse src, so keep a copy
+		raw = append([]byte(nil), s...)
+	default:
+		return errors.Errorf("databricks: cannot scan %T into Variant", src)
+	}
+	if !json.Valid(raw) {
+		return &ConversionError{Value: string(raw), Type: "Variant", Reason: "not valid JSON"}
+	}
+	v.raw, v.Valid = raw, true
+	return nil
+}
+
+// Raw returns the value as JSON text, or nil when the value is NULL.
+func (v Variant) Raw() json.RawMessage {
+	return v.raw
+}
+
+// String returns the value as JSON text, or NULL.
+func (v Variant) String() string {
+	if !v.Valid {
+		return "NULL"
+	}
+	return string(v.raw)
+}
+
+// Unmarshal parses the value into dest with json.Unmarshal. A NULL value leaves
+// dest unchanged.
+func (v Variant) Unmarshal(dest any) error {
+	if !v.Valid {
+		return nil
+	}
+	return json.Unmarshal(v.raw, dest)
+}
+
+// Field returns the field of an object value, which is NULL when the object has no
+// such field or the value is NULL. Values that are not objects are an error.
+func (v Variant) Field(name string) (Variant, error) {
+	if !v.Valid || isJSONNull(v.raw) {
+		return Variant{}, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(v.raw, &fields); err != nil {
+		return Variant{}, &ConversionError{Value: v.String(), Type: "object", Reason: err.Error()}
+	}
+	raw, ok := fields[name]
+	if !ok {
+		return Variant{}, nil
+	}
+	return newVariant(raw), nil
+}
+
+// Index returns the element at index i of an array value, which is NULL when i is
+// out of range or the value is NULL. Values that are not arrays are an error.
+func (v Variant) Index(i int) (Variant, error) {
+	if !v.Valid || isJSONNull(v.raw) {
+		return Variant{}, nil
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal(v.raw, &elems); err != nil {
+		return Variant{}, &ConversionError{Value: v.String(), Type: "array", Reason: err.Error()}
+	}
+	if i < 0 || i >= len(elems) {
+		return Variant{}, nil
+	}
+	return newVariant(elems[i]), nil
+}
+
+// newVariant returns the Variant of raw JSON, where a JSON null is NULL
+func newVariant(raw json.RawMessage) Variant {
+	if isJSONNull(raw) {
+		return Variant{}
+	}
+	return Variant{raw: raw, Valid: true}
+}
+
+func isJSONNull(raw []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+// isVariant returns true if the column is described as a VARIANT
+func isVariant(column *cli_service.TColumnDesc) bool {
+	if column == nil || column.TypeDesc == nil || len(column.TypeDesc.Types) == 0 || column.TypeDesc.Types[0] == nil {
+		return false
+	}
+	udt := column.TypeDesc.Types[0].UserDefinedTypeEntry
+	return udt != nil && strings.EqualFold(udt.TypeClassName, variantTypeName)
+}
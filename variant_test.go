@@ -0,0 +1,80 @@
+package dbsql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/databricks/databricks-sql-go/internal/cli_service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariant(t *testing.T) {
+	t.Run("scans JSON text and reads it lazily", func(t *testing.T) {
+		var v Variant
+		require.NoError(t, v.Scan([]byte(`{"name":"bob","tags":["a","b"],"age":null}`)))
+		assert.True(t, v.Valid)
+
+		name, err := v.Field("name")
+		require.NoError(t, err)
+		var s string
+		require.NoError(t, name.Unmarshal(&s))
+		assert.Equal(t, "bob", s)
+
+		tags, err := v.Field("tags")
+		require.NoError(t, err)
+		tag, err := tags.Index(1)
+		require.NoError(t, err)
+		assert.Equal(t, `"b"`, tag.String())
+
+		missing, err := tags.Index(5)
+		require.NoError(t, err)
+		assert.False(t, missing.Valid)
+
+		age, err := v.Field("age")
+		require.NoError(t, err)
+		assert.False(t, age.Valid)
+		assert.Equal(t, "NULL", age.String())
+	})
+
+	t.Run("scans NULL", func(t *testing.T) {
+		v := Variant{raw: json.RawMessage(`1`), Valid: true}
+		require.NoError(t, v.Scan(nil))
+		assert.False(t, v.Valid)
+		assert.Nil(t, v.Raw())
+		field, err := v.Field("a")
+		require.NoError(t, err)
+		assert.False(t, field.Valid)
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		var v Variant
+		var convErr *ConversionError
+		assert.ErrorAs(t, v.Scan("{not json"), &convErr)
+		assert.Error(t, v.Scan(42))
+
+		require.NoError(t, v.Scan("[1, 2]"))
+		_, err := v.Field("a")
+		assert.ErrorAs(t, err, &convErr)
+		require.NoError(t, v.Scan(`{"a": 1}`))
+		_, err = v.Index(0)
+		assert.ErrorAs(t, err, &convErr)
+	})
+}
+
+func TestVariantColumns(t *testing.T) {
+	variantDesc := &cli_service.TColumnDesc{TypeDesc: &cli_service.TTypeDesc{Types: []*cli_service.TTypeEntry{
+		{UserDefinedTypeEntry: &cli_service.TUserDefinedTypeEntry{TypeClassName: "variant"}},
+	}}}
+	assert.Equal(t, "VARIANT", getDBTypeName(variantDesc))
+	assert.Equal(t, scanTypeJSON, getScanType(variantDesc))
+
+	desc := newColumnDescriptor(variantDesc)
+	assert.Equal(t, "VARIANT", desc.DatabaseTypeName)
+	assert.Equal(t, "VARIANT", desc.TypeName)
+
+	col := &cli_service.TColumn{StringVal: &cli_service.TStringColumn{Values: []string{`{"a":1}`}}}
+	val, err := value(col, variantDesc, 0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), val)
+}
@@ -0,0 +1,63 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// VolumeFormat is the file format used when exporting query results to a volume.
+type VolumeFormat string
+
+const (
+	VolumeFormatParquet VolumeFormat = "PARQUET"
+	VolumeFormatCSV     VolumeFormat = "CSV"
+	VolumeFormatJSON    VolumeFormat = "JSON"
+)
+
+var errVolumeInvalidPath = "databricks: volume path must start with /Volumes/"
+var errVolumeInvalidFormat = "databricks: unsupported volume format"
+
+// Execer is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// QueryToVolume runs the query with args and writes its results as files of the
+// given format into a Unity Catalog volume directory, e.g.
+// /Volumes/main/default/exports/report.
+//
+// The export runs as INSERT OVERWRITE DIRECTORY, so the existing contents of the
+// directory are replaced by the exported files.
+//
+// The results are written by the warehouse itself, so exported data never
+// transits the client.
+func QueryToVolume(ctx context.Context, db Execer, volumePath string, format VolumeFormat, query string, args ...any) error {
+	stmt, err := queryToVolumeStatement(query, volumePath, format)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return wrapErrf(err, "failed to export query results to %s", volumePath)
+	}
+	return nil
+}
+
+func queryToVolumeStatement(query string, volumePath string, format VolumeFormat) (string, error) {
+	if !strings.HasPrefix(volumePath, "/Volumes/") {
+		return "", errors.New(errVolumeInvalidPath)
+	}
+	var options string
+	switch format {
+	case VolumeFormatParquet, VolumeFormatJSON:
+	case VolumeFormatCSV:
+		options = " OPTIONS ('header' = 'true')"
+	default:
+		return "", errors.Errorf("%s: %s", errVolumeInvalidFormat, format)
+	}
+	return fmt.Sprintf("INSERT OVERWRITE DIRECTORY %s USING %s%s %s", quoteStringLiteral(volumePath), format, options, trimStatement(query)), nil
+}
@@ -0,0 +1,102 @@
+package dbsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testExecer struct {
+	queries []string
+	args    [][]any
+	err     error
+}
+
+func (e *testExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	e.args = append(e.args, args)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &result{}, nil
+}
+
+func TestQueryToVolume(t *testing.T) {
+	t.Run("parquet export", func(t *testing.T) {
+		db := &testExecer{}
+		err := QueryToVolume(context.Background(), db, "/Volumes/main/default/exports/diamonds", VolumeFormatParquet, "select * from diamonds;")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"INSERT OVERWRITE DIRECTORY '/Volumes/main/default/exports/diamonds' USING PARQUET select * from diamonds"}, db.queries)
+	})
+	t.Run("csv export writes header", func(t *testing.T) {
+		db := &testExecer{}
+		err := QueryToVolume(context.Background(), db, "/Volumes/main/default/it's", VolumeFormatCSV, "select 1")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`INSERT OVERWRITE DIRECTORY '/Volumes/main/default/it\'s' USING CSV OPTIONS ('header' = 'true') select 1`}, db.queries)
+	})
+	t.Run("trailing comments and semicolons are removed", func(t *testing.T) {
+		db := &testExecer{}
+		err := QueryToVolume(context.Background(), db, "/Volumes/a/b/c", VolumeFormatJSON, "select '--;' as a -- trailing\n; /* done */ ;\n-- end")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"INSERT OVERWRITE DIRECTORY '/Volumes/a/b/c' USING JSON select '--;' as a"}, db.queries)
+	})
+	t.Run("query arguments are passed on", func(t *testing.T) {
+		db := &testExecer{}
+		err := QueryToVolume(context.Background(), db, "/Volumes/a/b/c", VolumeFormatParquet, "select * from t where id = ?", 7)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"INSERT OVERWRITE DIRECTORY '/Volumes/a/b/c' USING PARQUET select * from t where id = ?"}, db.queries)
+		assert.Equal(t, [][]any{{7}}, db.args)
+	})
+	t.Run("invalid path", func(t *testing.T) {
+		db := &testExecer{}
+		err := QueryToVolume(context.Background(), db, "dbfs:/tmp", VolumeFormatCSV, "select 1")
+		assert.EqualError(t, err, errVolumeInvalidPath)
+		assert.Empty(t, db.queries)
+	})
+	t.Run("invalid format", func(t *testing.T) {
+		db := &testExecer{}
+		err := QueryToVolume(context.Background(), db, "/Volumes/a/b/c", VolumeFormat("AVRO"), "select 1")
+		assert.Error(t, err)
+		assert.Empty(t, db.queries)
+	})
+	t.Run("exec errors are returned", func(t *testing.T) {
+		db := &testExecer{err: errors.New("boom")}
+		err := QueryToVolume(context.Background(), db, "/Volumes/a/b/c", VolumeFormatJSON, "select 1")
+		assert.ErrorContains(t, err, "boom")
+	})
+}
+
+// TestQueryToVolumeIntegration exports to a real volume and reads the files back.
+// It runs against the workspace given by DATABRICKS_HOST, DATABRICKS_HTTP_PATH,
+// DATABRICKS_TOKEN and a writable volume directory in DATABRICKS_TEST_VOLUME_PATH.
+func TestQueryToVolumeIntegration(t *testing.T) {
+	host, httpPath, token := os.Getenv("DATABRICKS_HOST"), os.Getenv("DATABRICKS_HTTP_PATH"), os.Getenv("DATABRICKS_TOKEN")
+	volumePath := os.Getenv("DATABRICKS_TEST_VOLUME_PATH")
+	if host == "" || httpPath == "" || token == "" || volumePath == "" {
+		t.Skip("DATABRICKS_HOST, DATABRICKS_HTTP_PATH, DATABRICKS_TOKEN and DATABRICKS_TEST_VOLUME_PATH are required")
+	}
+
+	connector, err := NewConnector(
+		WithServerHostname(host),
+		WithHTTPPath(httpPath),
+		WithAccessToken(token),
+	)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := context.Background()
+	err = QueryToVolume(ctx, db, volumePath, VolumeFormatParquet, "select id from range(10) -- ids")
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRowContext(ctx, fmt.Sprintf("select count(*) from parquet.`%s`", volumePath)).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 10, count)
+}